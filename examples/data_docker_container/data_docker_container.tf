@@ -0,0 +1,26 @@
+data "portainer_docker_container" "web" {
+  endpoint_id = var.endpoint_id
+
+  label_filter = {
+    "com.docker.compose.service" = var.compose_service
+  }
+}
+
+data "portainer_docker_container_logs" "web" {
+  endpoint_id = var.endpoint_id
+  container   = data.portainer_docker_container.web.id
+  tail        = 50
+  since       = "30m"
+}
+
+output "web_health" {
+  value = data.portainer_docker_container.web.health
+}
+
+output "web_ip_addresses" {
+  value = data.portainer_docker_container.web.ip_addresses
+}
+
+output "web_diagnostics" {
+  value = data.portainer_docker_container.web.health == "healthy" ? null : data.portainer_docker_container_logs.web.stderr_logs
+}
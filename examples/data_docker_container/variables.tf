@@ -0,0 +1,29 @@
+variable "portainer_url" {
+  description = "Default Portainer URL"
+  type        = string
+  default     = "https://localhost:9443"
+}
+
+variable "portainer_api_key" {
+  description = "Default Portainer Admin API Key"
+  type        = string
+  sensitive   = true
+}
+
+variable "portainer_skip_ssl_verify" {
+  description = "Set to true to skip TLS certificate verification (useful for self-signed certs)"
+  type        = bool
+  default     = true
+}
+
+variable "endpoint_id" {
+  description = "Portainer environment (endpoint) identifier"
+  type        = number
+  default     = 1
+}
+
+variable "compose_service" {
+  description = "Compose service whose container is inspected"
+  type        = string
+  default     = "web"
+}
@@ -9,3 +9,11 @@ output "edge_stack_id" {
 output "edge_stack_deployment_type" {
   value = data.portainer_edge_stack.example.deployment_type
 }
+
+data "portainer_edge_stack_status" "example" {
+  edge_stack_id = data.portainer_edge_stack.example.id
+}
+
+output "edge_stack_failing_environments" {
+  value = [for s in data.portainer_edge_stack_status.example.environment_status : "${s.endpoint_name}: ${s.message}" if s.status == "error"]
+}
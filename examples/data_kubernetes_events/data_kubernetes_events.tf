@@ -0,0 +1,10 @@
+data "portainer_kubernetes_events" "warnings" {
+  environment_id       = var.endpoint_id
+  namespace            = "default"
+  involved_object_kind = "Pod"
+  type                 = "Warning"
+}
+
+output "warnings" {
+  value = [for e in data.portainer_kubernetes_events.warnings.events : "${e.involved_object_name}: ${e.reason} - ${e.message}"]
+}
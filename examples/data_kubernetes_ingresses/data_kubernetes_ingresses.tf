@@ -0,0 +1,8 @@
+data "portainer_kubernetes_ingresses" "web" {
+  environment_id = var.endpoint_id
+  namespace      = "default"
+}
+
+output "hosts" {
+  value = distinct(flatten([for i in data.portainer_kubernetes_ingresses.web.ingresses : i.hosts]))
+}
@@ -0,0 +1,11 @@
+data "portainer_kubernetes_nodes" "all" {
+  environment_id = var.endpoint_id
+}
+
+output "ready_nodes" {
+  value = [for n in data.portainer_kubernetes_nodes.all.nodes : n.name if n.ready]
+}
+
+output "allocatable_cpu" {
+  value = { for n in data.portainer_kubernetes_nodes.all.nodes : n.name => n.allocatable["cpu"] }
+}
@@ -0,0 +1,13 @@
+data "portainer_kubernetes_pods" "web" {
+  environment_id = var.endpoint_id
+  namespace      = "default"
+  label_selector = "app=web"
+}
+
+output "not_ready_pods" {
+  value = [for p in data.portainer_kubernetes_pods.web.pods : p.name if !p.ready]
+}
+
+output "total_restarts" {
+  value = sum(concat([0], [for p in data.portainer_kubernetes_pods.web.pods : p.restart_count]))
+}
@@ -0,0 +1,14 @@
+data "portainer_kubernetes_rbac_report" "cluster" {
+  environment_id = var.endpoint_id
+
+  allowed_cluster_admin_subjects = ["Group:platform-admins"]
+  fail_on                        = "critical"
+}
+
+output "rbac_findings" {
+  value = [for f in data.portainer_kubernetes_rbac_report.cluster.findings : "${f.severity}: ${f.message}"]
+}
+
+output "users_with_cluster_roles" {
+  value = { for u in data.portainer_kubernetes_rbac_report.cluster.users : u.username => u.cluster_roles if length(u.cluster_roles) > 0 }
+}
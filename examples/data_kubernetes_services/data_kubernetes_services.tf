@@ -0,0 +1,12 @@
+data "portainer_kubernetes_services" "web" {
+  environment_id = var.endpoint_id
+  namespace      = "default"
+}
+
+output "cluster_ips" {
+  value = { for s in data.portainer_kubernetes_services.web.services : s.name => s.cluster_ip }
+}
+
+output "load_balancer_ips" {
+  value = flatten([for s in data.portainer_kubernetes_services.web.services : [for i in s.load_balancer_ingress : i.ip]])
+}
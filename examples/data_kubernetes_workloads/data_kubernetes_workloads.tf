@@ -0,0 +1,8 @@
+data "portainer_kubernetes_workloads" "web" {
+  environment_id = var.endpoint_id
+  namespace      = "default"
+}
+
+output "unready_workloads" {
+  value = [for w in data.portainer_kubernetes_workloads.web.workloads : "${w.kind}/${w.name}" if !w.ready]
+}
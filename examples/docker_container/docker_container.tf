@@ -0,0 +1,63 @@
+resource "portainer_docker_network" "frontend" {
+  endpoint_id = var.endpoint_id
+  name        = "frontend"
+}
+
+resource "portainer_docker_volume" "web_data" {
+  endpoint_id = var.endpoint_id
+  name        = "web-data"
+}
+
+resource "portainer_docker_container" "web" {
+  endpoint_id    = var.endpoint_id
+  name           = var.container_name
+  image          = var.container_image
+  restart_policy = "unless-stopped"
+
+  env    = var.container_env
+  labels = var.container_labels
+
+  port {
+    internal = 80
+    external = 8080
+  }
+
+  mount {
+    type   = "volume"
+    source = portainer_docker_volume.web_data.name
+    target = "/usr/share/nginx/html"
+  }
+
+  network {
+    name    = portainer_docker_network.frontend.name
+    aliases = ["web"]
+  }
+
+  healthcheck {
+    test     = ["CMD-SHELL", "curl -fs http://localhost/ || exit 1"]
+    interval = "30s"
+    timeout  = "5s"
+    retries  = 3
+  }
+
+  memory = 256
+  cpus   = 0.5
+
+  capabilities {
+    drop = ["NET_RAW"]
+  }
+
+  log_driver = "json-file"
+  log_opts = {
+    "max-size" = "10m"
+  }
+}
+
+resource "portainer_resource_control" "web" {
+  resource_id         = portainer_docker_container.web.id
+  resource_control_id = portainer_docker_container.web.resource_control_id
+  type                = 1
+  administrators_only = false
+  public              = false
+  teams               = var.team_ids
+}
@@ -0,0 +1,52 @@
+variable "portainer_url" {
+  description = "Default Portainer URL"
+  type        = string
+  # default     = "http://localhost:9000"
+}
+
+variable "portainer_api_key" {
+  description = "Default Portainer Admin API Key"
+  type        = string
+  sensitive   = true
+  # default     = "your-api-key-from-portainer"
+}
+
+variable "endpoint_id" {
+  description = "ID of the environment where the container will be created"
+  type        = number
+}
+
+variable "container_name" {
+  description = "Name of the Docker container"
+  type        = string
+  default     = "web"
+}
+
+variable "container_image" {
+  description = "Image used by the container"
+  type        = string
+  default     = "nginx:1.27"
+}
+
+variable "container_env" {
+  description = "Environment variables of the container"
+  type        = map(string)
+  default = {
+    "APP_ENV" = "production"
+  }
+}
+
+variable "container_labels" {
+  description = "Labels to apply to the container"
+  type        = map(string)
+  default = {
+    "env"     = "test"
+    "purpose" = "terraform"
+  }
+}
+
+variable "team_ids" {
+  description = "Portainer teams allowed to manage the container"
+  type        = list(number)
+  default     = []
+}
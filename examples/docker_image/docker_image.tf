@@ -1,4 +1,12 @@
 resource "portainer_docker_image" "image_test" {
-  endpoint_id = var.endpoint_id
-  image       = var.image
+  endpoint_id  = var.endpoint_id
+  image        = var.image
+  registry_id  = var.registry_id
+  keep_locally = var.keep_locally
+
+  pull_triggers = var.pull_triggers
+}
+
+output "image_repo_digest" {
+  value = portainer_docker_image.image_test.repo_digest
 }
@@ -20,3 +20,21 @@ variable "image" {
   description = "Docker image including tag (e.g., nginx:alpine)"
   type        = string
 }
+
+variable "registry_id" {
+  description = "ID of a Portainer registry whose credentials are used for the pull (optional)"
+  type        = number
+  default     = null
+}
+
+variable "keep_locally" {
+  description = "Keep the image on the host when the resource is destroyed"
+  type        = bool
+  default     = false
+}
+
+variable "pull_triggers" {
+  description = "Values that trigger a new pull when they change"
+  type        = list(string)
+  default     = []
+}
@@ -0,0 +1,4 @@
+#!/bin/sh
+set -eu
+
+curl -fsS "${TARGET_URL:-http://localhost:8080/health}" | jq .
@@ -0,0 +1,18 @@
+resource "portainer_docker_image_build" "helper" {
+  endpoint_id  = var.endpoint_id
+  context_path = "${path.module}/context"
+  dockerfile   = "Dockerfile"
+  tags         = var.tags
+
+  build_args = {
+    ALPINE_VERSION = var.alpine_version
+  }
+
+  labels = {
+    "org.opencontainers.image.source" = "terraform"
+  }
+}
+
+output "helper_image_id" {
+  value = portainer_docker_image_build.helper.image_id
+}
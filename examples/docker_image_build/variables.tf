@@ -0,0 +1,29 @@
+variable "portainer_url" {
+  description = "Default Portainer URL"
+  type        = string
+  # default     = "http://localhost:9000"
+}
+
+variable "portainer_api_key" {
+  description = "Default Portainer Admin API Key"
+  type        = string
+  sensitive   = true
+  # default     = "your-api-key-from-portainer"
+}
+
+variable "endpoint_id" {
+  description = "ID of the Portainer environment where the image is built"
+  type        = number
+}
+
+variable "tags" {
+  description = "Tags applied to the built image"
+  type        = list(string)
+  default     = ["edge-helper:1.0"]
+}
+
+variable "alpine_version" {
+  description = "Alpine version passed as build argument"
+  type        = string
+  default     = "3.20"
+}
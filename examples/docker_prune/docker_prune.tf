@@ -0,0 +1,40 @@
+resource "portainer_docker_prune" "cleanup" {
+  endpoint_id = var.endpoint_id
+
+  containers {
+    until = "24h"
+  }
+
+  images {
+    all    = true
+    until  = "168h"
+    labels = ["!keep"]
+  }
+
+  networks {}
+
+  build_cache {
+    keep_storage = 1073741824
+  }
+}
+
+resource "portainer_docker_prune" "edge_nightly" {
+  containers {
+    until = "24h"
+  }
+
+  images {
+    all   = true
+    until = "72h"
+  }
+
+  schedule {
+    name            = "nightly-docker-prune"
+    cron_expression = "0 3 * * *"
+    edge_groups     = var.edge_group_ids
+  }
+}
+
+output "reclaimed_bytes" {
+  value = portainer_docker_prune.cleanup.space_reclaimed
+}
@@ -0,0 +1,62 @@
+resource "portainer_docker_network" "frontend" {
+  endpoint_id = var.endpoint_id
+  name        = "frontend"
+  driver      = "overlay"
+  scope       = "swarm"
+  attachable  = true
+}
+
+resource "portainer_docker_secret" "db_password" {
+  endpoint_id = var.endpoint_id
+  name        = "db_password"
+  data        = base64encode(var.db_password)
+}
+
+resource "portainer_docker_service" "web" {
+  endpoint_id = var.endpoint_id
+  name        = var.service_name
+  image       = var.service_image
+  replicas    = var.service_replicas
+
+  env = {
+    DB_PASSWORD_FILE = "/run/secrets/db_password"
+  }
+
+  update_config {
+    parallelism    = 1
+    delay          = "10s"
+    failure_action = "rollback"
+    order          = "start-first"
+  }
+
+  rollback_config {
+    parallelism = 0
+  }
+
+  placement {
+    constraints = ["node.role==worker"]
+    preferences = ["node.labels.zone"]
+  }
+
+  secret {
+    secret_id   = portainer_docker_secret.db_password.id
+    secret_name = portainer_docker_secret.db_password.name
+    file_name   = "db_password"
+  }
+
+  port {
+    target_port    = 80
+    published_port = 8080
+  }
+
+  network {
+    name    = portainer_docker_network.frontend.name
+    aliases = ["web"]
+  }
+
+  resources {
+    limit_cpus     = 0.5
+    limit_memory   = 256
+    reserve_memory = 64
+  }
+}
@@ -0,0 +1,12 @@
+terraform {
+  required_providers {
+    portainer = {
+      source = "portainer/portainer"
+    }
+  }
+}
+
+provider "portainer" {
+  endpoint = var.portainer_url
+  api_key  = var.portainer_api_key
+}
@@ -0,0 +1,41 @@
+variable "portainer_url" {
+  description = "Default Portainer URL"
+  type        = string
+  # default     = "http://localhost:9000"
+}
+
+variable "portainer_api_key" {
+  description = "Default Portainer Admin API Key"
+  type        = string
+  sensitive   = true
+  # default     = "your-api-key-from-portainer"
+}
+
+variable "endpoint_id" {
+  description = "ID of the Swarm environment where the service will be created"
+  type        = number
+}
+
+variable "service_name" {
+  description = "Name of the Swarm service"
+  type        = string
+  default     = "web"
+}
+
+variable "service_image" {
+  description = "Image run by the service"
+  type        = string
+  default     = "nginx:1.27"
+}
+
+variable "service_replicas" {
+  description = "Number of replicas"
+  type        = number
+  default     = 2
+}
+
+variable "db_password" {
+  description = "Password exposed to the service as a Swarm secret"
+  type        = string
+  sensitive   = true
+}
@@ -0,0 +1,25 @@
+resource "portainer_docker_swarm" "cluster" {
+  endpoint_id = var.endpoint_id
+
+  task_history_retention_limit = 2
+  dispatcher_heartbeat_period  = "10s"
+  node_cert_expiry             = "720h"
+}
+
+data "portainer_docker_swarm" "cluster" {
+  endpoint_id = var.endpoint_id
+}
+
+# Command to run on the new node (e.g. from cloud-init)
+output "worker_join_command" {
+  value     = "docker swarm join --token ${data.portainer_docker_swarm.cluster.worker_join_token} ${data.portainer_docker_swarm.cluster.manager_address}"
+  sensitive = true
+}
+
+# Once the node has joined, label it by hostname
+resource "portainer_docker_node" "new_node" {
+  endpoint_id = var.endpoint_id
+  hostname    = var.new_node_hostname
+  role        = "worker"
+  labels      = var.new_node_labels
+}
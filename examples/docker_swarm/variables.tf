@@ -0,0 +1,32 @@
+variable "portainer_url" {
+  description = "Default Portainer URL"
+  type        = string
+  # default     = "http://localhost:9000"
+}
+
+variable "portainer_api_key" {
+  description = "Default Portainer Admin API Key"
+  type        = string
+  sensitive   = true
+  # default     = "your-api-key"
+}
+
+variable "endpoint_id" {
+  description = "ID of the Portainer Swarm environment"
+  type        = number
+  default     = 1
+}
+
+variable "new_node_hostname" {
+  description = "Hostname of the node joining the Swarm"
+  type        = string
+  default     = "edge-7"
+}
+
+variable "new_node_labels" {
+  description = "Labels assigned to the new node once it has joined"
+  type        = map(string)
+  default = {
+    site = "plant-7"
+  }
+}
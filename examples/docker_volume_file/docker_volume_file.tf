@@ -0,0 +1,32 @@
+resource "portainer_docker_volume" "config" {
+  endpoint_id = var.endpoint_id
+  name        = var.volume_name
+}
+
+resource "portainer_docker_volume_file" "nginx_conf" {
+  endpoint_id = var.endpoint_id
+  volume_name = portainer_docker_volume.config.name
+  path        = "conf.d/default.conf"
+  source      = "${path.module}/files/default.conf"
+  mode        = "0644"
+}
+
+resource "portainer_docker_volume_file" "upstreams" {
+  endpoint_id = var.endpoint_id
+  volume_name = portainer_docker_volume.config.name
+  path        = "conf.d/upstreams.conf"
+  content     = "upstream api {\n  server api:8080;\n}\n"
+  mode        = "0640"
+  uid         = 101
+  gid         = 101
+}
+
+data "portainer_docker_volume_file" "nginx_conf" {
+  endpoint_id = var.endpoint_id
+  volume_name = portainer_docker_volume.config.name
+  path        = portainer_docker_volume_file.nginx_conf.path
+}
+
+output "nginx_conf_checksum" {
+  value = data.portainer_docker_volume_file.nginx_conf.checksum
+}
@@ -0,0 +1,24 @@
+variable "portainer_url" {
+  description = "Default Portainer URL"
+  type        = string
+  # default     = "http://localhost:9000"
+}
+
+variable "portainer_api_key" {
+  description = "Default Portainer Admin API Key"
+  type        = string
+  sensitive   = true
+  # default     = "your-api-key"
+}
+
+variable "endpoint_id" {
+  description = "ID of the Portainer environment"
+  type        = number
+  default     = 1
+}
+
+variable "volume_name" {
+  description = "Name of the volume seeded with configuration files"
+  type        = string
+  default     = "nginx-config"
+}
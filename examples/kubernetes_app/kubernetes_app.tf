@@ -0,0 +1,34 @@
+resource "portainer_kubernetes_app" "web" {
+  endpoint_id = var.endpoint_id
+  namespace   = var.namespace
+  name        = "web"
+  image       = "nginx:1.27"
+  stack_name  = "frontend"
+  note        = "Deployed with Terraform"
+
+  replicas     = 2
+  cpu_limit    = "250m"
+  memory_limit = "128Mi"
+
+  env = {
+    LOG_LEVEL = "info"
+  }
+
+  persisted_folder {
+    path = "/usr/share/nginx/html"
+    size = "1Gi"
+  }
+
+  service_type = "NodePort"
+
+  published_port {
+    container_port = 80
+    node_port      = 30080
+  }
+
+  autoscaler {
+    min_replicas           = 2
+    max_replicas           = 5
+    target_cpu_utilization = 75
+  }
+}
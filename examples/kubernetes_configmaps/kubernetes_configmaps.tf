@@ -3,3 +3,13 @@ resource "portainer_kubernetes_configmaps" "example" {
   namespace   = var.namespace
   manifest    = file(var.manifest_file)
 }
+
+resource "portainer_kubernetes_configmaps" "typed" {
+  endpoint_id = var.endpoint_id
+  namespace   = var.namespace
+  name        = "app-config"
+
+  data = {
+    LOG_LEVEL = "info"
+  }
+}
@@ -3,3 +3,16 @@ resource "portainer_kubernetes_secret" "example" {
   namespace   = var.namespace
   manifest    = file(var.manifest_file)
 }
+
+resource "portainer_kubernetes_secret" "typed" {
+  endpoint_id = var.endpoint_id
+  namespace   = var.namespace
+  name        = "db-credentials"
+
+  string_data = {
+    username = "app"
+  }
+
+  string_data_wo  = jsonencode({ password = var.db_password })
+  data_wo_version = 1
+}
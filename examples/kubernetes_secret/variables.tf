@@ -28,3 +28,10 @@ variable "manifest_file" {
   type        = string
   default     = "secret.yaml"
 }
+
+variable "db_password" {
+  description = "Password written to the typed Secret (write-only, never stored in state)"
+  type        = string
+  sensitive   = true
+  ephemeral   = true
+}
@@ -3,3 +3,20 @@ resource "portainer_kubernetes_service" "example" {
   namespace   = var.namespace
   manifest    = file(var.manifest_file)
 }
+
+resource "portainer_kubernetes_service" "typed" {
+  endpoint_id = var.endpoint_id
+  namespace   = var.namespace
+  name        = "web"
+  type        = "ClusterIP"
+
+  selector = {
+    app = "web"
+  }
+
+  port {
+    name        = "http"
+    port        = 80
+    target_port = "8080"
+  }
+}
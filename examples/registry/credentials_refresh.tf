@@ -0,0 +1,16 @@
+# Propagate a password rotation of the custom registry to the stacks and
+# Swarm services of the environments with access to it.
+resource "portainer_registry_credentials_refresh" "custom_auth" {
+  registry_id = portainer_registry.custom_auth.id
+
+  triggers = {
+    password = sha256(var.custom_auth_password)
+  }
+}
+
+output "refreshed_workloads" {
+  value = concat(
+    portainer_registry_credentials_refresh.custom_auth.refreshed_stacks,
+    portainer_registry_credentials_refresh.custom_auth.refreshed_services,
+  )
+}
@@ -0,0 +1,17 @@
+resource "portainer_stack_promotion" "test" {
+  source_stack_id    = var.source_stack_id
+  target_endpoint_id = var.target_endpoint_id
+  name               = var.target_stack_name
+  env_overrides      = var.env_overrides
+  source_action      = "keep"
+
+  readiness_check {
+    wait                = 30
+    wait_between_checks = 10
+    max_retries         = 6
+  }
+}
+
+output "promotion_output" {
+  value = portainer_stack_promotion.test.output
+}
@@ -0,0 +1,38 @@
+variable "portainer_url" {
+  description = "Default Portainer URL"
+  type        = string
+  # default     = "https://localhost:9443"
+}
+
+variable "portainer_api_key" {
+  description = "Default Portainer Admin API Key"
+  type        = string
+  sensitive   = true
+  # default     = "your-api-key-from-portainer"
+}
+
+variable "source_stack_id" {
+  description = "ID of the stack to promote"
+  type        = number
+  default     = 1
+}
+
+variable "target_endpoint_id" {
+  description = "ID of the target environment to promote the stack to"
+  type        = number
+  default     = 2
+}
+
+variable "target_stack_name" {
+  description = "Name of the stack on the target environment"
+  type        = string
+  default     = "my-stack-production"
+}
+
+variable "env_overrides" {
+  description = "Environment variables overridden on the target environment"
+  type        = map(string)
+  default = {
+    LOG_LEVEL = "warn"
+  }
+}
@@ -0,0 +1,475 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// stackFile is a Compose file or Kubernetes manifest to validate.
+type stackFile struct {
+	Name    string
+	Content string
+}
+
+// stackFileViolation is a problem found in a stack file.
+type stackFileViolation struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (v stackFileViolation) String() string {
+	if v.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", v.File, v.Line, v.Message)
+	}
+	return fmt.Sprintf("%s: %s", v.File, v.Message)
+}
+
+// stackFileFetchError reports a file or setting the plan-time validation
+// could not read from Portainer.
+func stackFileFetchError(err error) error {
+	return fmt.Errorf("cannot validate stack file (set skip_file_validation to deploy without validation): %w", err)
+}
+
+// stackFileViolationsError reports the violations as one error, or nil.
+func stackFileViolationsError(violations []stackFileViolation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	lines := make([]string, 0, len(violations))
+	for _, v := range violations {
+		lines = append(lines, "  - "+v.String())
+	}
+	return fmt.Errorf("stack file validation failed (set skip_file_validation to deploy anyway):\n%s", strings.Join(lines, "\n"))
+}
+
+// composeTopLevelKeys are the top-level elements of the Compose specification.
+var composeTopLevelKeys = map[string]bool{
+	"version": true, "name": true, "include": true, "services": true,
+	"networks": true, "volumes": true, "configs": true, "secrets": true, "models": true,
+}
+
+// Node kinds accepted for the attributes of a Compose service.
+const (
+	composeScalar = 1 << iota
+	composeSequence
+	composeMapping
+)
+
+// composeServiceKeys are the service attributes of the Compose specification
+// with the YAML node kinds they accept.
+var composeServiceKeys = map[string]int{
+	"annotations": composeMapping | composeSequence, "attach": composeScalar,
+	"blkio_config": composeMapping, "build": composeScalar | composeMapping,
+	"cap_add": composeSequence, "cap_drop": composeSequence, "cgroup": composeScalar,
+	"cgroup_parent": composeScalar, "command": composeScalar | composeSequence,
+	"configs": composeSequence, "container_name": composeScalar, "cpu_count": composeScalar,
+	"cpu_percent": composeScalar, "cpu_period": composeScalar, "cpu_quota": composeScalar,
+	"cpu_rt_period": composeScalar, "cpu_rt_runtime": composeScalar, "cpu_shares": composeScalar,
+	"cpus": composeScalar, "cpuset": composeScalar, "credential_spec": composeMapping,
+	"depends_on": composeSequence | composeMapping, "deploy": composeMapping,
+	"develop": composeMapping, "device_cgroup_rules": composeSequence, "devices": composeSequence,
+	"dns": composeScalar | composeSequence, "dns_opt": composeSequence,
+	"dns_search": composeScalar | composeSequence, "domainname": composeScalar,
+	"driver_opts": composeMapping, "entrypoint": composeScalar | composeSequence,
+	"env_file": composeScalar | composeSequence, "environment": composeSequence | composeMapping,
+	"expose": composeSequence, "extends": composeScalar | composeMapping,
+	"external_links": composeSequence, "extra_hosts": composeSequence | composeMapping,
+	"gpus": composeScalar | composeSequence, "group_add": composeSequence,
+	"healthcheck": composeMapping, "hostname": composeScalar, "image": composeScalar,
+	"init": composeScalar, "ipc": composeScalar, "isolation": composeScalar,
+	"label_file": composeScalar | composeSequence, "labels": composeSequence | composeMapping,
+	"links": composeSequence, "logging": composeMapping, "mac_address": composeScalar,
+	"mem_limit": composeScalar, "mem_reservation": composeScalar, "mem_swappiness": composeScalar,
+	"memswap_limit": composeScalar, "models": composeSequence | composeMapping,
+	"network_mode": composeScalar, "networks": composeSequence | composeMapping,
+	"oom_kill_disable": composeScalar, "oom_score_adj": composeScalar, "pid": composeScalar,
+	"pids_limit": composeScalar, "platform": composeScalar, "ports": composeSequence,
+	"post_start": composeSequence, "pre_stop": composeSequence, "privileged": composeScalar,
+	"profiles": composeSequence, "provider": composeMapping, "pull_policy": composeScalar,
+	"read_only": composeScalar, "restart": composeScalar, "runtime": composeScalar,
+	"scale": composeScalar, "secrets": composeSequence, "security_opt": composeSequence,
+	"shm_size": composeScalar, "stdin_open": composeScalar, "stop_grace_period": composeScalar,
+	"stop_signal": composeScalar, "storage_opt": composeMapping, "sysctls": composeSequence | composeMapping,
+	"tmpfs": composeScalar | composeSequence, "tty": composeScalar, "ulimits": composeMapping,
+	"use_api_socket": composeScalar, "user": composeScalar, "userns_mode": composeScalar,
+	"uts": composeScalar, "volumes": composeSequence, "volumes_from": composeSequence,
+	"working_dir": composeScalar,
+}
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+): `)
+
+// parseStackFile parses every YAML document of a stack file, reporting
+// syntax errors and duplicate keys with their line number.
+func parseStackFile(file stackFile) ([]*yaml.Node, []stackFileViolation) {
+	var docs []*yaml.Node
+	dec := yaml.NewDecoder(strings.NewReader(file.Content))
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			v := stackFileViolation{File: file.Name, Message: strings.TrimPrefix(err.Error(), "yaml: ")}
+			if m := yamlErrorLine.FindStringSubmatchIndex(v.Message); m != nil {
+				v.Line, _ = strconv.Atoi(v.Message[m[2]:m[3]])
+				v.Message = v.Message[:m[0]] + v.Message[m[1]:]
+			}
+			return nil, []stackFileViolation{v}
+		}
+		if dups := duplicateKeys(file.Name, &doc); len(dups) > 0 {
+			return nil, dups
+		}
+		if len(doc.Content) > 0 {
+			docs = append(docs, doc.Content[0])
+		}
+	}
+}
+
+// duplicateKeys reports keys defined twice in the same mapping, which YAML
+// parsers reject when decoding the file.
+func duplicateKeys(file string, n *yaml.Node) []stackFileViolation {
+	var violations []stackFileViolation
+	if n.Kind == yaml.MappingNode {
+		seen := map[string]bool{}
+		for _, entry := range mappingEntries(n) {
+			if entry[0].Value != "<<" && seen[entry[0].Value] {
+				violations = append(violations, stackFileViolation{File: file, Line: entry[0].Line, Message: fmt.Sprintf("duplicate key %q", entry[0].Value)})
+			}
+			seen[entry[0].Value] = true
+		}
+	}
+	for _, child := range n.Content {
+		violations = append(violations, duplicateKeys(file, child)...)
+	}
+	return violations
+}
+
+// mappingEntries returns the key and value nodes of a YAML mapping.
+func mappingEntries(n *yaml.Node) [][2]*yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	entries := make([][2]*yaml.Node, 0, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		entries = append(entries, [2]*yaml.Node{n.Content[i], n.Content[i+1]})
+	}
+	return entries
+}
+
+func composeNodeKind(n *yaml.Node) int {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return composeScalar
+	case yaml.SequenceNode:
+		return composeSequence
+	case yaml.MappingNode:
+		return composeMapping
+	case yaml.AliasNode:
+		return composeNodeKind(n.Alias)
+	}
+	return 0
+}
+
+func describeComposeKinds(kinds int) string {
+	var names []string
+	if kinds&composeScalar != 0 {
+		names = append(names, "a scalar")
+	}
+	if kinds&composeSequence != 0 {
+		names = append(names, "a list")
+	}
+	if kinds&composeMapping != 0 {
+		names = append(names, "a mapping")
+	}
+	return strings.Join(names, " or ")
+}
+
+// validateComposeFiles checks Compose files against the Compose specification.
+// The files are merged the way Compose merges override files, so a service
+// only needs an image or a build section in one of them. When security is
+// not nil, services are also checked against the environment's security
+// settings for regular users.
+func validateComposeFiles(files []stackFile, security *SecuritySettings) []stackFileViolation {
+	var violations []stackFileViolation
+	type serviceSource struct {
+		file     string
+		line     int
+		hasImage bool
+	}
+	services := map[string]*serviceSource{}
+	var order []string
+	included := false
+
+	for _, file := range files {
+		docs, parseErrs := parseStackFile(file)
+		violations = append(violations, parseErrs...)
+		if len(docs) > 1 {
+			violations = append(violations, stackFileViolation{File: file.Name, Line: docs[1].Line, Message: "a Compose file must contain a single YAML document"})
+		}
+		if len(docs) == 0 {
+			continue
+		}
+		root := docs[0]
+		if root.Kind != yaml.MappingNode {
+			violations = append(violations, stackFileViolation{File: file.Name, Line: root.Line, Message: "a Compose file must be a mapping"})
+			continue
+		}
+
+		for _, entry := range mappingEntries(root) {
+			key, value := entry[0], entry[1]
+			switch {
+			case strings.HasPrefix(key.Value, "x-"):
+			case !composeTopLevelKeys[key.Value]:
+				violations = append(violations, stackFileViolation{File: file.Name, Line: key.Line, Message: fmt.Sprintf("unknown top-level element %q", key.Value)})
+			case key.Value == "include":
+				included = true
+			case key.Value == "services":
+				if value.Kind != yaml.MappingNode {
+					violations = append(violations, stackFileViolation{File: file.Name, Line: value.Line, Message: "services must be a mapping"})
+					continue
+				}
+				for _, svc := range mappingEntries(value) {
+					src, ok := services[svc[0].Value]
+					if !ok {
+						src = &serviceSource{file: file.Name, line: svc[0].Line}
+						services[svc[0].Value] = src
+						order = append(order, svc[0].Value)
+					}
+					violations = append(violations, validateComposeService(file.Name, svc[0].Value, svc[1], security)...)
+					for _, attr := range mappingEntries(svc[1]) {
+						if attr[0].Value == "image" || attr[0].Value == "build" || attr[0].Value == "extends" {
+							src.hasImage = true
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Services of included files may be completed there.
+	if !included {
+		for _, name := range order {
+			if src := services[name]; !src.hasImage {
+				violations = append(violations, stackFileViolation{File: src.file, Line: src.line, Message: fmt.Sprintf("service %q has neither an image nor a build section", name)})
+			}
+		}
+	}
+	return violations
+}
+
+// validateComposeService checks the attributes of a service.
+func validateComposeService(file, name string, svc *yaml.Node, security *SecuritySettings) []stackFileViolation {
+	if svc.Kind == yaml.ScalarNode && svc.Tag == "!!null" {
+		return nil
+	}
+	if svc.Kind != yaml.MappingNode {
+		return []stackFileViolation{{File: file, Line: svc.Line, Message: fmt.Sprintf("service %q must be a mapping", name)}}
+	}
+
+	var violations []stackFileViolation
+	deny := func(n *yaml.Node, format string, args ...interface{}) {
+		violations = append(violations, stackFileViolation{File: file, Line: n.Line, Message: fmt.Sprintf("service %q: ", name) + fmt.Sprintf(format, args...)})
+	}
+	for _, entry := range mappingEntries(svc) {
+		key, value := entry[0], entry[1]
+		if strings.HasPrefix(key.Value, "x-") {
+			continue
+		}
+		kinds, ok := composeServiceKeys[key.Value]
+		if !ok {
+			deny(key, "unknown attribute %q", key.Value)
+			continue
+		}
+		if value.Tag != "!!null" && composeNodeKind(value)&kinds == 0 {
+			deny(value, "%s must be %s", key.Value, describeComposeKinds(kinds))
+			continue
+		}
+		if security == nil {
+			continue
+		}
+
+		switch key.Value {
+		case "privileged":
+			if value.Value == "true" && !security.AllowPrivilegedModeForRegularUsers {
+				deny(value, "privileged mode is disabled on the environment")
+			}
+		case "network_mode", "pid", "ipc", "uts", "userns_mode":
+			if value.Value == "host" && !security.AllowHostNamespaceForRegularUsers {
+				deny(value, "%s: host is disabled on the environment (host namespaces)", key.Value)
+			}
+		case "cap_add":
+			if len(value.Content) > 0 && !security.AllowContainerCapabilitiesForRegularUsers {
+				deny(value, "adding container capabilities is disabled on the environment")
+			}
+		case "devices":
+			if len(value.Content) > 0 && !security.AllowDeviceMappingForRegularUsers {
+				deny(value, "device mapping is disabled on the environment")
+			}
+		case "sysctls":
+			if len(value.Content) > 0 && !security.AllowSysctlSettingForRegularUsers {
+				deny(value, "sysctl settings are disabled on the environment")
+			}
+		case "volumes":
+			if security.AllowBindMountsForRegularUsers {
+				continue
+			}
+			for _, vol := range value.Content {
+				if composeVolumeIsBind(vol) {
+					deny(vol, "bind mounts are disabled on the environment")
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// composeVolumeIsBind reports whether a service volume, in short or long
+// syntax, mounts a host path.
+func composeVolumeIsBind(vol *yaml.Node) bool {
+	if vol.Kind == yaml.MappingNode {
+		for _, attr := range mappingEntries(vol) {
+			if attr[0].Value == "type" {
+				return attr[1].Value == "bind"
+			}
+		}
+		return false
+	}
+	src, _, hasTarget := strings.Cut(vol.Value, ":")
+	return hasTarget && (strings.HasPrefix(src, "/") || strings.HasPrefix(src, ".") || strings.HasPrefix(src, "~") || strings.HasPrefix(src, "$"))
+}
+
+// validateKubernetesManifest checks that every document of a manifest is a
+// Kubernetes object with an apiVersion, a kind and a metadata name.
+func validateKubernetesManifest(file stackFile) []stackFileViolation {
+	docs, violations := parseStackFile(file)
+	for _, doc := range docs {
+		if doc.Kind != yaml.MappingNode {
+			violations = append(violations, stackFileViolation{File: file.Name, Line: doc.Line, Message: "a Kubernetes object must be a mapping"})
+			continue
+		}
+		fields := map[string]*yaml.Node{}
+		for _, entry := range mappingEntries(doc) {
+			fields[entry[0].Value] = entry[1]
+		}
+		for _, key := range []string{"apiVersion", "kind"} {
+			if v := fields[key]; v == nil || v.Kind != yaml.ScalarNode || v.Value == "" {
+				violations = append(violations, stackFileViolation{File: file.Name, Line: doc.Line, Message: fmt.Sprintf("object is missing %s", key)})
+			}
+		}
+		if kind := fields["kind"]; kind != nil && strings.HasSuffix(kind.Value, "List") {
+			continue
+		}
+		named := false
+		for _, entry := range mappingEntries(fields["metadata"]) {
+			if entry[0].Value == "name" || entry[0].Value == "generateName" {
+				named = entry[1].Value != ""
+			}
+		}
+		if !named {
+			violations = append(violations, stackFileViolation{File: file.Name, Line: doc.Line, Message: "object is missing metadata.name"})
+		}
+	}
+	return violations
+}
+
+// stackRepositoryFiles reads the main file and the additional_files of a
+// repository stack at repository_reference_name, in that order, through
+// Portainer's Git file preview, with the credentials of
+// stackRepositoryCredentials.
+func stackRepositoryFiles(client *APIClient, d rawConfigReader, mainFile string) ([]stackFile, error) {
+	repoURL, username, password, known := stackRepositoryCredentials(d)
+	if !known {
+		return nil, errRepositoryUnknown
+	}
+	var files []stackFile
+	for _, path := range append([]string{mainFile}, expandStringList(d.Get("additional_files").([]interface{}))...) {
+		payload := map[string]interface{}{
+			"repository":    repoURL,
+			"reference":     d.Get("repository_reference_name").(string),
+			"targetFile":    path,
+			"TLSSkipVerify": d.Get("tlsskip_verify").(bool),
+		}
+		if d.Get("git_repository_authentication").(bool) {
+			payload["username"] = username
+			payload["password"] = password
+		}
+		if id := d.Get("repository_git_credential_id").(int); id != 0 {
+			payload["gitCredentialID"] = id
+		}
+		content, err := fetchRepositoryFile(client, payload)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, stackFile{Name: path, Content: content})
+	}
+	return files, nil
+}
+
+// fetchRepositoryFile reads a file of a Git repository through Portainer.
+func fetchRepositoryFile(client *APIClient, payload map[string]interface{}) (string, error) {
+	resp, err := client.DoRequest(http.MethodPost, "/gitops/repo/file/preview", nil, payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to preview Git repository file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to preview Git repository file %v (status %d): %s", payload["targetFile"], resp.StatusCode, string(data))
+	}
+	var result struct {
+		FileContent string `json:"FileContent"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode file preview response: %w", err)
+	}
+	return result.FileContent, nil
+}
+
+// fetchEndpointSecuritySettings returns the security settings that apply to
+// stacks deployed by the authenticated user on an environment, or nil when
+// the user is an administrator, to whom they do not apply.
+func fetchEndpointSecuritySettings(ctx context.Context, client *APIClient, endpointID int) (*SecuritySettings, error) {
+	body, code, err := apiGETWithCodeCtx(ctx, client.Endpoint+"/users/me", client.APIKey, client)
+	if err != nil {
+		return nil, err
+	}
+	if code != http.StatusOK {
+		return nil, fmt.Errorf("failed to read current user, status %d: %s", code, string(body))
+	}
+	var user struct {
+		Role int `json:"Role"`
+	}
+	if err := json.Unmarshal(body, &user); err != nil {
+		return nil, fmt.Errorf("failed to decode current user: %w", err)
+	}
+	if user.Role == 1 {
+		return nil, nil
+	}
+
+	body, code, err = apiGETWithCodeCtx(ctx, fmt.Sprintf("%s/endpoints/%d", client.Endpoint, endpointID), client.APIKey, client)
+	if err != nil {
+		return nil, err
+	}
+	if code != http.StatusOK {
+		return nil, fmt.Errorf("failed to read environment %d, status %d: %s", endpointID, code, string(body))
+	}
+	var endpoint struct {
+		SecuritySettings *SecuritySettings `json:"SecuritySettings"`
+	}
+	if err := json.Unmarshal(body, &endpoint); err != nil {
+		return nil, fmt.Errorf("failed to decode environment %d: %w", endpointID, err)
+	}
+	return endpoint.SecuritySettings, nil
+}
@@ -0,0 +1,279 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func violationStrings(violations []stackFileViolation) []string {
+	out := make([]string, 0, len(violations))
+	for _, v := range violations {
+		out = append(out, v.String())
+	}
+	return out
+}
+
+func TestValidateComposeFiles(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		files    []stackFile
+		security *SecuritySettings
+		want     []string
+	}{
+		{
+			name: "valid",
+			files: []stackFile{{Name: "docker-compose.yml", Content: `services:
+  web:
+    image: nginx
+    ports: ["80:80"]
+    volumes:
+      - data:/data
+      - ./conf:/etc/nginx/conf.d
+    x-custom: true
+volumes:
+  data: {}
+x-common: &common {}
+`}},
+		},
+		{
+			name:  "syntax error",
+			files: []stackFile{{Name: "docker-compose.yml", Content: "services:\n  web:\n\timage: nginx\n"}},
+			want:  []string{"docker-compose.yml:3: found character that cannot start any token"},
+		},
+		{
+			name:  "duplicate key",
+			files: []stackFile{{Name: "docker-compose.yml", Content: "services:\n  web:\n    image: nginx\n    image: httpd\n"}},
+			want:  []string{`docker-compose.yml:4: duplicate key "image"`},
+		},
+		{
+			name: "spec violations",
+			files: []stackFile{{Name: "docker-compose.yml", Content: `servics: {}
+services:
+  web:
+    imag: nginx
+    ports: "80:80"
+`}},
+			want: []string{
+				`docker-compose.yml:1: unknown top-level element "servics"`,
+				`docker-compose.yml:4: service "web": unknown attribute "imag"`,
+				`docker-compose.yml:5: service "web": ports must be a list`,
+				`docker-compose.yml:3: service "web" has neither an image nor a build section`,
+			},
+		},
+		{
+			name: "additional files are merged",
+			files: []stackFile{
+				{Name: "docker-compose.yml", Content: "services:\n  web:\n    image: nginx\n"},
+				{Name: "docker-compose.prod.yml", Content: "services:\n  web:\n    restart: always\n  worker:\n    restart: always\n"},
+			},
+			want: []string{`docker-compose.prod.yml:4: service "worker" has neither an image nor a build section`},
+		},
+		{
+			name: "security settings",
+			files: []stackFile{{Name: "docker-compose.yml", Content: `services:
+  agent:
+    image: agent
+    privileged: true
+    network_mode: host
+    cap_add: [NET_ADMIN]
+    devices: ["/dev/fuse"]
+    volumes:
+      - /var/run/docker.sock:/var/run/docker.sock
+      - type: bind
+        source: ./data
+        target: /data
+      - logs:/logs
+`}},
+			security: &SecuritySettings{AllowDeviceMappingForRegularUsers: true},
+			want: []string{
+				`docker-compose.yml:4: service "agent": privileged mode is disabled on the environment`,
+				`docker-compose.yml:5: service "agent": network_mode: host is disabled on the environment (host namespaces)`,
+				`docker-compose.yml:6: service "agent": adding container capabilities is disabled on the environment`,
+				`docker-compose.yml:9: service "agent": bind mounts are disabled on the environment`,
+				`docker-compose.yml:10: service "agent": bind mounts are disabled on the environment`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := violationStrings(validateComposeFiles(tc.files, tc.security))
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("violations:\n got %q\nwant %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateKubernetesManifest(t *testing.T) {
+	got := violationStrings(validateKubernetesManifest(stackFile{Name: "app.yaml", Content: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+---
+kind: Deployment
+metadata:
+  labels: {app: web}
+`}))
+	want := []string{
+		"app.yaml:6: object is missing apiVersion",
+		"app.yaml:6: object is missing metadata.name",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("violations:\n got %q\nwant %q", got, want)
+	}
+}
+
+// TestStackCustomizeDiff_Validation reports bind mounts forbidden on the
+// environment at plan time for regular users, and not for administrators.
+func TestStackCustomizeDiff_Validation(t *testing.T) {
+	for _, tc := range []struct {
+		role    int
+		wantErr bool
+	}{
+		{2, true},
+		{1, false},
+	} {
+		mock := NewMockServer(t)
+		mock.On("GET", "/users/me", RespondJSON(http.StatusOK, map[string]interface{}{"Id": 3, "Role": tc.role}))
+		mock.On("GET", "/endpoints/1", RespondJSON(http.StatusOK, map[string]interface{}{
+			"Id":               1,
+			"SecuritySettings": map[string]interface{}{"allowBindMountsForRegularUsers": false},
+		}))
+
+		r := resourcePortainerStack()
+		cfg := terraform.NewResourceConfigRaw(map[string]interface{}{
+			"name":               "app",
+			"endpoint_id":        1,
+			"deployment_type":    "standalone",
+			"method":             "string",
+			"stack_file_content": "services:\n  app:\n    image: app\n    volumes:\n      - /srv/app:/data\n",
+		})
+
+		_, err := r.Diff(context.Background(), nil, cfg, mock.Client())
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("role %d: expected error=%v, got %v", tc.role, tc.wantErr, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), `stack_file_content:5: service "app": bind mounts are disabled on the environment`) {
+			t.Errorf("role %d: unexpected error: %v", tc.role, err)
+		}
+	}
+}
+
+// TestEdgeStackCustomizeDiff_Validation reports an invalid edge stack file at
+// plan time.
+func TestEdgeStackCustomizeDiff_Validation(t *testing.T) {
+	r := resourceEdgeStack()
+	cfg := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":               "edge",
+		"deployment_type":    0,
+		"edge_groups":        []interface{}{1},
+		"stack_file_content": "services:\n  web:\n    image: nginx\n    restart: [always]\n",
+	})
+	_, err := r.Diff(context.Background(), nil, cfg, nil)
+	if err == nil || !strings.Contains(err.Error(), `stack_file_content:4: service "web": restart must be a scalar`) {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+}
+
+// TestStackCustomizeDiff_ValidationFetchError fails the plan when the files
+// to validate cannot be read, instead of skipping the validation.
+func TestStackCustomizeDiff_ValidationFetchError(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("POST", "/gitops/repo/file/preview", RespondString(http.StatusInternalServerError, "text/plain", "unreachable"))
+
+	r := resourcePortainerStack()
+	cfg := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":                      "app",
+		"endpoint_id":               1,
+		"deployment_type":           "standalone",
+		"method":                    "repository",
+		"repository_url":            "https://git.example.com/app.git",
+		"repository_reference_name": "refs/heads/main",
+	})
+
+	_, err := r.Diff(context.Background(), nil, cfg, mock.Client())
+	if err == nil || !strings.Contains(err.Error(), "cannot validate stack file") || !strings.Contains(err.Error(), "unreachable") {
+		t.Fatalf("expected a fetch error, got %v", err)
+	}
+}
+
+// TestStackCustomizeDiff_ValidationWriteOnlyCredentials reads repository files
+// with the write-only credentials, and skips the validation while they are
+// not known.
+func TestStackCustomizeDiff_ValidationWriteOnlyCredentials(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/users/me", RespondJSON(http.StatusOK, map[string]interface{}{"Id": 1, "Role": 1}))
+	mock.On("POST", "/gitops/repo/file/preview", RespondJSON(http.StatusOK, map[string]interface{}{"FileContent": "services:\n  app:\n    image: app\n"}))
+
+	r := resourcePortainerStack()
+	raw := map[string]interface{}{
+		"name":                          "app",
+		"endpoint_id":                   1,
+		"deployment_type":               "standalone",
+		"method":                        "repository",
+		"repository_url":                "https://git.example.com/app.git",
+		"repository_reference_name":     "refs/heads/next",
+		"git_repository_authentication": true,
+		"repository_wo_version":         1,
+	}
+	state := &terraform.InstanceState{ID: "4", Attributes: map[string]string{
+		"id":                            "4",
+		"name":                          "app",
+		"endpoint_id":                   "1",
+		"deployment_type":               "standalone",
+		"method":                        "repository",
+		"repository_url":                "https://git.example.com/app.git",
+		"repository_reference_name":     "refs/heads/main",
+		"git_repository_authentication": "true",
+		"repository_wo_version":         "1",
+		"compose_format":                "false",
+		"support_relative_path":         "false",
+	}}
+
+	state.RawConfig = cty.ObjectVal(map[string]cty.Value{
+		"repository_username_wo": cty.StringVal("bot"),
+		"repository_password_wo": cty.StringVal("s3cret"),
+	})
+	if _, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), mock.Client()); err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	var payload map[string]interface{}
+	if err := mock.FindRequest("POST", "/gitops/repo/file/preview").DecodeJSON(&payload); err != nil {
+		t.Fatalf("decode preview payload: %v", err)
+	}
+	if payload["username"] != "bot" || payload["password"] != "s3cret" {
+		t.Errorf("expected the write-only credentials, got %v", payload)
+	}
+
+	mock = NewMockServer(t)
+	state.RawConfig = cty.ObjectVal(map[string]cty.Value{
+		"repository_username_wo": cty.StringVal("bot"),
+		"repository_password_wo": cty.UnknownVal(cty.String),
+	})
+	if _, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), mock.Client()); err != nil {
+		t.Fatalf("expected the validation to be skipped, got %v", err)
+	}
+	if mock.FindRequest("POST", "/gitops/repo/file/preview") != nil {
+		t.Error("did not expect a file preview with unknown credentials")
+	}
+}
+
+// TestEdgeStackCustomizeDiff_ValidationMissingFile plans a stack_file_path
+// written during apply.
+func TestEdgeStackCustomizeDiff_ValidationMissingFile(t *testing.T) {
+	r := resourceEdgeStack()
+	cfg := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":            "edge",
+		"deployment_type": 0,
+		"edge_groups":     []interface{}{1},
+		"stack_file_path": filepath.Join(t.TempDir(), "compose.yml"),
+	})
+	if _, err := r.Diff(context.Background(), nil, cfg, nil); err != nil {
+		t.Errorf("expected the plan to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,329 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceDockerContainer() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceDockerContainerRead,
+
+		Schema: map[string]*schema.Schema{
+			"endpoint_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "Identifier of the Portainer endpoint where the container runs.",
+			},
+			"swarm_node_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Swarm node on which the container runs (sent as `X-PortainerAgent-Target`). Required for containers on other nodes of an agent-managed Swarm.",
+			},
+			"name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"name", "label_filter"},
+				Description:  "Name or ID of the container.",
+			},
+			"label_filter": {
+				Type:         schema.TypeMap,
+				Optional:     true,
+				ExactlyOneOf: []string{"name", "label_filter"},
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				Description:  "Labels the container must have (e.g. `com.docker.compose.service = \"web\"`). Exactly one container must match.",
+			},
+			// Computed attributes
+			"container_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Container name.",
+			},
+			"image": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Image reference the container was created from.",
+			},
+			"image_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "ID of the container image.",
+			},
+			"status": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Container state (`created`, `running`, `paused`, `restarting`, `exited`, `dead`).",
+			},
+			"running": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the container is running.",
+			},
+			"exit_code": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Exit code of the last run.",
+			},
+			"started_at": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Time the container was last started.",
+			},
+			"finished_at": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Time the container last exited.",
+			},
+			"restart_count": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of restarts performed by the restart policy.",
+			},
+			"health": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Health status (`starting`, `healthy`, `unhealthy`), empty when the container has no healthcheck.",
+			},
+			"health_failing_streak": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of consecutive failed healthchecks.",
+			},
+			"health_last_output": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Output of the last healthcheck probe.",
+			},
+			"labels": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Container labels.",
+			},
+			"ip_addresses": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "IP address of the container per network name.",
+			},
+			"networks": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Networks the container is attached to, sorted by name.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Network name.",
+						},
+						"ip_address": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "IPv4 address in the network.",
+						},
+						"gateway": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Gateway of the network.",
+						},
+						"mac_address": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "MAC address in the network.",
+						},
+						"aliases": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "DNS aliases in the network.",
+						},
+					},
+				},
+			},
+			"mounts": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Mounts of the container.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Mount type (`volume`, `bind`, `tmpfs`).",
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Volume name for volume mounts.",
+						},
+						"source": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Source path on the host.",
+						},
+						"destination": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Path inside the container.",
+						},
+						"read_only": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether the mount is read-only.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// findDockerContainerByLabels returns the ID of the only container (running
+// or not) carrying all the given labels.
+func findDockerContainerByLabels(client *APIClient, endpointID int, labels map[string]interface{}, headers map[string]string) (string, error) {
+	filter := []string{}
+	for k, v := range labels {
+		filter = append(filter, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(filter)
+	filters, _ := json.Marshal(map[string][]string{"label": filter})
+
+	path := fmt.Sprintf("/endpoints/%d/docker/containers/json?all=1&filters=%s", endpointID, url.QueryEscape(string(filters)))
+	resp, err := client.DoRequest(http.MethodGet, path, headers, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to list docker containers: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to list docker containers, status %d: %s", resp.StatusCode, string(data))
+	}
+
+	var containers []struct {
+		ID    string   `json:"Id"`
+		Names []string `json:"Names"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&containers); err != nil {
+		return "", fmt.Errorf("failed to decode docker container list: %w", err)
+	}
+
+	switch len(containers) {
+	case 0:
+		return "", fmt.Errorf("no docker container with labels %s found in endpoint %d", strings.Join(filter, ","), endpointID)
+	case 1:
+		return containers[0].ID, nil
+	}
+	names := []string{}
+	for _, c := range containers {
+		names = append(names, strings.TrimPrefix(strings.Join(c.Names, ","), "/"))
+	}
+	return "", fmt.Errorf("%d docker containers match labels %s: %s", len(containers), strings.Join(filter, ","), strings.Join(names, ", "))
+}
+
+func dataSourceDockerContainerRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+	headers := dockerContainerHeaders(d)
+
+	container := d.Get("name").(string)
+	if labels := d.Get("label_filter").(map[string]interface{}); len(labels) > 0 {
+		id, err := findDockerContainerByLabels(client, endpointID, labels, headers)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		container = id
+	}
+
+	path := fmt.Sprintf("/endpoints/%d/docker/containers/%s/json", endpointID, url.PathEscape(container))
+	resp, err := client.DoRequest(http.MethodGet, path, headers, nil)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to inspect docker container: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return diag.FromErr(fmt.Errorf("docker container %s not found in endpoint %d", container, endpointID))
+	}
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return diag.FromErr(fmt.Errorf("failed to inspect docker container, status %d: %s", resp.StatusCode, string(data)))
+	}
+
+	var result dockerContainerInspect
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to decode docker container response: %w", err))
+	}
+
+	d.SetId(result.ID)
+	_ = d.Set("container_name", strings.TrimPrefix(result.Name, "/"))
+	_ = d.Set("image", result.Config.Image)
+	_ = d.Set("image_id", result.Image)
+	_ = d.Set("status", result.State.Status)
+	_ = d.Set("running", result.State.Running)
+	_ = d.Set("exit_code", result.State.ExitCode)
+	_ = d.Set("started_at", result.State.StartedAt)
+	_ = d.Set("finished_at", result.State.FinishedAt)
+	_ = d.Set("restart_count", result.RestartCount)
+	_ = d.Set("labels", result.Config.Labels)
+
+	health, streak, lastOutput := "", 0, ""
+	if h := result.State.Health; h != nil {
+		health, streak = h.Status, h.FailingStreak
+		if len(h.Log) > 0 {
+			lastOutput = strings.TrimSpace(h.Log[len(h.Log)-1].Output)
+		}
+	}
+	_ = d.Set("health", health)
+	_ = d.Set("health_failing_streak", streak)
+	_ = d.Set("health_last_output", lastOutput)
+
+	networkNames := make([]string, 0, len(result.NetworkSettings.Networks))
+	for name := range result.NetworkSettings.Networks {
+		networkNames = append(networkNames, name)
+	}
+	sort.Strings(networkNames)
+	ips := map[string]interface{}{}
+	networks := make([]map[string]interface{}, 0, len(networkNames))
+	for _, name := range networkNames {
+		n := result.NetworkSettings.Networks[name]
+		ips[name] = n.IPAddress
+		networks = append(networks, map[string]interface{}{
+			"name":        name,
+			"ip_address":  n.IPAddress,
+			"gateway":     n.Gateway,
+			"mac_address": n.MacAddress,
+			"aliases":     n.Aliases,
+		})
+	}
+	_ = d.Set("ip_addresses", ips)
+	if err := d.Set("networks", networks); err != nil {
+		return diag.FromErr(err)
+	}
+
+	mounts := make([]map[string]interface{}, 0, len(result.Mounts))
+	for _, m := range result.Mounts {
+		mounts = append(mounts, map[string]interface{}{
+			"type":        m.Type,
+			"name":        m.Name,
+			"source":      m.Source,
+			"destination": m.Destination,
+			"read_only":   !m.RW,
+		})
+	}
+	if err := d.Set("mounts", mounts); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
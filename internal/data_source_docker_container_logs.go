@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceDockerContainerLogs() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceDockerContainerLogsRead,
+
+		Schema: map[string]*schema.Schema{
+			"endpoint_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "Identifier of the Portainer endpoint where the container runs.",
+			},
+			"swarm_node_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Swarm node on which the container runs (sent as `X-PortainerAgent-Target`).",
+			},
+			"container": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name or ID of the container.",
+			},
+			"tail": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     100,
+				Description: "Number of lines to return from the end of the logs. `0` returns all lines.",
+			},
+			"since": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDockerLogsSince,
+				Description:  "Only return logs newer than this: a duration relative to now (e.g. `15m`), an RFC3339 time or a UNIX timestamp.",
+			},
+			"stdout": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Whether to include stdout.",
+			},
+			"stderr": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Whether to include stderr.",
+			},
+			"timestamps": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether to prefix every line with its timestamp.",
+			},
+			// Computed attributes
+			"logs": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Selected streams interleaved in the order they were written.",
+			},
+			"stdout_logs": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "stdout lines only. Containers with a TTY have a single stream, reported as stdout.",
+			},
+			"stderr_logs": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "stderr lines only.",
+			},
+		},
+	}
+}
+
+// dockerLogsSince converts the `since` argument to the UNIX timestamp expected
+// by the Docker logs API.
+func dockerLogsSince(v string, now time.Time) (string, error) {
+	if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return v, nil
+	}
+	if dur, err := time.ParseDuration(v); err == nil {
+		return strconv.FormatInt(now.Add(-dur).Unix(), 10), nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return strconv.FormatInt(t.Unix(), 10), nil
+	}
+	return "", fmt.Errorf("%q is neither a duration, an RFC3339 time nor a UNIX timestamp", v)
+}
+
+func validateDockerLogsSince(v interface{}, k string) ([]string, []error) {
+	if _, err := dockerLogsSince(v.(string), time.Now()); err != nil {
+		return nil, []error{fmt.Errorf("%s: %w", k, err)}
+	}
+	return nil, nil
+}
+
+// demuxDockerLogs splits the multiplexed stream Docker returns for containers
+// without a TTY: every frame starts with an 8-byte header holding the stream
+// type (1 = stdout, 2 = stderr) and the big-endian payload size. Raw TTY
+// output has no such header and is returned as stdout.
+func demuxDockerLogs(data []byte) (combined, stdout, stderr string) {
+	if len(data) < 8 || data[0] > 2 || data[1] != 0 || data[2] != 0 || data[3] != 0 {
+		return string(data), string(data), ""
+	}
+
+	var all, out, errOut bytes.Buffer
+	for len(data) >= 8 {
+		stream := data[0]
+		size := int(binary.BigEndian.Uint32(data[4:8]))
+		data = data[8:]
+		if size > len(data) {
+			size = len(data)
+		}
+		payload := data[:size]
+		data = data[size:]
+
+		all.Write(payload)
+		if stream == 2 {
+			errOut.Write(payload)
+		} else {
+			out.Write(payload)
+		}
+	}
+	return all.String(), out.String(), errOut.String()
+}
+
+func dataSourceDockerContainerLogsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+	container := d.Get("container").(string)
+
+	query := url.Values{}
+	query.Set("stdout", strconv.FormatBool(d.Get("stdout").(bool)))
+	query.Set("stderr", strconv.FormatBool(d.Get("stderr").(bool)))
+	query.Set("timestamps", strconv.FormatBool(d.Get("timestamps").(bool)))
+	query.Set("tail", "all")
+	if tail := d.Get("tail").(int); tail > 0 {
+		query.Set("tail", strconv.Itoa(tail))
+	}
+	if v := d.Get("since").(string); v != "" {
+		since, err := dockerLogsSince(v, time.Now())
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		query.Set("since", since)
+	}
+
+	path := fmt.Sprintf("/endpoints/%d/docker/containers/%s/logs?%s", endpointID, url.PathEscape(container), query.Encode())
+	resp, err := client.DoRequest(http.MethodGet, path, dockerContainerHeaders(d), nil)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to fetch docker container logs: %w", err))
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to read docker container logs: %w", err))
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return diag.FromErr(fmt.Errorf("docker container %s not found in endpoint %d", container, endpointID))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return diag.FromErr(fmt.Errorf("failed to fetch docker container logs, status %d: %s", resp.StatusCode, string(data)))
+	}
+
+	combined, stdout, stderr := demuxDockerLogs(data)
+	_ = d.Set("logs", combined)
+	_ = d.Set("stdout_logs", stdout)
+	_ = d.Set("stderr_logs", stderr)
+
+	d.SetId(fmt.Sprintf("%d:%s:%s", endpointID, container, query.Encode()))
+	return nil
+}
@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func dockerLogFrame(stream byte, payload string) string {
+	size := len(payload)
+	return string([]byte{stream, 0, 0, 0, byte(size >> 24), byte(size >> 16), byte(size >> 8), byte(size)}) + payload
+}
+
+// TestDataSourceDockerContainerLogsRead_Multiplexed splits stdout and stderr
+// frames and passes tail/since to Docker.
+func TestDataSourceDockerContainerLogsRead_Multiplexed(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/containers/web-1/logs", RespondString(http.StatusOK, "application/vnd.docker.multiplexed-stream",
+		dockerLogFrame(1, "starting\n")+dockerLogFrame(2, "warning: no config\n")+dockerLogFrame(1, "listening on :80\n")))
+
+	ds := dataSourceDockerContainerLogs()
+	d := ds.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("container", "web-1")
+	_ = d.Set("tail", 50)
+	_ = d.Set("since", "1700000000")
+	_ = d.Set("stdout", true)
+	_ = d.Set("stderr", true)
+
+	if err := rcRead(ds, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if got := d.Get("logs"); got != "starting\nwarning: no config\nlistening on :80\n" {
+		t.Errorf("unexpected combined logs: %q", got)
+	}
+	if got := d.Get("stdout_logs"); got != "starting\nlistening on :80\n" {
+		t.Errorf("unexpected stdout: %q", got)
+	}
+	if got := d.Get("stderr_logs"); got != "warning: no config\n" {
+		t.Errorf("unexpected stderr: %q", got)
+	}
+
+	query, _ := url.ParseQuery(mock.FindRequest("GET", "/endpoints/1/docker/containers/web-1/logs").Query)
+	if query.Get("tail") != "50" || query.Get("since") != "1700000000" || query.Get("stderr") != "true" {
+		t.Errorf("unexpected query: %v", query)
+	}
+}
+
+// TestDataSourceDockerContainerLogsRead_TTY returns raw TTY output as stdout.
+func TestDataSourceDockerContainerLogsRead_TTY(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/containers/shell/logs", RespondString(http.StatusOK, "text/plain", "root@shell:/# ls\r\n"))
+
+	ds := dataSourceDockerContainerLogs()
+	d := ds.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("container", "shell")
+
+	if err := rcRead(ds, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if d.Get("stdout_logs") != "root@shell:/# ls\r\n" || d.Get("stderr_logs") != "" {
+		t.Errorf("unexpected TTY logs: %q / %q", d.Get("stdout_logs"), d.Get("stderr_logs"))
+	}
+	query, _ := url.ParseQuery(mock.FindRequest("GET", "/endpoints/1/docker/containers/shell/logs").Query)
+	if query.Get("tail") != "all" {
+		t.Errorf("expected tail=all when tail is 0, got %q", query.Get("tail"))
+	}
+}
+
+// TestDockerLogsSince accepts durations, RFC3339 times and UNIX timestamps.
+func TestDockerLogsSince(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	for in, want := range map[string]string{
+		"15m":                  "1699999100",
+		"2023-11-14T22:13:20Z": "1700000000",
+		"1690000000":           "1690000000",
+	} {
+		if got, err := dockerLogsSince(in, now); err != nil || got != want {
+			t.Errorf("dockerLogsSince(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := dockerLogsSince("yesterday", now); err == nil {
+		t.Error("expected an error for an invalid value")
+	}
+}
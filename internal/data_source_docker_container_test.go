@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+var dockerContainerInspectFixture = map[string]interface{}{
+	"Id":           "c0ffee",
+	"Name":         "/web-1",
+	"Image":        "sha256:img",
+	"RestartCount": 3,
+	"State": map[string]interface{}{
+		"Status":   "running",
+		"Running":  true,
+		"ExitCode": 0,
+		"Health": map[string]interface{}{
+			"Status":        "unhealthy",
+			"FailingStreak": 2,
+			"Log":           []map[string]interface{}{{"ExitCode": 0, "Output": "ok"}, {"ExitCode": 1, "Output": "connection refused\n"}},
+		},
+	},
+	"Config": map[string]interface{}{"Image": "nginx:1.27", "Labels": map[string]string{"com.docker.compose.service": "web"}},
+	"Mounts": []map[string]interface{}{{"Type": "volume", "Name": "data", "Source": "/var/lib/docker/volumes/data/_data", "Destination": "/data", "RW": false}},
+	"NetworkSettings": map[string]interface{}{"Networks": map[string]interface{}{
+		"frontend": map[string]interface{}{"IPAddress": "10.0.1.5", "Gateway": "10.0.1.1"},
+		"backend":  map[string]interface{}{"IPAddress": "10.0.2.5", "Aliases": []string{"web"}},
+	}},
+}
+
+// TestDataSourceDockerContainerRead_ByName exposes state, health, networks
+// and mounts of the inspected container.
+func TestDataSourceDockerContainerRead_ByName(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/containers/web-1/json", RespondJSON(http.StatusOK, dockerContainerInspectFixture))
+
+	ds := dataSourceDockerContainer()
+	d := ds.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("name", "web-1")
+	_ = d.Set("swarm_node_id", "node-a")
+
+	if err := rcRead(ds, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if d.Id() != "c0ffee" || d.Get("container_name") != "web-1" || d.Get("status") != "running" {
+		t.Errorf("unexpected container: id=%s name=%v status=%v", d.Id(), d.Get("container_name"), d.Get("status"))
+	}
+	if d.Get("health") != "unhealthy" || d.Get("health_failing_streak").(int) != 2 || d.Get("health_last_output") != "connection refused" {
+		t.Errorf("unexpected health: %v %v %q", d.Get("health"), d.Get("health_failing_streak"), d.Get("health_last_output"))
+	}
+	if d.Get("networks.0.name") != "backend" || d.Get("ip_addresses.frontend") != "10.0.1.5" {
+		t.Errorf("unexpected networks: %v %v", d.Get("networks"), d.Get("ip_addresses"))
+	}
+	if d.Get("mounts.0.destination") != "/data" || !d.Get("mounts.0.read_only").(bool) {
+		t.Errorf("unexpected mounts: %v", d.Get("mounts"))
+	}
+	if got := mock.FindRequest("GET", "/endpoints/1/docker/containers/web-1/json").Headers.Get("X-PortainerAgent-Target"); got != "node-a" {
+		t.Errorf("expected agent target header, got %q", got)
+	}
+}
+
+// TestDataSourceDockerContainerRead_ByLabels looks the container up with a
+// label filter and requires a single match.
+func TestDataSourceDockerContainerRead_ByLabels(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/containers/json", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{"Id": "c0ffee", "Names": []string{"/web-1"}},
+	}))
+	mock.On("GET", "/endpoints/1/docker/containers/c0ffee/json", RespondJSON(http.StatusOK, dockerContainerInspectFixture))
+
+	ds := dataSourceDockerContainer()
+	d := ds.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("label_filter", map[string]interface{}{"com.docker.compose.service": "web"})
+
+	if err := rcRead(ds, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	list := mock.FindRequest("GET", "/endpoints/1/docker/containers/json")
+	query, _ := url.ParseQuery(list.Query)
+	if query.Get("all") != "1" || query.Get("filters") != `{"label":["com.docker.compose.service=web"]}` {
+		t.Errorf("unexpected list query: %s", list.Query)
+	}
+	if d.Id() != "c0ffee" {
+		t.Errorf("expected container c0ffee, got %q", d.Id())
+	}
+}
+
+// TestDataSourceDockerContainerRead_Ambiguous errors out when several
+// containers match the label filter.
+func TestDataSourceDockerContainerRead_Ambiguous(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/containers/json", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{"Id": "a", "Names": []string{"/web-1"}},
+		{"Id": "b", "Names": []string{"/web-2"}},
+	}))
+
+	ds := dataSourceDockerContainer()
+	d := ds.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("label_filter", map[string]interface{}{"app": "web"})
+
+	err := rcRead(ds, d, mock.Client())
+	if err == nil || !strings.Contains(err.Error(), "web-1, web-2") {
+		t.Fatalf("expected ambiguity error, got %v", err)
+	}
+}
@@ -2,10 +2,7 @@ package internal
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -22,9 +19,18 @@ func dataSourceDockerNode() *schema.Resource {
 				Description: "ID of the Portainer environment (Docker Swarm cluster) where the node is located.",
 			},
 			"hostname": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Hostname of the Docker Swarm node to look up.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"hostname", "node_id"},
+				Description:  "Hostname of the Docker Swarm node to look up.",
+			},
+			"node_id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"hostname", "node_id"},
+				Description:  "ID of the Docker Swarm node to look up, as an alternative to `hostname`.",
 			},
 			"role": {
 				Type:        schema.TypeString,
@@ -36,6 +42,37 @@ func dataSourceDockerNode() *schema.Resource {
 				Computed:    true,
 				Description: "Current status state of the Swarm node (e.g., ready, down, disconnected).",
 			},
+			"version": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Current version of the Swarm node.",
+			},
+			"availability": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Availability of the Swarm node (active, pause, or drain).",
+			},
+			"labels": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Labels of the Swarm node.",
+			},
+			"addr": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "IP address of the Swarm node.",
+			},
+			"manager_addr": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Address (`host:port`) of the manager API; empty for workers.",
+			},
+			"leader": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the node is the leader manager.",
+			},
 		},
 	}
 }
@@ -44,38 +81,15 @@ func dataSourceDockerNodeRead(ctx context.Context, d *schema.ResourceData, meta
 	client := meta.(*APIClient)
 	endpointID := d.Get("endpoint_id").(int)
 	hostname := d.Get("hostname").(string)
+	nodeID := d.Get("node_id").(string)
 
-	path := fmt.Sprintf("/endpoints/%d/docker/nodes", endpointID)
-	resp, err := client.DoRequest(http.MethodGet, path, nil, nil)
+	nodes, err := listDockerNodes(client, endpointID)
 	if err != nil {
-		return diag.FromErr(fmt.Errorf("failed to list docker nodes: %w", err))
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		data, _ := io.ReadAll(resp.Body)
-		// Nodes endpoint might fail if not in a Swarm cluster
-		return diag.FromErr(fmt.Errorf("failed to list docker nodes (is this a Swarm cluster?), status %d: %s", resp.StatusCode, string(data)))
-	}
-
-	var nodes []struct {
-		ID          string `json:"ID"`
-		Description struct {
-			Hostname string `json:"Hostname"`
-		} `json:"Description"`
-		Spec struct {
-			Role string `json:"Role"`
-		} `json:"Spec"`
-		Status struct {
-			State string `json:"State"`
-		} `json:"Status"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&nodes); err != nil {
-		return diag.FromErr(fmt.Errorf("failed to decode docker node list: %w", err))
+		return diag.FromErr(err)
 	}
 
 	for _, n := range nodes {
-		if n.Description.Hostname == hostname {
+		if (nodeID != "" && n.ID == nodeID) || (nodeID == "" && n.Description.Hostname == hostname) {
 			d.SetId(n.ID)
 			if err := d.Set("role", n.Spec.Role); err != nil {
 				return diag.FromErr(err)
@@ -83,9 +97,24 @@ func dataSourceDockerNodeRead(ctx context.Context, d *schema.ResourceData, meta
 			if err := d.Set("status", n.Status.State); err != nil {
 				return diag.FromErr(err)
 			}
+			_ = d.Set("node_id", n.ID)
+			_ = d.Set("hostname", n.Description.Hostname)
+			_ = d.Set("version", n.Version.Index)
+			_ = d.Set("availability", n.Spec.Availability)
+			_ = d.Set("labels", n.Spec.Labels)
+			_ = d.Set("addr", n.Status.Addr)
+			managerAddr, leader := "", false
+			if n.ManagerStatus != nil {
+				managerAddr, leader = n.ManagerStatus.Addr, n.ManagerStatus.Leader
+			}
+			_ = d.Set("manager_addr", managerAddr)
+			_ = d.Set("leader", leader)
 			return nil
 		}
 	}
 
+	if nodeID != "" {
+		return diag.FromErr(fmt.Errorf("docker node %s not found in endpoint %d", nodeID, endpointID))
+	}
 	return diag.FromErr(fmt.Errorf("docker node with hostname %s not found in endpoint %d", hostname, endpointID))
 }
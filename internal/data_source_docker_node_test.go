@@ -86,3 +86,32 @@ func TestDataSourceDockerNodeRead_HTTPError(t *testing.T) {
 		t.Fatal("expected error on HTTP 503, got nil")
 	}
 }
+
+// TestDataSourceDockerNodeRead_ByID looks the node up by ID and exposes its
+// hostname and manager address.
+func TestDataSourceDockerNodeRead_ByID(t *testing.T) {
+	mock := NewMockServer(t)
+
+	mock.On("GET", "/endpoints/9/docker/nodes", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{
+			"ID":            "node-mgr-1",
+			"Version":       map[string]interface{}{"Index": 31},
+			"Description":   map[string]interface{}{"Hostname": "mgr-1"},
+			"Spec":          map[string]interface{}{"Role": "manager", "Availability": "active"},
+			"Status":        map[string]interface{}{"State": "ready", "Addr": "10.0.0.1"},
+			"ManagerStatus": map[string]interface{}{"Leader": true, "Reachability": "reachable", "Addr": "10.0.0.1:2377"},
+		},
+	}))
+
+	ds := dataSourceDockerNode()
+	d := ds.TestResourceData()
+	_ = d.Set("endpoint_id", 9)
+	_ = d.Set("node_id", "node-mgr-1")
+
+	if err := rcRead(ds, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if d.Get("hostname") != "mgr-1" || d.Get("version") != 31 || d.Get("manager_addr") != "10.0.0.1:2377" || !d.Get("leader").(bool) {
+		t.Errorf("unexpected node: hostname=%v version=%v manager_addr=%v", d.Get("hostname"), d.Get("version"), d.Get("manager_addr"))
+	}
+}
@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceDockerSwarm() *schema.Resource {
+	computedInt := func(description string) *schema.Schema {
+		return &schema.Schema{Type: schema.TypeInt, Computed: true, Description: description}
+	}
+	computedString := func(description string) *schema.Schema {
+		return &schema.Schema{Type: schema.TypeString, Computed: true, Description: description}
+	}
+
+	return &schema.Resource{
+		ReadContext: dataSourceDockerSwarmRead,
+
+		Schema: map[string]*schema.Schema{
+			"endpoint_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "ID of the Portainer environment (Docker Swarm cluster).",
+			},
+			// Computed attributes
+			"swarm_id":   computedString("ID of the Swarm cluster."),
+			"created_at": computedString("Time the Swarm was initialized."),
+			"updated_at": computedString("Time the Swarm settings were last updated."),
+			"version":    computedInt("Version of the Swarm object."),
+			"worker_join_token": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Token joining a node as worker.",
+			},
+			"manager_join_token": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Token joining a node as manager.",
+			},
+			"manager_address": computedString("Address (`host:port`) of the leader manager, to pass to `docker swarm join`."),
+			"manager_addresses": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Addresses of all reachable managers, sorted.",
+			},
+			"task_history_retention_limit":   computedInt("Number of historic tasks kept per task slot."),
+			"snapshot_interval":              computedInt("Number of Raft log entries between snapshots."),
+			"keep_old_snapshots":             computedInt("Number of old Raft snapshots kept beside the current one."),
+			"log_entries_for_slow_followers": computedInt("Number of Raft log entries kept to sync up slow followers."),
+			"election_tick":                  computedInt("Raft ticks without leader before an election starts."),
+			"heartbeat_tick":                 computedInt("Raft ticks between leader heartbeats."),
+			"dispatcher_heartbeat_period":    computedString("Interval at which nodes report their status to the managers."),
+			"node_cert_expiry":               computedString("Validity of the node certificates issued by the Swarm CA."),
+			"ca_force_rotate":                computedInt("Root CA rotation counter."),
+			"root_rotation_in_progress": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether a root CA rotation is in progress.",
+			},
+			"autolock_managers": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether manager autolock is enabled.",
+			},
+			"default_addr_pool": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Address pools used for overlay network subnets.",
+			},
+			"subnet_size":    computedInt("Prefix length of the subnets allocated from the address pools."),
+			"data_path_port": computedInt("UDP port used for overlay data traffic."),
+		},
+	}
+}
+
+func dataSourceDockerSwarmRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+
+	swarm, err := inspectDockerSwarm(client, endpointID)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	setDockerSwarmSpec(d, swarm)
+	_ = d.Set("created_at", swarm.CreatedAt)
+	_ = d.Set("updated_at", swarm.UpdatedAt)
+	_ = d.Set("worker_join_token", swarm.JoinTokens.Worker)
+	_ = d.Set("manager_join_token", swarm.JoinTokens.Manager)
+	_ = d.Set("election_tick", swarm.Spec.Raft.ElectionTick)
+	_ = d.Set("heartbeat_tick", swarm.Spec.Raft.HeartbeatTick)
+	_ = d.Set("default_addr_pool", swarm.DefaultAddrPool)
+	_ = d.Set("subnet_size", swarm.SubnetSize)
+	_ = d.Set("data_path_port", swarm.DataPathPort)
+
+	nodes, err := listDockerNodes(client, endpointID)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	leader := ""
+	managers := []string{}
+	for _, n := range nodes {
+		if n.ManagerStatus == nil || n.ManagerStatus.Reachability != "reachable" {
+			continue
+		}
+		managers = append(managers, n.ManagerStatus.Addr)
+		if n.ManagerStatus.Leader {
+			leader = n.ManagerStatus.Addr
+		}
+	}
+	sort.Strings(managers)
+	_ = d.Set("manager_address", leader)
+	_ = d.Set("manager_addresses", managers)
+
+	d.SetId(fmt.Sprintf("%d-%s", endpointID, swarm.ID))
+	return nil
+}
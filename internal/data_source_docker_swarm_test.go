@@ -0,0 +1,36 @@
+package internal
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestDataSourceDockerSwarmRead exposes the join tokens, the leader address
+// and the cluster spec.
+func TestDataSourceDockerSwarmRead(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/swarm", RespondJSON(http.StatusOK, dockerSwarmInspectResponse(false)))
+	mock.On("GET", "/endpoints/1/docker/nodes", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{"ID": "n2", "ManagerStatus": map[string]interface{}{"Leader": false, "Reachability": "reachable", "Addr": "10.0.0.2:2377"}},
+		{"ID": "n1", "ManagerStatus": map[string]interface{}{"Leader": true, "Reachability": "reachable", "Addr": "10.0.0.1:2377"}},
+		{"ID": "n3", "ManagerStatus": map[string]interface{}{"Reachability": "unreachable", "Addr": "10.0.0.3:2377"}},
+		{"ID": "w1"},
+	}))
+
+	ds := dataSourceDockerSwarm()
+	d := ds.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+
+	if err := rcRead(ds, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if d.Get("worker_join_token") != "SWMTKN-1-worker" || d.Get("manager_join_token") != "SWMTKN-1-manager" {
+		t.Errorf("unexpected join tokens: %v %v", d.Get("worker_join_token"), d.Get("manager_join_token"))
+	}
+	if d.Get("manager_address") != "10.0.0.1:2377" || d.Get("manager_addresses.#") != 2 {
+		t.Errorf("unexpected manager addresses: %v %v", d.Get("manager_address"), d.Get("manager_addresses"))
+	}
+	if d.Get("election_tick") != 10 || d.Get("data_path_port") != 4789 || d.Get("default_addr_pool.0") != "10.0.0.0/8" {
+		t.Errorf("unexpected spec: election_tick=%v data_path_port=%v", d.Get("election_tick"), d.Get("data_path_port"))
+	}
+}
@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceDockerVolumeFile() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceDockerVolumeFileRead,
+
+		Schema: map[string]*schema.Schema{
+			"endpoint_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "ID of the Portainer environment where the volume is located.",
+			},
+			"swarm_node_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Swarm node holding the volume (sent as `X-PortainerAgent-Target`).",
+			},
+			"volume_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the Docker volume.",
+			},
+			"path": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateDockerVolumeFilePath,
+				Description:  "Path of the file relative to the root of the volume.",
+			},
+			"helper_image": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "busybox:latest",
+				Description: "Image of the short-lived helper container mounting the volume. It is pulled if missing and never started.",
+			},
+			// Computed attributes
+			"content": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Content of the file. Empty for files that are not valid UTF-8, use `content_base64`.",
+			},
+			"content_base64": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Base64-encoded content of the file.",
+			},
+			"checksum": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "SHA256 of the file content.",
+			},
+			"size": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Size of the file in bytes.",
+			},
+			"mode": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Octal file mode.",
+			},
+			"uid": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Numeric user ID owning the file.",
+			},
+			"gid": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Numeric group ID owning the file.",
+			},
+		},
+	}
+}
+
+func dataSourceDockerVolumeFileRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+	volume := d.Get("volume_name").(string)
+	filePath := dockerVolumeFilePath(d.Get("path").(string))
+	headers := dockerContainerHeaders(d)
+
+	exists, err := dockerVolumeExists(client, endpointID, volume, headers)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if !exists {
+		return diag.FromErr(fmt.Errorf("docker volume %s not found in endpoint %d", volume, endpointID))
+	}
+
+	var file *dockerVolumeFileInfo
+	err = withDockerVolumeHelper(client, endpointID, volume, d.Get("helper_image").(string), nil, headers, func(id string) error {
+		var err error
+		file, err = readDockerVolumeFile(client, endpointID, id, filePath, headers)
+		return err
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if file == nil {
+		return diag.FromErr(fmt.Errorf("file %s not found in docker volume %s", filePath, volume))
+	}
+
+	content := ""
+	if utf8.Valid(file.Content) {
+		content = string(file.Content)
+	}
+	_ = d.Set("content", content)
+	_ = d.Set("content_base64", base64.StdEncoding.EncodeToString(file.Content))
+	_ = d.Set("checksum", fmt.Sprintf("%x", sha256.Sum256(file.Content)))
+	_ = d.Set("size", len(file.Content))
+	_ = d.Set("mode", fmt.Sprintf("%04o", file.Mode))
+	_ = d.Set("uid", file.UID)
+	_ = d.Set("gid", file.GID)
+
+	d.SetId(fmt.Sprintf("%d:%s:%s", endpointID, volume, filePath))
+	return nil
+}
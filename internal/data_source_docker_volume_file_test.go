@@ -0,0 +1,36 @@
+package internal
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+)
+
+// TestDataSourceDockerVolumeFileRead reads the file content and metadata
+// back out of the volume.
+func TestDataSourceDockerVolumeFileRead(t *testing.T) {
+	mock := NewMockServer(t)
+	registerDockerVolumeHelperMocks(mock)
+	mock.On("GET", "/endpoints/1/docker/containers/helper1/archive", RespondString(http.StatusOK, "application/x-tar",
+		dockerVolumeFileArchive(t, "app.yml", "listen: 8080\n", 0o600, 0, 33)))
+
+	ds := dataSourceDockerVolumeFile()
+	d := ds.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("volume_name", "app-config")
+	_ = d.Set("path", "/conf/app.yml")
+	_ = d.Set("helper_image", "busybox:latest")
+
+	if err := rcRead(ds, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if d.Get("content") != "listen: 8080\n" || d.Get("content_base64") != base64.StdEncoding.EncodeToString([]byte("listen: 8080\n")) {
+		t.Errorf("unexpected content: %q", d.Get("content"))
+	}
+	if d.Get("mode") != "0600" || d.Get("gid") != 33 || d.Get("size") != 13 {
+		t.Errorf("unexpected metadata: mode=%v gid=%v size=%v", d.Get("mode"), d.Get("gid"), d.Get("size"))
+	}
+	if d.Id() != "1:app-config:conf/app.yml" {
+		t.Errorf("unexpected ID %q", d.Id())
+	}
+}
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceEdgeStackStatus() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceEdgeStackStatusRead,
+
+		Schema: map[string]*schema.Schema{
+			"edge_stack_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "ID of the edge stack.",
+			},
+			"total": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of environments the edge stack is deployed to.",
+			},
+			"running": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of environments running the edge stack.",
+			},
+			"failed": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of environments reporting a deployment error.",
+			},
+			"pending": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of environments where the deployment is still in progress.",
+			},
+			"environment_status": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        edgeStackEnvironmentStatusSchema(),
+				Description: "Deployment status of the edge stack on each environment.",
+			},
+		},
+	}
+}
+
+func dataSourceEdgeStackStatusRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	id := strconv.Itoa(d.Get("edge_stack_id").(int))
+
+	statuses, found, listErr, err := fetchEdgeStackEnvironments(ctx, client, id)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if !found {
+		return diag.FromErr(fmt.Errorf("edge stack %s not found", id))
+	}
+	// The counters would only cover the reporting environments.
+	if listErr != nil {
+		return diag.FromErr(listErr)
+	}
+
+	succeeded, failed, pending := classifyEdgeStackEnvironments(statuses)
+	d.SetId(id)
+	if err := d.Set("total", len(statuses)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("running", len(succeeded)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("failed", len(failed)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("pending", len(pending)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("environment_status", flattenEdgeStackEnvironments(statuses)); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceKubernetesEvents() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceKubernetesEventsRead,
+
+		Schema: map[string]*schema.Schema{
+			"environment_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "Environment (endpoint) identifier.",
+			},
+			"namespace": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Namespace to list events from. If not set, events of all namespaces are listed.",
+			},
+			"involved_object_kind": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return events about objects of this kind (e.g. `Pod`, `Deployment`).",
+			},
+			"involved_object_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return events about the object with this name.",
+			},
+			"type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"Normal", "Warning"}, false),
+				Description:  "Only return events of this type (`Normal` or `Warning`).",
+			},
+			// Computed attributes
+			"events": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Events matching the filters.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Event object name.",
+						},
+						"namespace": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Event namespace.",
+						},
+						"type": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Event type (Normal or Warning).",
+						},
+						"reason": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Short machine-readable reason (e.g. `BackOff`, `FailedScheduling`).",
+						},
+						"message": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Human-readable event message.",
+						},
+						"count": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Number of times the event occurred.",
+						},
+						"first_timestamp": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Time the event was first recorded.",
+						},
+						"last_timestamp": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Time the event was most recently recorded.",
+						},
+						"involved_object_kind": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Kind of the object the event is about.",
+						},
+						"involved_object_name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Name of the object the event is about.",
+						},
+						"source_component": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Component that reported the event (e.g. `kubelet`).",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+type k8sEvent struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Type           string `json:"type"`
+	Reason         string `json:"reason"`
+	Message        string `json:"message"`
+	Count          int    `json:"count"`
+	FirstTimestamp string `json:"firstTimestamp"`
+	LastTimestamp  string `json:"lastTimestamp"`
+	InvolvedObject struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	} `json:"involvedObject"`
+	Source struct {
+		Component string `json:"component"`
+	} `json:"source"`
+}
+
+func dataSourceKubernetesEventsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+
+	envID := d.Get("environment_id").(int)
+	namespace := d.Get("namespace").(string)
+
+	// The core events API supports field selectors on involvedObject.* and type,
+	// so filtering happens server-side.
+	var selectors []string
+	if v := d.Get("involved_object_kind").(string); v != "" {
+		selectors = append(selectors, "involvedObject.kind="+v)
+	}
+	if v := d.Get("involved_object_name").(string); v != "" {
+		selectors = append(selectors, "involvedObject.name="+v)
+	}
+	if v := d.Get("type").(string); v != "" {
+		selectors = append(selectors, "type="+v)
+	}
+	fieldSelector := strings.Join(selectors, ",")
+
+	listURL := k8sProxyURL(client, envID, "", namespace, "events")
+	if fieldSelector != "" {
+		listURL += "?" + url.Values{"fieldSelector": {fieldSelector}}.Encode()
+	}
+
+	var items []k8sEvent
+	if err := k8sProxyList(ctx, client, listURL, "events", &items); err != nil {
+		return diag.FromErr(err)
+	}
+
+	events := make([]map[string]interface{}, 0, len(items))
+	for _, e := range items {
+		events = append(events, map[string]interface{}{
+			"name":                 e.Metadata.Name,
+			"namespace":            e.Metadata.Namespace,
+			"type":                 e.Type,
+			"reason":               e.Reason,
+			"message":              e.Message,
+			"count":                e.Count,
+			"first_timestamp":      e.FirstTimestamp,
+			"last_timestamp":       e.LastTimestamp,
+			"involved_object_kind": e.InvolvedObject.Kind,
+			"involved_object_name": e.InvolvedObject.Name,
+			"source_component":     e.Source.Component,
+		})
+	}
+	if err := d.Set("events", events); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(fmt.Sprintf("%d:%s:%s", envID, namespace, fieldSelector))
+	return nil
+}
@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// TestDataSourceKubernetesEventsRead forwards involvedObject filters as a field
+// selector and maps the returned events.
+func TestDataSourceKubernetesEventsRead(t *testing.T) {
+	mock := NewMockServer(t)
+
+	mock.On("GET", "/endpoints/3/kubernetes/api/v1/namespaces/web/events",
+		RespondJSON(http.StatusOK, map[string]interface{}{
+			"items": []map[string]interface{}{
+				{
+					"metadata":       map[string]interface{}{"name": "web-1.17a", "namespace": "web"},
+					"type":           "Warning",
+					"reason":         "BackOff",
+					"message":        "Back-off restarting failed container",
+					"count":          7,
+					"involvedObject": map[string]interface{}{"kind": "Pod", "name": "web-1"},
+					"source":         map[string]interface{}{"component": "kubelet"},
+				},
+			},
+		}))
+
+	ds := dataSourceKubernetesEvents()
+	d := ds.TestResourceData()
+	_ = d.Set("environment_id", 3)
+	_ = d.Set("namespace", "web")
+	_ = d.Set("involved_object_kind", "Pod")
+	_ = d.Set("involved_object_name", "web-1")
+	_ = d.Set("type", "Warning")
+
+	if err := rcRead(ds, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	req := mock.FindRequest("GET", "/endpoints/3/kubernetes/api/v1/namespaces/web/events")
+	q, _ := url.ParseQuery(req.Query)
+	if got := q.Get("fieldSelector"); got != "involvedObject.kind=Pod,involvedObject.name=web-1,type=Warning" {
+		t.Errorf("unexpected fieldSelector %q", got)
+	}
+
+	events := d.Get("events").([]interface{})
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	e := events[0].(map[string]interface{})
+	if e["reason"] != "BackOff" || e["count"] != 7 || e["source_component"] != "kubelet" {
+		t.Errorf("unexpected event fields: %v", e)
+	}
+}
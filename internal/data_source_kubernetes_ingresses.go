@@ -0,0 +1,203 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceKubernetesIngresses() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceKubernetesIngressesRead,
+
+		Schema: map[string]*schema.Schema{
+			"environment_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "Environment (endpoint) identifier.",
+			},
+			"namespace": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Namespace to list ingresses from. If not set, ingresses of all namespaces are listed.",
+			},
+			"label_selector": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Kubernetes label selector applied to the ingresses.",
+			},
+			// Computed attributes
+			"ingresses": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Ingresses matching the selector.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Ingress name.",
+						},
+						"namespace": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Ingress namespace.",
+						},
+						"class_name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "IngressClass handling the ingress.",
+						},
+						"hosts": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Distinct hostnames resolved from the ingress rules.",
+						},
+						"tls_hosts": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Hostnames covered by TLS.",
+						},
+						"paths": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "Routing rules flattened to one entry per host/path.",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"host": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Hostname of the rule (empty for catch-all rules).",
+									},
+									"path": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "URL path.",
+									},
+									"path_type": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Path matching strategy.",
+									},
+									"service_name": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Backend Service name.",
+									},
+									"service_port": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Backend Service port (number or name).",
+									},
+								},
+							},
+						},
+						"load_balancer_ingress": k8sLoadBalancerIngressSchema,
+					},
+				},
+			},
+		},
+	}
+}
+
+type k8sIngressObject struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		IngressClassName string `json:"ingressClassName"`
+		TLS              []struct {
+			Hosts []string `json:"hosts"`
+		} `json:"tls"`
+		Rules []struct {
+			Host string `json:"host"`
+			HTTP struct {
+				Paths []struct {
+					Path     string `json:"path"`
+					PathType string `json:"pathType"`
+					Backend  struct {
+						Service struct {
+							Name string `json:"name"`
+							Port struct {
+								Number int    `json:"number"`
+								Name   string `json:"name"`
+							} `json:"port"`
+						} `json:"service"`
+					} `json:"backend"`
+				} `json:"paths"`
+			} `json:"http"`
+		} `json:"rules"`
+	} `json:"spec"`
+	Status struct {
+		LoadBalancer k8sLoadBalancerStatus `json:"loadBalancer"`
+	} `json:"status"`
+}
+
+func dataSourceKubernetesIngressesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+
+	envID := d.Get("environment_id").(int)
+	namespace := d.Get("namespace").(string)
+	selector := d.Get("label_selector").(string)
+
+	listURL := k8sProxyURL(client, envID, "networking.k8s.io/v1", namespace, "ingresses")
+	if selector != "" {
+		listURL += "?" + url.Values{"labelSelector": {selector}}.Encode()
+	}
+
+	var items []k8sIngressObject
+	if err := k8sProxyList(ctx, client, listURL, "ingresses", &items); err != nil {
+		return diag.FromErr(err)
+	}
+
+	ingresses := make([]map[string]interface{}, 0, len(items))
+	for _, ing := range items {
+		hosts := []string{}
+		seen := map[string]bool{}
+		paths := []map[string]interface{}{}
+		for _, rule := range ing.Spec.Rules {
+			if rule.Host != "" && !seen[rule.Host] {
+				seen[rule.Host] = true
+				hosts = append(hosts, rule.Host)
+			}
+			for _, p := range rule.HTTP.Paths {
+				port := p.Backend.Service.Port.Name
+				if port == "" && p.Backend.Service.Port.Number != 0 {
+					port = fmt.Sprint(p.Backend.Service.Port.Number)
+				}
+				paths = append(paths, map[string]interface{}{
+					"host":         rule.Host,
+					"path":         p.Path,
+					"path_type":    p.PathType,
+					"service_name": p.Backend.Service.Name,
+					"service_port": port,
+				})
+			}
+		}
+		tlsHosts := []string{}
+		for _, t := range ing.Spec.TLS {
+			tlsHosts = append(tlsHosts, t.Hosts...)
+		}
+		ingresses = append(ingresses, map[string]interface{}{
+			"name":                  ing.Metadata.Name,
+			"namespace":             ing.Metadata.Namespace,
+			"class_name":            ing.Spec.IngressClassName,
+			"hosts":                 hosts,
+			"tls_hosts":             tlsHosts,
+			"paths":                 paths,
+			"load_balancer_ingress": flattenK8sLoadBalancer(ing.Status.LoadBalancer),
+		})
+	}
+	if err := d.Set("ingresses", ingresses); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(fmt.Sprintf("%d:%s:%s", envID, namespace, selector))
+	return nil
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestDataSourceKubernetesIngressesRead resolves distinct hosts and flattens paths.
+func TestDataSourceKubernetesIngressesRead(t *testing.T) {
+	mock := NewMockServer(t)
+
+	mock.On("GET", "/endpoints/3/kubernetes/apis/networking.k8s.io/v1/namespaces/web/ingresses",
+		RespondJSON(http.StatusOK, map[string]interface{}{
+			"items": []map[string]interface{}{
+				{
+					"metadata": map[string]interface{}{"name": "web", "namespace": "web"},
+					"spec": map[string]interface{}{
+						"ingressClassName": "nginx",
+						"tls":              []map[string]interface{}{{"hosts": []string{"app.example.com"}}},
+						"rules": []map[string]interface{}{
+							{"host": "app.example.com", "http": map[string]interface{}{"paths": []map[string]interface{}{
+								{"path": "/", "pathType": "Prefix", "backend": map[string]interface{}{"service": map[string]interface{}{"name": "web", "port": map[string]interface{}{"number": 80}}}},
+								{"path": "/api", "pathType": "Prefix", "backend": map[string]interface{}{"service": map[string]interface{}{"name": "api", "port": map[string]interface{}{"name": "http"}}}},
+							}}},
+						},
+					},
+					"status": map[string]interface{}{
+						"loadBalancer": map[string]interface{}{
+							"ingress": []map[string]interface{}{{"hostname": "lb.example.net"}},
+						},
+					},
+				},
+			},
+		}))
+
+	ds := dataSourceKubernetesIngresses()
+	d := ds.TestResourceData()
+	_ = d.Set("environment_id", 3)
+	_ = d.Set("namespace", "web")
+
+	if err := rcRead(ds, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	ing := d.Get("ingresses").([]interface{})[0].(map[string]interface{})
+	hosts := ing["hosts"].([]interface{})
+	if len(hosts) != 1 || hosts[0] != "app.example.com" {
+		t.Errorf("expected one distinct host, got %v", hosts)
+	}
+	paths := ing["paths"].([]interface{})
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 paths, got %d", len(paths))
+	}
+	if paths[0].(map[string]interface{})["service_port"] != "80" || paths[1].(map[string]interface{})["service_port"] != "http" {
+		t.Errorf("unexpected service ports: %v", paths)
+	}
+	if ing["load_balancer_ingress"].([]interface{})[0].(map[string]interface{})["hostname"] != "lb.example.net" {
+		t.Errorf("unexpected load balancer ingress: %v", ing["load_balancer_ingress"])
+	}
+}
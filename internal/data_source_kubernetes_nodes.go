@@ -0,0 +1,234 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceKubernetesNodes() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceKubernetesNodesRead,
+
+		Schema: map[string]*schema.Schema{
+			"environment_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "Environment (endpoint) identifier.",
+			},
+			"label_selector": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Kubernetes label selector (e.g. `node-role.kubernetes.io/control-plane`).",
+			},
+			// Computed attributes
+			"nodes": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Nodes matching the selector.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Node name.",
+						},
+						"ready": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether the node reports the Ready condition as True.",
+						},
+						"unschedulable": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether the node is cordoned.",
+						},
+						"internal_ip": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Node InternalIP address.",
+						},
+						"kubelet_version": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Kubelet version reported by the node.",
+						},
+						"labels": {
+							Type:        schema.TypeMap,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Node labels.",
+						},
+						"capacity": {
+							Type:        schema.TypeMap,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Total node resources (cpu, memory, pods, ephemeral-storage, …).",
+						},
+						"allocatable": {
+							Type:        schema.TypeMap,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Resources available for scheduling.",
+						},
+						"taints": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "Node taints.",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"key": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Taint key.",
+									},
+									"value": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Taint value.",
+									},
+									"effect": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Taint effect (NoSchedule, PreferNoSchedule, NoExecute).",
+									},
+								},
+							},
+						},
+						"conditions": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "Node conditions.",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"type": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Condition type (Ready, MemoryPressure, DiskPressure, …).",
+									},
+									"status": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Condition status (True, False, Unknown).",
+									},
+									"reason": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Machine-readable reason for the condition.",
+									},
+									"message": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Human-readable condition message.",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+type k8sNode struct {
+	Metadata struct {
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		Unschedulable bool `json:"unschedulable"`
+		Taints        []struct {
+			Key    string `json:"key"`
+			Value  string `json:"value"`
+			Effect string `json:"effect"`
+		} `json:"taints"`
+	} `json:"spec"`
+	Status struct {
+		Capacity    map[string]string `json:"capacity"`
+		Allocatable map[string]string `json:"allocatable"`
+		Conditions  []struct {
+			Type    string `json:"type"`
+			Status  string `json:"status"`
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"conditions"`
+		Addresses []struct {
+			Type    string `json:"type"`
+			Address string `json:"address"`
+		} `json:"addresses"`
+		NodeInfo struct {
+			KubeletVersion string `json:"kubeletVersion"`
+		} `json:"nodeInfo"`
+	} `json:"status"`
+}
+
+func dataSourceKubernetesNodesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+
+	envID := d.Get("environment_id").(int)
+	selector := d.Get("label_selector").(string)
+
+	listURL := k8sProxyURL(client, envID, "", "", "nodes")
+	if selector != "" {
+		listURL += "?" + url.Values{"labelSelector": {selector}}.Encode()
+	}
+
+	var items []k8sNode
+	if err := k8sProxyList(ctx, client, listURL, "nodes", &items); err != nil {
+		return diag.FromErr(err)
+	}
+
+	nodes := make([]map[string]interface{}, 0, len(items))
+	for _, n := range items {
+		taints := make([]map[string]interface{}, 0, len(n.Spec.Taints))
+		for _, t := range n.Spec.Taints {
+			taints = append(taints, map[string]interface{}{
+				"key":    t.Key,
+				"value":  t.Value,
+				"effect": t.Effect,
+			})
+		}
+		ready := false
+		conditions := make([]map[string]interface{}, 0, len(n.Status.Conditions))
+		for _, c := range n.Status.Conditions {
+			if c.Type == "Ready" && c.Status == "True" {
+				ready = true
+			}
+			conditions = append(conditions, map[string]interface{}{
+				"type":    c.Type,
+				"status":  c.Status,
+				"reason":  c.Reason,
+				"message": c.Message,
+			})
+		}
+		internalIP := ""
+		for _, a := range n.Status.Addresses {
+			if a.Type == "InternalIP" {
+				internalIP = a.Address
+				break
+			}
+		}
+		nodes = append(nodes, map[string]interface{}{
+			"name":            n.Metadata.Name,
+			"ready":           ready,
+			"unschedulable":   n.Spec.Unschedulable,
+			"internal_ip":     internalIP,
+			"kubelet_version": n.Status.NodeInfo.KubeletVersion,
+			"labels":          n.Metadata.Labels,
+			"capacity":        n.Status.Capacity,
+			"allocatable":     n.Status.Allocatable,
+			"taints":          taints,
+			"conditions":      conditions,
+		})
+	}
+	if err := d.Set("nodes", nodes); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(fmt.Sprintf("%d:%s", envID, selector))
+	return nil
+}
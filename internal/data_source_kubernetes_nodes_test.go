@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestDataSourceKubernetesNodesRead maps capacity, taints and the Ready condition.
+func TestDataSourceKubernetesNodesRead(t *testing.T) {
+	mock := NewMockServer(t)
+
+	mock.On("GET", "/endpoints/3/kubernetes/api/v1/nodes",
+		RespondJSON(http.StatusOK, map[string]interface{}{
+			"items": []map[string]interface{}{
+				{
+					"metadata": map[string]interface{}{"name": "node-a", "labels": map[string]string{"zone": "a"}},
+					"spec": map[string]interface{}{
+						"taints": []map[string]interface{}{{"key": "dedicated", "value": "gpu", "effect": "NoSchedule"}},
+					},
+					"status": map[string]interface{}{
+						"capacity":    map[string]string{"cpu": "8", "memory": "32Gi"},
+						"allocatable": map[string]string{"cpu": "7500m"},
+						"conditions": []map[string]interface{}{
+							{"type": "MemoryPressure", "status": "False"},
+							{"type": "Ready", "status": "True", "reason": "KubeletReady"},
+						},
+						"addresses": []map[string]interface{}{
+							{"type": "Hostname", "address": "node-a"},
+							{"type": "InternalIP", "address": "192.168.1.10"},
+						},
+						"nodeInfo": map[string]interface{}{"kubeletVersion": "v1.30.1"},
+					},
+				},
+			},
+		}))
+
+	ds := dataSourceKubernetesNodes()
+	d := ds.TestResourceData()
+	_ = d.Set("environment_id", 3)
+
+	if err := rcRead(ds, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	nodes := d.Get("nodes").([]interface{})
+	if len(nodes) != 1 {
+		t.Fatalf("expected 1 node, got %d", len(nodes))
+	}
+	n := nodes[0].(map[string]interface{})
+	if n["ready"] != true || n["internal_ip"] != "192.168.1.10" || n["kubelet_version"] != "v1.30.1" {
+		t.Errorf("unexpected node fields: %v", n)
+	}
+	if n["capacity"].(map[string]interface{})["memory"] != "32Gi" {
+		t.Errorf("capacity.memory: expected 32Gi, got %v", n["capacity"])
+	}
+	taint := n["taints"].([]interface{})[0].(map[string]interface{})
+	if taint["effect"] != "NoSchedule" {
+		t.Errorf("taint effect: expected NoSchedule, got %v", taint["effect"])
+	}
+}
@@ -0,0 +1,224 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceKubernetesPods() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceKubernetesPodsRead,
+
+		Schema: map[string]*schema.Schema{
+			"environment_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "Environment (endpoint) identifier.",
+			},
+			"namespace": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Namespace to list pods from. If not set, pods of all namespaces are listed.",
+			},
+			"label_selector": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Kubernetes label selector (e.g. `app=web,tier!=cache`).",
+			},
+			"field_selector": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Kubernetes field selector (e.g. `status.phase=Running`).",
+			},
+			// Computed attributes
+			"pods": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Pods matching the selectors.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Pod name.",
+						},
+						"namespace": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Pod namespace.",
+						},
+						"phase": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Pod phase (Pending, Running, Succeeded, Failed, Unknown).",
+						},
+						"ready": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether every container of the pod is ready.",
+						},
+						"restart_count": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Sum of container restart counts.",
+						},
+						"node_name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Node the pod is scheduled on.",
+						},
+						"pod_ip": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Pod IP address.",
+						},
+						"start_time": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Time the pod was acknowledged by the kubelet.",
+						},
+						"labels": {
+							Type:        schema.TypeMap,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Pod labels.",
+						},
+						"containers": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "Container statuses.",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"name": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Container name.",
+									},
+									"image": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Container image.",
+									},
+									"ready": {
+										Type:        schema.TypeBool,
+										Computed:    true,
+										Description: "Whether the container passes its readiness probe.",
+									},
+									"restart_count": {
+										Type:        schema.TypeInt,
+										Computed:    true,
+										Description: "Number of container restarts.",
+									},
+									"state": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Current state (`running`, `waiting` or `terminated`).",
+									},
+									"reason": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Reason for a waiting or terminated state (e.g. `CrashLoopBackOff`).",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+type k8sPod struct {
+	Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		NodeName string `json:"nodeName"`
+	} `json:"spec"`
+	Status struct {
+		Phase             string `json:"phase"`
+		PodIP             string `json:"podIP"`
+		StartTime         string `json:"startTime"`
+		ContainerStatuses []struct {
+			Name         string `json:"name"`
+			Image        string `json:"image"`
+			Ready        bool   `json:"ready"`
+			RestartCount int    `json:"restartCount"`
+			State        map[string]struct {
+				Reason string `json:"reason"`
+			} `json:"state"`
+		} `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+func dataSourceKubernetesPodsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+
+	envID := d.Get("environment_id").(int)
+	namespace := d.Get("namespace").(string)
+
+	query := url.Values{}
+	if v := d.Get("label_selector").(string); v != "" {
+		query.Set("labelSelector", v)
+	}
+	if v := d.Get("field_selector").(string); v != "" {
+		query.Set("fieldSelector", v)
+	}
+	listURL := k8sProxyURL(client, envID, "", namespace, "pods")
+	if len(query) > 0 {
+		listURL += "?" + query.Encode()
+	}
+
+	var items []k8sPod
+	if err := k8sProxyList(ctx, client, listURL, "pods", &items); err != nil {
+		return diag.FromErr(err)
+	}
+
+	pods := make([]map[string]interface{}, 0, len(items))
+	for _, p := range items {
+		ready := len(p.Status.ContainerStatuses) > 0
+		restarts := 0
+		containers := make([]map[string]interface{}, 0, len(p.Status.ContainerStatuses))
+		for _, c := range p.Status.ContainerStatuses {
+			ready = ready && c.Ready
+			restarts += c.RestartCount
+			state, reason := "", ""
+			for s, detail := range c.State {
+				state, reason = s, detail.Reason
+			}
+			containers = append(containers, map[string]interface{}{
+				"name":          c.Name,
+				"image":         c.Image,
+				"ready":         c.Ready,
+				"restart_count": c.RestartCount,
+				"state":         state,
+				"reason":        reason,
+			})
+		}
+		pods = append(pods, map[string]interface{}{
+			"name":          p.Metadata.Name,
+			"namespace":     p.Metadata.Namespace,
+			"phase":         p.Status.Phase,
+			"ready":         ready,
+			"restart_count": restarts,
+			"node_name":     p.Spec.NodeName,
+			"pod_ip":        p.Status.PodIP,
+			"start_time":    p.Status.StartTime,
+			"labels":        p.Metadata.Labels,
+			"containers":    containers,
+		})
+	}
+	if err := d.Set("pods", pods); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(fmt.Sprintf("%d:%s:%s", envID, namespace, query.Encode()))
+	return nil
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestDataSourceKubernetesPodsRead lists pods through the Kubernetes proxy with
+// the label selector forwarded, and aggregates readiness and restart counts.
+func TestDataSourceKubernetesPodsRead(t *testing.T) {
+	mock := NewMockServer(t)
+
+	mock.On("GET", "/endpoints/3/kubernetes/api/v1/namespaces/web/pods",
+		RespondJSON(http.StatusOK, map[string]interface{}{
+			"items": []map[string]interface{}{
+				{
+					"metadata": map[string]interface{}{"name": "web-1", "namespace": "web", "labels": map[string]string{"app": "web"}},
+					"spec":     map[string]interface{}{"nodeName": "node-a"},
+					"status": map[string]interface{}{
+						"phase": "Running",
+						"podIP": "10.0.0.5",
+						"containerStatuses": []map[string]interface{}{
+							{"name": "app", "image": "nginx:1.27", "ready": true, "restartCount": 2, "state": map[string]interface{}{"running": map[string]interface{}{}}},
+							{"name": "sidecar", "image": "envoy", "ready": false, "restartCount": 3, "state": map[string]interface{}{"waiting": map[string]interface{}{"reason": "CrashLoopBackOff"}}},
+						},
+					},
+				},
+			},
+		}))
+
+	ds := dataSourceKubernetesPods()
+	d := ds.TestResourceData()
+	_ = d.Set("environment_id", 3)
+	_ = d.Set("namespace", "web")
+	_ = d.Set("label_selector", "app=web")
+
+	if err := rcRead(ds, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	req := mock.FindRequest("GET", "/endpoints/3/kubernetes/api/v1/namespaces/web/pods")
+	if req == nil || req.Query != "labelSelector=app%3Dweb" {
+		t.Fatalf("expected label selector in query, got %+v", req)
+	}
+
+	pods := d.Get("pods").([]interface{})
+	if len(pods) != 1 {
+		t.Fatalf("expected 1 pod, got %d", len(pods))
+	}
+	p := pods[0].(map[string]interface{})
+	if p["phase"] != "Running" || p["node_name"] != "node-a" || p["pod_ip"] != "10.0.0.5" {
+		t.Errorf("unexpected pod fields: %v", p)
+	}
+	if p["ready"] != false {
+		t.Errorf("ready: expected false when a container is not ready, got %v", p["ready"])
+	}
+	if p["restart_count"] != 5 {
+		t.Errorf("restart_count: expected 5, got %v", p["restart_count"])
+	}
+	c := p["containers"].([]interface{})[1].(map[string]interface{})
+	if c["state"] != "waiting" || c["reason"] != "CrashLoopBackOff" {
+		t.Errorf("unexpected container state: %v", c)
+	}
+}
+
+// TestDataSourceKubernetesPodsRead_HTTPError surfaces proxy errors.
+func TestDataSourceKubernetesPodsRead_HTTPError(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/3/kubernetes/api/v1/pods",
+		RespondString(http.StatusForbidden, "application/json", `{"message":"forbidden"}`))
+
+	ds := dataSourceKubernetesPods()
+	d := ds.TestResourceData()
+	_ = d.Set("environment_id", 3)
+
+	if err := rcRead(ds, d, mock.Client()); err == nil {
+		t.Fatal("expected error on HTTP 403, got nil")
+	}
+}
@@ -0,0 +1,542 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// Portainer maps every user to a service account in the "portainer" namespace
+// (portainer-sa-user-<instanceID>-<userID>) and every administrator to
+// portainer-sa-clusteradmin. Namespace access is stored in the portainer-config
+// ConfigMap under NamespaceAccessPolicies.
+const (
+	portainerSystemNamespace       = "portainer"
+	portainerClusterAdminSA        = "portainer-sa-clusteradmin"
+	portainerConfigMapName         = "portainer-config"
+	portainerNamespaceAccessKey    = "NamespaceAccessPolicies"
+	kubernetesClusterAdminRoleName = "cluster-admin"
+)
+
+var portainerUserSAPattern = regexp.MustCompile(`^portainer-sa-user-(?:.+-)?(\d+)$`)
+
+var rbacSeverityRank = map[string]int{"none": 0, "warning": 1, "critical": 2}
+
+func dataSourceKubernetesRBACReport() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceKubernetesRBACReportRead,
+
+		Schema: map[string]*schema.Schema{
+			"environment_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "Environment (endpoint) identifier of the Kubernetes cluster to audit.",
+			},
+			"allowed_cluster_admin_subjects": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Binding subjects allowed to hold cluster-admin besides Portainer administrators and `system:masters`, as `Kind:name` or `Kind:namespace/name` (e.g. `Group:platform-admins`, `ServiceAccount:flux-system/kustomize-controller`).",
+			},
+			"fail_on": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "none",
+				ValidateFunc: validation.StringInSlice([]string{"none", "warning", "critical"}, false),
+				Description:  "Validation mode: fail the read when a finding of this severity or higher is reported (`none`, `warning` or `critical`).",
+			},
+			// Computed attributes
+			"users": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Effective Kubernetes permissions of every Portainer user.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"user_id": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Portainer user identifier.",
+						},
+						"username": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Portainer username.",
+						},
+						"role": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Portainer role (`administrator` or `standard`).",
+						},
+						"environment_access": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether Portainer grants the user access to the environment.",
+						},
+						"access_sources": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Where the environment access comes from: `environment`, `environment_group`, `team:<name>` or `administrator`.",
+						},
+						"authorized_namespaces": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Namespaces Portainer grants the user through namespace access (`*` for administrators).",
+						},
+						"service_account": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Service account Portainer uses for the user, as `namespace/name`, when one is bound.",
+						},
+						"cluster_roles": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "ClusterRoles bound cluster-wide to the user's service account.",
+						},
+						"namespace_roles": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Roles bound in namespaces to the user's service account, as `namespace/Kind/name`.",
+						},
+					},
+				},
+			},
+			"teams": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Access Portainer grants to every team on the environment.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"team_id": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Portainer team identifier.",
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Team name.",
+						},
+						"environment_access": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether the team has access to the environment, directly or through its group.",
+						},
+						"authorized_namespaces": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Namespaces the team is granted through namespace access.",
+						},
+					},
+				},
+			},
+			"findings": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Discrepancies between Portainer access and the Kubernetes RBAC of the cluster.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"severity": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "`critical` or `warning`.",
+						},
+						"type": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Finding type: `cluster_admin_binding`, `binding_without_environment_access`, `namespace_binding_without_access` or `orphaned_service_account`.",
+						},
+						"subject": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Binding subject the finding is about, as `Kind:namespace/name`.",
+						},
+						"binding": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Binding granting the permission, as `ClusterRoleBinding/name` or `RoleBinding/namespace/name`.",
+						},
+						"message": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Human-readable description of the finding.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+type rbacBinding struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	RoleRef struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	} `json:"roleRef"`
+	Subjects []struct {
+		Kind      string `json:"kind"`
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"subjects"`
+}
+
+type rbacUser struct {
+	ID       int    `json:"Id"`
+	Username string `json:"Username"`
+	Role     int    `json:"Role"`
+}
+
+type rbacFinding struct {
+	Severity, Type, Subject, Binding, Message string
+}
+
+func rbacGetJSON(ctx context.Context, client *APIClient, url, what string, out interface{}) (bool, error) {
+	data, status, err := k8sProxyDo(ctx, client, http.MethodGet, url, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch %s: %w", what, err)
+	}
+	if status == http.StatusNotFound {
+		return false, nil
+	}
+	if status != http.StatusOK {
+		return false, fmt.Errorf("failed to fetch %s (%d): %s", what, status, string(data))
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return false, fmt.Errorf("failed to decode %s: %w", what, err)
+	}
+	return true, nil
+}
+
+func rbacSubjectKey(kind, namespace, name string) string {
+	if namespace == "" {
+		return kind + ":" + name
+	}
+	return kind + ":" + namespace + "/" + name
+}
+
+func dataSourceKubernetesRBACReportRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	envID := d.Get("environment_id").(int)
+
+	// Portainer side: users, teams, memberships and the three access layers.
+	var users []rbacUser
+	if _, err := rbacGetJSON(ctx, client, client.Endpoint+"/users", "users", &users); err != nil {
+		return diag.FromErr(err)
+	}
+	var teams []struct {
+		ID   int    `json:"Id"`
+		Name string `json:"Name"`
+	}
+	if _, err := rbacGetJSON(ctx, client, client.Endpoint+"/teams", "teams", &teams); err != nil {
+		return diag.FromErr(err)
+	}
+	var memberships []struct {
+		UserID int `json:"UserID"`
+		TeamID int `json:"TeamID"`
+	}
+	if _, err := rbacGetJSON(ctx, client, client.Endpoint+"/team_memberships", "team memberships", &memberships); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var endpoint struct {
+		GroupID int `json:"GroupId"`
+		EndpointGroupAccessPolicies
+	}
+	found, err := rbacGetJSON(ctx, client, fmt.Sprintf("%s/endpoints/%d", client.Endpoint, envID), "environment", &endpoint)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if !found {
+		return diag.FromErr(fmt.Errorf("environment %d not found", envID))
+	}
+	group := &EndpointGroupAccessPolicies{}
+	if endpoint.GroupID != 0 {
+		group, err = getEndpointGroupPolicies(ctx, client, endpoint.GroupID)
+		if errors.Is(err, ErrEndpointGroupNotFound) {
+			group = &EndpointGroupAccessPolicies{}
+		} else if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	namespaceAccess := map[string]EndpointGroupAccessPolicies{}
+	var cm struct {
+		Data map[string]string `json:"data"`
+	}
+	cmURL := k8sProxyURL(client, envID, "", portainerSystemNamespace, "configmaps") + "/" + portainerConfigMapName
+	if _, err := rbacGetJSON(ctx, client, cmURL, "namespace access policies", &cm); err != nil {
+		return diag.FromErr(err)
+	}
+	if raw := cm.Data[portainerNamespaceAccessKey]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &namespaceAccess); err != nil {
+			return diag.FromErr(fmt.Errorf("failed to decode %s: %w", portainerNamespaceAccessKey, err))
+		}
+	}
+
+	// Kubernetes side: every binding of the cluster.
+	var clusterBindings, roleBindings []rbacBinding
+	if err := k8sProxyList(ctx, client, k8sProxyURL(client, envID, "rbac.authorization.k8s.io/v1", "", "clusterrolebindings"), "clusterrolebindings", &clusterBindings); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := k8sProxyList(ctx, client, k8sProxyURL(client, envID, "rbac.authorization.k8s.io/v1", "", "rolebindings"), "rolebindings", &roleBindings); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// Intended access per team and user.
+	teamNames := map[int]string{}
+	teamEnvAccess := map[int]bool{}
+	teamNamespaces := map[int][]string{}
+	namespaces := make([]string, 0, len(namespaceAccess))
+	for ns := range namespaceAccess {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	for _, t := range teams {
+		id := strconv.Itoa(t.ID)
+		teamNames[t.ID] = t.Name
+		_, direct := endpoint.TeamAccessPolicies[id]
+		_, viaGroup := group.TeamAccessPolicies[id]
+		teamEnvAccess[t.ID] = direct || viaGroup
+		teamNamespaces[t.ID] = []string{}
+		for _, ns := range namespaces {
+			if _, ok := namespaceAccess[ns].TeamAccessPolicies[id]; ok {
+				teamNamespaces[t.ID] = append(teamNamespaces[t.ID], ns)
+			}
+		}
+	}
+	userTeams := map[int][]int{}
+	for _, m := range memberships {
+		userTeams[m.UserID] = append(userTeams[m.UserID], m.TeamID)
+	}
+
+	type userAccess struct {
+		admin      bool
+		envAccess  bool
+		sources    []string
+		namespaces map[string]bool
+	}
+	access := map[int]*userAccess{}
+	for _, u := range users {
+		a := &userAccess{admin: u.Role == 1, sources: []string{}, namespaces: map[string]bool{}}
+		id := strconv.Itoa(u.ID)
+		if a.admin {
+			a.envAccess = true
+			a.sources = append(a.sources, "administrator")
+		}
+		if _, ok := endpoint.UserAccessPolicies[id]; ok {
+			a.envAccess = true
+			a.sources = append(a.sources, "environment")
+		}
+		if _, ok := group.UserAccessPolicies[id]; ok {
+			a.envAccess = true
+			a.sources = append(a.sources, "environment_group")
+		}
+		for _, tid := range userTeams[u.ID] {
+			if teamEnvAccess[tid] {
+				a.envAccess = true
+				a.sources = append(a.sources, "team:"+teamNames[tid])
+			}
+			for _, ns := range teamNamespaces[tid] {
+				a.namespaces[ns] = true
+			}
+		}
+		for _, ns := range namespaces {
+			if _, ok := namespaceAccess[ns].UserAccessPolicies[id]; ok {
+				a.namespaces[ns] = true
+			}
+		}
+		access[u.ID] = a
+	}
+
+	// Effective access: walk every binding and attribute it to its subjects.
+	allowed := map[string]bool{
+		rbacSubjectKey("ServiceAccount", portainerSystemNamespace, portainerClusterAdminSA): true,
+		rbacSubjectKey("Group", "", "system:masters"):                                       true,
+	}
+	for _, s := range d.Get("allowed_cluster_admin_subjects").([]interface{}) {
+		allowed[s.(string)] = true
+	}
+
+	userSA := map[int]string{}
+	clusterRoles := map[int][]string{}
+	namespaceRoles := map[int][]string{}
+	var findings []rbacFinding
+
+	type boundSubject struct {
+		binding, namespace, roleKind, roleName string
+		kind, subjectNS, name                  string
+	}
+	var bound []boundSubject
+	for _, b := range clusterBindings {
+		for _, s := range b.Subjects {
+			bound = append(bound, boundSubject{"ClusterRoleBinding/" + b.Metadata.Name, "", b.RoleRef.Kind, b.RoleRef.Name, s.Kind, s.Namespace, s.Name})
+		}
+	}
+	for _, b := range roleBindings {
+		for _, s := range b.Subjects {
+			bound = append(bound, boundSubject{"RoleBinding/" + b.Metadata.Namespace + "/" + b.Metadata.Name, b.Metadata.Namespace, b.RoleRef.Kind, b.RoleRef.Name, s.Kind, s.Namespace, s.Name})
+		}
+	}
+
+	for _, b := range bound {
+		subject := rbacSubjectKey(b.kind, b.subjectNS, b.name)
+		userID := -1
+		if b.kind == "ServiceAccount" && b.subjectNS == portainerSystemNamespace {
+			if m := portainerUserSAPattern.FindStringSubmatch(b.name); m != nil {
+				userID, _ = strconv.Atoi(m[1])
+			}
+		}
+		a := access[userID]
+
+		if b.roleKind == "ClusterRole" && b.roleName == kubernetesClusterAdminRoleName && !allowed[subject] && (a == nil || !a.admin) {
+			scope := "cluster-wide"
+			if b.namespace != "" {
+				scope = "in namespace " + b.namespace
+			}
+			msg := fmt.Sprintf("%s grants cluster-admin %s to %s, which is neither a Portainer administrator nor an allowed subject", b.binding, scope, subject)
+			if a != nil {
+				msg = fmt.Sprintf("%s grants cluster-admin %s to the service account of standard user %q", b.binding, scope, rbacUsername(users, userID))
+			}
+			findings = append(findings, rbacFinding{"critical", "cluster_admin_binding", subject, b.binding, msg})
+		}
+
+		if userID < 0 {
+			continue
+		}
+		if a == nil {
+			findings = append(findings, rbacFinding{"warning", "orphaned_service_account", subject, b.binding,
+				fmt.Sprintf("%s binds %s/%s to the service account of Portainer user %d, which no longer exists", b.binding, b.roleKind, b.roleName, userID)})
+			continue
+		}
+
+		userSA[userID] = b.subjectNS + "/" + b.name
+		if b.namespace == "" {
+			clusterRoles[userID] = append(clusterRoles[userID], b.roleName)
+		} else {
+			namespaceRoles[userID] = append(namespaceRoles[userID], b.namespace+"/"+b.roleKind+"/"+b.roleName)
+		}
+		if a.admin {
+			continue
+		}
+		if !a.envAccess {
+			findings = append(findings, rbacFinding{"warning", "binding_without_environment_access", subject, b.binding,
+				fmt.Sprintf("%s still binds %s/%s to user %q, who has no access to the environment", b.binding, b.roleKind, b.roleName, rbacUsername(users, userID))})
+		} else if b.namespace != "" && !a.namespaces[b.namespace] {
+			findings = append(findings, rbacFinding{"warning", "namespace_binding_without_access", subject, b.binding,
+				fmt.Sprintf("%s binds %s/%s to user %q in namespace %s, which Portainer namespace access does not grant", b.binding, b.roleKind, b.roleName, rbacUsername(users, userID), b.namespace)})
+		}
+	}
+
+	userList := make([]map[string]interface{}, 0, len(users))
+	for _, u := range users {
+		a := access[u.ID]
+		role := "standard"
+		authorized := []string{}
+		if a.admin {
+			role = "administrator"
+			authorized = append(authorized, "*")
+		} else {
+			for _, ns := range namespaces {
+				if a.namespaces[ns] {
+					authorized = append(authorized, ns)
+				}
+			}
+		}
+		userList = append(userList, map[string]interface{}{
+			"user_id":               u.ID,
+			"username":              u.Username,
+			"role":                  role,
+			"environment_access":    a.envAccess,
+			"access_sources":        a.sources,
+			"authorized_namespaces": authorized,
+			"service_account":       userSA[u.ID],
+			"cluster_roles":         rbacUniqueSorted(clusterRoles[u.ID]),
+			"namespace_roles":       rbacUniqueSorted(namespaceRoles[u.ID]),
+		})
+	}
+	teamList := make([]map[string]interface{}, 0, len(teams))
+	for _, t := range teams {
+		teamList = append(teamList, map[string]interface{}{
+			"team_id":               t.ID,
+			"name":                  t.Name,
+			"environment_access":    teamEnvAccess[t.ID],
+			"authorized_namespaces": teamNamespaces[t.ID],
+		})
+	}
+	findingList := make([]map[string]interface{}, 0, len(findings))
+	for _, f := range findings {
+		findingList = append(findingList, map[string]interface{}{
+			"severity": f.Severity,
+			"type":     f.Type,
+			"subject":  f.Subject,
+			"binding":  f.Binding,
+			"message":  f.Message,
+		})
+	}
+
+	if err := d.Set("users", userList); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("teams", teamList); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("findings", findingList); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(strconv.Itoa(envID))
+
+	threshold := rbacSeverityRank[d.Get("fail_on").(string)]
+	if threshold == 0 {
+		return nil
+	}
+	var failing []string
+	for _, f := range findings {
+		if rbacSeverityRank[f.Severity] >= threshold {
+			failing = append(failing, fmt.Sprintf("[%s] %s", f.Severity, f.Message))
+		}
+	}
+	if len(failing) > 0 {
+		return diag.Errorf("RBAC validation failed for environment %d with %d finding(s):\n%s", envID, len(failing), strings.Join(failing, "\n"))
+	}
+	return nil
+}
+
+func rbacUsername(users []rbacUser, id int) string {
+	for _, u := range users {
+		if u.ID == id {
+			return u.Username
+		}
+	}
+	return strconv.Itoa(id)
+}
+
+func rbacUniqueSorted(in []string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, s := range in {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func registerRBACReportMocks(mock *MockServer) {
+	mock.On("GET", "/users", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{"Id": 1, "Username": "admin", "Role": 1},
+		{"Id": 2, "Username": "alice", "Role": 2},
+		{"Id": 3, "Username": "bob", "Role": 2},
+	}))
+	mock.On("GET", "/teams", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{"Id": 10, "Name": "devs"},
+	}))
+	mock.On("GET", "/team_memberships", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{"UserID": 2, "TeamID": 10},
+	}))
+	mock.On("GET", "/endpoints/4", RespondJSON(http.StatusOK, map[string]interface{}{
+		"GroupId":            1,
+		"UserAccessPolicies": map[string]interface{}{},
+		"TeamAccessPolicies": map[string]interface{}{"10": map[string]int{"RoleId": 0}},
+	}))
+	mock.On("GET", "/endpoint_groups/1", RespondJSON(http.StatusOK, map[string]interface{}{
+		"UserAccessPolicies": map[string]interface{}{},
+		"TeamAccessPolicies": map[string]interface{}{},
+	}))
+	mock.On("GET", "/endpoints/4/kubernetes/api/v1/namespaces/portainer/configmaps/portainer-config", RespondJSON(http.StatusOK, map[string]interface{}{
+		"data": map[string]string{
+			"NamespaceAccessPolicies": `{"web":{"UserAccessPolicies":{},"TeamAccessPolicies":{"10":{"RoleId":0}}}}`,
+		},
+	}))
+	mock.On("GET", "/endpoints/4/kubernetes/apis/rbac.authorization.k8s.io/v1/clusterrolebindings", RespondJSON(http.StatusOK, map[string]interface{}{
+		"items": []map[string]interface{}{
+			{
+				"metadata": map[string]interface{}{"name": "portainer-crb-clusteradmin"},
+				"roleRef":  map[string]interface{}{"kind": "ClusterRole", "name": "cluster-admin"},
+				"subjects": []map[string]interface{}{{"kind": "ServiceAccount", "namespace": "portainer", "name": "portainer-sa-clusteradmin"}},
+			},
+			{
+				"metadata": map[string]interface{}{"name": "debug-alice"},
+				"roleRef":  map[string]interface{}{"kind": "ClusterRole", "name": "cluster-admin"},
+				"subjects": []map[string]interface{}{{"kind": "ServiceAccount", "namespace": "portainer", "name": "portainer-sa-user-abc123-2"}},
+			},
+			{
+				"metadata": map[string]interface{}{"name": "platform"},
+				"roleRef":  map[string]interface{}{"kind": "ClusterRole", "name": "cluster-admin"},
+				"subjects": []map[string]interface{}{{"kind": "Group", "name": "platform-admins"}},
+			},
+		},
+	}))
+	mock.On("GET", "/endpoints/4/kubernetes/apis/rbac.authorization.k8s.io/v1/rolebindings", RespondJSON(http.StatusOK, map[string]interface{}{
+		"items": []map[string]interface{}{
+			{
+				"metadata": map[string]interface{}{"name": "portainer-rb-abc123-web", "namespace": "web"},
+				"roleRef":  map[string]interface{}{"kind": "ClusterRole", "name": "edit"},
+				"subjects": []map[string]interface{}{{"kind": "ServiceAccount", "namespace": "portainer", "name": "portainer-sa-user-abc123-2"}},
+			},
+			{
+				"metadata": map[string]interface{}{"name": "leftover", "namespace": "payments"},
+				"roleRef":  map[string]interface{}{"kind": "ClusterRole", "name": "edit"},
+				"subjects": []map[string]interface{}{
+					{"kind": "ServiceAccount", "namespace": "portainer", "name": "portainer-sa-user-abc123-3"},
+					{"kind": "ServiceAccount", "namespace": "portainer", "name": "portainer-sa-user-abc123-99"},
+				},
+			},
+		},
+	}))
+}
+
+// TestDataSourceKubernetesRBACReportRead computes effective access and flags
+// cluster-admin grants, stale bindings and orphaned service accounts.
+func TestDataSourceKubernetesRBACReportRead(t *testing.T) {
+	mock := NewMockServer(t)
+	registerRBACReportMocks(mock)
+
+	ds := dataSourceKubernetesRBACReport()
+	d := ds.TestResourceData()
+	_ = d.Set("environment_id", 4)
+	_ = d.Set("allowed_cluster_admin_subjects", []interface{}{"Group:platform-admins"})
+
+	if err := rcRead(ds, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	users := d.Get("users").([]interface{})
+	if len(users) != 3 {
+		t.Fatalf("expected 3 users, got %d", len(users))
+	}
+	alice := users[1].(map[string]interface{})
+	if alice["environment_access"] != true || alice["access_sources"].([]interface{})[0] != "team:devs" {
+		t.Errorf("unexpected alice access: %v", alice)
+	}
+	if ns := alice["authorized_namespaces"].([]interface{}); len(ns) != 1 || ns[0] != "web" {
+		t.Errorf("unexpected alice namespaces: %v", ns)
+	}
+	if alice["service_account"] != "portainer/portainer-sa-user-abc123-2" {
+		t.Errorf("unexpected alice service account: %v", alice["service_account"])
+	}
+	if roles := alice["cluster_roles"].([]interface{}); len(roles) != 1 || roles[0] != "cluster-admin" {
+		t.Errorf("unexpected alice cluster roles: %v", roles)
+	}
+
+	types := map[string]string{}
+	for _, f := range d.Get("findings").([]interface{}) {
+		m := f.(map[string]interface{})
+		types[m["type"].(string)] = m["subject"].(string)
+	}
+	if len(types) != 3 {
+		t.Errorf("expected 3 finding types, got %v", types)
+	}
+	if types["cluster_admin_binding"] != "ServiceAccount:portainer/portainer-sa-user-abc123-2" {
+		t.Errorf("expected cluster-admin finding for alice, got %v", types)
+	}
+	if types["binding_without_environment_access"] != "ServiceAccount:portainer/portainer-sa-user-abc123-3" {
+		t.Errorf("expected stale binding finding for bob, got %v", types)
+	}
+	if types["orphaned_service_account"] != "ServiceAccount:portainer/portainer-sa-user-abc123-99" {
+		t.Errorf("expected orphaned service account finding, got %v", types)
+	}
+}
+
+// TestDataSourceKubernetesRBACReportRead_FailOn verifies validation mode turns
+// findings at or above the threshold into an error.
+func TestDataSourceKubernetesRBACReportRead_FailOn(t *testing.T) {
+	mock := NewMockServer(t)
+	registerRBACReportMocks(mock)
+
+	ds := dataSourceKubernetesRBACReport()
+	d := ds.TestResourceData()
+	_ = d.Set("environment_id", 4)
+	_ = d.Set("fail_on", "critical")
+
+	err := rcRead(ds, d, mock.Client())
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	if !strings.Contains(err.Error(), "debug-alice") || !strings.Contains(err.Error(), "platform-admins") {
+		t.Errorf("expected both cluster-admin findings in error, got: %v", err)
+	}
+	if strings.Contains(err.Error(), "[warning]") {
+		t.Errorf("warnings must not fail a critical threshold: %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// k8sLoadBalancerIngressSchema is shared by the services and ingresses data sources.
+var k8sLoadBalancerIngressSchema = &schema.Schema{
+	Type:        schema.TypeList,
+	Computed:    true,
+	Description: "Load balancer ingress points reported in status.loadBalancer.ingress.",
+	Elem: &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"ip": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Ingress IP address.",
+			},
+			"hostname": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Ingress hostname.",
+			},
+		},
+	},
+}
+
+type k8sLoadBalancerStatus struct {
+	Ingress []struct {
+		IP       string `json:"ip"`
+		Hostname string `json:"hostname"`
+	} `json:"ingress"`
+}
+
+func flattenK8sLoadBalancer(lb k8sLoadBalancerStatus) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(lb.Ingress))
+	for _, i := range lb.Ingress {
+		out = append(out, map[string]interface{}{
+			"ip":       i.IP,
+			"hostname": i.Hostname,
+		})
+	}
+	return out
+}
+
+func dataSourceKubernetesServices() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceKubernetesServicesRead,
+
+		Schema: map[string]*schema.Schema{
+			"environment_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "Environment (endpoint) identifier.",
+			},
+			"namespace": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Namespace to list services from. If not set, services of all namespaces are listed.",
+			},
+			"label_selector": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Kubernetes label selector applied to the services.",
+			},
+			// Computed attributes
+			"services": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Services matching the selector.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Service name.",
+						},
+						"namespace": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Service namespace.",
+						},
+						"type": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Service type (ClusterIP, NodePort, LoadBalancer, ExternalName).",
+						},
+						"cluster_ip": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Primary cluster IP.",
+						},
+						"cluster_ips": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "All cluster IPs (dual-stack).",
+						},
+						"external_ips": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "External IPs routed to the service.",
+						},
+						"external_name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "DNS name for ExternalName services.",
+						},
+						"selector": {
+							Type:        schema.TypeMap,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Pod selector of the service.",
+						},
+						"labels": {
+							Type:        schema.TypeMap,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Service labels.",
+						},
+						"ports": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "Service ports.",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"name": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Port name.",
+									},
+									"protocol": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Port protocol.",
+									},
+									"port": {
+										Type:        schema.TypeInt,
+										Computed:    true,
+										Description: "Service port.",
+									},
+									"target_port": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Target port on the pods (number or name).",
+									},
+									"node_port": {
+										Type:        schema.TypeInt,
+										Computed:    true,
+										Description: "Node port, for NodePort and LoadBalancer services.",
+									},
+								},
+							},
+						},
+						"load_balancer_ingress": k8sLoadBalancerIngressSchema,
+					},
+				},
+			},
+		},
+	}
+}
+
+type k8sServiceObject struct {
+	Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		Type         string            `json:"type"`
+		ClusterIP    string            `json:"clusterIP"`
+		ClusterIPs   []string          `json:"clusterIPs"`
+		ExternalIPs  []string          `json:"externalIPs"`
+		ExternalName string            `json:"externalName"`
+		Selector     map[string]string `json:"selector"`
+		Ports        []struct {
+			Name       string      `json:"name"`
+			Protocol   string      `json:"protocol"`
+			Port       int         `json:"port"`
+			TargetPort interface{} `json:"targetPort"`
+			NodePort   int         `json:"nodePort"`
+		} `json:"ports"`
+	} `json:"spec"`
+	Status struct {
+		LoadBalancer k8sLoadBalancerStatus `json:"loadBalancer"`
+	} `json:"status"`
+}
+
+func dataSourceKubernetesServicesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+
+	envID := d.Get("environment_id").(int)
+	namespace := d.Get("namespace").(string)
+	selector := d.Get("label_selector").(string)
+
+	listURL := k8sProxyURL(client, envID, "", namespace, "services")
+	if selector != "" {
+		listURL += "?" + url.Values{"labelSelector": {selector}}.Encode()
+	}
+
+	var items []k8sServiceObject
+	if err := k8sProxyList(ctx, client, listURL, "services", &items); err != nil {
+		return diag.FromErr(err)
+	}
+
+	services := make([]map[string]interface{}, 0, len(items))
+	for _, s := range items {
+		ports := make([]map[string]interface{}, 0, len(s.Spec.Ports))
+		for _, p := range s.Spec.Ports {
+			// targetPort is an IntOrString; render it as a string either way.
+			target := ""
+			if p.TargetPort != nil {
+				target = fmt.Sprint(p.TargetPort)
+			}
+			ports = append(ports, map[string]interface{}{
+				"name":        p.Name,
+				"protocol":    p.Protocol,
+				"port":        p.Port,
+				"target_port": target,
+				"node_port":   p.NodePort,
+			})
+		}
+		services = append(services, map[string]interface{}{
+			"name":                  s.Metadata.Name,
+			"namespace":             s.Metadata.Namespace,
+			"type":                  s.Spec.Type,
+			"cluster_ip":            s.Spec.ClusterIP,
+			"cluster_ips":           s.Spec.ClusterIPs,
+			"external_ips":          s.Spec.ExternalIPs,
+			"external_name":         s.Spec.ExternalName,
+			"selector":              s.Spec.Selector,
+			"labels":                s.Metadata.Labels,
+			"ports":                 ports,
+			"load_balancer_ingress": flattenK8sLoadBalancer(s.Status.LoadBalancer),
+		})
+	}
+	if err := d.Set("services", services); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(fmt.Sprintf("%d:%s:%s", envID, namespace, selector))
+	return nil
+}
@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestDataSourceKubernetesServicesRead maps ports and load balancer ingress.
+func TestDataSourceKubernetesServicesRead(t *testing.T) {
+	mock := NewMockServer(t)
+
+	mock.On("GET", "/endpoints/3/kubernetes/api/v1/namespaces/web/services",
+		RespondJSON(http.StatusOK, map[string]interface{}{
+			"items": []map[string]interface{}{
+				{
+					"metadata": map[string]interface{}{"name": "web", "namespace": "web"},
+					"spec": map[string]interface{}{
+						"type":       "LoadBalancer",
+						"clusterIP":  "10.43.0.10",
+						"clusterIPs": []string{"10.43.0.10"},
+						"selector":   map[string]string{"app": "web"},
+						"ports": []map[string]interface{}{
+							{"name": "http", "protocol": "TCP", "port": 80, "targetPort": 8080, "nodePort": 30080},
+							{"name": "metrics", "protocol": "TCP", "port": 9090, "targetPort": "metrics"},
+						},
+					},
+					"status": map[string]interface{}{
+						"loadBalancer": map[string]interface{}{
+							"ingress": []map[string]interface{}{{"ip": "203.0.113.7"}},
+						},
+					},
+				},
+			},
+		}))
+
+	ds := dataSourceKubernetesServices()
+	d := ds.TestResourceData()
+	_ = d.Set("environment_id", 3)
+	_ = d.Set("namespace", "web")
+
+	if err := rcRead(ds, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	svc := d.Get("services").([]interface{})[0].(map[string]interface{})
+	if svc["type"] != "LoadBalancer" || svc["cluster_ip"] != "10.43.0.10" {
+		t.Errorf("unexpected service fields: %v", svc)
+	}
+	ports := svc["ports"].([]interface{})
+	if ports[0].(map[string]interface{})["target_port"] != "8080" || ports[1].(map[string]interface{})["target_port"] != "metrics" {
+		t.Errorf("unexpected target ports: %v", ports)
+	}
+	lb := svc["load_balancer_ingress"].([]interface{})[0].(map[string]interface{})
+	if lb["ip"] != "203.0.113.7" {
+		t.Errorf("load balancer ip: expected 203.0.113.7, got %v", lb["ip"])
+	}
+}
@@ -0,0 +1,189 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceKubernetesWorkloads() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceKubernetesWorkloadsRead,
+
+		Schema: map[string]*schema.Schema{
+			"environment_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "Environment (endpoint) identifier.",
+			},
+			"namespace": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Namespace to list workloads from. If not set, workloads of all namespaces are listed.",
+			},
+			"label_selector": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Kubernetes label selector applied to the workloads.",
+			},
+			"kinds": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Workload kinds to list (`Deployment`, `StatefulSet`). Defaults to both.",
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice([]string{"Deployment", "StatefulSet"}, false),
+				},
+			},
+			// Computed attributes
+			"workloads": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Workloads matching the filters.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"kind": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Workload kind (Deployment or StatefulSet).",
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Workload name.",
+						},
+						"namespace": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Workload namespace.",
+						},
+						"replicas": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Desired number of replicas.",
+						},
+						"ready_replicas": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Number of ready replicas.",
+						},
+						"available_replicas": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Number of available replicas.",
+						},
+						"updated_replicas": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Number of replicas running the current template.",
+						},
+						"ready": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether every desired replica is ready and up to date.",
+						},
+						"images": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Container images of the pod template.",
+						},
+						"labels": {
+							Type:        schema.TypeMap,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Workload labels.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+type k8sWorkload struct {
+	Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		Replicas *int `json:"replicas"`
+		Template struct {
+			Spec struct {
+				Containers []struct {
+					Image string `json:"image"`
+				} `json:"containers"`
+			} `json:"spec"`
+		} `json:"template"`
+	} `json:"spec"`
+	Status struct {
+		ReadyReplicas     int `json:"readyReplicas"`
+		AvailableReplicas int `json:"availableReplicas"`
+		UpdatedReplicas   int `json:"updatedReplicas"`
+	} `json:"status"`
+}
+
+func dataSourceKubernetesWorkloadsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+
+	envID := d.Get("environment_id").(int)
+	namespace := d.Get("namespace").(string)
+	selector := d.Get("label_selector").(string)
+
+	kinds := []string{"Deployment", "StatefulSet"}
+	if raw := d.Get("kinds").([]interface{}); len(raw) > 0 {
+		kinds = kinds[:0]
+		for _, k := range raw {
+			kinds = append(kinds, k.(string))
+		}
+	}
+
+	workloads := []map[string]interface{}{}
+	for _, kind := range kinds {
+		listURL := k8sProxyURL(client, envID, "apps/v1", namespace, strings.ToLower(kind)+"s")
+		if selector != "" {
+			listURL += "?" + url.Values{"labelSelector": {selector}}.Encode()
+		}
+
+		var items []k8sWorkload
+		if err := k8sProxyList(ctx, client, listURL, strings.ToLower(kind)+"s", &items); err != nil {
+			return diag.FromErr(err)
+		}
+
+		for _, w := range items {
+			// spec.replicas defaults to 1 when omitted.
+			replicas := 1
+			if w.Spec.Replicas != nil {
+				replicas = *w.Spec.Replicas
+			}
+			images := make([]string, 0, len(w.Spec.Template.Spec.Containers))
+			for _, c := range w.Spec.Template.Spec.Containers {
+				images = append(images, c.Image)
+			}
+			workloads = append(workloads, map[string]interface{}{
+				"kind":               kind,
+				"name":               w.Metadata.Name,
+				"namespace":          w.Metadata.Namespace,
+				"replicas":           replicas,
+				"ready_replicas":     w.Status.ReadyReplicas,
+				"available_replicas": w.Status.AvailableReplicas,
+				"updated_replicas":   w.Status.UpdatedReplicas,
+				"ready":              w.Status.ReadyReplicas >= replicas && w.Status.UpdatedReplicas >= replicas,
+				"images":             images,
+				"labels":             w.Metadata.Labels,
+			})
+		}
+	}
+	if err := d.Set("workloads", workloads); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(fmt.Sprintf("%d:%s:%s:%s", envID, namespace, selector, strings.Join(kinds, ",")))
+	return nil
+}
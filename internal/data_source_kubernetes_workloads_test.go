@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestDataSourceKubernetesWorkloadsRead lists deployments and statefulsets and
+// computes readiness from the replica counters.
+func TestDataSourceKubernetesWorkloadsRead(t *testing.T) {
+	mock := NewMockServer(t)
+
+	mock.On("GET", "/endpoints/3/kubernetes/apis/apps/v1/namespaces/web/deployments",
+		RespondJSON(http.StatusOK, map[string]interface{}{
+			"items": []map[string]interface{}{
+				{
+					"metadata": map[string]interface{}{"name": "api", "namespace": "web"},
+					"spec": map[string]interface{}{
+						"replicas": 3,
+						"template": map[string]interface{}{"spec": map[string]interface{}{
+							"containers": []map[string]interface{}{{"image": "api:1.0"}},
+						}},
+					},
+					"status": map[string]interface{}{"readyReplicas": 3, "availableReplicas": 3, "updatedReplicas": 3},
+				},
+			},
+		}))
+	mock.On("GET", "/endpoints/3/kubernetes/apis/apps/v1/namespaces/web/statefulsets",
+		RespondJSON(http.StatusOK, map[string]interface{}{
+			"items": []map[string]interface{}{
+				{
+					"metadata": map[string]interface{}{"name": "db", "namespace": "web"},
+					"spec":     map[string]interface{}{"replicas": 2},
+					"status":   map[string]interface{}{"readyReplicas": 1, "updatedReplicas": 2},
+				},
+			},
+		}))
+
+	ds := dataSourceKubernetesWorkloads()
+	d := ds.TestResourceData()
+	_ = d.Set("environment_id", 3)
+	_ = d.Set("namespace", "web")
+
+	if err := rcRead(ds, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	workloads := d.Get("workloads").([]interface{})
+	if len(workloads) != 2 {
+		t.Fatalf("expected 2 workloads, got %d", len(workloads))
+	}
+	api := workloads[0].(map[string]interface{})
+	if api["kind"] != "Deployment" || api["ready"] != true || api["images"].([]interface{})[0] != "api:1.0" {
+		t.Errorf("unexpected deployment: %v", api)
+	}
+	db := workloads[1].(map[string]interface{})
+	if db["kind"] != "StatefulSet" || db["ready"] != false || db["ready_replicas"] != 1 {
+		t.Errorf("unexpected statefulset: %v", db)
+	}
+}
+
+// TestDataSourceKubernetesWorkloadsRead_KindsFilter only queries the requested kinds.
+func TestDataSourceKubernetesWorkloadsRead_KindsFilter(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/3/kubernetes/apis/apps/v1/statefulsets",
+		RespondJSON(http.StatusOK, map[string]interface{}{"items": []interface{}{}}))
+
+	ds := dataSourceKubernetesWorkloads()
+	d := ds.TestResourceData()
+	_ = d.Set("environment_id", 3)
+	_ = d.Set("kinds", []interface{}{"StatefulSet"})
+
+	if err := rcRead(ds, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if mock.FindRequest("GET", "/endpoints/3/kubernetes/apis/apps/v1/deployments") != nil {
+		t.Error("deployments should not be listed when kinds = [StatefulSet]")
+	}
+}
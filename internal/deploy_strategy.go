@@ -0,0 +1,506 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// deployStrategySchema is the strategy block of portainer_deploy.
+func deployStrategySchema() *schema.Schema {
+	return &schema.Schema{
+		Type:          schema.TypeList,
+		Optional:      true,
+		ForceNew:      true,
+		MaxItems:      1,
+		ConflictsWith: []string{"force_update"},
+		Description:   "Deployment strategy. Services are updated in phases whose outcome is reported in output, and the deployment waits for convergence. Canary deployments and parallelism require a Docker Swarm environment.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"type": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      "rolling",
+					ValidateFunc: validation.StringInSlice([]string{"rolling", "canary"}, false),
+					Description:  "Strategy type: 'rolling' updates the services with the update settings below, 'canary' first updates the canary and checks its health before updating the rest.",
+				},
+				"parallelism": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      1,
+					ValidateFunc: validation.IntAtLeast(0),
+					Description:  "Number of tasks of a service updated simultaneously (0 updates all tasks at once).",
+				},
+				"delay": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      0,
+					ValidateFunc: validation.IntAtLeast(0),
+					Description:  "Seconds to wait between updates of batches of tasks.",
+				},
+				"failure_action": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      "pause",
+					ValidateFunc: validation.StringInSlice([]string{"pause", "continue", "rollback"}, false),
+					Description:  "Action of Docker Swarm when the update of a task fails: 'pause', 'continue' or 'rollback'.",
+				},
+				"auto_rollback": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     true,
+					Description: "Roll the updated services (and stack_env_var on standalone environments) back to their previous revision when a phase fails.",
+				},
+				"canary": {
+					Type:        schema.TypeList,
+					Optional:    true,
+					MaxItems:    1,
+					Description: "Canary settings, used when type is 'canary'. Without this block the first service of services_list is the canary.",
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"services": {
+								Type:        schema.TypeString,
+								Optional:    true,
+								Description: "Comma-separated list of services (without stack prefix) updated first. Conflicts with fraction.",
+							},
+							"fraction": {
+								Type:         schema.TypeFloat,
+								Optional:     true,
+								ValidateFunc: validation.FloatBetween(0.01, 1),
+								Description:  "Fraction of the replicas of every service updated first, e.g. 0.1 for 10% (at least one replica). Conflicts with services.",
+							},
+							"wait": {
+								Type:        schema.TypeInt,
+								Optional:    true,
+								Default:     30,
+								Description: "Seconds to wait after updating the canary before the first health check.",
+							},
+							"wait_between_checks": {
+								Type:        schema.TypeInt,
+								Optional:    true,
+								Default:     10,
+								Description: "Seconds between health checks of the canary.",
+							},
+							"max_retries": {
+								Type:         schema.TypeInt,
+								Optional:     true,
+								Default:      3,
+								ValidateFunc: validation.IntAtLeast(1),
+								Description:  "Number of health checks before the canary is considered failed.",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// deployStrategy holds the strategy block.
+type deployStrategy struct {
+	Type          string
+	Parallelism   int
+	Delay         time.Duration
+	FailureAction string
+	AutoRollback  bool
+
+	// CanaryServices are full service names; when empty and CanaryFraction
+	// is zero, the first service is the canary.
+	CanaryServices   []string
+	CanaryFraction   float64
+	CanaryWait       time.Duration
+	CanaryInterval   time.Duration
+	CanaryMaxRetries int
+}
+
+// expandDeployStrategy reads the strategy block, or returns nil without it.
+func expandDeployStrategy(d *schema.ResourceData, stackName string) (*deployStrategy, error) {
+	raw := d.Get("strategy").([]interface{})
+	if len(raw) == 0 || raw[0] == nil {
+		return nil, nil
+	}
+	m := raw[0].(map[string]interface{})
+	s := &deployStrategy{
+		Type:             m["type"].(string),
+		Parallelism:      m["parallelism"].(int),
+		Delay:            time.Duration(m["delay"].(int)) * time.Second,
+		FailureAction:    m["failure_action"].(string),
+		AutoRollback:     m["auto_rollback"].(bool),
+		CanaryWait:       30 * time.Second,
+		CanaryInterval:   10 * time.Second,
+		CanaryMaxRetries: 3,
+	}
+	if canary, ok := m["canary"].([]interface{}); ok && len(canary) > 0 && canary[0] != nil {
+		c := canary[0].(map[string]interface{})
+		if err := validateCanaryServices(d.Get("services_list").(string), c["services"].(string)); err != nil {
+			return nil, err
+		}
+		for _, name := range splitAndTrimCSV(c["services"].(string)) {
+			s.CanaryServices = append(s.CanaryServices, fmt.Sprintf("%s_%s", stackName, name))
+		}
+		s.CanaryFraction = c["fraction"].(float64)
+		s.CanaryWait = time.Duration(c["wait"].(int)) * time.Second
+		s.CanaryInterval = time.Duration(c["wait_between_checks"].(int)) * time.Second
+		s.CanaryMaxRetries = c["max_retries"].(int)
+		if len(s.CanaryServices) > 0 && s.CanaryFraction > 0 {
+			return nil, fmt.Errorf("strategy.canary: services and fraction cannot be set together")
+		}
+	}
+	return s, nil
+}
+
+// validateCanaryServices fails when a canary service is not in services_list:
+// a typo would otherwise leave the canary empty and update every service
+// without health gate.
+func validateCanaryServices(servicesList, canaryServices string) error {
+	services := splitAndTrimCSV(servicesList)
+	for _, name := range splitAndTrimCSV(canaryServices) {
+		if !contains(services, name) {
+			return fmt.Errorf("strategy.canary.services: service %q is not in services_list (%s)", name, strings.Join(services, ", "))
+		}
+	}
+	return nil
+}
+
+// customizeDiffDeployStrategy validates the canary services at plan time.
+func customizeDiffDeployStrategy(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if !d.NewValueKnown("services_list") || !d.NewValueKnown("strategy") {
+		return nil
+	}
+	raw := d.Get("strategy").([]interface{})
+	if len(raw) == 0 || raw[0] == nil {
+		return nil
+	}
+	canary, _ := raw[0].(map[string]interface{})["canary"].([]interface{})
+	if len(canary) == 0 || canary[0] == nil {
+		return nil
+	}
+	return validateCanaryServices(d.Get("services_list").(string), canary[0].(map[string]interface{})["services"].(string))
+}
+
+// updateConfig returns the Swarm UpdateConfig of the strategy, keeping the
+// other settings of the service's current UpdateConfig.
+func (s *deployStrategy) updateConfig(current interface{}, parallelism int, delay time.Duration) map[string]interface{} {
+	cfg := map[string]interface{}{}
+	for k, v := range mustMap(current) {
+		cfg[k] = v
+	}
+	cfg["Parallelism"] = parallelism
+	cfg["Delay"] = delay.Nanoseconds()
+	cfg["FailureAction"] = s.FailureAction
+	return cfg
+}
+
+// canaryWindow is the longest time the canary health check can take.
+func (s *deployStrategy) canaryWindow() time.Duration {
+	return s.CanaryWait + time.Duration(s.CanaryMaxRetries)*s.CanaryInterval
+}
+
+// fetchSwarmService reads a Swarm service by name or ID.
+func fetchSwarmService(ctx context.Context, client *APIClient, endpointID int, name string) (map[string]interface{}, error) {
+	body, code, err := apiGETWithCodeCtx(ctx, fmt.Sprintf("%s/endpoints/%d/docker/services/%s", client.Endpoint, endpointID, url.PathEscape(name)), client.APIKey, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service %s: %w", name, err)
+	}
+	if code != 200 {
+		return nil, fmt.Errorf("failed to read service %s: status %d, body: %s", name, code, string(body))
+	}
+	var svc map[string]interface{}
+	if err := json.Unmarshal(body, &svc); err != nil {
+		return nil, fmt.Errorf("failed to parse service %s: %w", name, err)
+	}
+	return svc, nil
+}
+
+// updateSwarmServiceImage updates the image of a Swarm service and, when not
+// nil, its UpdateConfig.
+func updateSwarmServiceImage(ctx context.Context, client *APIClient, endpointID int, svc map[string]interface{}, image string, updateConfig map[string]interface{}) error {
+	spec := mustMap(svc["Spec"])
+	name, _ := spec["Name"].(string)
+	containerSpec := mustMap(mustMap(spec["TaskTemplate"])["ContainerSpec"])
+	containerSpec["Image"] = image
+	if labels, ok := spec["Labels"].(map[string]interface{}); ok {
+		labels["com.docker.stack.image"] = image
+	}
+	if updateConfig != nil {
+		spec["UpdateConfig"] = updateConfig
+	}
+
+	index := fmt.Sprintf("%.0f", mustMap(svc["Version"])["Index"])
+	postURL := fmt.Sprintf("%s/endpoints/%d/docker/services/%s/update?version=%s", client.Endpoint, endpointID, url.PathEscape(name), index)
+	postBody, _ := json.Marshal(spec)
+	respBytes, code, err := apiPOSTWithCodeCtx(ctx, postURL, client.APIKey, client, postBody)
+	if err != nil {
+		return fmt.Errorf("service %s update request failed: %w", name, err)
+	}
+	if code != 200 {
+		return fmt.Errorf("service %s update failed: status %d, body: %s", name, code, string(respBytes))
+	}
+	return nil
+}
+
+// swarmServiceImage returns the image of a Swarm service.
+func swarmServiceImage(svc map[string]interface{}) string {
+	image, _ := mustMap(mustMap(mustMap(svc["Spec"])["TaskTemplate"])["ContainerSpec"])["Image"].(string)
+	return image
+}
+
+// swarmServiceReplicas returns the number of replicas of a replicated
+// service, or 1 for global services.
+func swarmServiceReplicas(svc map[string]interface{}) int {
+	replicas, ok := mustMap(mustMap(mustMap(svc["Spec"])["Mode"])["Replicated"])["Replicas"].(float64)
+	if !ok || replicas < 1 {
+		return 1
+	}
+	return int(replicas)
+}
+
+// swarmFailedRevisionMatcher accepts tasks of the revision that failed or were
+// rejected.
+func swarmFailedRevisionMatcher(revision string) func(task map[string]interface{}) bool {
+	failed := swarmRevisionMatcher(revision, "failed")
+	rejected := swarmRevisionMatcher(revision, "rejected")
+	return func(t map[string]interface{}) bool {
+		return failed(t) || rejected(t)
+	}
+}
+
+// swarmServiceUpdateStarted returns when Swarm started the last update of a
+// service, by its own clock, or false when it is not known.
+func swarmServiceUpdateStarted(svc map[string]interface{}) (time.Time, bool) {
+	started, _ := mustMap(svc["UpdateStatus"])["StartedAt"].(string)
+	t, err := time.Parse(time.RFC3339Nano, started)
+	return t, err == nil
+}
+
+// swarmTaskCreatedSince restricts match to the tasks created at or after t.
+func swarmTaskCreatedSince(t time.Time, match func(task map[string]interface{}) bool) func(task map[string]interface{}) bool {
+	return func(task map[string]interface{}) bool {
+		createdAt, _ := task["CreatedAt"].(string)
+		created, err := time.Parse(time.RFC3339Nano, createdAt)
+		return err == nil && !created.Before(t) && match(task)
+	}
+}
+
+// checkSwarmCanary checks the health of the canary like portainer_check: after
+// the initial wait, every service must have at least need[service] running
+// tasks of the revision, within max_retries checks. Tasks of the revision
+// that failed since the canary update started fail the check at once; older
+// ones, e.g. of an earlier deployment of the same revision, are ignored.
+func checkSwarmCanary(ctx context.Context, client *APIClient, endpointID int, need map[string]int, order []string, revision string, s *deployStrategy, out *strings.Builder) error {
+	failed := map[string]func(task map[string]interface{}) bool{}
+	for _, service := range order {
+		svc, err := fetchSwarmService(ctx, client, endpointID, service)
+		if err != nil {
+			return err
+		}
+		if started, ok := swarmServiceUpdateStarted(svc); ok {
+			failed[service] = swarmTaskCreatedSince(started, swarmFailedRevisionMatcher(revision))
+		}
+	}
+	if err := sleepCtx(ctx, s.CanaryWait); err != nil {
+		return err
+	}
+	running := swarmRevisionMatcher(revision, "running")
+	for attempt := 1; attempt <= s.CanaryMaxRetries; attempt++ {
+		pending := []string{}
+		for _, service := range order {
+			// Without the update start, only the running tasks are checked.
+			if match := failed[service]; match != nil {
+				bad, _, _, err := matchSwarmTasks(client, endpointID, service, "shutdown", match)
+				if err != nil {
+					return err
+				}
+				if bad > 0 {
+					return fmt.Errorf("%d task(s) of service %q failed at revision %q", bad, service, revision)
+				}
+			}
+			ok, _, _, err := matchSwarmTasks(client, endpointID, service, "running", running)
+			if err != nil {
+				return err
+			}
+			if ok < need[service] {
+				pending = append(pending, fmt.Sprintf("%s %d/%d", service, ok, need[service]))
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		out.WriteString(fmt.Sprintf("Canary check %d/%d: waiting for %s\n", attempt, s.CanaryMaxRetries, strings.Join(pending, ", ")))
+		if attempt < s.CanaryMaxRetries {
+			if err := sleepCtx(ctx, s.CanaryInterval); err != nil {
+				return err
+			}
+		}
+	}
+	return fmt.Errorf("canary did not become healthy at revision %q after %d checks", revision, s.CanaryMaxRetries)
+}
+
+// sleepCtx waits for d or until ctx is done.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// runSwarmDeployStrategy updates the services to their new image in the
+// phases of the strategy: canary (for type canary), rollout, and rollback of
+// every updated service when a phase fails and auto_rollback is enabled.
+// images maps the full service names to update, in order, to their new image.
+func runSwarmDeployStrategy(ctx context.Context, client *APIClient, endpointID int, order []string, images map[string]string, revision string, s *deployStrategy, useEvents bool, interval time.Duration, out *strings.Builder) error {
+	previous := map[string]string{}
+	// UpdateConfig of the services before the deployment: the strategy
+	// settings are applied to it, so that the hold of a fractional canary
+	// does not remain after the rollout, and it is restored on rollback.
+	previousConfig := map[string]map[string]interface{}{}
+	var updated []string
+
+	update := func(name string, parallelism int, delay time.Duration) error {
+		svc, err := fetchSwarmService(ctx, client, endpointID, name)
+		if err != nil {
+			return err
+		}
+		if _, seen := previous[name]; !seen {
+			previous[name] = swarmServiceImage(svc)
+			previousConfig[name] = mustMap(mustMap(svc["Spec"])["UpdateConfig"])
+		}
+		if err := updateSwarmServiceImage(ctx, client, endpointID, svc, images[name], s.updateConfig(previousConfig[name], parallelism, delay)); err != nil {
+			return err
+		}
+		if !contains(updated, name) {
+			updated = append(updated, name)
+		}
+		return nil
+	}
+	fail := func(phase string, err error) error {
+		out.WriteString(fmt.Sprintf("Phase %s: FAILED — %v\n", phase, err))
+		if !s.AutoRollback || len(updated) == 0 {
+			return fmt.Errorf("%s phase failed: %w", phase, err)
+		}
+		// Roll back even when the create timeout expired.
+		rbCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Minute)
+		defer cancel()
+		var rbErrs []string
+		for _, name := range updated {
+			svc, rbErr := fetchSwarmService(rbCtx, client, endpointID, name)
+			if rbErr == nil {
+				rbErr = updateSwarmServiceImage(rbCtx, client, endpointID, svc, previous[name], previousConfig[name])
+			}
+			if rbErr != nil {
+				rbErrs = append(rbErrs, rbErr.Error())
+				continue
+			}
+			out.WriteString(fmt.Sprintf("Phase rollback: service %q rolled back to %q\n", name, previous[name]))
+		}
+		if len(rbErrs) > 0 {
+			return fmt.Errorf("%s phase failed: %w; rollback failed: %s", phase, err, strings.Join(rbErrs, "; "))
+		}
+		return fmt.Errorf("%s phase failed, services rolled back: %w", phase, err)
+	}
+
+	rest := order
+	if s.Type == "canary" && len(order) > 0 {
+		need := map[string]int{}
+		var canary []string
+		switch {
+		case s.CanaryFraction > 0:
+			// Update a fraction of the replicas, then hold the update for the
+			// duration of the health check.
+			for _, name := range order {
+				svc, err := fetchSwarmService(ctx, client, endpointID, name)
+				if err != nil {
+					return fail("canary", err)
+				}
+				need[name] = int(math.Ceil(s.CanaryFraction * float64(swarmServiceReplicas(svc))))
+				if err := update(name, need[name], s.canaryWindow()+time.Minute); err != nil {
+					return fail("canary", err)
+				}
+				canary = append(canary, name)
+			}
+		case len(s.CanaryServices) > 0:
+			for _, name := range order {
+				if contains(s.CanaryServices, name) {
+					canary = append(canary, name)
+				}
+			}
+		default:
+			canary = order[:1]
+		}
+		if s.CanaryFraction == 0 {
+			for _, name := range canary {
+				if err := update(name, s.Parallelism, s.Delay); err != nil {
+					return fail("canary", err)
+				}
+				svc, err := fetchSwarmService(ctx, client, endpointID, name)
+				if err != nil {
+					return fail("canary", err)
+				}
+				need[name] = swarmServiceReplicas(svc)
+			}
+			rest = nil
+			for _, name := range order {
+				if !contains(canary, name) {
+					rest = append(rest, name)
+				}
+			}
+		}
+
+		if len(canary) > 0 {
+			if err := checkSwarmCanary(ctx, client, endpointID, need, canary, revision, s, out); err != nil {
+				return fail("canary", err)
+			}
+			parts := make([]string, 0, len(canary))
+			for _, name := range canary {
+				parts = append(parts, fmt.Sprintf("%s (%d tasks)", name, need[name]))
+			}
+			out.WriteString(fmt.Sprintf("Phase canary: OK — %s healthy at revision %q\n", strings.Join(parts, ", "), revision))
+		} else {
+			out.WriteString("Phase canary: skipped — no canary service needs an update\n")
+		}
+	}
+
+	// Resume the services held by a fractional canary and update the others.
+	for _, name := range rest {
+		if err := update(name, s.Parallelism, s.Delay); err != nil {
+			return fail("rollout", err)
+		}
+	}
+	if err := waitForDeployConvergence(ctx, client, endpointID, true, order, revision, useEvents, interval, out); err != nil {
+		return fail("rollout", err)
+	}
+	out.WriteString(fmt.Sprintf("Phase rollout: OK — %s at revision %q\n", strings.Join(order, ", "), revision))
+	return nil
+}
+
+// rollbackStandaloneStack redeploys a standalone stack with the environment
+// it had before the deployment, after the rollout failed with cause.
+func rollbackStandaloneStack(ctx context.Context, client *APIClient, endpointID, stackID int, stackFile string, env []map[string]string, cause error, out *strings.Builder) error {
+	rbCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Minute)
+	defer cancel()
+	body, _ := json.Marshal(map[string]interface{}{
+		"env":              env,
+		"prune":            true,
+		"pullImage":        true,
+		"stackFileContent": stackFile,
+	})
+	updURL := fmt.Sprintf("%s/stacks/%d?endpointId=%d", client.Endpoint, stackID, endpointID)
+	resp, code, err := apiPUTWithCodeCtx(rbCtx, updURL, client.APIKey, client, body)
+	if err != nil || code != 200 {
+		return fmt.Errorf("rollout phase failed: %w; rollback failed (status %d): %s", cause, code, string(resp))
+	}
+	out.WriteString(fmt.Sprintf("Phase rollback: stack %d redeployed with its previous environment\n", stackID))
+	return fmt.Errorf("rollout phase failed, stack rolled back: %w", cause)
+}
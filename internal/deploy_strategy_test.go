@@ -0,0 +1,378 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+// fakeSwarm serves the Docker Swarm endpoints used by the deploy strategies:
+// service inspect/update and task listing. Every replica of a service runs its
+// current image, except images listed in broken whose tasks fail. Images in
+// stale have failed tasks left from before the last update.
+type fakeSwarm struct {
+	mu        sync.Mutex
+	images    map[string]string
+	replicas  map[string]int
+	broken    map[string]bool
+	stale     map[string]bool
+	configs   map[string]interface{} // UpdateConfig of the services
+	updatedAt map[string]time.Time   // start of the last update
+	updates   []string               // "service=image", in order
+}
+
+func newFakeSwarm(mock *MockServer, images map[string]string, broken ...string) *fakeSwarm {
+	f := &fakeSwarm{images: images, replicas: map[string]int{}, broken: map[string]bool{}, stale: map[string]bool{}, configs: map[string]interface{}{}, updatedAt: map[string]time.Time{}}
+	for _, image := range broken {
+		f.broken[image] = true
+	}
+
+	mock.On("GET", "/endpoints/1/docker/swarm", RespondJSON(http.StatusOK, map[string]interface{}{"ID": "sw1"}))
+	mock.On("GET", "/stacks", RespondJSON(http.StatusOK, []map[string]interface{}{{"Id": 3, "Name": "app"}}))
+	mock.On("GET", "/endpoints/1/docker/services", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		var list []map[string]interface{}
+		for name := range f.images {
+			list = append(list, f.service(name))
+		}
+		writeTestJSON(w, list)
+	})
+	for name := range images {
+		name := name
+		f.replicas[name] = 2
+		f.configs[name] = map[string]interface{}{"Order": "start-first"}
+		mock.On("GET", "/endpoints/1/docker/services/"+name, func(w http.ResponseWriter, r *http.Request) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			writeTestJSON(w, f.service(name))
+		})
+		mock.On("POST", "/endpoints/1/docker/services/"+name+"/update", func(w http.ResponseWriter, r *http.Request) {
+			var spec map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&spec)
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.images[name] = swarmServiceImage(map[string]interface{}{"Spec": spec})
+			f.configs[name] = spec["UpdateConfig"]
+			f.updatedAt[name] = time.Now()
+			f.updates = append(f.updates, name+"="+f.images[name])
+			writeTestJSON(w, map[string]interface{}{"Warnings": nil})
+		})
+	}
+	mock.On("GET", "/endpoints/1/docker/tasks", func(w http.ResponseWriter, r *http.Request) {
+		var filters struct {
+			Service      map[string]bool `json:"service"`
+			DesiredState map[string]bool `json:"desired-state"`
+		}
+		_ = json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		tasks := []map[string]interface{}{}
+		for name := range filters.Service {
+			image := f.images[name]
+			task := func(state string, created time.Time) map[string]interface{} {
+				return map[string]interface{}{
+					"CreatedAt": created.UTC().Format(time.RFC3339Nano),
+					"Spec":      map[string]interface{}{"ContainerSpec": map[string]interface{}{"Image": image}},
+					"Status":    map[string]interface{}{"State": state},
+				}
+			}
+			created := f.updatedAt[name].Add(time.Millisecond)
+			switch {
+			case filters.DesiredState["running"] && !f.broken[image]:
+				for i := 0; i < f.replicas[name]; i++ {
+					tasks = append(tasks, task("running", created))
+				}
+			case filters.DesiredState["shutdown"] && f.broken[image]:
+				tasks = append(tasks, task("failed", created))
+			case filters.DesiredState["shutdown"] && f.stale[image]:
+				tasks = append(tasks, task("failed", f.updatedAt[name].Add(-time.Hour)))
+			}
+		}
+		writeTestJSON(w, tasks)
+	})
+	return f
+}
+
+func (f *fakeSwarm) service(name string) map[string]interface{} {
+	svc := map[string]interface{}{
+		"ID":      name,
+		"Version": map[string]interface{}{"Index": 10},
+		"Spec": map[string]interface{}{
+			"Name":         name,
+			"Labels":       map[string]interface{}{},
+			"Mode":         map[string]interface{}{"Replicated": map[string]interface{}{"Replicas": f.replicas[name]}},
+			"TaskTemplate": map[string]interface{}{"ContainerSpec": map[string]interface{}{"Image": f.images[name]}},
+			"UpdateConfig": f.configs[name],
+		},
+	}
+	if started, ok := f.updatedAt[name]; ok {
+		svc["UpdateStatus"] = map[string]interface{}{"State": "updating", "StartedAt": started.UTC().Format(time.RFC3339Nano)}
+	}
+	return svc
+}
+
+func writeTestJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func strategyDeployData(t *testing.T, strategy map[string]interface{}) (*schema.ResourceData, error) {
+	t.Helper()
+	r := resourceDeploy()
+	d := r.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("stack_name", "app")
+	_ = d.Set("stack_env_var", "TAG")
+	_ = d.Set("revision", "2.0")
+	_ = d.Set("services_list", "web,worker")
+	_ = d.Set("use_events", false)
+	_ = d.Set("wait_between_checks", 1)
+	// Set does not apply the defaults of nested blocks.
+	for k, v := range map[string]interface{}{"type": "rolling", "parallelism": 1, "failure_action": "pause", "auto_rollback": true} {
+		if _, ok := strategy[k]; !ok {
+			strategy[k] = v
+		}
+	}
+	return d, d.Set("strategy", []interface{}{strategy})
+}
+
+func TestDeployCreate_CanaryStrategy(t *testing.T) {
+	mock := NewMockServer(t)
+	swarm := newFakeSwarm(mock, map[string]string{"app_web": "repo/web:1.0", "app_worker": "repo/worker:1.0"})
+
+	d, err := strategyDeployData(t, map[string]interface{}{
+		"type":        "canary",
+		"parallelism": 2,
+		"canary": []interface{}{map[string]interface{}{
+			"services":            "worker",
+			"wait":                0,
+			"wait_between_checks": 1,
+			"max_retries":         1,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rcCreate(resourceDeploy(), d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	want := []string{"app_worker=repo/worker:2.0", "app_web=repo/web:2.0"}
+	if strings.Join(swarm.updates, ",") != strings.Join(want, ",") {
+		t.Errorf("updates: expected %v (canary first), got %v", want, swarm.updates)
+	}
+	out := d.Get("output").(string)
+	for _, s := range []string{`Phase canary: OK — app_worker (2 tasks) healthy at revision "2.0"`, "Phase rollout: OK"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("output: expected %q, got:\n%s", s, out)
+		}
+	}
+
+	update := mock.FindRequest("POST", "/endpoints/1/docker/services/app_worker/update")
+	var spec map[string]interface{}
+	if err := update.DecodeJSON(&spec); err != nil {
+		t.Fatal(err)
+	}
+	cfg := mustMap(spec["UpdateConfig"])
+	if cfg["Parallelism"] != float64(2) || cfg["FailureAction"] != "pause" || cfg["Order"] != "start-first" {
+		t.Errorf("UpdateConfig: expected strategy settings merged into the current ones, got %v", cfg)
+	}
+}
+
+func TestDeployCreate_CanaryStrategy_RollsBack(t *testing.T) {
+	mock := NewMockServer(t)
+	swarm := newFakeSwarm(mock, map[string]string{"app_web": "repo/web:1.0", "app_worker": "repo/worker:1.0"}, "repo/web:2.0")
+
+	d, err := strategyDeployData(t, map[string]interface{}{
+		"type": "canary",
+		"canary": []interface{}{map[string]interface{}{
+			"wait":                0,
+			"wait_between_checks": 1,
+			"max_retries":         1,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rcCreate(resourceDeploy(), d, mock.Client())
+	if err == nil || !strings.Contains(err.Error(), "canary phase failed, services rolled back") {
+		t.Fatalf("expected the canary phase to fail and roll back, got %v", err)
+	}
+
+	// The first service of services_list is the canary; the other one is never
+	// touched.
+	want := []string{"app_web=repo/web:2.0", "app_web=repo/web:1.0"}
+	if strings.Join(swarm.updates, ",") != strings.Join(want, ",") {
+		t.Errorf("updates: expected %v, got %v", want, swarm.updates)
+	}
+	out := d.Get("output").(string)
+	for _, s := range []string{"Phase canary: FAILED", `Phase rollback: service "app_web" rolled back to "repo/web:1.0"`} {
+		if !strings.Contains(out, s) {
+			t.Errorf("output: expected %q, got:\n%s", s, out)
+		}
+	}
+}
+
+// TestDeployCreate_CanaryStrategy_IgnoresStaleFailures ignores tasks of the
+// revision that failed before the canary update, e.g. in an earlier attempt.
+func TestDeployCreate_CanaryStrategy_IgnoresStaleFailures(t *testing.T) {
+	mock := NewMockServer(t)
+	swarm := newFakeSwarm(mock, map[string]string{"app_web": "repo/web:1.0", "app_worker": "repo/worker:1.0"})
+	swarm.stale["repo/web:2.0"] = true
+
+	d, err := strategyDeployData(t, map[string]interface{}{
+		"type": "canary",
+		"canary": []interface{}{map[string]interface{}{
+			"wait":                0,
+			"wait_between_checks": 1,
+			"max_retries":         1,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rcCreate(resourceDeploy(), d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if out := d.Get("output").(string); !strings.Contains(out, "Phase canary: OK") {
+		t.Errorf("expected the canary to pass, got:\n%s", out)
+	}
+}
+
+func TestDeployCreate_CanaryFraction(t *testing.T) {
+	mock := NewMockServer(t)
+	newFakeSwarm(mock, map[string]string{"app_web": "repo/web:1.0", "app_worker": "repo/worker:1.0"})
+
+	d, err := strategyDeployData(t, map[string]interface{}{
+		"type": "canary",
+		"canary": []interface{}{map[string]interface{}{
+			"fraction":            0.1,
+			"wait":                0,
+			"wait_between_checks": 1,
+			"max_retries":         1,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rcCreate(resourceDeploy(), d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// Each service is updated twice: one replica held, then resumed.
+	var parallelism []interface{}
+	for _, req := range mock.Requests() {
+		if req.Method != "POST" || !strings.HasSuffix(req.Path, "/update") {
+			continue
+		}
+		var spec map[string]interface{}
+		if err := req.DecodeJSON(&spec); err != nil {
+			t.Fatal(err)
+		}
+		parallelism = append(parallelism, mustMap(spec["UpdateConfig"])["Parallelism"])
+	}
+	if len(parallelism) != 4 || parallelism[0] != float64(1) || parallelism[1] != float64(1) || parallelism[2] != float64(1) {
+		t.Errorf("expected 2 canary updates with parallelism 1 followed by 2 rollout updates, got %v", parallelism)
+	}
+}
+
+func TestDeployCreate_CanaryStrategy_RequiresSwarm(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/swarm", RespondString(http.StatusNotFound, "application/json", `{"message":"not a swarm"}`))
+	mock.On("GET", "/stacks", RespondJSON(http.StatusOK, []map[string]interface{}{{"Id": 3, "Name": "app"}}))
+
+	d, err := strategyDeployData(t, map[string]interface{}{"type": "canary"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rcCreate(resourceDeploy(), d, mock.Client())
+	if err == nil || !strings.Contains(err.Error(), "requires a Docker Swarm environment") {
+		t.Fatalf("expected a swarm requirement error, got %v", err)
+	}
+	if mock.FindRequest("PUT", "/stacks/3") != nil {
+		t.Error("expected no redeploy of the standalone stack")
+	}
+}
+
+func TestExpandDeployStrategy_CanaryConflict(t *testing.T) {
+	d, err := strategyDeployData(t, map[string]interface{}{
+		"type":   "canary",
+		"canary": []interface{}{map[string]interface{}{"services": "web", "fraction": 0.5}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := expandDeployStrategy(d, "app"); err == nil {
+		t.Error("expected an error when canary services and fraction are both set")
+	}
+}
+
+func TestDeployCreate_CanaryFraction_RollbackRestoresUpdateConfig(t *testing.T) {
+	mock := NewMockServer(t)
+	swarm := newFakeSwarm(mock, map[string]string{"app_web": "repo/web:1.0", "app_worker": "repo/worker:1.0"}, "repo/worker:2.0")
+
+	d, err := strategyDeployData(t, map[string]interface{}{
+		"type": "canary",
+		"canary": []interface{}{map[string]interface{}{
+			"fraction":            0.5,
+			"wait":                0,
+			"wait_between_checks": 1,
+			"max_retries":         1,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rcCreate(resourceDeploy(), d, mock.Client())
+	if err == nil || !strings.Contains(err.Error(), "canary phase failed, services rolled back") {
+		t.Fatalf("expected the canary phase to fail and roll back, got %v", err)
+	}
+	for _, name := range []string{"app_web", "app_worker"} {
+		if cfg := mustMap(swarm.configs[name]); len(cfg) != 1 || cfg["Order"] != "start-first" {
+			t.Errorf("%s: expected the original UpdateConfig to be restored, got %v", name, cfg)
+		}
+	}
+}
+
+func TestDeployCreate_UnknownCanaryService(t *testing.T) {
+	mock := NewMockServer(t)
+	swarm := newFakeSwarm(mock, map[string]string{"app_web": "repo/web:1.0", "app_worker": "repo/worker:1.0"})
+
+	d, err := strategyDeployData(t, map[string]interface{}{
+		"type":   "canary",
+		"canary": []interface{}{map[string]interface{}{"services": "wbe"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rcCreate(resourceDeploy(), d, mock.Client())
+	if err == nil || !strings.Contains(err.Error(), `service "wbe" is not in services_list (web, worker)`) {
+		t.Fatalf("expected an unknown canary service error, got %v", err)
+	}
+	if len(swarm.updates) != 0 {
+		t.Errorf("expected no service update, got %v", swarm.updates)
+	}
+
+	raw := map[string]interface{}{
+		"endpoint_id":   1,
+		"stack_name":    "app",
+		"stack_env_var": "TAG",
+		"revision":      "2.0",
+		"services_list": "web,worker",
+		"strategy": []interface{}{map[string]interface{}{
+			"type":   "canary",
+			"canary": []interface{}{map[string]interface{}{"services": "wbe"}},
+		}},
+	}
+	if _, err := resourceDeploy().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(raw), nil); err == nil || !strings.Contains(err.Error(), `service "wbe" is not in services_list`) {
+		t.Errorf("expected the plan to fail, got %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// errDockerEventsUnavailable is returned by waitForDockerConvergence when the
+// Docker events stream cannot be opened through Portainer, so that callers
+// fall back to polling.
+var errDockerEventsUnavailable = errors.New("docker events stream unavailable")
+
+// dockerEvent is a message of the Docker events stream.
+type dockerEvent struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	TimeNano int64 `json:"timeNano"`
+}
+
+// dockerConvergenceCheck inspects the current state once. It returns whether
+// the desired state is reached and a short status for the output.
+type dockerConvergenceCheck func() (bool, string, error)
+
+// openDockerEvents subscribes to service and container events of an
+// environment, replaying events since the given time (if not zero).
+func openDockerEvents(ctx context.Context, client *APIClient, endpointID int, since time.Time) (io.ReadCloser, error) {
+	filters, _ := json.Marshal(map[string][]string{"type": {"service", "container"}})
+	query := url.Values{"filters": {string(filters)}}
+	if !since.IsZero() {
+		query.Set("since", fmt.Sprintf("%d.%09d", since.Unix(), since.Nanosecond()))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/endpoints/%d/docker/events?%s", client.Endpoint, endpointID, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	if client.APIKey != "" {
+		req.Header.Set("X-API-Key", client.APIKey)
+	} else if client.JWTToken != "" {
+		req.Header.Set("Authorization", "Bearer "+client.JWTToken)
+	} else {
+		return nil, fmt.Errorf("no valid authentication method provided (api_key or jwt token)")
+	}
+
+	resp, err := client.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errDockerEventsUnavailable, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: status code %d, body: %s", errDockerEventsUnavailable, resp.StatusCode, string(data))
+	}
+	return resp.Body, nil
+}
+
+// dockerEventService returns the watched service an event relates to, or an
+// empty string. Service events carry the service name; container events are
+// matched like checkStandaloneContainers matches container names.
+func dockerEventService(ev dockerEvent, services []string) string {
+	attrs := ev.Actor.Attributes
+	for _, key := range []string{"name", "com.docker.swarm.service.name"} {
+		if contains(services, attrs[key]) {
+			return attrs[key]
+		}
+	}
+	if ev.Type != "container" {
+		return ""
+	}
+	name := strings.ReplaceAll(attrs["name"], "-", "_")
+	for _, s := range services {
+		if strings.Contains(name, strings.ReplaceAll(s, "-", "_")) {
+			return s
+		}
+	}
+	return ""
+}
+
+// dockerEventFailure returns an error for service events meaning the update
+// failed: Swarm started a rollback or paused the update.
+func dockerEventFailure(ev dockerEvent, service string) error {
+	if ev.Type != "service" || ev.Action != "update" {
+		return nil
+	}
+	state := ev.Actor.Attributes["updatestate.new"]
+	switch state {
+	case "rollback_started", "rollback_completed", "paused":
+		if msg := ev.Actor.Attributes["updatestate.message"]; msg != "" {
+			return fmt.Errorf("update of service %q failed (%s): %s", service, state, msg)
+		}
+		return fmt.Errorf("update of service %q failed (%s)", service, state)
+	}
+	return nil
+}
+
+// waitForDockerConvergence runs check whenever the events stream reports a
+// change on one of the services, and every interval (if > 0) in case the
+// relevant event happened on another node. It returns as soon as check
+// succeeds, fails fast on rollback events and gives up when ctx is done.
+// errDockerEventsUnavailable is returned if the stream cannot be (re)opened.
+func waitForDockerConvergence(ctx context.Context, client *APIClient, endpointID int, services []string, interval time.Duration, out *strings.Builder, check dockerConvergenceCheck) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Subscribe before the first check so that no change is missed.
+	stream, err := openDockerEvents(ctx, client, endpointID, time.Time{})
+	if err != nil {
+		return err
+	}
+	out.WriteString("Watching Docker events for convergence.\n")
+
+	events := make(chan dockerEvent)
+	streamErr := make(chan error, 1)
+	readEvents := func(stream io.ReadCloser) {
+		defer stream.Close()
+		dec := json.NewDecoder(stream)
+		for {
+			var ev dockerEvent
+			if err := dec.Decode(&ev); err != nil {
+				streamErr <- err
+				return
+			}
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+	go readEvents(stream)
+	opened := time.Now()
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	lastStatus := ""
+	runCheck := func() (bool, error) {
+		done, status, err := check()
+		if err != nil {
+			return false, err
+		}
+		if status != "" && status != lastStatus {
+			out.WriteString(status + "\n")
+			lastStatus = status
+		}
+		return done, nil
+	}
+
+	if done, err := runCheck(); err != nil || done {
+		return err
+	}
+
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("services %s did not converge in time (last status: %s)", strings.Join(services, ", "), lastStatus)
+
+		case err := <-streamErr:
+			if ctx.Err() != nil {
+				continue
+			}
+			if time.Since(opened) < time.Second {
+				// A stream closed right away is not usable.
+				return fmt.Errorf("%w: %v", errDockerEventsUnavailable, err)
+			}
+			// Proxies may close long-running requests: resume from the last event.
+			stream, err := openDockerEvents(ctx, client, endpointID, last)
+			if err != nil {
+				if ctx.Err() != nil {
+					continue
+				}
+				return err
+			}
+			go readEvents(stream)
+			opened = time.Now()
+
+		case <-tick:
+			if done, err := runCheck(); err != nil || done {
+				return err
+			}
+
+		case ev := <-events:
+			if ev.TimeNano > 0 {
+				last = time.Unix(0, ev.TimeNano)
+			}
+			service := dockerEventService(ev, services)
+			if service == "" {
+				continue
+			}
+			if err := dockerEventFailure(ev, service); err != nil {
+				return err
+			}
+			action := ev.Action
+			if state := ev.Actor.Attributes["updatestate.new"]; state != "" {
+				action += " " + state
+			}
+			out.WriteString(fmt.Sprintf("Event: %s %s (%s)\n", ev.Type, action, service))
+			if done, err := runCheck(); err != nil || done {
+				return err
+			}
+		}
+	}
+}
+
+// pollDockerConvergence runs check every interval until it succeeds or ctx is
+// done. It is the fallback of waitForDockerConvergence.
+func pollDockerConvergence(ctx context.Context, services []string, interval time.Duration, out *strings.Builder, check dockerConvergenceCheck) error {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	lastStatus := ""
+	for attempt := 1; ; attempt++ {
+		done, status, err := check()
+		if err != nil {
+			return err
+		}
+		if status != "" {
+			out.WriteString(fmt.Sprintf("Check %d: %s\n", attempt, status))
+			lastStatus = status
+		}
+		if done {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("services %s did not converge in time (last status: %s)", strings.Join(services, ", "), lastStatus)
+		case <-time.After(interval):
+		}
+	}
+}
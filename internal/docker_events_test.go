@@ -0,0 +1,166 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// streamDockerEvents returns a handler writing the events, then keeping the
+// stream open like the Docker daemon does until the client disconnects.
+func streamDockerEvents(events ...map[string]interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		enc := json.NewEncoder(w)
+		for _, ev := range events {
+			_ = enc.Encode(ev)
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}
+}
+
+func serviceUpdateEvent(service, state string) map[string]interface{} {
+	return map[string]interface{}{
+		"Type":   "service",
+		"Action": "update",
+		"Actor": map[string]interface{}{
+			"ID":         "svc1",
+			"Attributes": map[string]string{"name": service, "updatestate.new": state},
+		},
+		"timeNano": time.Now().UnixNano(),
+	}
+}
+
+// TestWaitForDockerConvergence_Events re-checks when an event of a watched
+// service arrives and returns as soon as the check succeeds.
+func TestWaitForDockerConvergence_Events(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/events", streamDockerEvents(
+		map[string]interface{}{"Type": "container", "Action": "start", "Actor": map[string]interface{}{"Attributes": map[string]string{"name": "other_db.1"}}},
+		serviceUpdateEvent("mystack_web", "completed"),
+	))
+
+	checks := 0
+	check := func() (bool, string, error) {
+		checks++
+		return checks == 2, "", nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var out strings.Builder
+	if err := waitForDockerConvergence(ctx, mock.Client(), 1, []string{"mystack_web"}, 0, &out, check); err != nil {
+		t.Fatalf("waitForDockerConvergence failed: %v", err)
+	}
+	if checks != 2 {
+		t.Errorf("expected 2 checks (initial + completed event), got %d", checks)
+	}
+	if !strings.Contains(out.String(), "Event: service update completed (mystack_web)") {
+		t.Errorf("output does not mention the event:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "other_db") {
+		t.Errorf("output mentions an unrelated event:\n%s", out.String())
+	}
+}
+
+// TestWaitForDockerConvergence_Rollback fails fast when Swarm rolls back.
+func TestWaitForDockerConvergence_Rollback(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/events", streamDockerEvents(serviceUpdateEvent("mystack_web", "rollback_started")))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var out strings.Builder
+	err := waitForDockerConvergence(ctx, mock.Client(), 1, []string{"mystack_web"}, 0, &out, func() (bool, string, error) {
+		return false, "Waiting for tasks: mystack_web 0/1", nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "rollback_started") {
+		t.Fatalf("expected rollback error, got %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Error("expected to fail before the timeout")
+	}
+}
+
+// TestWaitForDockerConvergence_Unavailable reports a missing events endpoint
+// so that callers fall back to polling.
+func TestWaitForDockerConvergence_Unavailable(t *testing.T) {
+	mock := NewMockServer(t)
+
+	var out strings.Builder
+	err := waitForDockerConvergence(context.Background(), mock.Client(), 1, []string{"mystack_web"}, 0, &out, func() (bool, string, error) {
+		t.Error("check must not run without events stream")
+		return false, "", nil
+	})
+	if !errors.Is(err, errDockerEventsUnavailable) {
+		t.Fatalf("expected errDockerEventsUnavailable, got %v", err)
+	}
+}
+
+// TestCheckCreate_EventsFallback polls like before when the events stream is
+// unavailable.
+func TestCheckCreate_EventsFallback(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/swarm", RespondString(http.StatusNotFound, "application/json", `{"message":"not a swarm"}`))
+	mock.On("GET", "/endpoints/1/docker/containers/json", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{"Names": []interface{}{"/mystack_web.1"}, "State": "running", "Image": "nginx:1.25"},
+	}))
+
+	r := resourceCheck()
+	d := r.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("stack_name", "mystack")
+	_ = d.Set("revision", "1.25")
+	_ = d.Set("services_list", "web")
+	_ = d.Set("desired_state", "running")
+	_ = d.Set("wait", 0)
+	_ = d.Set("wait_between_checks", 1)
+	_ = d.Set("max_retries", 1)
+	_ = d.Set("use_events", true)
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if got := d.Get("output").(string); !strings.Contains(got, "falling back to polling") || !strings.Contains(got, `Container "mystack_web.1" OK`) {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+}
+
+// TestCheckCreate_Events finishes the swarm check from the events stream.
+func TestCheckCreate_Events(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/swarm", RespondJSON(http.StatusOK, map[string]interface{}{"ID": "swarm-cluster-id"}))
+	mock.On("GET", "/endpoints/1/docker/events", streamDockerEvents())
+	mock.On("GET", "/endpoints/1/docker/tasks", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{
+			"Spec":   map[string]interface{}{"ContainerSpec": map[string]interface{}{"Image": "nginx:1.25"}},
+			"Status": map[string]interface{}{"State": "running"},
+		},
+	}))
+
+	r := resourceCheck()
+	d := r.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("stack_name", "mystack")
+	_ = d.Set("revision", "1.25")
+	_ = d.Set("services_list", "web")
+	_ = d.Set("desired_state", "running")
+	_ = d.Set("wait", 0)
+	_ = d.Set("wait_between_checks", 5)
+	_ = d.Set("max_retries", 3)
+	_ = d.Set("use_events", true)
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	got := d.Get("output").(string)
+	if !strings.Contains(got, "Watching Docker events") || !strings.Contains(got, "Services mystack_web OK") {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+}
@@ -0,0 +1,366 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// edgeStackStatusTypes maps Portainer's edge stack status types to the names
+// exposed in environment_status.
+var edgeStackStatusTypes = map[int]string{
+	0:  "pending",
+	1:  "deployment_received",
+	2:  "error",
+	3:  "acknowledged",
+	4:  "removed",
+	5:  "remote_update_success",
+	6:  "images_pulled",
+	7:  "running",
+	8:  "deploying",
+	9:  "removing",
+	10: "paused_deploying",
+	11: "paused_removing",
+	12: "completed",
+}
+
+// edgeStackEnvironmentStatus is the deployment status of an edge stack on one
+// environment.
+type edgeStackEnvironmentStatus struct {
+	EndpointID   int
+	EndpointName string
+	Status       string
+	Message      string
+	UpdatedAt    int64
+}
+
+// edgeStackStatusPayload is the part of GET /edge_stacks/{id} holding the
+// per-environment status. Status entries carry a status history since
+// Portainer 2.19 and boolean Details before.
+type edgeStackStatusPayload struct {
+	StackFileVersion int `json:"StackFileVersion"`
+	Status           map[string]struct {
+		EndpointID int `json:"EndpointID"`
+		Status     []struct {
+			Type  int    `json:"Type"`
+			Error string `json:"Error"`
+			Time  int64  `json:"Time"`
+		} `json:"Status"`
+		DeploymentInfo *struct {
+			FileVersion int `json:"FileVersion"`
+		} `json:"DeploymentInfo"`
+		Details *struct {
+			Pending      bool `json:"Pending"`
+			Ok           bool `json:"Ok"`
+			Error        bool `json:"Error"`
+			Acknowledged bool `json:"Acknowledged"`
+			ImagesPulled bool `json:"ImagesPulled"`
+		} `json:"Details"`
+		Error string `json:"Error"`
+	} `json:"Status"`
+}
+
+// environmentStatuses returns the latest status reported by each environment.
+// Environments still running a previous version of the stack file are
+// reported as pending.
+func (p edgeStackStatusPayload) environmentStatuses() map[int]edgeStackEnvironmentStatus {
+	statuses := make(map[int]edgeStackEnvironmentStatus, len(p.Status))
+	for key, s := range p.Status {
+		id := s.EndpointID
+		if id == 0 {
+			_, _ = fmt.Sscanf(key, "%d", &id)
+		}
+		status := edgeStackEnvironmentStatus{EndpointID: id, Status: "pending"}
+
+		switch {
+		case len(s.Status) > 0:
+			latest := s.Status[0]
+			for _, entry := range s.Status[1:] {
+				if entry.Time >= latest.Time {
+					latest = entry
+				}
+			}
+			if name, ok := edgeStackStatusTypes[latest.Type]; ok {
+				status.Status = name
+			}
+			status.Message = latest.Error
+			status.UpdatedAt = latest.Time
+		case s.Details != nil:
+			switch {
+			case s.Details.Error:
+				status.Status = "error"
+			case s.Details.Ok:
+				status.Status = "running"
+			case s.Details.ImagesPulled:
+				status.Status = "images_pulled"
+			case s.Details.Acknowledged:
+				status.Status = "acknowledged"
+			}
+			status.Message = s.Error
+		}
+
+		if s.DeploymentInfo != nil && s.DeploymentInfo.FileVersion > 0 && s.DeploymentInfo.FileVersion < p.StackFileVersion {
+			status.Status = "pending"
+			status.Message = fmt.Sprintf("running stack file version %d, waiting for version %d", s.DeploymentInfo.FileVersion, p.StackFileVersion)
+		}
+		statuses[id] = status
+	}
+	return statuses
+}
+
+// edgeStackEnvironments returns the status of every environment the edge stack
+// is deployed to, sorted by environment ID. Environments related to the stack
+// through its edge groups that did not report yet are pending. When the
+// environments cannot be listed, only the reporting environments are
+// returned, without names, along with the error.
+func edgeStackEnvironments(ctx context.Context, client *APIClient, stackID string, payload edgeStackStatusPayload) ([]edgeStackEnvironmentStatus, error) {
+	statuses := payload.environmentStatuses()
+
+	var listErr error
+	body, code, err := apiGETWithCodeCtx(ctx, fmt.Sprintf("%s/endpoints?edgeStackId=%s", client.Endpoint, stackID), client.APIKey, client)
+	var endpoints []struct {
+		ID   int    `json:"Id"`
+		Name string `json:"Name"`
+	}
+	switch {
+	case err != nil:
+		listErr = fmt.Errorf("failed to list the environments of edge stack %s: %w", stackID, err)
+	case code != http.StatusOK:
+		listErr = fmt.Errorf("failed to list the environments of edge stack %s, status %d: %s", stackID, code, string(body))
+	default:
+		if err := json.Unmarshal(body, &endpoints); err != nil {
+			listErr = fmt.Errorf("failed to decode the environments of edge stack %s: %w", stackID, err)
+		}
+	}
+	for _, ep := range endpoints {
+		status, ok := statuses[ep.ID]
+		if !ok {
+			status = edgeStackEnvironmentStatus{EndpointID: ep.ID, Status: "pending"}
+		}
+		status.EndpointName = ep.Name
+		statuses[ep.ID] = status
+	}
+
+	out := make([]edgeStackEnvironmentStatus, 0, len(statuses))
+	for _, s := range statuses {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].EndpointID < out[j].EndpointID })
+	return out, listErr
+}
+
+// fetchEdgeStackEnvironments reads the edge stack and returns the status of
+// its environments. found is false when the edge stack does not exist;
+// listErr reports that the environments could not be listed, in which case
+// statuses only holds the reporting environments.
+func fetchEdgeStackEnvironments(ctx context.Context, client *APIClient, stackID string) (statuses []edgeStackEnvironmentStatus, found bool, listErr error, err error) {
+	body, code, err := apiGETWithCodeCtx(ctx, fmt.Sprintf("%s/edge_stacks/%s", client.Endpoint, stackID), client.APIKey, client)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	if code == http.StatusNotFound {
+		return nil, false, nil, nil
+	}
+	if code != http.StatusOK {
+		return nil, false, nil, fmt.Errorf("failed to read edge stack %s, status %d: %s", stackID, code, string(body))
+	}
+	var payload edgeStackStatusPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, false, nil, fmt.Errorf("failed to decode edge stack %s: %w", stackID, err)
+	}
+	statuses, listErr = edgeStackEnvironments(ctx, client, stackID, payload)
+	return statuses, true, listErr, nil
+}
+
+// flattenEdgeStackEnvironments converts the statuses to environment_status.
+func flattenEdgeStackEnvironments(statuses []edgeStackEnvironmentStatus) []interface{} {
+	out := make([]interface{}, 0, len(statuses))
+	for _, s := range statuses {
+		out = append(out, map[string]interface{}{
+			"endpoint_id":   s.EndpointID,
+			"endpoint_name": s.EndpointName,
+			"status":        s.Status,
+			"message":       s.Message,
+			"updated_at":    s.UpdatedAt,
+		})
+	}
+	return out
+}
+
+// edgeStackEnvironmentStatusSchema is the element schema of environment_status,
+// shared by the edge stack resource and the edge stack status data source.
+func edgeStackEnvironmentStatusSchema() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"endpoint_id": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the environment.",
+			},
+			"endpoint_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Name of the environment.",
+			},
+			"status": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Latest deployment status: pending, acknowledged, deployment_received, deploying, images_pulled, running, completed, error, ...",
+			},
+			"message": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Error message reported by the edge agent, if any.",
+			},
+			"updated_at": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Unix timestamp of the latest status.",
+			},
+		},
+	}
+}
+
+// edgeStackStatusSucceeded reports whether the stack is deployed on the
+// environment.
+func edgeStackStatusSucceeded(status string) bool {
+	return status == "running" || status == "completed"
+}
+
+// edgeStackDeploymentWait holds the wait_for_deployment settings.
+type edgeStackDeploymentWait struct {
+	Threshold  string
+	Percentage int
+	MinCount   int
+	Timeout    time.Duration
+	Interval   time.Duration
+	// FailFast stops waiting as soon as the threshold cannot be reached
+	// anymore. It is disabled when edge agents retry failed deployments.
+	FailFast bool
+	// Staggered adds the status of the staggered rollout to the messages.
+	Staggered bool
+}
+
+// required returns the number of environments that must run the stack.
+func (w edgeStackDeploymentWait) required(total int) int {
+	switch w.Threshold {
+	case "percentage":
+		return (total*w.Percentage + 99) / 100
+	case "min_count":
+		return w.MinCount
+	default:
+		return total
+	}
+}
+
+// describe returns the threshold for messages.
+func (w edgeStackDeploymentWait) describe() string {
+	switch w.Threshold {
+	case "percentage":
+		return fmt.Sprintf("%d%% of environments", w.Percentage)
+	case "min_count":
+		return fmt.Sprintf("at least %d environments", w.MinCount)
+	default:
+		return "all environments"
+	}
+}
+
+// formatEdgeStackEnvironments lists environments for error messages.
+func formatEdgeStackEnvironments(statuses []edgeStackEnvironmentStatus) string {
+	lines := make([]string, 0, len(statuses))
+	for _, s := range statuses {
+		name := s.EndpointName
+		if name == "" {
+			name = fmt.Sprintf("environment %d", s.EndpointID)
+		} else {
+			name = fmt.Sprintf("%s (%d)", name, s.EndpointID)
+		}
+		line := fmt.Sprintf("  - %s: %s", name, s.Status)
+		if s.Message != "" {
+			line += ": " + s.Message
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// classifyEdgeStackEnvironments splits the statuses into environments
+// running the stack, failing and still in progress.
+func classifyEdgeStackEnvironments(statuses []edgeStackEnvironmentStatus) (succeeded, failed, pending []edgeStackEnvironmentStatus) {
+	for _, s := range statuses {
+		switch {
+		case edgeStackStatusSucceeded(s.Status):
+			succeeded = append(succeeded, s)
+		case s.Status == "error":
+			failed = append(failed, s)
+		default:
+			pending = append(pending, s)
+		}
+	}
+	return succeeded, failed, pending
+}
+
+// waitForEdgeStackDeployment polls the edge stack until enough environments
+// run it. It fails with the list of failing environments when the threshold
+// cannot be reached anymore or the timeout expires.
+func waitForEdgeStackDeployment(ctx context.Context, client *APIClient, stackID string, wait edgeStackDeploymentWait) ([]edgeStackEnvironmentStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, wait.Timeout)
+	defer cancel()
+
+	interval := wait.Interval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	var statuses []edgeStackEnvironmentStatus
+	var listErr error
+	progress := ""
+	for {
+		current, found, currentListErr, err := fetchEdgeStackEnvironments(ctx, client, stackID)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			return statuses, err
+		case err == nil && !found:
+			return nil, fmt.Errorf("edge stack %s not found while waiting for its deployment", stackID)
+		case err == nil:
+			statuses, listErr = current, currentListErr
+		}
+
+		succeeded, failed, pending := classifyEdgeStackEnvironments(statuses)
+		required := wait.required(len(statuses))
+		// Without the full list of environments, or before any environment
+		// is known, the threshold cannot be assessed: keep polling.
+		known := err == nil && listErr == nil && len(statuses) > 0
+		if known && len(succeeded) >= required {
+			return statuses, nil
+		}
+		if err == nil && wait.Staggered {
+			if status, err := fetchEdgeStackStaggerStatus(ctx, client, stackID); err == nil && status != "" {
+				progress = fmt.Sprintf(", staggered rollout %s", status)
+			}
+		}
+		if known && wait.FailFast && len(succeeded)+len(pending) < required {
+			return statuses, fmt.Errorf("edge stack %s cannot be deployed to %s (%d/%d required environments running%s), failing environments:\n%s",
+				stackID, wait.describe(), len(succeeded), required, progress, formatEdgeStackEnvironments(failed))
+		}
+
+		select {
+		case <-ctx.Done():
+			if listErr != nil {
+				return statuses, fmt.Errorf("timeout waiting for edge stack %s to be deployed to %s: %w", stackID, wait.describe(), listErr)
+			}
+			if len(statuses) == 0 {
+				return statuses, fmt.Errorf("timeout waiting for edge stack %s to be deployed to %s: no environment is related to the edge stack", stackID, wait.describe())
+			}
+			return statuses, fmt.Errorf("timeout waiting for edge stack %s to be deployed to %s (%d/%d required environments running%s), failing environments:\n%s",
+				stackID, wait.describe(), len(succeeded), required, progress, formatEdgeStackEnvironments(append(failed, pending...)))
+		case <-time.After(interval):
+		}
+	}
+}
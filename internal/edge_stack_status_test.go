@@ -0,0 +1,183 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// edgeStackWithStatus is a GET /edge_stacks/{id} response where environment 1
+// runs the stack, environment 2 failed to pull the image and environment 3
+// has not reported yet.
+func edgeStackWithStatus(id int) map[string]interface{} {
+	return map[string]interface{}{
+		"Id":               id,
+		"Name":             "edge-rollout",
+		"StackFileVersion": 2,
+		"Status": map[string]interface{}{
+			"1": map[string]interface{}{
+				"EndpointID":     1,
+				"Status":         []map[string]interface{}{{"Type": 3, "Time": 100}, {"Type": 7, "Time": 200}},
+				"DeploymentInfo": map[string]interface{}{"FileVersion": 2},
+			},
+			"2": map[string]interface{}{
+				"EndpointID": 2,
+				"Status":     []map[string]interface{}{{"Type": 2, "Error": "image pull failed", "Time": 150}},
+			},
+		},
+	}
+}
+
+func mockEdgeStackEnvironments(mock *MockServer) {
+	mock.On("GET", "/endpoints", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{"Id": 1, "Name": "edge-a"},
+		{"Id": 2, "Name": "edge-b"},
+		{"Id": 3, "Name": "edge-c"},
+	}))
+}
+
+// TestEdgeStackEnvironmentStatuses covers statuses of Portainer versions
+// before 2.19 and environments running an outdated stack file.
+func TestEdgeStackEnvironmentStatuses(t *testing.T) {
+	var payload edgeStackStatusPayload
+	if err := json.Unmarshal([]byte(`{
+		"StackFileVersion": 3,
+		"Status": {
+			"4": {"Details": {"Error": true}, "Error": "no space left on device"},
+			"5": {"EndpointID": 5, "Status": [{"Type": 7, "Time": 10}], "DeploymentInfo": {"FileVersion": 2}}
+		}
+	}`), &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := payload.environmentStatuses()
+	if s := statuses[4]; s.Status != "error" || s.Message != "no space left on device" {
+		t.Errorf("legacy status: got %+v", s)
+	}
+	if s := statuses[5]; s.Status != "pending" {
+		t.Errorf("environment running an outdated stack file must be pending, got %+v", s)
+	}
+}
+
+// TestEdgeStackCreate_WaitForDeploymentFails fails the apply with the failing
+// environments once the threshold cannot be reached anymore.
+func TestEdgeStackCreate_WaitForDeploymentFails(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/edge_stacks", RespondJSON(http.StatusOK, []map[string]interface{}{}))
+	mock.On("POST", "/edge_stacks/create/string", RespondJSON(http.StatusOK, map[string]interface{}{"Id": 11}))
+	mock.On("GET", "/edge_stacks/11", RespondJSON(http.StatusOK, edgeStackWithStatus(11)))
+	mockEdgeStackEnvironments(mock)
+
+	r := resourceEdgeStack()
+	d := r.TestResourceData()
+	_ = d.Set("name", "edge-rollout")
+	_ = d.Set("deployment_type", 0)
+	_ = d.Set("edge_groups", []interface{}{3})
+	_ = d.Set("stack_file_content", "services: {}\n")
+	_ = d.Set("wait_for_deployment", []interface{}{map[string]interface{}{
+		"threshold": "all", "percentage": 100, "min_count": 1, "timeout": "10s", "interval": 1,
+	}})
+
+	err := rcCreate(r, d, mock.Client())
+	if err == nil {
+		t.Fatal("expected deployment failure")
+	}
+	if !strings.Contains(err.Error(), "edge-b (2): error: image pull failed") {
+		t.Errorf("error does not list the failing environment: %v", err)
+	}
+	if strings.Contains(err.Error(), "edge-a") {
+		t.Errorf("error lists a running environment: %v", err)
+	}
+}
+
+// TestEdgeStackCreate_WaitForDeploymentMinCount succeeds once enough
+// environments run the stack, and records the status of each environment.
+func TestEdgeStackCreate_WaitForDeploymentMinCount(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/edge_stacks", RespondJSON(http.StatusOK, []map[string]interface{}{}))
+	mock.On("POST", "/edge_stacks/create/string", RespondJSON(http.StatusOK, map[string]interface{}{"Id": 11}))
+	mock.On("GET", "/edge_stacks/11", RespondJSON(http.StatusOK, edgeStackWithStatus(11)))
+	mockEdgeStackEnvironments(mock)
+
+	r := resourceEdgeStack()
+	d := r.TestResourceData()
+	_ = d.Set("name", "edge-rollout")
+	_ = d.Set("deployment_type", 0)
+	_ = d.Set("edge_groups", []interface{}{3})
+	_ = d.Set("stack_file_content", "services: {}\n")
+	_ = d.Set("wait_for_deployment", []interface{}{map[string]interface{}{
+		"threshold": "min_count", "percentage": 100, "min_count": 1, "timeout": "10s", "interval": 1,
+	}})
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if req := mock.FindRequest("GET", "/endpoints"); req == nil || req.Query != "edgeStackId=11" {
+		t.Fatalf("expected environments of the edge stack to be listed, got %+v", req)
+	}
+
+	want := []struct {
+		name, status, message string
+	}{
+		{"edge-a", "running", ""},
+		{"edge-b", "error", "image pull failed"},
+		{"edge-c", "pending", ""},
+	}
+	got := d.Get("environment_status").([]interface{})
+	if len(got) != len(want) {
+		t.Fatalf("environment_status: expected %d entries, got %v", len(want), got)
+	}
+	for i, w := range want {
+		s := got[i].(map[string]interface{})
+		if s["endpoint_name"] != w.name || s["status"] != w.status || s["message"] != w.message {
+			t.Errorf("environment_status[%d]: expected %+v, got %v", i, w, s)
+		}
+	}
+}
+
+func TestDataSourceEdgeStackStatusRead(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/edge_stacks/11", RespondJSON(http.StatusOK, edgeStackWithStatus(11)))
+	mockEdgeStackEnvironments(mock)
+
+	ds := dataSourceEdgeStackStatus()
+	d := ds.TestResourceData()
+	_ = d.Set("edge_stack_id", 11)
+
+	if err := rcRead(ds, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	for key, want := range map[string]int{"total": 3, "running": 1, "failed": 1, "pending": 1} {
+		if got := d.Get(key).(int); got != want {
+			t.Errorf("%s: expected %d, got %d", key, want, got)
+		}
+	}
+}
+
+// TestWaitForEdgeStackDeployment_NoEnvironmentKnown keeps polling while no
+// environment is known, and reports why when the environments cannot be
+// listed.
+func TestWaitForEdgeStackDeployment_NoEnvironmentKnown(t *testing.T) {
+	wait := edgeStackDeploymentWait{Threshold: "all", Timeout: 2 * time.Second, Interval: 500 * time.Millisecond}
+
+	mock := NewMockServer(t)
+	mock.On("GET", "/edge_stacks/11", RespondJSON(http.StatusOK, map[string]interface{}{"Id": 11, "StackFileVersion": 1}))
+	mock.On("GET", "/endpoints", RespondJSON(http.StatusInternalServerError, map[string]interface{}{"message": "boom"}))
+
+	_, err := waitForEdgeStackDeployment(context.Background(), mock.Client(), "11", wait)
+	if err == nil || !strings.Contains(err.Error(), "failed to list the environments of edge stack 11, status 500") {
+		t.Fatalf("expected a timeout with the listing error, got %v", err)
+	}
+
+	mock = NewMockServer(t)
+	mock.On("GET", "/edge_stacks/11", RespondJSON(http.StatusOK, map[string]interface{}{"Id": 11, "StackFileVersion": 1}))
+	mock.On("GET", "/endpoints", RespondJSON(http.StatusOK, []map[string]interface{}{}))
+
+	_, err = waitForEdgeStackDeployment(context.Background(), mock.Client(), "11", wait)
+	if err == nil || !strings.Contains(err.Error(), "no environment is related to the edge stack") {
+		t.Fatalf("expected a timeout without environments, got %v", err)
+	}
+}
@@ -0,0 +1,278 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseEnvFile parses a .env file following Docker Compose's rules:
+//
+//   - blank lines and lines starting with # are ignored, an optional
+//     "export " prefix is dropped;
+//   - unquoted values are trimmed and end at an inline " #" comment;
+//   - single-quoted values are literal;
+//   - double-quoted values support \n, \r, \t, \", \\ and \$ escapes;
+//   - quoted values may span several lines;
+//   - ${VAR}, $VAR and the ${VAR:-default}, ${VAR-default}, ${VAR:+alt},
+//     ${VAR+alt}, ${VAR:?err} and ${VAR?err} forms are interpolated in unquoted
+//     and double-quoted values from the variables defined earlier in the file;
+//   - a name without "=" takes its value from the host environment in Compose
+//     and is ignored here, so the result does not depend on the machine
+//     running Terraform.
+func parseEnvFile(content string) (map[string]string, error) {
+	vars := map[string]string{}
+	src := strings.ReplaceAll(content, "\r\n", "\n")
+	line := 1
+
+	for len(src) > 0 {
+		// Skip leading blank space and empty lines.
+		trimmed := strings.TrimLeft(src, " \t\n")
+		line += strings.Count(src[:len(src)-len(trimmed)], "\n")
+		src = trimmed
+		if src == "" {
+			break
+		}
+
+		if src[0] == '#' {
+			src = skipLine(src)
+			continue
+		}
+
+		end := strings.IndexAny(src, "=\n")
+		if end < 0 || src[end] == '\n' {
+			// Name without value.
+			src = skipLine(src)
+			line++
+			continue
+		}
+
+		key := strings.TrimSpace(src[:end])
+		if strings.HasPrefix(key, "export ") || strings.HasPrefix(key, "export\t") {
+			key = strings.TrimSpace(key[len("export"):])
+		}
+		if !isEnvName(key) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", line, key)
+		}
+		src = strings.TrimLeft(src[end+1:], " \t")
+
+		var value string
+		var err error
+		startLine := line
+		switch {
+		case strings.HasPrefix(src, "'"):
+			closing := strings.IndexByte(src[1:], '\'')
+			if closing < 0 {
+				return nil, fmt.Errorf("line %d: unterminated single-quoted value for %s", startLine, key)
+			}
+			value = src[1 : closing+1]
+			line += strings.Count(value, "\n")
+			src, err = endOfQuotedValue(src[closing+2:], startLine, key)
+		case strings.HasPrefix(src, `"`):
+			closing := -1
+			for i := 1; i < len(src); i++ {
+				if src[i] == '\\' {
+					i++
+					continue
+				}
+				if src[i] == '"' {
+					closing = i
+					break
+				}
+			}
+			if closing < 0 {
+				return nil, fmt.Errorf("line %d: unterminated double-quoted value for %s", startLine, key)
+			}
+			raw := src[1:closing]
+			line += strings.Count(raw, "\n")
+			if value, err = expandEnv(raw, vars, true); err != nil {
+				return nil, fmt.Errorf("line %d: %s: %w", startLine, key, err)
+			}
+			src, err = endOfQuotedValue(src[closing+1:], startLine, key)
+		default:
+			raw := src
+			if nl := strings.IndexByte(src, '\n'); nl >= 0 {
+				raw = src[:nl]
+			}
+			src = src[len(raw):]
+			for i := 1; i < len(raw); i++ {
+				if raw[i] == '#' && (raw[i-1] == ' ' || raw[i-1] == '\t') {
+					raw = raw[:i]
+					break
+				}
+			}
+			if value, err = interpolateEnv(strings.TrimSpace(raw), vars); err != nil {
+				err = fmt.Errorf("line %d: %s: %w", startLine, key, err)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// endOfQuotedValue checks that only blank space or a comment follows a quoted
+// value and returns the remaining input.
+func endOfQuotedValue(rest string, line int, key string) (string, error) {
+	tail := rest
+	if nl := strings.IndexByte(rest, '\n'); nl >= 0 {
+		tail = rest[:nl]
+	}
+	if t := strings.TrimSpace(tail); t != "" && !strings.HasPrefix(t, "#") {
+		return "", fmt.Errorf("line %d: unexpected characters after quoted value for %s: %q", line, key, t)
+	}
+	return rest[len(tail):], nil
+}
+
+func skipLine(src string) string {
+	if nl := strings.IndexByte(src, '\n'); nl >= 0 {
+		return src[nl+1:]
+	}
+	return ""
+}
+
+func isEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9', c == '.', c == '-':
+			if i == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// interpolateEnv substitutes variable references in an unquoted value with
+// vars. \$ is an escaped dollar sign.
+func interpolateEnv(value string, vars map[string]string) (string, error) {
+	return expandEnv(value, vars, false)
+}
+
+// expandEnv substitutes variable references in value with vars. In a
+// double-quoted value (quoted), the escape sequences are resolved in the same
+// pass, so that an escaped backslash followed by a reference, e.g. "\\$VAR",
+// yields a backslash and the value of VAR.
+func expandEnv(value string, vars map[string]string, quoted bool) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c == '\\' && i+1 < len(value) && (quoted || value[i+1] == '$') {
+			i++
+			switch value[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(value[i])
+			}
+			continue
+		}
+		if c != '$' || i+1 == len(value) {
+			b.WriteByte(c)
+			continue
+		}
+
+		if value[i+1] == '$' {
+			b.WriteByte('$')
+			i++
+			continue
+		}
+
+		if value[i+1] != '{' {
+			j := i + 1
+			for j < len(value) && (value[j] == '_' || value[j] >= 'A' && value[j] <= 'Z' || value[j] >= 'a' && value[j] <= 'z' || j > i+1 && value[j] >= '0' && value[j] <= '9') {
+				j++
+			}
+			if j == i+1 {
+				b.WriteByte(c)
+				continue
+			}
+			b.WriteString(vars[value[i+1:j]])
+			i = j - 1
+			continue
+		}
+
+		closing, depth := -1, 0
+		for j := i + 2; j < len(value) && closing < 0; j++ {
+			switch value[j] {
+			case '{':
+				depth++
+			case '}':
+				if depth == 0 {
+					closing = j - i
+				}
+				depth--
+			}
+		}
+		if closing < 0 {
+			return "", fmt.Errorf("unterminated variable reference %q", value[i:])
+		}
+		expr := value[i+2 : i+closing]
+		i += closing
+
+		name, op, arg := expr, "", ""
+		if k := strings.IndexAny(expr, ":-+?"); k >= 0 {
+			name, op = expr[:k], expr[k:k+1]
+			rest := expr[k+1:]
+			if op == ":" {
+				if rest == "" || !strings.ContainsAny(rest[:1], "-+?") {
+					return "", fmt.Errorf("invalid variable reference ${%s}", expr)
+				}
+				op, rest = ":"+rest[:1], rest[1:]
+			}
+			var err error
+			if arg, err = expandEnv(rest, vars, quoted); err != nil {
+				return "", err
+			}
+		}
+		if !isEnvName(name) {
+			return "", fmt.Errorf("invalid variable reference ${%s}", expr)
+		}
+
+		v, set := vars[name]
+		switch op {
+		case "":
+			b.WriteString(v)
+		case "-":
+			if !set {
+				v = arg
+			}
+			b.WriteString(v)
+		case ":-":
+			if v == "" {
+				v = arg
+			}
+			b.WriteString(v)
+		case "+":
+			if set {
+				b.WriteString(arg)
+			}
+		case ":+":
+			if v != "" {
+				b.WriteString(arg)
+			}
+		case "?":
+			if !set {
+				return "", fmt.Errorf("%s is not set: %s", name, arg)
+			}
+			b.WriteString(v)
+		case ":?":
+			if v == "" {
+				return "", fmt.Errorf("%s is not set: %s", name, arg)
+			}
+			b.WriteString(v)
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	content := strings.Join([]string{
+		"# database settings",
+		"export DB_HOST=db.internal",
+		"DB_PORT = 5432 # default port",
+		"DB_URL=postgres://${DB_HOST}:$DB_PORT/app",
+		`GREETING="hello\nworld" # comment`,
+		`LITERAL='${DB_HOST} stays # as is'`,
+		`ESCAPED="price: \$5"`,
+		`BACKSLASH="C:\\$DB_PORT"`,
+		`BACKSLASH_BRACES="\\${DB_HOST}\\\$DB_HOST"`,
+		"CERT=\"line1",
+		"line2\"",
+		"LOG_LEVEL=${LOG_LEVEL:-info}",
+		"EMPTY=",
+		"ALT=${EMPTY:+set}${DB_HOST:+set}",
+		"FROM_HOST",
+		"",
+	}, "\r\n")
+
+	got, err := parseEnvFile(content)
+	if err != nil {
+		t.Fatalf("parseEnvFile failed: %v", err)
+	}
+	want := map[string]string{
+		"DB_HOST":          "db.internal",
+		"DB_PORT":          "5432",
+		"DB_URL":           "postgres://db.internal:5432/app",
+		"GREETING":         "hello\nworld",
+		"LITERAL":          "${DB_HOST} stays # as is",
+		"ESCAPED":          "price: $5",
+		"BACKSLASH":        `C:\5432`,
+		"BACKSLASH_BRACES": `\db.internal\$DB_HOST`,
+		"CERT":             "line1\nline2",
+		"LOG_LEVEL":        "info",
+		"EMPTY":            "",
+		"ALT":              "set",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseEnvFile:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestParseEnvFile_Errors(t *testing.T) {
+	for _, content := range []string{
+		"1ABC=value",
+		`QUOTED="unterminated`,
+		`QUOTED="value" trailing`,
+		"REQUIRED=${MISSING:?must be set}",
+		"BROKEN=${UNCLOSED",
+	} {
+		if _, err := parseEnvFile(content); err == nil {
+			t.Errorf("%q: expected an error", content)
+		}
+	}
+}
@@ -74,6 +74,7 @@ func Provider() *schema.Provider {
 			"portainer_endpoint_group":                          resourceEndpointGroup(),
 			"portainer_tag":                                     resourceTag(),
 			"portainer_registry":                                resourceRegistry(),
+			"portainer_registry_credentials_refresh":            resourceRegistryCredentialsRefresh(),
 			"portainer_backup":                                  resourceBackup(),
 			"portainer_backup_s3":                               resourceBackupS3(),
 			"portainer_edge_group":                              resourceEdgeGroup(),
@@ -86,9 +87,15 @@ func Provider() *schema.Provider {
 			"portainer_check":                                   resourceCheck(),
 			"portainer_container_exec":                          resourceContainerExec(),
 			"portainer_docker_node":                             resourceDockerNode(),
+			"portainer_docker_swarm":                            resourceDockerSwarm(),
+			"portainer_docker_container":                        resourceDockerContainer(),
+			"portainer_docker_service":                          resourceDockerService(),
 			"portainer_docker_network":                          resourceDockerNetwork(),
 			"portainer_docker_image":                            resourceDockerImage(),
+			"portainer_docker_image_build":                      resourceDockerImageBuild(),
 			"portainer_docker_volume":                           resourceDockerVolume(),
+			"portainer_docker_volume_file":                      resourceDockerVolumeFile(),
+			"portainer_docker_prune":                            resourceDockerPrune(),
 			"portainer_docker_plugin":                           resourceDockerPlugin(),
 			"portainer_open_amt":                                resourceOpenAMT(),
 			"portainer_settings":                                resourceSettings(),
@@ -109,6 +116,7 @@ func Provider() *schema.Provider {
 			"portainer_kubernetes_namespace_ingresscontrollers": resourceKubernetesNamespaceIngressControllers(),
 			"portainer_kubernetes_ingresses":                    resourceKubernetesNamespaceIngress(),
 			"portainer_kubernetes_application":                  resourceKubernetesApplication(),
+			"portainer_kubernetes_app":                          resourceKubernetesApp(),
 			"portainer_kubernetes_namespace_system":             resourceKubernetesNamespaceSystem(),
 			"portainer_kubernetes_delete_object":                resourceKubernetesDeleteObject(),
 			"portainer_resource_control":                        resourceResourceControl(),
@@ -152,43 +160,56 @@ func Provider() *schema.Provider {
 			"portainer_user_git_credential":                     resourcePortainerUserGitCredential(),
 			"portainer_helm_user_repository":                    resourceHelmUserRepository(),
 			"portainer_stack_migrate":                           resourceStackMigrate(),
+			"portainer_stack_promotion":                         resourceStackPromotion(),
 			"portainer_ldap_settings":                           resourceLDAPSettings(),
 			"portainer_helm_rollback":                           resourceHelmRollback(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
-			"portainer_user":                  dataSourceUser(),
-			"portainer_team":                  dataSourceTeam(),
-			"portainer_environment":           dataSourceEnvironment(),
-			"portainer_endpoint_group":        dataSourceEndpointGroup(),
-			"portainer_tag":                   dataSourceTag(),
-			"portainer_registry":              dataSourceRegistry(),
-			"portainer_stack":                 dataSourceStack(),
-			"portainer_edge_group":            dataSourceEdgeGroup(),
-			"portainer_custom_template":       dataSourceCustomTemplate(),
-			"portainer_cloud_credentials":     dataSourceCloudCredentials(),
-			"portainer_edge_stack":            dataSourceEdgeStack(),
-			"portainer_edge_job":              dataSourceEdgeJob(),
-			"portainer_edge_configuration":    dataSourceEdgeConfiguration(),
-			"portainer_webhook":               dataSourceWebhook(),
-			"portainer_team_membership":       dataSourceTeamMembership(),
-			"portainer_endpoint_group_access": dataSourceEndpointGroupAccess(),
-			"portainer_registry_access":       dataSourceRegistryAccess(),
-			"portainer_docker_network":        dataSourceDockerNetwork(),
-			"portainer_docker_volume":         dataSourceDockerVolume(),
-			"portainer_docker_config":         dataSourceDockerConfig(),
-			"portainer_docker_secret":         dataSourceDockerSecret(),
-			"portainer_docker_image":          dataSourceDockerImage(),
-			"portainer_docker_node":           dataSourceDockerNode(),
-			"portainer_policy":                dataSourcePortainerPolicy(),
-			"portainer_policy_template":       dataSourcePortainerPolicyTemplate(),
-			"portainer_shared_git_credential": dataSourcePortainerSharedGitCredential(),
-			"portainer_user_activity":         dataSourceUserActivity(),
-			"portainer_role":                  dataSourceRole(),
-			"portainer_kubernetes_crd":        dataSourceKubernetesCRD(),
-			"portainer_helm_git_dryrun":       dataSourceHelmGitDryRun(),
-			"portainer_gitops_repo_refs":      dataSourceGitopsRepoRefs(),
-			"portainer_gitops_repo_file":      dataSourceGitopsRepoFile(),
-			"portainer_helm_release_history":  dataSourceHelmReleaseHistory(),
+			"portainer_user":                   dataSourceUser(),
+			"portainer_team":                   dataSourceTeam(),
+			"portainer_environment":            dataSourceEnvironment(),
+			"portainer_endpoint_group":         dataSourceEndpointGroup(),
+			"portainer_tag":                    dataSourceTag(),
+			"portainer_registry":               dataSourceRegistry(),
+			"portainer_stack":                  dataSourceStack(),
+			"portainer_edge_group":             dataSourceEdgeGroup(),
+			"portainer_custom_template":        dataSourceCustomTemplate(),
+			"portainer_cloud_credentials":      dataSourceCloudCredentials(),
+			"portainer_edge_stack":             dataSourceEdgeStack(),
+			"portainer_edge_stack_status":      dataSourceEdgeStackStatus(),
+			"portainer_edge_job":               dataSourceEdgeJob(),
+			"portainer_edge_configuration":     dataSourceEdgeConfiguration(),
+			"portainer_webhook":                dataSourceWebhook(),
+			"portainer_team_membership":        dataSourceTeamMembership(),
+			"portainer_endpoint_group_access":  dataSourceEndpointGroupAccess(),
+			"portainer_registry_access":        dataSourceRegistryAccess(),
+			"portainer_docker_network":         dataSourceDockerNetwork(),
+			"portainer_docker_volume":          dataSourceDockerVolume(),
+			"portainer_docker_volume_file":     dataSourceDockerVolumeFile(),
+			"portainer_docker_config":          dataSourceDockerConfig(),
+			"portainer_docker_secret":          dataSourceDockerSecret(),
+			"portainer_docker_image":           dataSourceDockerImage(),
+			"portainer_docker_node":            dataSourceDockerNode(),
+			"portainer_docker_swarm":           dataSourceDockerSwarm(),
+			"portainer_docker_container":       dataSourceDockerContainer(),
+			"portainer_docker_container_logs":  dataSourceDockerContainerLogs(),
+			"portainer_policy":                 dataSourcePortainerPolicy(),
+			"portainer_policy_template":        dataSourcePortainerPolicyTemplate(),
+			"portainer_shared_git_credential":  dataSourcePortainerSharedGitCredential(),
+			"portainer_user_activity":          dataSourceUserActivity(),
+			"portainer_role":                   dataSourceRole(),
+			"portainer_kubernetes_crd":         dataSourceKubernetesCRD(),
+			"portainer_helm_git_dryrun":        dataSourceHelmGitDryRun(),
+			"portainer_gitops_repo_refs":       dataSourceGitopsRepoRefs(),
+			"portainer_gitops_repo_file":       dataSourceGitopsRepoFile(),
+			"portainer_helm_release_history":   dataSourceHelmReleaseHistory(),
+			"portainer_kubernetes_pods":        dataSourceKubernetesPods(),
+			"portainer_kubernetes_nodes":       dataSourceKubernetesNodes(),
+			"portainer_kubernetes_events":      dataSourceKubernetesEvents(),
+			"portainer_kubernetes_workloads":   dataSourceKubernetesWorkloads(),
+			"portainer_kubernetes_services":    dataSourceKubernetesServices(),
+			"portainer_kubernetes_ingresses":   dataSourceKubernetesIngresses(),
+			"portainer_kubernetes_rbac_report": dataSourceKubernetesRBACReport(),
 		},
 		ConfigureContextFunc: configureProvider,
 	}
@@ -3,6 +3,7 @@ package internal
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"regexp"
@@ -18,7 +19,7 @@ func resourceCheck() *schema.Resource {
 		CreateContext: resourceCheckCreate,
 		ReadContext:   resourceCheckRead,
 		DeleteContext: resourceCheckDelete,
-		UpdateContext: nil,
+		UpdateContext: resourceCheckUpdate,
 		Schema: map[string]*schema.Schema{
 			"endpoint_id": {
 				Type:        schema.TypeInt,
@@ -72,6 +73,12 @@ func resourceCheck() *schema.Resource {
 				ForceNew:    true,
 				Description: "Maximum retries for each service check.",
 			},
+			"use_events": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Track convergence through the Docker events stream: re-check as soon as a service or container event arrives (and every `wait_between_checks`), fail fast on rollbacks, and give up after `max_retries` × `wait_between_checks` seconds. Falls back to polling when the stream is unavailable.",
+			},
 			"output": {
 				Type:        schema.TypeString,
 				Computed:    true,
@@ -116,6 +123,25 @@ func resourceCheckCreate(ctx context.Context, d *schema.ResourceData, meta inter
 	}
 	isSwarm := swCode == 200 && strings.Contains(string(swBody), `"ID"`)
 
+	if d.Get("use_events").(bool) {
+		check := standaloneConvergenceCheck(client, endpointID, fullServices, revision, desiredState)
+		if isSwarm {
+			check = swarmConvergenceCheck(client, endpointID, fullServices, desiredState, 0, swarmRevisionMatcher(revision, desiredState))
+		}
+		waitCtx, cancel := context.WithTimeout(ctx, time.Duration(maxRetries*waitBetween)*time.Second)
+		err := waitForDockerConvergence(waitCtx, client, endpointID, fullServices, time.Duration(waitBetween)*time.Second, &out, check)
+		cancel()
+		if err == nil {
+			_ = d.Set("output", out.String())
+			d.SetId(fmt.Sprintf("check-%d", time.Now().Unix()))
+			return nil
+		}
+		if !errors.Is(err, errDockerEventsUnavailable) {
+			return diag.FromErr(err)
+		}
+		out.WriteString(fmt.Sprintf("Docker events unavailable (%v) — falling back to polling.\n", err))
+	}
+
 	if isSwarm {
 		out.WriteString("Docker Swarm detected — using swarm check logic.\n")
 		if err := checkSwarmServices(client, endpointID, revision, desiredState, fullServices, maxRetries, waitBetween, &out); err != nil {
@@ -133,129 +159,203 @@ func resourceCheckCreate(ctx context.Context, d *schema.ResourceData, meta inter
 	return nil
 }
 
-func checkSwarmServices(client *APIClient, endpointID int, revision, desiredState string, fullServices []string, maxRetries, waitBetween int, out *strings.Builder) error {
+// swarmRevisionMatcher accepts tasks running the revision (image tag) in the
+// desired state.
+func swarmRevisionMatcher(revision, desiredState string) func(task map[string]interface{}) bool {
 	imgRe := regexp.MustCompile(`^(.+?):([^@]+)(?:@.*)?$`)
+	return func(t map[string]interface{}) bool {
+		cs := mustMap(mustMap(t["Spec"])["ContainerSpec"])
+		image, _ := cs["Image"].(string)
+		state, _ := mustMap(t["Status"])["State"].(string)
 
+		m := imgRe.FindStringSubmatch(image)
+		return len(m) == 3 && m[2] == revision && strings.ToLower(state) == desiredState
+	}
+}
+
+func checkSwarmServices(client *APIClient, endpointID int, revision, desiredState string, fullServices []string, maxRetries, waitBetween int, out *strings.Builder) error {
+	match := swarmRevisionMatcher(revision, desiredState)
 	for _, service := range fullServices {
-		success := false
-		for attempt := 1; attempt <= maxRetries; attempt++ {
-			filter := fmt.Sprintf(`{"service":{"%s":true},"desired-state":{"%s":true}}`, service, desiredState)
-			tasksURL := fmt.Sprintf("%s/endpoints/%d/docker/tasks?filters=%s", client.Endpoint, endpointID, url.QueryEscape(filter))
-			tasksBody, code, err := apiGETWithCode(tasksURL, client.APIKey, client)
+		target := fmt.Sprintf("revision %q and state %q", revision, desiredState)
+		if err := waitForSwarmTasks(client, endpointID, service, desiredState, 0, maxRetries, waitBetween, target, out, match); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchSwarmTasks lists the tasks of a Swarm service with the given desired
+// state once and counts those accepted by match.
+func matchSwarmTasks(client *APIClient, endpointID int, service, desiredState string, match func(task map[string]interface{}) bool) (okReplicas, total, code int, err error) {
+	filter := fmt.Sprintf(`{"service":{"%s":true},"desired-state":{"%s":true}}`, service, desiredState)
+	tasksURL := fmt.Sprintf("%s/endpoints/%d/docker/tasks?filters=%s", client.Endpoint, endpointID, url.QueryEscape(filter))
+	tasksBody, code, err := apiGETWithCode(tasksURL, client.APIKey, client)
+	if err != nil {
+		return 0, 0, code, fmt.Errorf("error fetching tasks for %s: %w", service, err)
+	}
+	if code != 200 {
+		return 0, 0, code, nil
+	}
+
+	var tasks []map[string]interface{}
+	if err := json.Unmarshal(tasksBody, &tasks); err != nil {
+		return 0, 0, code, fmt.Errorf("failed to parse tasks JSON for %s: %w", service, err)
+	}
+	for _, t := range tasks {
+		if match(t) {
+			okReplicas++
+		}
+	}
+	return okReplicas, len(tasks), code, nil
+}
+
+// swarmConvergenceCheck checks once that all tasks of every service are
+// accepted by match, for use with waitForDockerConvergence.
+func swarmConvergenceCheck(client *APIClient, endpointID int, services []string, desiredState string, minTasks int, match func(task map[string]interface{}) bool) dockerConvergenceCheck {
+	return func() (bool, string, error) {
+		pending := []string{}
+		for _, service := range services {
+			okReplicas, total, _, err := matchSwarmTasks(client, endpointID, service, desiredState, match)
 			if err != nil {
-				return fmt.Errorf("error fetching tasks for %s: %w", service, err)
+				return false, "", err
 			}
-			if code != 200 {
-				out.WriteString(fmt.Sprintf("Attempt %d/%d: failed to fetch tasks (status %d)\n", attempt, maxRetries, code))
-				time.Sleep(time.Duration(waitBetween) * time.Second)
-				continue
+			if total == 0 || okReplicas != total || okReplicas < minTasks {
+				pending = append(pending, fmt.Sprintf("%s %d/%d", service, okReplicas, total))
 			}
+		}
+		if len(pending) == 0 {
+			return true, fmt.Sprintf("Services %s OK — all tasks converged", strings.Join(services, ", ")), nil
+		}
+		return false, fmt.Sprintf("Waiting for tasks: %s", strings.Join(pending, ", ")), nil
+	}
+}
 
-			var tasks []map[string]interface{}
-			if err := json.Unmarshal(tasksBody, &tasks); err != nil {
-				return fmt.Errorf("failed to parse tasks JSON for %s: %w", service, err)
-			}
-			if len(tasks) == 0 {
-				out.WriteString(fmt.Sprintf("Attempt %d/%d: no tasks found for service %q\n", attempt, maxRetries, service))
-				time.Sleep(time.Duration(waitBetween) * time.Second)
-				continue
-			}
+// waitForSwarmTasks polls the tasks of a Swarm service (name or ID) that have
+// the given desired state until at least minTasks of them exist and all are
+// accepted by match. target describes the awaited condition in the output.
+func waitForSwarmTasks(client *APIClient, endpointID int, service, desiredState string, minTasks, maxRetries, waitBetween int, target string, out *strings.Builder, match func(task map[string]interface{}) bool) error {
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		okReplicas, total, code, err := matchSwarmTasks(client, endpointID, service, desiredState, match)
+		if err != nil {
+			return err
+		}
+		if code != 200 {
+			out.WriteString(fmt.Sprintf("Attempt %d/%d: failed to fetch tasks (status %d)\n", attempt, maxRetries, code))
+			time.Sleep(time.Duration(waitBetween) * time.Second)
+			continue
+		}
+		if total == 0 {
+			out.WriteString(fmt.Sprintf("Attempt %d/%d: no tasks found for service %q\n", attempt, maxRetries, service))
+			time.Sleep(time.Duration(waitBetween) * time.Second)
+			continue
+		}
 
-			okReplicas := 0
-			for _, t := range tasks {
-				spec := mustMap(t["Spec"])
-				cs := mustMap(spec["ContainerSpec"])
-				image, _ := cs["Image"].(string)
-				state := strings.ToLower(mustMap(t["Status"])["State"].(string))
-
-				if m := imgRe.FindStringSubmatch(image); len(m) == 3 {
-					if m[2] == revision && state == desiredState {
-						okReplicas++
-					}
-				}
-			}
+		if okReplicas == total && okReplicas >= minTasks {
+			out.WriteString(fmt.Sprintf("Service %q OK — all %d/%d tasks at %s\n", service, okReplicas, total, target))
+			return nil
+		}
+		out.WriteString(fmt.Sprintf("Attempt %d/%d: %d/%d tasks match %s\n", attempt, maxRetries, okReplicas, total, target))
+		time.Sleep(time.Duration(waitBetween) * time.Second)
+	}
+	return fmt.Errorf("service %q did not reach %s after %d retries", service, target, maxRetries)
+}
 
-			if okReplicas == len(tasks) {
-				out.WriteString(fmt.Sprintf("Service %q OK — all %d/%d tasks at revision %q and state %q\n",
-					service, okReplicas, len(tasks), revision, desiredState))
+func checkStandaloneContainers(client *APIClient, endpointID int, revision, desiredState string, fullServices []string, maxRetries, waitBetween int, out *strings.Builder) error {
+	for _, service := range fullServices {
+		success := false
+		for attempt := 1; attempt <= maxRetries; attempt++ {
+			ok, err := matchStandaloneContainer(client, endpointID, service, revision, desiredState, out)
+			if err != nil {
+				return err
+			}
+			if ok {
 				success = true
 				break
-			} else {
-				out.WriteString(fmt.Sprintf("Attempt %d/%d: %d/%d tasks match revision %q and state %q\n",
-					attempt, maxRetries, okReplicas, len(tasks), revision, desiredState))
-				time.Sleep(time.Duration(waitBetween) * time.Second)
 			}
+			out.WriteString(fmt.Sprintf("Attempt %d/%d: container %q not yet matching desired revision/state\n", attempt, maxRetries, service))
+			time.Sleep(time.Duration(waitBetween) * time.Second)
 		}
+
 		if !success {
-			return fmt.Errorf("service %q did not reach revision %q and state %q after %d retries",
+			return fmt.Errorf("container %q is not running in revision %q and state %q after %d retries",
 				service, revision, desiredState, maxRetries)
 		}
 	}
 	return nil
 }
 
-func checkStandaloneContainers(client *APIClient, endpointID int, revision, desiredState string, fullServices []string, maxRetries, waitBetween int, out *strings.Builder) error {
-	for _, service := range fullServices {
-		success := false
-		for attempt := 1; attempt <= maxRetries; attempt++ {
-			containersURL := fmt.Sprintf("%s/endpoints/%d/docker/containers/json?all=1", client.Endpoint, endpointID)
-			containersBody, code, err := apiGETWithCode(containersURL, client.APIKey, client)
-			if err != nil || code != 200 {
-				return fmt.Errorf("failed to list containers (status %d): %w", code, err)
-			}
+// matchStandaloneContainer lists the containers once and reports whether one
+// of them belongs to the service and runs the revision in the desired state.
+func matchStandaloneContainer(client *APIClient, endpointID int, service, revision, desiredState string, out *strings.Builder) (bool, error) {
+	containersURL := fmt.Sprintf("%s/endpoints/%d/docker/containers/json?all=1", client.Endpoint, endpointID)
+	containersBody, code, err := apiGETWithCode(containersURL, client.APIKey, client)
+	if err != nil || code != 200 {
+		return false, fmt.Errorf("failed to list containers (status %d): %w", code, err)
+	}
 
-			var containers []map[string]interface{}
-			if err := json.Unmarshal(containersBody, &containers); err != nil {
-				return fmt.Errorf("failed to parse containers list: %w", err)
-			}
+	var containers []map[string]interface{}
+	if err := json.Unmarshal(containersBody, &containers); err != nil {
+		return false, fmt.Errorf("failed to parse containers list: %w", err)
+	}
 
-			for _, c := range containers {
-				nameList, _ := c["Names"].([]interface{})
-				state := strings.ToLower(c["State"].(string))
-				image, _ := c["Image"].(string)
-				if len(nameList) == 0 {
-					continue
-				}
-				name := strings.TrimPrefix(nameList[0].(string), "/")
-
-				out.WriteString(fmt.Sprintf("DEBUG: checking container=%q (image=%q, state=%q)\n", name, image, state))
-
-				normalizedName := strings.ReplaceAll(name, "-", "_")
-				normalizedService := strings.ReplaceAll(service, "-", "_")
-
-				if strings.Contains(normalizedName, normalizedService) {
-					cleanImage := image
-					if strings.Contains(image, "@") {
-						cleanImage = strings.Split(image, "@")[0]
-					}
-					if strings.HasSuffix(cleanImage, ":"+revision) && state == desiredState {
-						out.WriteString(fmt.Sprintf("Container %q OK — revision %q, state %q\n", name, revision, desiredState))
-						success = true
-						break
-					}
-				}
-			}
+	for _, c := range containers {
+		nameList, _ := c["Names"].([]interface{})
+		state := strings.ToLower(c["State"].(string))
+		image, _ := c["Image"].(string)
+		if len(nameList) == 0 {
+			continue
+		}
+		name := strings.TrimPrefix(nameList[0].(string), "/")
 
-			if success {
-				break
-			} else {
-				out.WriteString(fmt.Sprintf("Attempt %d/%d: container %q not yet matching desired revision/state\n", attempt, maxRetries, service))
-				time.Sleep(time.Duration(waitBetween) * time.Second)
+		out.WriteString(fmt.Sprintf("DEBUG: checking container=%q (image=%q, state=%q)\n", name, image, state))
+
+		normalizedName := strings.ReplaceAll(name, "-", "_")
+		normalizedService := strings.ReplaceAll(service, "-", "_")
+
+		if strings.Contains(normalizedName, normalizedService) {
+			cleanImage := image
+			if strings.Contains(image, "@") {
+				cleanImage = strings.Split(image, "@")[0]
+			}
+			if strings.HasSuffix(cleanImage, ":"+revision) && state == desiredState {
+				out.WriteString(fmt.Sprintf("Container %q OK — revision %q, state %q\n", name, revision, desiredState))
+				return true, nil
 			}
 		}
+	}
+	return false, nil
+}
 
-		if !success {
-			return fmt.Errorf("container %q is not running in revision %q and state %q after %d retries",
-				service, revision, desiredState, maxRetries)
+// standaloneConvergenceCheck checks once that every service has a container
+// running the revision, for use with waitForDockerConvergence.
+func standaloneConvergenceCheck(client *APIClient, endpointID int, services []string, revision, desiredState string) dockerConvergenceCheck {
+	return func() (bool, string, error) {
+		pending := []string{}
+		for _, service := range services {
+			ok, err := matchStandaloneContainer(client, endpointID, service, revision, desiredState, &strings.Builder{})
+			if err != nil {
+				return false, "", err
+			}
+			if !ok {
+				pending = append(pending, service)
+			}
 		}
+		if len(pending) == 0 {
+			return true, fmt.Sprintf("Containers %s OK — revision %q, state %q", strings.Join(services, ", "), revision, desiredState), nil
+		}
+		return false, fmt.Sprintf("Waiting for containers: %s", strings.Join(pending, ", ")), nil
 	}
-	return nil
 }
 
 func resourceCheckRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	return nil // Stateless
 }
 
+func resourceCheckUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// Only use_events can change in place; it is used by the next check.
+	return resourceCheckRead(ctx, d, meta)
+}
+
 func resourceCheckDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	d.SetId("")
 	return nil
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"regexp"
@@ -19,7 +20,8 @@ func resourceDeploy() *schema.Resource {
 		CreateContext: resourceDeployCreate,
 		ReadContext:   resourceDeployRead,   // stateless
 		DeleteContext: resourceDeployDelete, // stateless
-		UpdateContext: nil,
+		UpdateContext: resourceDeployUpdate,
+		CustomizeDiff: customizeDiffDeployStrategy,
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(15 * time.Minute),
 		},
@@ -75,6 +77,25 @@ func resourceDeploy() *schema.Resource {
 				ForceNew:    true,
 				Description: "Seconds to wait before force-updating a service (only when force_update = true).",
 			},
+			"wait_for_convergence": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If true, wait until every service runs the revision (all running tasks on Swarm, a running container on standalone), within the create timeout.",
+			},
+			"use_events": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Track convergence through the Docker events stream and fail fast on rollbacks, falling back to polling when the stream is unavailable (only when wait_for_convergence = true).",
+			},
+			"wait_between_checks": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     10,
+				Description: "Seconds between convergence checks when polling, and between safety re-checks while watching events (only when wait_for_convergence = true).",
+			},
+			"strategy": deployStrategySchema(),
 			"output": {
 				Type:        schema.TypeString,
 				Computed:    true,
@@ -110,6 +131,11 @@ func resourceDeployCreate(ctx context.Context, d *schema.ResourceData, meta inte
 		fullServices = append(fullServices, fmt.Sprintf("%s_%s", stackName, s))
 	}
 
+	strategy, err := expandDeployStrategy(d, stackName)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	var out strings.Builder
 
 	// Detect swarm
@@ -177,6 +203,8 @@ func resourceDeployCreate(ctx context.Context, d *schema.ResourceData, meta inte
 		}
 
 		updatedAny := false
+		var strategyOrder []string
+		strategyImages := map[string]string{}
 		imgTagRe := regexp.MustCompile(`^(.+?):([^@]+)(?:@.*)?$`)
 
 		for _, svc := range services {
@@ -206,6 +234,11 @@ func resourceDeployCreate(ctx context.Context, d *schema.ResourceData, meta inte
 
 			// set new image tag and label
 			newImage := fmt.Sprintf("%s:%s", imageRepo, revision)
+			if strategy != nil {
+				strategyOrder = append(strategyOrder, svcName)
+				strategyImages[svcName] = newImage
+				continue
+			}
 			containerSpec["Image"] = newImage
 
 			labels := mustMap(spec["Labels"])
@@ -257,6 +290,23 @@ func resourceDeployCreate(ctx context.Context, d *schema.ResourceData, meta inte
 			}
 		}
 
+		if len(strategyOrder) > 0 {
+			// Update in the order of services_list, the first one being the
+			// default canary.
+			strategyOrder = strategyOrder[:0]
+			for _, name := range fullServices {
+				if _, ok := strategyImages[name]; ok {
+					strategyOrder = append(strategyOrder, name)
+				}
+			}
+			interval := time.Duration(d.Get("wait_between_checks").(int)) * time.Second
+			if err := runSwarmDeployStrategy(ctx, client, endpointID, strategyOrder, strategyImages, revision, strategy, d.Get("use_events").(bool), interval, &out); err != nil {
+				_ = d.Set("output", out.String())
+				return diag.FromErr(err)
+			}
+			updatedAny = true
+		}
+
 		if !updatedAny {
 			out.WriteString(fmt.Sprintf("No update needed. All requested services already use revision %q.\n", revision))
 		}
@@ -357,6 +407,13 @@ func resourceDeployCreate(ctx context.Context, d *schema.ResourceData, meta inte
 			return diag.FromErr(fmt.Errorf("stack %q not found", stackName))
 		}
 
+		if strategy != nil && strategy.Type == "canary" {
+			return diag.FromErr(fmt.Errorf("the canary strategy requires a Docker Swarm environment: standalone stacks are redeployed at once"))
+		}
+		if strategy != nil {
+			out.WriteString("Phase rollout: standalone stacks are redeployed at once, parallelism and delay do not apply.\n")
+		}
+
 		// standalone: pouze update stack_env_var v env + pullImage=true, prune=true
 		if updateRevision {
 			sfURL := fmt.Sprintf("%s/stacks/%d/file", client.Endpoint, stackSpec.ID)
@@ -374,8 +431,10 @@ func resourceDeployCreate(ctx context.Context, d *schema.ResourceData, meta inte
 			// ensure/update stack_env_var in Env
 			env := stackSpec.Env
 			found := false
+			previousRevision := ""
 			for i := range env {
 				if env[i].Name == stackEnvVar {
+					previousRevision = env[i].Value
 					env[i].Value = revision
 					found = true
 					break
@@ -411,11 +470,40 @@ func resourceDeployCreate(ctx context.Context, d *schema.ResourceData, meta inte
 				return diag.FromErr(fmt.Errorf("failed to update stack (standalone) (status %d): %s", code, string(resp)))
 			}
 			out.WriteString(fmt.Sprintf("Standalone stack %q updated with %s=%q\n", stackName, stackEnvVar, revision))
+
+			if strategy != nil {
+				interval := time.Duration(d.Get("wait_between_checks").(int)) * time.Second
+				if err := waitForDeployConvergence(ctx, client, endpointID, false, fullServices, revision, d.Get("use_events").(bool), interval, &out); err != nil {
+					out.WriteString(fmt.Sprintf("Phase rollout: FAILED — %v\n", err))
+					if strategy.AutoRollback {
+						rollbackEnv := make([]map[string]string, 0, len(envPayload))
+						for _, kv := range envPayload {
+							if kv.Name != stackEnvVar {
+								rollbackEnv = append(rollbackEnv, map[string]string{"name": kv.Name, "value": kv.Value})
+							} else if found {
+								rollbackEnv = append(rollbackEnv, map[string]string{"name": kv.Name, "value": previousRevision})
+							}
+						}
+						err = rollbackStandaloneStack(ctx, client, endpointID, stackSpec.ID, sf.StackFileContent, rollbackEnv, err, &out)
+					}
+					_ = d.Set("output", out.String())
+					return diag.FromErr(err)
+				}
+				out.WriteString(fmt.Sprintf("Phase rollout: OK — %s at revision %q\n", strings.Join(fullServices, ", "), revision))
+			}
 		} else {
 			out.WriteString("Standalone mode — update_revision=false, nothing to update.\n")
 		}
 	}
 
+	if d.Get("wait_for_convergence").(bool) && strategy == nil {
+		interval := time.Duration(d.Get("wait_between_checks").(int)) * time.Second
+		if err := waitForDeployConvergence(ctx, client, endpointID, isSwarm, fullServices, revision, d.Get("use_events").(bool), interval, &out); err != nil {
+			_ = d.Set("output", out.String())
+			return diag.FromErr(err)
+		}
+	}
+
 	// Save output and ID
 	if err := d.Set("output", out.String()); err != nil {
 		return diag.FromErr(err)
@@ -424,11 +512,35 @@ func resourceDeployCreate(ctx context.Context, d *schema.ResourceData, meta inte
 	return nil
 }
 
+// waitForDeployConvergence waits until every service runs the revision, from
+// Docker events when possible and by polling otherwise.
+func waitForDeployConvergence(ctx context.Context, client *APIClient, endpointID int, isSwarm bool, services []string, revision string, useEvents bool, interval time.Duration, out *strings.Builder) error {
+	check := standaloneConvergenceCheck(client, endpointID, services, revision, "running")
+	if isSwarm {
+		check = swarmConvergenceCheck(client, endpointID, services, "running", 1, swarmRevisionMatcher(revision, "running"))
+	}
+	if useEvents {
+		err := waitForDockerConvergence(ctx, client, endpointID, services, interval, out, check)
+		if !errors.Is(err, errDockerEventsUnavailable) {
+			return err
+		}
+		out.WriteString(fmt.Sprintf("Docker events unavailable (%v) — falling back to polling.\n", err))
+	}
+	return pollDockerConvergence(ctx, services, interval, out, check)
+}
+
 func resourceDeployRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	// Stateless resource: nothing to read/refresh that makes sense.
 	return nil
 }
 
+func resourceDeployUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// Only the convergence settings (wait_for_convergence, use_events,
+	// wait_between_checks) can change in place; they are used by the next
+	// deployment.
+	return resourceDeployRead(ctx, d, meta)
+}
+
 func resourceDeployDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	// Stateless effect; nothing to delete.
 	d.SetId("")
@@ -1,10 +1,13 @@
 package internal
 
 import (
+	"context"
 	"net/http"
 	"reflect"
 	"regexp"
 	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 // resource_deploy is a stateless "action" resource. Create detects whether the
@@ -359,3 +362,38 @@ func TestDeployDelete_ClearsState(t *testing.T) {
 		t.Errorf("expected no API calls on Delete, got %d", len(mock.Requests()))
 	}
 }
+
+// TestDeployDiff_ConvergenceSettingsInPlace does not redeploy when the state
+// predates the convergence settings or when they change.
+func TestDeployDiff_ConvergenceSettingsInPlace(t *testing.T) {
+	r := resourceDeploy()
+	state := &terraform.InstanceState{ID: "deploy", Attributes: map[string]string{
+		"id":              "deploy",
+		"endpoint_id":     "1",
+		"stack_name":      "app",
+		"stack_env_var":   "VERSION",
+		"revision":        "1.0",
+		"services_list":   "web",
+		"update_revision": "true",
+		"force_update":    "true",
+		"wait":            "30",
+	}}
+	cfg := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"endpoint_id":          1,
+		"stack_name":           "app",
+		"stack_env_var":        "VERSION",
+		"revision":             "1.0",
+		"services_list":        "web",
+		"update_revision":      true,
+		"force_update":         true,
+		"wait_for_convergence": true,
+	})
+
+	diff, err := r.Diff(context.Background(), state, cfg, nil)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if diff != nil && diff.RequiresNew() {
+		t.Errorf("expected no redeploy, got %+v", diff.Attributes)
+	}
+}
@@ -0,0 +1,885 @@
+package internal
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceDockerContainer() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceDockerContainerCreate,
+		ReadContext:   resourceDockerContainerRead,
+		UpdateContext: resourceDockerContainerUpdate,
+		DeleteContext: resourceDockerContainerDelete,
+		CustomizeDiff: customdiff.All(
+			customizeDiffDockerContainerImage,
+			customdiff.ForceNewIf("memory", dockerContainerLimitRemoved("memory")),
+			customdiff.ForceNewIf("cpus", dockerContainerLimitRemoved("cpus")),
+		),
+		Importer: &schema.ResourceImporter{
+			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+				// Expect ID in format "<endpoint_id>:<container_id>"
+				parts := strings.SplitN(d.Id(), ":", 2)
+				if len(parts) != 2 {
+					return nil, fmt.Errorf("unexpected format of ID (%q), expected <endpoint_id>:<container_id>", d.Id())
+				}
+				endpointID, err := strconv.Atoi(parts[0])
+				if err != nil {
+					return nil, fmt.Errorf("invalid endpoint ID: %w", err)
+				}
+				_ = d.Set("endpoint_id", endpointID)
+				_ = d.Set("must_run", true)
+				_ = d.Set("restart_policy", "no")
+				_ = d.Set("remove_volumes", true)
+				d.SetId(parts[1])
+				return []*schema.ResourceData{d}, nil
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"endpoint_id": {Type: schema.TypeInt, Required: true, ForceNew: true, Description: "ID of the Portainer environment (Docker standalone host) where the container runs."},
+			"name":        {Type: schema.TypeString, Required: true, ForceNew: true, Description: "Name of the Docker container."},
+			"image":       {Type: schema.TypeString, Required: true, ForceNew: true, Description: "Image reference used to create the container (for example `nginx:1.27`)."},
+			"swarm_node_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Swarm node ID where the container operation is targeted, when applicable.",
+			},
+			"pull_image": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Whether to pull the image through Portainer before creating the container.",
+			},
+			"registry_id": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "ID of a Portainer registry whose credentials are used to pull the image.",
+			},
+			"command":     {Type: schema.TypeList, Optional: true, ForceNew: true, Elem: &schema.Schema{Type: schema.TypeString}, Description: "Command to run, overriding the image CMD."},
+			"entrypoint":  {Type: schema.TypeList, Optional: true, ForceNew: true, Elem: &schema.Schema{Type: schema.TypeString}, Description: "Entrypoint, overriding the image ENTRYPOINT."},
+			"working_dir": {Type: schema.TypeString, Optional: true, ForceNew: true, Description: "Working directory inside the container."},
+			"user":        {Type: schema.TypeString, Optional: true, ForceNew: true, Description: "User (and optionally group) the container process runs as."},
+			"env":         {Type: schema.TypeMap, Optional: true, ForceNew: true, Elem: &schema.Schema{Type: schema.TypeString}, Description: "Environment variables set in the container."},
+			"labels":      {Type: schema.TypeMap, Optional: true, ForceNew: true, Elem: &schema.Schema{Type: schema.TypeString}, Description: "Key/value labels attached to the container."},
+			"privileged":  {Type: schema.TypeBool, Optional: true, ForceNew: true, Description: "Whether the container runs in privileged mode."},
+			"port": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Port published on the host.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"internal": {Type: schema.TypeInt, Required: true, ForceNew: true, Description: "Port inside the container."},
+						"external": {Type: schema.TypeInt, Optional: true, ForceNew: true, Description: "Port on the host. Docker picks a random port when omitted."},
+						"ip":       {Type: schema.TypeString, Optional: true, ForceNew: true, Description: "Host IP the port is bound to. Binds on all interfaces when omitted."},
+						"protocol": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ForceNew:     true,
+							Default:      "tcp",
+							ValidateFunc: validation.StringInSlice([]string{"tcp", "udp", "sctp"}, false),
+							Description:  "Port protocol: `tcp`, `udp` or `sctp`.",
+						},
+					},
+				},
+			},
+			"mount": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Volume, bind or tmpfs mount.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ForceNew:     true,
+							Default:      "volume",
+							ValidateFunc: validation.StringInSlice([]string{"volume", "bind", "tmpfs"}, false),
+							Description:  "Mount type: `volume`, `bind` or `tmpfs`.",
+						},
+						"source":    {Type: schema.TypeString, Optional: true, ForceNew: true, Description: "Volume name or host path. Leave empty for tmpfs mounts."},
+						"target":    {Type: schema.TypeString, Required: true, ForceNew: true, Description: "Path inside the container."},
+						"read_only": {Type: schema.TypeBool, Optional: true, ForceNew: true, Description: "Whether the mount is read-only."},
+					},
+				},
+			},
+			"network": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Networks the container is attached to. The first one is used at creation, the others are connected afterwards.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name":         {Type: schema.TypeString, Required: true, ForceNew: true, Description: "Network name or ID."},
+						"aliases":      {Type: schema.TypeList, Optional: true, ForceNew: true, Elem: &schema.Schema{Type: schema.TypeString}, Description: "DNS aliases of the container on this network."},
+						"ipv4_address": {Type: schema.TypeString, Optional: true, ForceNew: true, Description: "Static IPv4 address on this network."},
+					},
+				},
+			},
+			"restart_policy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "no",
+				ValidateFunc: validation.StringInSlice([]string{"no", "always", "unless-stopped", "on-failure"}, false),
+				Description:  "Restart policy: `no`, `always`, `unless-stopped` or `on-failure`.",
+			},
+			"max_retry_count": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "Maximum restart attempts when restart_policy is `on-failure`.",
+			},
+			"healthcheck": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				MaxItems:    1,
+				Description: "Healthcheck overriding the one defined by the image.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"test":         {Type: schema.TypeList, Required: true, ForceNew: true, Elem: &schema.Schema{Type: schema.TypeString}, Description: "Test to perform, e.g. `[\"CMD-SHELL\", \"curl -f http://localhost/\"]` or `[\"NONE\"]`."},
+						"interval":     dockerDurationSchema("Time between two checks (e.g. `30s`).", true),
+						"timeout":      dockerDurationSchema("Time after which a check is considered hung.", true),
+						"start_period": dockerDurationSchema("Grace period during which failures are not counted.", true),
+						"retries":      {Type: schema.TypeInt, Optional: true, ForceNew: true, Description: "Consecutive failures needed to report unhealthy."},
+					},
+				},
+			},
+			"memory": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Memory limit in MB. 0 means unlimited.",
+			},
+			"cpus": {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				ValidateFunc: validation.FloatAtLeast(0),
+				Description:  "Number of CPUs the container may use (e.g. `0.5`). 0 means unlimited.",
+			},
+			"cpu_shares": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "Relative CPU weight.",
+			},
+			"capabilities": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				MaxItems:    1,
+				Description: "Kernel capabilities added to or dropped from the container.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"add":  {Type: schema.TypeSet, Optional: true, ForceNew: true, Elem: &schema.Schema{Type: schema.TypeString}, Description: "Capabilities to add (e.g. `NET_ADMIN`)."},
+						"drop": {Type: schema.TypeSet, Optional: true, ForceNew: true, Elem: &schema.Schema{Type: schema.TypeString}, Description: "Capabilities to drop (e.g. `ALL`)."},
+					},
+				},
+			},
+			"log_driver": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "Logging driver. Defaults to the daemon's driver.",
+			},
+			"log_opts": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Options passed to the logging driver.",
+			},
+			"must_run": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Whether the container should be running. A stopped container is started again on the next apply.",
+			},
+			"remove_volumes": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Whether anonymous volumes are removed together with the container.",
+			},
+			"image_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "ID (digest) of the image the container was created from. The container is replaced when the image reference resolves to a different ID on the host.",
+			},
+			"resource_control_id": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the Portainer resource control associated with this Docker container.",
+			},
+		},
+	}
+}
+
+// dockerDurationSchema is an optional Go duration string (e.g. `1m30s`);
+// equivalent spellings such as `90s` do not produce a diff.
+func dockerDurationSchema(description string, forceNew bool) *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeString,
+		Optional: true,
+		ForceNew: forceNew,
+		ValidateFunc: func(v interface{}, k string) ([]string, []error) {
+			if _, err := time.ParseDuration(v.(string)); err != nil {
+				return nil, []error{fmt.Errorf("%s: invalid duration %q: %v", k, v, err)}
+			}
+			return nil, nil
+		},
+		DiffSuppressFunc: func(_, old, new string, _ *schema.ResourceData) bool {
+			o, err1 := time.ParseDuration(old)
+			n, err2 := time.ParseDuration(new)
+			return err1 == nil && err2 == nil && o == n
+		},
+		Description: description,
+	}
+}
+
+type dockerContainerInspect struct {
+	ID    string `json:"Id"`
+	Name  string `json:"Name"`
+	Image string `json:"Image"`
+	State struct {
+		Status     string `json:"Status"`
+		Running    bool   `json:"Running"`
+		ExitCode   int    `json:"ExitCode"`
+		StartedAt  string `json:"StartedAt"`
+		FinishedAt string `json:"FinishedAt"`
+		Health     *struct {
+			Status        string `json:"Status"`
+			FailingStreak int    `json:"FailingStreak"`
+			Log           []struct {
+				ExitCode int    `json:"ExitCode"`
+				Output   string `json:"Output"`
+			} `json:"Log"`
+		} `json:"Health"`
+	} `json:"State"`
+	RestartCount int `json:"RestartCount"`
+	Mounts       []struct {
+		Type        string `json:"Type"`
+		Name        string `json:"Name"`
+		Source      string `json:"Source"`
+		Destination string `json:"Destination"`
+		RW          bool   `json:"RW"`
+	} `json:"Mounts"`
+	Config struct {
+		Image       string            `json:"Image"`
+		Cmd         []string          `json:"Cmd"`
+		Entrypoint  []string          `json:"Entrypoint"`
+		Env         []string          `json:"Env"`
+		Labels      map[string]string `json:"Labels"`
+		WorkingDir  string            `json:"WorkingDir"`
+		User        string            `json:"User"`
+		Healthcheck *struct {
+			Test        []string `json:"Test"`
+			Interval    int64    `json:"Interval"`
+			Timeout     int64    `json:"Timeout"`
+			StartPeriod int64    `json:"StartPeriod"`
+			Retries     int      `json:"Retries"`
+		} `json:"Healthcheck"`
+	} `json:"Config"`
+	HostConfig struct {
+		Privileged    bool     `json:"Privileged"`
+		CapAdd        []string `json:"CapAdd"`
+		CapDrop       []string `json:"CapDrop"`
+		Memory        int64    `json:"Memory"`
+		NanoCpus      int64    `json:"NanoCpus"`
+		CpuShares     int      `json:"CpuShares"`
+		RestartPolicy struct {
+			Name              string `json:"Name"`
+			MaximumRetryCount int    `json:"MaximumRetryCount"`
+		} `json:"RestartPolicy"`
+		LogConfig struct {
+			Type   string            `json:"Type"`
+			Config map[string]string `json:"Config"`
+		} `json:"LogConfig"`
+		PortBindings map[string][]struct {
+			HostIp   string `json:"HostIp"`
+			HostPort string `json:"HostPort"`
+		} `json:"PortBindings"`
+		Mounts []struct {
+			Type     string `json:"Type"`
+			Source   string `json:"Source"`
+			Target   string `json:"Target"`
+			ReadOnly bool   `json:"ReadOnly"`
+		} `json:"Mounts"`
+	} `json:"HostConfig"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			Aliases    []string `json:"Aliases"`
+			IPAddress  string   `json:"IPAddress"`
+			Gateway    string   `json:"Gateway"`
+			MacAddress string   `json:"MacAddress"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+	Portainer struct {
+		ResourceControl struct {
+			Id int `json:"Id"`
+		} `json:"ResourceControl"`
+	} `json:"Portainer"`
+}
+
+func dockerContainerHeaders(d interface{ Get(string) interface{} }) map[string]string {
+	headers := map[string]string{}
+	if nodeID, ok := d.Get("swarm_node_id").(string); ok && nodeID != "" {
+		headers["X-PortainerAgent-Target"] = nodeID
+	}
+	return headers
+}
+
+// customizeDiffDockerContainerImage resolves the image reference on the host
+// and forces a replacement when it points to another image than the one the
+// container was created from (e.g. after the tag has been pulled again).
+func customizeDiffDockerContainerImage(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || d.HasChange("image") {
+		return nil
+	}
+	client, ok := meta.(*APIClient)
+	if !ok || client == nil {
+		return nil
+	}
+	imageID, err := dockerImageID(client, d.Get("endpoint_id").(int), d.Get("image").(string), dockerContainerHeaders(d))
+	if err != nil || imageID == "" || imageID == d.Get("image_id").(string) {
+		return nil
+	}
+	if err := d.SetNew("image_id", imageID); err != nil {
+		return err
+	}
+	return d.ForceNew("image_id")
+}
+
+// dockerContainerLimitRemoved reports whether a memory or CPU limit is
+// removed (set back to 0). Docker's update API treats 0 as "unchanged", so
+// the container is recreated without the limit instead.
+func dockerContainerLimitRemoved(key string) customdiff.ResourceConditionFunc {
+	return func(_ context.Context, d *schema.ResourceDiff, _ interface{}) bool {
+		o, n := d.GetChange(key)
+		switch o := o.(type) {
+		case int:
+			return o != 0 && n.(int) == 0
+		case float64:
+			return o != 0 && n.(float64) == 0
+		}
+		return false
+	}
+}
+
+// pullDockerContainerImage pulls the image through the Docker proxy. Portainer
+// injects the credentials of the registry referenced by registryId.
+func pullDockerContainerImage(d *schema.ResourceData, client *APIClient, headers map[string]string) error {
+	endpointID := d.Get("endpoint_id").(int)
+	image := d.Get("image").(string)
+
+	auth := []byte(`{}`)
+	if v, ok := d.GetOk("registry_id"); ok {
+		auth, _ = json.Marshal(map[string]int{"registryId": v.(int)})
+	}
+	pullHeaders := map[string]string{"X-Registry-Auth": base64.StdEncoding.EncodeToString(auth)}
+	for k, v := range headers {
+		pullHeaders[k] = v
+	}
+
+	path := fmt.Sprintf("/endpoints/%d/docker/images/create?%s", endpointID, url.Values{"fromImage": {image}}.Encode())
+	resp, err := client.DoRequest(http.MethodPost, path, pullHeaders, nil)
+	if err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", image, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to pull image %s: %s", image, string(body))
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	if err := dockerPullStreamError(body); err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", image, err)
+	}
+	return nil
+}
+
+func buildDockerContainerPayload(d *schema.ResourceData) map[string]interface{} {
+	payload := map[string]interface{}{
+		"Image": d.Get("image").(string),
+	}
+	hostConfig := map[string]interface{}{
+		"Privileged": d.Get("privileged").(bool),
+		"RestartPolicy": map[string]interface{}{
+			"Name":              d.Get("restart_policy").(string),
+			"MaximumRetryCount": d.Get("max_retry_count").(int),
+		},
+	}
+
+	if v, ok := d.GetOk("command"); ok {
+		payload["Cmd"] = v.([]interface{})
+	}
+	if v, ok := d.GetOk("entrypoint"); ok {
+		payload["Entrypoint"] = v.([]interface{})
+	}
+	if v, ok := d.GetOk("working_dir"); ok {
+		payload["WorkingDir"] = v.(string)
+	}
+	if v, ok := d.GetOk("user"); ok {
+		payload["User"] = v.(string)
+	}
+	if v, ok := d.GetOk("env"); ok {
+		env := []string{}
+		for k, val := range v.(map[string]interface{}) {
+			env = append(env, k+"="+val.(string))
+		}
+		sort.Strings(env)
+		payload["Env"] = env
+	}
+	if v, ok := d.GetOk("labels"); ok {
+		payload["Labels"] = v.(map[string]interface{})
+	}
+
+	if v, ok := d.GetOk("port"); ok {
+		exposed := map[string]interface{}{}
+		bindings := map[string][]map[string]string{}
+		for _, p := range v.(*schema.Set).List() {
+			port := p.(map[string]interface{})
+			key := fmt.Sprintf("%d/%s", port["internal"].(int), port["protocol"].(string))
+			exposed[key] = map[string]interface{}{}
+			hostPort := ""
+			if ext := port["external"].(int); ext != 0 {
+				hostPort = strconv.Itoa(ext)
+			}
+			bindings[key] = append(bindings[key], map[string]string{
+				"HostIp":   port["ip"].(string),
+				"HostPort": hostPort,
+			})
+		}
+		payload["ExposedPorts"] = exposed
+		hostConfig["PortBindings"] = bindings
+	}
+
+	if v, ok := d.GetOk("mount"); ok {
+		mounts := []map[string]interface{}{}
+		for _, m := range v.(*schema.Set).List() {
+			mount := m.(map[string]interface{})
+			item := map[string]interface{}{
+				"Type":     mount["type"].(string),
+				"Target":   mount["target"].(string),
+				"ReadOnly": mount["read_only"].(bool),
+			}
+			if src := mount["source"].(string); src != "" {
+				item["Source"] = src
+			}
+			mounts = append(mounts, item)
+		}
+		hostConfig["Mounts"] = mounts
+	}
+
+	if networks := d.Get("network").([]interface{}); len(networks) > 0 {
+		first := networks[0].(map[string]interface{})
+		hostConfig["NetworkMode"] = first["name"].(string)
+		payload["NetworkingConfig"] = map[string]interface{}{
+			"EndpointsConfig": map[string]interface{}{
+				first["name"].(string): dockerEndpointSettings(first),
+			},
+		}
+	}
+
+	if hc := d.Get("healthcheck").([]interface{}); len(hc) > 0 && hc[0] != nil {
+		check := hc[0].(map[string]interface{})
+		healthcheck := map[string]interface{}{
+			"Test":    check["test"].([]interface{}),
+			"Retries": check["retries"].(int),
+		}
+		for attr, field := range map[string]string{"interval": "Interval", "timeout": "Timeout", "start_period": "StartPeriod"} {
+			if dur, err := time.ParseDuration(check[attr].(string)); err == nil {
+				healthcheck[field] = dur.Nanoseconds()
+			}
+		}
+		payload["Healthcheck"] = healthcheck
+	}
+
+	if v, ok := d.GetOk("memory"); ok {
+		hostConfig["Memory"] = int64(v.(int)) * 1024 * 1024
+	}
+	if v, ok := d.GetOk("cpus"); ok {
+		hostConfig["NanoCpus"] = int64(math.Round(v.(float64) * 1e9))
+	}
+	if v, ok := d.GetOk("cpu_shares"); ok {
+		hostConfig["CpuShares"] = v.(int)
+	}
+
+	if caps := d.Get("capabilities").([]interface{}); len(caps) > 0 && caps[0] != nil {
+		c := caps[0].(map[string]interface{})
+		hostConfig["CapAdd"] = c["add"].(*schema.Set).List()
+		hostConfig["CapDrop"] = c["drop"].(*schema.Set).List()
+	}
+
+	if v, ok := d.GetOk("log_driver"); ok {
+		logConfig := map[string]interface{}{"Type": v.(string)}
+		if opts, ok := d.GetOk("log_opts"); ok {
+			logConfig["Config"] = opts.(map[string]interface{})
+		}
+		hostConfig["LogConfig"] = logConfig
+	}
+
+	payload["HostConfig"] = hostConfig
+	return payload
+}
+
+func dockerEndpointSettings(network map[string]interface{}) map[string]interface{} {
+	settings := map[string]interface{}{}
+	if aliases := network["aliases"].([]interface{}); len(aliases) > 0 {
+		settings["Aliases"] = aliases
+	}
+	if ip := network["ipv4_address"].(string); ip != "" {
+		settings["IPAMConfig"] = map[string]string{"IPv4Address": ip}
+	}
+	return settings
+}
+
+// dockerContainerAction sends a start/stop style POST to the container.
+func dockerContainerAction(client *APIClient, endpointID int, id, action string, headers map[string]string, body interface{}) error {
+	path := fmt.Sprintf("/endpoints/%d/docker/containers/%s/%s", endpointID, id, action)
+	resp, err := client.DoRequest(http.MethodPost, path, headers, body)
+	if err != nil {
+		return fmt.Errorf("failed to %s docker container: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	// 304 means the container already is in the requested state.
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotModified {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to %s docker container: %s", action, string(data))
+	}
+	return nil
+}
+
+func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+	headers := dockerContainerHeaders(d)
+
+	if d.Get("pull_image").(bool) {
+		if err := pullDockerContainerImage(d, client, headers); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	path := fmt.Sprintf("/endpoints/%d/docker/containers/create?%s", endpointID, url.Values{"name": {d.Get("name").(string)}}.Encode())
+	resp, err := client.DoRequest(http.MethodPost, path, headers, buildDockerContainerPayload(d))
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to create docker container: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return diag.FromErr(fmt.Errorf("failed to create docker container: %s", string(body)))
+	}
+
+	var response struct {
+		ID        string `json:"Id"`
+		Portainer struct {
+			ResourceControl struct {
+				Id int `json:"Id"`
+			} `json:"ResourceControl"`
+		} `json:"Portainer"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to decode docker container create response: %w", err))
+	}
+	d.SetId(response.ID)
+	if response.Portainer.ResourceControl.Id != 0 {
+		_ = d.Set("resource_control_id", response.Portainer.ResourceControl.Id)
+	}
+
+	networks := d.Get("network").([]interface{})
+	for i := 1; i < len(networks); i++ {
+		network := networks[i].(map[string]interface{})
+		connectPath := fmt.Sprintf("/endpoints/%d/docker/networks/%s/connect", endpointID, url.PathEscape(network["name"].(string)))
+		connectResp, err := client.DoRequest(http.MethodPost, connectPath, headers, map[string]interface{}{
+			"Container":      response.ID,
+			"EndpointConfig": dockerEndpointSettings(network),
+		})
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("failed to connect container to network %s: %w", network["name"], err))
+		}
+		connectResp.Body.Close()
+		if connectResp.StatusCode != http.StatusOK {
+			return diag.FromErr(fmt.Errorf("failed to connect container to network %s: status %d", network["name"], connectResp.StatusCode))
+		}
+	}
+
+	if d.Get("must_run").(bool) {
+		if err := dockerContainerAction(client, endpointID, response.ID, "start", headers, nil); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return resourceDockerContainerRead(ctx, d, meta)
+}
+
+// dockerAuthoredStrings keeps only the keys present in the configuration, so
+// values inherited from the image (PATH, image labels, ...) do not show as drift.
+func dockerAuthoredStrings(authored map[string]interface{}, live map[string]string) map[string]interface{} {
+	out := make(map[string]interface{}, len(authored))
+	for k := range authored {
+		if v, ok := live[k]; ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func resourceDockerContainerRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+
+	path := fmt.Sprintf("/endpoints/%d/docker/containers/%s/json", endpointID, d.Id())
+	resp, err := client.DoRequest(http.MethodGet, path, dockerContainerHeaders(d), nil)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to read docker container: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		d.SetId("")
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return diag.FromErr(fmt.Errorf("failed to read docker container: %s", string(body)))
+	}
+
+	var result dockerContainerInspect
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to decode docker container response: %w", err))
+	}
+
+	d.SetId(result.ID)
+	_ = d.Set("name", strings.TrimPrefix(result.Name, "/"))
+	_ = d.Set("image", result.Config.Image)
+	_ = d.Set("image_id", result.Image)
+	_ = d.Set("privileged", result.HostConfig.Privileged)
+
+	// Fields defaulted by the image are only refreshed when configured.
+	if len(d.Get("command").([]interface{})) > 0 {
+		_ = d.Set("command", result.Config.Cmd)
+	}
+	if len(d.Get("entrypoint").([]interface{})) > 0 {
+		_ = d.Set("entrypoint", result.Config.Entrypoint)
+	}
+	if d.Get("working_dir").(string) != "" {
+		_ = d.Set("working_dir", result.Config.WorkingDir)
+	}
+	if d.Get("user").(string) != "" {
+		_ = d.Set("user", result.Config.User)
+	}
+
+	liveEnv := map[string]string{}
+	for _, kv := range result.Config.Env {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			liveEnv[k] = v
+		}
+	}
+	_ = d.Set("env", dockerAuthoredStrings(d.Get("env").(map[string]interface{}), liveEnv))
+	_ = d.Set("labels", dockerAuthoredStrings(d.Get("labels").(map[string]interface{}), result.Config.Labels))
+
+	ports := []interface{}{}
+	for key, bindings := range result.HostConfig.PortBindings {
+		portStr, protocol, _ := strings.Cut(key, "/")
+		internal, _ := strconv.Atoi(portStr)
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		for _, b := range bindings {
+			external, _ := strconv.Atoi(b.HostPort)
+			ports = append(ports, map[string]interface{}{
+				"internal": internal,
+				"external": external,
+				"ip":       b.HostIp,
+				"protocol": protocol,
+			})
+		}
+	}
+	_ = d.Set("port", ports)
+
+	mounts := []interface{}{}
+	for _, m := range result.HostConfig.Mounts {
+		mounts = append(mounts, map[string]interface{}{
+			"type":      m.Type,
+			"source":    m.Source,
+			"target":    m.Target,
+			"read_only": m.ReadOnly,
+		})
+	}
+	_ = d.Set("mount", mounts)
+
+	// Networks keep their configured order and aliases; networks the
+	// container left are dropped and unexpected ones appended, so both show
+	// as drift. Without configured networks the daemon default is ignored.
+	if configured := d.Get("network").([]interface{}); len(configured) > 0 {
+		networks := []interface{}{}
+		known := map[string]bool{}
+		for _, n := range configured {
+			network := n.(map[string]interface{})
+			name := network["name"].(string)
+			known[name] = true
+			live, ok := result.NetworkSettings.Networks[name]
+			if !ok {
+				continue
+			}
+			aliases := []interface{}{}
+			for _, a := range network["aliases"].([]interface{}) {
+				for _, la := range live.Aliases {
+					if la == a.(string) {
+						aliases = append(aliases, a)
+						break
+					}
+				}
+			}
+			networks = append(networks, map[string]interface{}{
+				"name":         name,
+				"aliases":      aliases,
+				"ipv4_address": network["ipv4_address"],
+			})
+		}
+		extra := []string{}
+		for name := range result.NetworkSettings.Networks {
+			if !known[name] {
+				extra = append(extra, name)
+			}
+		}
+		sort.Strings(extra)
+		for _, name := range extra {
+			networks = append(networks, map[string]interface{}{"name": name})
+		}
+		_ = d.Set("network", networks)
+	}
+
+	restartPolicy := result.HostConfig.RestartPolicy.Name
+	if restartPolicy == "" {
+		restartPolicy = "no"
+	}
+	_ = d.Set("restart_policy", restartPolicy)
+	_ = d.Set("max_retry_count", result.HostConfig.RestartPolicy.MaximumRetryCount)
+
+	if hc := result.Config.Healthcheck; hc != nil && len(d.Get("healthcheck").([]interface{})) > 0 {
+		check := map[string]interface{}{
+			"test":    hc.Test,
+			"retries": hc.Retries,
+		}
+		for attr, ns := range map[string]int64{"interval": hc.Interval, "timeout": hc.Timeout, "start_period": hc.StartPeriod} {
+			if ns != 0 {
+				check[attr] = time.Duration(ns).String()
+			}
+		}
+		_ = d.Set("healthcheck", []interface{}{check})
+	}
+
+	_ = d.Set("memory", int(result.HostConfig.Memory/(1024*1024)))
+	_ = d.Set("cpus", float64(result.HostConfig.NanoCpus)/1e9)
+	_ = d.Set("cpu_shares", result.HostConfig.CpuShares)
+
+	if len(d.Get("capabilities").([]interface{})) > 0 || len(result.HostConfig.CapAdd) > 0 || len(result.HostConfig.CapDrop) > 0 {
+		_ = d.Set("capabilities", []interface{}{map[string]interface{}{
+			"add":  result.HostConfig.CapAdd,
+			"drop": result.HostConfig.CapDrop,
+		}})
+	}
+
+	_ = d.Set("log_driver", result.HostConfig.LogConfig.Type)
+	if _, ok := d.GetOk("log_opts"); ok {
+		opts := make(map[string]interface{}, len(result.HostConfig.LogConfig.Config))
+		for k, v := range result.HostConfig.LogConfig.Config {
+			opts[k] = v
+		}
+		_ = d.Set("log_opts", opts)
+	}
+
+	if d.Get("must_run").(bool) && !result.State.Running {
+		_ = d.Set("must_run", false)
+	}
+
+	if result.Portainer.ResourceControl.Id != 0 {
+		_ = d.Set("resource_control_id", result.Portainer.ResourceControl.Id)
+	}
+
+	return nil
+}
+
+func resourceDockerContainerUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+	headers := dockerContainerHeaders(d)
+
+	if d.HasChanges("restart_policy", "max_retry_count", "memory", "cpus", "cpu_shares") {
+		update := map[string]interface{}{
+			"RestartPolicy": map[string]interface{}{
+				"Name":              d.Get("restart_policy").(string),
+				"MaximumRetryCount": d.Get("max_retry_count").(int),
+			},
+			"NanoCpus":  int64(math.Round(d.Get("cpus").(float64) * 1e9)),
+			"CpuShares": d.Get("cpu_shares").(int),
+		}
+		if memory := int64(d.Get("memory").(int)) * 1024 * 1024; memory > 0 {
+			// Keep the daemon's default of swap = 2x memory, otherwise raising
+			// the limit above the current swap limit is rejected.
+			update["Memory"] = memory
+			update["MemorySwap"] = 2 * memory
+		}
+		if err := dockerContainerAction(client, endpointID, d.Id(), "update", headers, update); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if d.HasChange("must_run") {
+		action := "stop"
+		if d.Get("must_run").(bool) {
+			action = "start"
+		}
+		if err := dockerContainerAction(client, endpointID, d.Id(), action, headers, nil); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return resourceDockerContainerRead(ctx, d, meta)
+}
+
+func resourceDockerContainerDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+
+	path := fmt.Sprintf("/endpoints/%d/docker/containers/%s?force=true&v=%t", endpointID, d.Id(), d.Get("remove_volumes").(bool))
+	resp, err := client.DoRequest(http.MethodDelete, path, dockerContainerHeaders(d), nil)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to delete docker container: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		body, _ := io.ReadAll(resp.Body)
+		return diag.FromErr(fmt.Errorf("failed to delete docker container: %s", string(body)))
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,372 @@
+package internal
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func dockerContainerInspectResponse(running bool) map[string]interface{} {
+	return map[string]interface{}{
+		"Id":    "c0ffee",
+		"Name":  "/web",
+		"Image": "sha256:1111",
+		"State": map[string]interface{}{"Running": running},
+		"Config": map[string]interface{}{
+			"Image":  "nginx:1.27",
+			"Cmd":    []string{"nginx", "-g", "daemon off;"},
+			"Env":    []string{"PATH=/usr/bin", "APP_ENV=prod"},
+			"Labels": map[string]string{"team": "web", "maintainer": "NGINX"},
+		},
+		"HostConfig": map[string]interface{}{
+			"RestartPolicy": map[string]interface{}{"Name": "unless-stopped"},
+			"Memory":        256 * 1024 * 1024,
+			"NanoCpus":      500000000,
+			"LogConfig":     map[string]interface{}{"Type": "json-file"},
+			"PortBindings": map[string]interface{}{
+				"80/tcp": []map[string]string{{"HostIp": "", "HostPort": "8080"}},
+			},
+		},
+		"NetworkSettings": map[string]interface{}{
+			"Networks": map[string]interface{}{
+				"frontend": map[string]interface{}{"Aliases": []string{"web", "c0ffee"}},
+				"debug":    map[string]interface{}{},
+			},
+		},
+		"Portainer": map[string]interface{}{
+			"ResourceControl": map[string]interface{}{"Id": 12},
+		},
+	}
+}
+
+// TestDockerContainerCreate pulls the image with the Portainer registry
+// credentials, creates the container with Docker's payload layout, connects
+// additional networks and starts it.
+func TestDockerContainerCreate(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("POST", "/endpoints/1/docker/images/create", RespondString(http.StatusOK, "application/json", `{"status":"Pulling"}`+"\n"))
+	mock.On("POST", "/endpoints/1/docker/containers/create", RespondJSON(http.StatusCreated, map[string]interface{}{
+		"Id":        "c0ffee",
+		"Portainer": map[string]interface{}{"ResourceControl": map[string]interface{}{"Id": 12}},
+	}))
+	mock.On("POST", "/endpoints/1/docker/networks/backend/connect", RespondJSON(http.StatusOK, nil))
+	mock.On("POST", "/endpoints/1/docker/containers/c0ffee/start", RespondJSON(http.StatusNoContent, nil))
+	mock.On("GET", "/endpoints/1/docker/containers/c0ffee/json", RespondJSON(http.StatusOK, dockerContainerInspectResponse(true)))
+
+	r := resourceDockerContainer()
+	d := r.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("name", "web")
+	_ = d.Set("image", "nginx:1.27")
+	_ = d.Set("pull_image", true)
+	_ = d.Set("must_run", true)
+	_ = d.Set("registry_id", 3)
+	_ = d.Set("env", map[string]interface{}{"APP_ENV": "prod"})
+	_ = d.Set("port", []interface{}{map[string]interface{}{"internal": 80, "external": 8080, "protocol": "tcp"}})
+	_ = d.Set("mount", []interface{}{map[string]interface{}{"type": "volume", "source": "data", "target": "/data"}})
+	_ = d.Set("network", []interface{}{
+		map[string]interface{}{"name": "frontend", "aliases": []interface{}{"web"}},
+		map[string]interface{}{"name": "backend", "ipv4_address": "10.0.0.5"},
+	})
+	_ = d.Set("healthcheck", []interface{}{map[string]interface{}{"test": []interface{}{"CMD", "true"}, "interval": "30s", "retries": 3}})
+	_ = d.Set("capabilities", []interface{}{map[string]interface{}{"add": []interface{}{"NET_ADMIN"}}})
+	_ = d.Set("memory", 256)
+	_ = d.Set("log_driver", "json-file")
+	_ = d.Set("log_opts", map[string]interface{}{"max-size": "10m"})
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if d.Id() != "c0ffee" || d.Get("resource_control_id").(int) != 12 || d.Get("image_id").(string) != "sha256:1111" {
+		t.Errorf("unexpected state: id=%s rc=%v image_id=%v", d.Id(), d.Get("resource_control_id"), d.Get("image_id"))
+	}
+
+	pull := mock.FindRequest("POST", "/endpoints/1/docker/images/create")
+	if pull == nil || pull.Query != "fromImage=nginx%3A1.27" {
+		t.Fatalf("expected image pull, got %+v", pull)
+	}
+	if auth, _ := base64.StdEncoding.DecodeString(pull.Headers.Get("X-Registry-Auth")); string(auth) != `{"registryId":3}` {
+		t.Errorf("unexpected registry auth: %s", auth)
+	}
+
+	create := mock.FindRequest("POST", "/endpoints/1/docker/containers/create")
+	if create.Query != "name=web" {
+		t.Errorf("unexpected create query: %s", create.Query)
+	}
+	var payload struct {
+		Env          []string
+		ExposedPorts map[string]interface{}
+		Healthcheck  struct {
+			Interval int64
+			Retries  int
+		}
+		HostConfig struct {
+			NetworkMode  string
+			Memory       int64
+			CapAdd       []string
+			PortBindings map[string][]map[string]string
+			Mounts       []map[string]interface{}
+			LogConfig    struct {
+				Type   string
+				Config map[string]string
+			}
+		}
+		NetworkingConfig struct {
+			EndpointsConfig map[string]struct{ Aliases []string }
+		}
+	}
+	if err := create.DecodeJSON(&payload); err != nil {
+		t.Fatalf("decode create payload: %v", err)
+	}
+	if len(payload.Env) != 1 || payload.Env[0] != "APP_ENV=prod" {
+		t.Errorf("unexpected Env: %v", payload.Env)
+	}
+	if _, ok := payload.ExposedPorts["80/tcp"]; !ok || payload.HostConfig.PortBindings["80/tcp"][0]["HostPort"] != "8080" {
+		t.Errorf("unexpected ports: %v %v", payload.ExposedPorts, payload.HostConfig.PortBindings)
+	}
+	if payload.Healthcheck.Interval != 30e9 || payload.Healthcheck.Retries != 3 {
+		t.Errorf("unexpected healthcheck: %+v", payload.Healthcheck)
+	}
+	if payload.HostConfig.NetworkMode != "frontend" || payload.NetworkingConfig.EndpointsConfig["frontend"].Aliases[0] != "web" {
+		t.Errorf("unexpected network config: %+v %+v", payload.HostConfig.NetworkMode, payload.NetworkingConfig)
+	}
+	if payload.HostConfig.Memory != 256*1024*1024 || payload.HostConfig.CapAdd[0] != "NET_ADMIN" || payload.HostConfig.LogConfig.Config["max-size"] != "10m" {
+		t.Errorf("unexpected host config: %+v", payload.HostConfig)
+	}
+	if len(payload.HostConfig.Mounts) != 1 || payload.HostConfig.Mounts[0]["Source"] != "data" {
+		t.Errorf("unexpected mounts: %v", payload.HostConfig.Mounts)
+	}
+
+	connect := mock.FindRequest("POST", "/endpoints/1/docker/networks/backend/connect")
+	if connect == nil {
+		t.Fatal("expected backend network connect")
+	}
+	var conn struct {
+		Container      string
+		EndpointConfig struct {
+			IPAMConfig struct{ IPv4Address string }
+		}
+	}
+	_ = connect.DecodeJSON(&conn)
+	if conn.Container != "c0ffee" || conn.EndpointConfig.IPAMConfig.IPv4Address != "10.0.0.5" {
+		t.Errorf("unexpected connect payload: %+v", conn)
+	}
+	if mock.FindRequest("POST", "/endpoints/1/docker/containers/c0ffee/start") == nil {
+		t.Error("expected container start")
+	}
+}
+
+// TestDockerContainerRead_Drift ignores values inherited from the image and
+// reports a stopped container and unexpected networks as drift.
+func TestDockerContainerRead_Drift(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/containers/c0ffee/json", RespondJSON(http.StatusOK, dockerContainerInspectResponse(false)))
+
+	r := resourceDockerContainer()
+	d := r.TestResourceData()
+	d.SetId("c0ffee")
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("env", map[string]interface{}{"APP_ENV": "prod"})
+	_ = d.Set("labels", map[string]interface{}{"team": "web"})
+	_ = d.Set("network", []interface{}{map[string]interface{}{"name": "frontend", "aliases": []interface{}{"web"}}})
+
+	if err := rcRead(r, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if env := d.Get("env").(map[string]interface{}); len(env) != 1 || env["APP_ENV"] != "prod" {
+		t.Errorf("image env must not be refreshed: %v", env)
+	}
+	if labels := d.Get("labels").(map[string]interface{}); len(labels) != 1 {
+		t.Errorf("image labels must not be refreshed: %v", labels)
+	}
+	if d.Get("command.#").(int) != 0 {
+		t.Error("image default command must not be refreshed")
+	}
+	if d.Get("must_run").(bool) {
+		t.Error("expected must_run=false for a stopped container")
+	}
+	networks := d.Get("network").([]interface{})
+	if len(networks) != 2 || networks[1].(map[string]interface{})["name"] != "debug" {
+		t.Errorf("expected unexpected network to be appended, got %v", networks)
+	}
+	if aliases := networks[0].(map[string]interface{})["aliases"].([]interface{}); len(aliases) != 1 {
+		t.Errorf("expected only configured aliases, got %v", aliases)
+	}
+	if d.Get("restart_policy") != "unless-stopped" || d.Get("memory").(int) != 256 || d.Get("cpus").(float64) != 0.5 {
+		t.Errorf("unexpected resources: %v %v %v", d.Get("restart_policy"), d.Get("memory"), d.Get("cpus"))
+	}
+	ports := d.Get("port").(interface{ Len() int })
+	if ports.Len() != 1 {
+		t.Errorf("expected one port, got %d", ports.Len())
+	}
+}
+
+// TestDockerContainerUpdate changes the restart policy and resources in place
+// and restarts a container that was stopped.
+func TestDockerContainerUpdate(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("POST", "/endpoints/1/docker/containers/c0ffee/update", RespondJSON(http.StatusOK, map[string]interface{}{"Warnings": []string{}}))
+	mock.On("POST", "/endpoints/1/docker/containers/c0ffee/start", RespondJSON(http.StatusNoContent, nil))
+	mock.On("GET", "/endpoints/1/docker/containers/c0ffee/json", RespondJSON(http.StatusOK, dockerContainerInspectResponse(true)))
+
+	r := resourceDockerContainer()
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"endpoint_id":    1,
+		"name":           "web",
+		"image":          "nginx:1.27",
+		"restart_policy": "unless-stopped",
+		"memory":         256,
+	})
+	d.SetId("c0ffee")
+
+	if err := rcUpdate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	update := mock.FindRequest("POST", "/endpoints/1/docker/containers/c0ffee/update")
+	if update == nil {
+		t.Fatal("expected container update")
+	}
+	var payload struct {
+		Memory        int64
+		MemorySwap    int64
+		RestartPolicy struct{ Name string }
+	}
+	_ = update.DecodeJSON(&payload)
+	if payload.RestartPolicy.Name != "unless-stopped" || payload.Memory != 256*1024*1024 || payload.MemorySwap != 2*payload.Memory {
+		t.Errorf("unexpected update payload: %+v", payload)
+	}
+	if mock.FindRequest("POST", "/endpoints/1/docker/containers/c0ffee/start") == nil {
+		t.Error("expected container start")
+	}
+}
+
+// TestDockerContainerDelete force-removes the container and its anonymous
+// volumes; a missing container is not an error.
+func TestDockerContainerDelete(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("DELETE", "/endpoints/1/docker/containers/c0ffee", RespondJSON(http.StatusNotFound, nil))
+
+	r := resourceDockerContainer()
+	d := r.TestResourceData()
+	d.SetId("c0ffee")
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("remove_volumes", true)
+
+	if err := rcDelete(r, d, mock.Client()); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	del := mock.FindRequest("DELETE", "/endpoints/1/docker/containers/c0ffee")
+	if del == nil || del.Query != "force=true&v=true" {
+		t.Errorf("unexpected delete request: %+v", del)
+	}
+	if d.Id() != "" {
+		t.Error("expected ID to be cleared")
+	}
+}
+
+// TestDockerImageID resolves a tag to its local image ID and treats a missing
+// image as unknown rather than an error.
+func TestDockerImageID(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/images/nginx:1.27/json", RespondJSON(http.StatusOK, map[string]string{"Id": "sha256:2222"}))
+
+	id, err := dockerImageID(mock.Client(), 1, "nginx:1.27", nil)
+	if err != nil || id != "sha256:2222" {
+		t.Errorf("expected sha256:2222, got %q (%v)", id, err)
+	}
+	id, err = dockerImageID(mock.Client(), 1, "missing:latest", nil)
+	if err != nil || id != "" {
+		t.Errorf("expected empty ID for missing image, got %q (%v)", id, err)
+	}
+}
+
+// TestDockerContainerDiff_ImageDigestChange replaces the container when the
+// image reference resolves to another image ID on the host.
+func TestDockerContainerDiff_ImageDigestChange(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/images/nginx:1.27/json", RespondJSON(http.StatusOK, map[string]string{"Id": "sha256:2222"}))
+
+	r := resourceDockerContainer()
+	state := &terraform.InstanceState{ID: "c0ffee", Attributes: map[string]string{
+		"id":             "c0ffee",
+		"endpoint_id":    "1",
+		"name":           "web",
+		"image":          "nginx:1.27",
+		"image_id":       "sha256:1111",
+		"pull_image":     "true",
+		"must_run":       "true",
+		"remove_volumes": "true",
+		"restart_policy": "no",
+	}}
+	cfg := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"endpoint_id": 1,
+		"name":        "web",
+		"image":       "nginx:1.27",
+	})
+
+	diff, err := r.Diff(context.Background(), state, cfg, mock.Client())
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	attr := diff.Attributes["image_id"]
+	if attr == nil || !attr.RequiresNew {
+		t.Fatalf("expected image_id to force replacement, got %+v", attr)
+	}
+
+	state.Attributes["image_id"] = "sha256:2222"
+	diff, err = r.Diff(context.Background(), state, cfg, mock.Client())
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if diff != nil && !diff.Empty() {
+		t.Errorf("expected no diff for an unchanged image, got %+v", diff.Attributes)
+	}
+}
+
+// TestDockerContainerDiff_LimitRemoved replaces the container when memory or
+// cpus is removed, which the Docker update API cannot do, and updates it in
+// place when a limit changes.
+func TestDockerContainerDiff_LimitRemoved(t *testing.T) {
+	r := resourceDockerContainer()
+	state := &terraform.InstanceState{ID: "c0ffee", Attributes: map[string]string{
+		"id":             "c0ffee",
+		"endpoint_id":    "1",
+		"name":           "web",
+		"image":          "nginx:1.27",
+		"memory":         "512",
+		"cpus":           "1.5",
+		"pull_image":     "true",
+		"must_run":       "true",
+		"remove_volumes": "true",
+		"restart_policy": "no",
+	}}
+	for _, tc := range []struct {
+		config      map[string]interface{}
+		attr        string
+		requiresNew bool
+	}{
+		{map[string]interface{}{"cpus": 1.5}, "memory", true},
+		{map[string]interface{}{"memory": 512}, "cpus", true},
+		{map[string]interface{}{"memory": 1024, "cpus": 1.5}, "memory", false},
+		{map[string]interface{}{"memory": 512, "cpus": 2}, "cpus", false},
+	} {
+		raw := map[string]interface{}{"endpoint_id": 1, "name": "web", "image": "nginx:1.27"}
+		for k, v := range tc.config {
+			raw[k] = v
+		}
+		diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), nil)
+		if err != nil {
+			t.Fatalf("Diff failed: %v", err)
+		}
+		attr := diff.Attributes[tc.attr]
+		if attr == nil || attr.RequiresNew != tc.requiresNew {
+			t.Errorf("%v: expected %s RequiresNew=%v, got %+v", tc.config, tc.attr, tc.requiresNew, attr)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
@@ -27,15 +28,45 @@ func resourceDockerImage() *schema.Resource {
 		CreateContext: resourceDockerImageCreate,
 		ReadContext:   resourceDockerImageRead,
 		DeleteContext: resourceDockerImageDelete,
-		UpdateContext: nil,
+		UpdateContext: resourceDockerImageUpdate,
+		CustomizeDiff: customizeDiffDockerImageDigest,
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(10 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
 			Delete: schema.DefaultTimeout(5 * time.Minute),
 		},
 		Schema: map[string]*schema.Schema{
 			"endpoint_id":   {Type: schema.TypeInt, Required: true, ForceNew: true, Description: "Identifier of the Portainer endpoint on which the Docker image should be pulled."},
 			"image":         {Type: schema.TypeString, Required: true, ForceNew: true, Description: "Image reference to pull (for example `nginx:latest`)."},
-			"registry_auth": {Type: schema.TypeString, Optional: true, Sensitive: true, ForceNew: true, Description: "Sensitive base64-encoded JSON object with registry credentials (username, password, email, serveraddress) used to pull the image."},
+			"registry_auth": {Type: schema.TypeString, Optional: true, Sensitive: true, ForceNew: true, ConflictsWith: []string{"registry_id"}, Description: "Sensitive base64-encoded JSON object with registry credentials (username, password, email, serveraddress) used to pull the image."},
+			"registry_id": {
+				Type:          schema.TypeInt,
+				Optional:      true,
+				ConflictsWith: []string{"registry_auth"},
+				Description:   "ID of a `portainer_registry` whose credentials Portainer injects into the pull.",
+			},
+			"keep_locally": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether the image is left on the host when the resource is destroyed.",
+			},
+			"pull_triggers": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Arbitrary values that trigger a new pull of the image when they change.",
+			},
+			"image_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Local ID of the pulled image.",
+			},
+			"repo_digest": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Repository digest (`repo@sha256:...`) of the pulled image. A new pull is planned when the registry reports another digest for the tag.",
+			},
 		},
 	}
 }
@@ -44,23 +75,93 @@ func resourceDockerImageCreate(ctx context.Context, d *schema.ResourceData, meta
 	client := meta.(*APIClient)
 	image := d.Get("image").(string)
 	endpointID := d.Get("endpoint_id").(int)
-	auth := d.Get("registry_auth").(string)
 
 	timeout := d.Timeout(schema.TimeoutCreate)
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	if err := pullDockerImage(ctx, d, client); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(fmt.Sprintf("%d-%s", endpointID, image))
+
+	if err := refreshDockerImage(d, client); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func resourceDockerImageUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+
+	// Only a new pull changes the remote state; keep_locally and
+	// registry_id are used on the next pull or destroy.
+	if d.HasChanges("pull_triggers", "repo_digest") {
+		timeout := d.Timeout(schema.TimeoutUpdate)
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		if err := pullDockerImage(ctx, d, client); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if err := refreshDockerImage(d, client); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// dockerImageRegistryAuth builds the X-Registry-Auth header. With registry_id
+// Portainer replaces the header with the stored registry credentials.
+// It accepts a ResourceData or a ResourceDiff.
+func dockerImageRegistryAuth(d interface{ Get(string) interface{} }) (string, error) {
+	image := d.Get("image").(string)
+	if id := d.Get("registry_id").(int); id != 0 {
+		jsonData, _ := json.Marshal(map[string]int{"registryId": id})
+		return base64.StdEncoding.EncodeToString(jsonData), nil
+	}
+
+	auth := d.Get("registry_auth").(string)
+	if auth == "" {
+		return base64.StdEncoding.EncodeToString([]byte(`{}`)), nil
+	}
+	split := strings.SplitN(auth, ":", 2)
+	if len(split) != 2 {
+		return "", fmt.Errorf("invalid registry_auth format (expected username:password)")
+	}
+	payload := dockerImageAuth{
+		Username:      split[0],
+		Password:      split[1],
+		Email:         "",
+		ServerAddress: strings.Split(image, "/")[0],
+	}
+	jsonData, _ := json.Marshal(payload)
+	return base64.StdEncoding.EncodeToString(jsonData), nil
+}
+
+func pullDockerImage(ctx context.Context, d *schema.ResourceData, client *APIClient) error {
+	image := d.Get("image").(string)
+	endpointID := d.Get("endpoint_id").(int)
+
+	auth, err := dockerImageRegistryAuth(d)
+	if err != nil {
+		return err
+	}
+
 	params := url.Values{}
 	params.Add("fromImage", image)
 	urlPath := fmt.Sprintf("%s/endpoints/%d/docker/images/create?%s", client.Endpoint, endpointID, params.Encode())
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, urlPath, nil)
 	if err != nil {
-		return diag.FromErr(fmt.Errorf("failed to build request: %w", err))
+		return fmt.Errorf("failed to build request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Content-Length", "0")
+	req.Header.Set("X-Registry-Auth", auth)
 
 	if client.APIKey != "" {
 		req.Header.Set("X-API-Key", client.APIKey)
@@ -68,47 +169,207 @@ func resourceDockerImageCreate(ctx context.Context, d *schema.ResourceData, meta
 		req.Header.Set("Authorization", "Bearer "+client.JWTToken)
 	}
 
-	if auth != "" {
-		split := strings.SplitN(auth, ":", 2)
-		if len(split) != 2 {
-			return diag.FromErr(fmt.Errorf("invalid registry_auth format (expected username:password)"))
+	resp, err := client.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("failed to pull image, status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+	if err := dockerPullStreamError(body); err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", image, err)
+	}
+	return nil
+}
+
+// dockerPullStreamError returns the error reported in a pull progress stream;
+// Docker answers 200 and reports failures such as a missing tag in-band.
+func dockerPullStreamError(body []byte) error {
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		var msg struct {
+			Error string `json:"error"`
 		}
-		payload := dockerImageAuth{
-			Username:      split[0],
-			Password:      split[1],
-			Email:         "",
-			ServerAddress: strings.Split(image, "/")[0],
+		if json.Unmarshal(line, &msg) == nil && msg.Error != "" {
+			return fmt.Errorf("%s", msg.Error)
 		}
-		jsonData, _ := json.Marshal(payload)
-		encoded := base64.StdEncoding.EncodeToString(jsonData)
-		req.Header.Set("X-Registry-Auth", encoded)
-	} else {
-		req.Header.Set("X-Registry-Auth", base64.StdEncoding.EncodeToString([]byte(`{}`)))
 	}
+	return nil
+}
 
-	resp, err := client.HTTPClient.Do(req)
+type dockerImageInspect struct {
+	ID          string   `json:"Id"`
+	RepoTags    []string `json:"RepoTags"`
+	RepoDigests []string `json:"RepoDigests"`
+}
+
+// inspectDockerImage returns the local image for a reference, or nil when the
+// image is not present on the host.
+func inspectDockerImage(client *APIClient, endpointID int, image string, headers map[string]string) (*dockerImageInspect, error) {
+	path := fmt.Sprintf("/endpoints/%d/docker/images/%s/json", endpointID, url.PathEscape(image))
+	resp, err := client.DoRequest(http.MethodGet, path, headers, nil)
 	if err != nil {
-		return diag.FromErr(fmt.Errorf("request failed: %w", err))
+		return nil, fmt.Errorf("failed to inspect docker image: %w", err)
 	}
 	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode >= 400 {
-		return diag.FromErr(fmt.Errorf("failed to pull image, status code: %d, body: %s", resp.StatusCode, string(body)))
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to inspect docker image: %s", string(body))
 	}
-	fmt.Printf("[DEBUG] Docker image pull result: %s\n", string(body))
 
-	d.SetId(fmt.Sprintf("%d-%s", endpointID, image))
+	var result dockerImageInspect
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode docker image response: %w", err)
+	}
+	return &result, nil
+}
+
+// dockerImageID returns the local ID of an image reference, or an empty
+// string when the image is not present on the host.
+func dockerImageID(client *APIClient, endpointID int, image string, headers map[string]string) (string, error) {
+	info, err := inspectDockerImage(client, endpointID, image, headers)
+	if err != nil || info == nil {
+		return "", err
+	}
+	return info.ID, nil
+}
+
+// dockerImageRepository strips the tag and digest from an image reference.
+func dockerImageRepository(image string) string {
+	image = strings.SplitN(image, "@", 2)[0]
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// dockerRepoDigest picks the repo digest matching the image repository.
+func dockerRepoDigest(image string, repoDigests []string) string {
+	repo := dockerImageRepository(image)
+	for _, rd := range repoDigests {
+		if strings.HasPrefix(rd, repo+"@") {
+			return rd
+		}
+	}
+	if len(repoDigests) > 0 {
+		return repoDigests[0]
+	}
+	return ""
+}
+
+// refreshDockerImage stores the ID and digest of the local image.
+func refreshDockerImage(d *schema.ResourceData, client *APIClient) error {
+	image := d.Get("image").(string)
+	info, err := inspectDockerImage(client, d.Get("endpoint_id").(int), image, nil)
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		_ = d.Set("image_id", "")
+		_ = d.Set("repo_digest", "")
+		return nil
+	}
+	_ = d.Set("image_id", info.ID)
+	_ = d.Set("repo_digest", dockerRepoDigest(image, info.RepoDigests))
 	return nil
 }
 
+// customizeDiffDockerImageDigest plans a new pull when pull_triggers changed
+// or the registry reports another digest for the tag than the local image.
+// Registry errors are ignored so plans keep working offline.
+func customizeDiffDockerImageDigest(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || d.HasChange("image") {
+		return nil
+	}
+	if d.HasChange("pull_triggers") {
+		_ = d.SetNewComputed("image_id")
+		return d.SetNewComputed("repo_digest")
+	}
+
+	image := d.Get("image").(string)
+	client, ok := meta.(*APIClient)
+	if !ok || client == nil || strings.Contains(image, "@") {
+		return nil
+	}
+
+	auth, err := dockerImageRegistryAuth(d)
+	if err != nil {
+		return err
+	}
+	remote, err := dockerRegistryDigest(client, d.Get("endpoint_id").(int), image, map[string]string{"X-Registry-Auth": auth})
+	if err != nil || remote == "" {
+		return nil
+	}
+
+	local := d.Get("repo_digest").(string)
+	if strings.HasSuffix(local, "@"+remote) {
+		return nil
+	}
+	// The digest is stored as Docker reports it after the pull, which may
+	// name the repository differently than the image reference does.
+	if err := d.SetNewComputed("repo_digest"); err != nil {
+		return err
+	}
+	return d.SetNewComputed("image_id")
+}
+
+// dockerRegistryDigest asks the Docker host for the digest the registry
+// currently serves for an image reference.
+func dockerRegistryDigest(client *APIClient, endpointID int, image string, headers map[string]string) (string, error) {
+	path := fmt.Sprintf("/endpoints/%d/docker/distribution/%s/json", endpointID, url.PathEscape(image))
+	resp, err := client.DoRequest(http.MethodGet, path, headers, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to query registry digest: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to query registry digest: %s", string(body))
+	}
+
+	var result struct {
+		Descriptor struct {
+			Digest string `json:"digest"`
+		} `json:"Descriptor"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode registry digest response: %w", err)
+	}
+	return result.Descriptor.Digest, nil
+}
+
 func resourceDockerImageRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+
+	info, err := inspectDockerImage(client, d.Get("endpoint_id").(int), d.Get("image").(string), nil)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if info == nil {
+		// Removed outside of Terraform: plan a new pull.
+		d.SetId("")
+		return nil
+	}
+	_ = d.Set("image_id", info.ID)
+	_ = d.Set("repo_digest", dockerRepoDigest(d.Get("image").(string), info.RepoDigests))
 	return nil
 }
 
 func resourceDockerImageDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client := meta.(*APIClient)
 
+	if d.Get("keep_locally").(bool) {
+		d.SetId("")
+		return nil
+	}
+
 	endpointID := d.Get("endpoint_id").(int)
 	image := d.Get("image").(string)
 
@@ -141,7 +402,6 @@ func resourceDockerImageDelete(ctx context.Context, d *schema.ResourceData, meta
 	if resp.StatusCode >= 400 {
 		return diag.FromErr(fmt.Errorf("failed to delete image, status code: %d, body: %s", resp.StatusCode, string(body)))
 	}
-	fmt.Printf("[DEBUG] Docker image delete result: %s\n", string(body))
 
 	d.SetId("")
 	return nil
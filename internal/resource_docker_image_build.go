@@ -0,0 +1,567 @@
+package internal
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceDockerImageBuild() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceDockerImageBuildCreate,
+		ReadContext:   resourceDockerImageBuildRead,
+		DeleteContext: resourceDockerImageBuildDelete,
+		UpdateContext: resourceDockerImageBuildUpdate,
+		CustomizeDiff: customizeDiffDockerImageBuildContext,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(20 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"endpoint_id": {Type: schema.TypeInt, Required: true, ForceNew: true, Description: "Identifier of the Portainer environment on which the image is built."},
+			"context_path": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"context_path", "git_url"},
+				Description:  "Local directory used as build context. It is tarred (honouring `.dockerignore`) and streamed to the Docker host.",
+			},
+			"git_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"context_path", "git_url"},
+				Description:  "Git repository used as remote build context (e.g. `https://github.com/acme/tools.git#v1.2:docker`). The Docker host clones it.",
+			},
+			"dockerfile": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     "Dockerfile",
+				Description: "Path of the Dockerfile relative to the build context.",
+			},
+			"tags": {
+				Type:        schema.TypeList,
+				Required:    true,
+				ForceNew:    true,
+				MinItems:    1,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Tags applied to the built image (e.g. `helper:1.0`).",
+			},
+			"build_args": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Build-time variables (`ARG`) passed to the build.",
+			},
+			"target": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Target stage of a multi-stage Dockerfile.",
+			},
+			"labels": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Labels set on the built image.",
+			},
+			"no_cache": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Whether to build without the layer cache.",
+			},
+			"pull": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Whether to always pull newer versions of the base images.",
+			},
+			"keep_locally": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether the tags are left on the host when the resource is destroyed.",
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Arbitrary values that trigger a rebuild when they change (useful with `git_url`).",
+			},
+			"context_hash": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "SHA256 of the local build context. A change triggers a rebuild.",
+			},
+			"image_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "ID of the built image.",
+			},
+		},
+	}
+}
+
+// dockerIgnoreRule is one line of a .dockerignore file, compiled to a regular
+// expression with the semantics of Docker's pattern matcher.
+type dockerIgnoreRule struct {
+	pattern string
+	exclude bool
+	re      *regexp.Regexp
+}
+
+func newDockerIgnoreRule(line string) (dockerIgnoreRule, error) {
+	rule := dockerIgnoreRule{exclude: true}
+	if strings.HasPrefix(line, "!") {
+		rule.exclude = false
+		line = strings.TrimSpace(line[1:])
+	}
+	rule.pattern = filepath.ToSlash(filepath.Clean(line))
+	if len(rule.pattern) > 1 && rule.pattern[0] == '/' {
+		rule.pattern = rule.pattern[1:]
+	}
+	re, err := regexp.Compile(dockerIgnoreRegexp(rule.pattern))
+	if err != nil {
+		return rule, fmt.Errorf("invalid .dockerignore pattern %q: %w", line, err)
+	}
+	rule.re = re
+	return rule, nil
+}
+
+// dockerIgnoreRegexp translates a .dockerignore pattern: "*" and "?" do not
+// match "/", "**" matches any number of directories, and "\" escapes the next
+// character.
+func dockerIgnoreRegexp(pattern string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; {
+		case ch == '*' && i+1 < len(pattern) && pattern[i+1] == '*':
+			i++
+			if i+1 < len(pattern) && pattern[i+1] == '/' {
+				i++
+			}
+			if i+1 == len(pattern) {
+				b.WriteString(".*")
+			} else {
+				b.WriteString("(.*/)?")
+			}
+		case ch == '*':
+			b.WriteString("[^/]*")
+		case ch == '?':
+			b.WriteString("[^/]")
+		case ch == '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			} else {
+				b.WriteString(`\\`)
+			}
+		case strings.IndexByte(".+()|{}$", ch) >= 0:
+			b.WriteString(`\` + string(ch))
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// readDockerIgnore reads the .dockerignore rules of the context. Like
+// `docker build`, it keeps the Dockerfile and the .dockerignore file in the
+// context even when they match an exclusion.
+func readDockerIgnore(contextPath, dockerfile string) ([]dockerIgnoreRule, error) {
+	data, err := os.ReadFile(filepath.Join(contextPath, ".dockerignore"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rules []dockerIgnoreRule
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := newDockerIgnoreRule(line)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for _, name := range []string{".dockerignore", filepath.ToSlash(filepath.Clean(dockerfile))} {
+		if dockerIgnored(name, rules) {
+			rule, err := newDockerIgnoreRule("!" + name)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// dockerIgnored evaluates the rules in order, the last matching rule wins. A
+// pattern also matches everything below a matching directory.
+func dockerIgnored(rel string, rules []dockerIgnoreRule) bool {
+	ignored := false
+	dirs := strings.Split(path.Dir(rel), "/")
+	for _, r := range rules {
+		match := r.re.MatchString(rel)
+		for i := 0; !match && dirs[0] != "." && i < len(dirs); i++ {
+			match = r.re.MatchString(strings.Join(dirs[:i+1], "/"))
+		}
+		if match {
+			ignored = r.exclude
+		}
+	}
+	return ignored
+}
+
+// walkDockerBuildContext calls fn for every regular file of the context that
+// is not excluded by .dockerignore, in lexical order.
+func walkDockerBuildContext(contextPath, dockerfile string, fn func(rel string, path string, info fs.FileInfo) error) error {
+	rules, err := readDockerIgnore(contextPath, dockerfile)
+	if err != nil {
+		return fmt.Errorf("failed to read .dockerignore: %w", err)
+	}
+	return filepath.Walk(contextPath, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(contextPath, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() || !info.Mode().IsRegular() || dockerIgnored(rel, rules) {
+			return nil
+		}
+		return fn(rel, path, info)
+	})
+}
+
+// dockerBuildContextHash hashes the names, modes and contents of the files
+// sent as build context.
+func dockerBuildContextHash(contextPath, dockerfile string) (string, error) {
+	h := sha256.New()
+	err := walkDockerBuildContext(contextPath, dockerfile, func(rel, path string, info fs.FileInfo) error {
+		fileHash, err := sha256File(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%o\x00%s\n", rel, info.Mode().Perm(), fileHash)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// dockerBuildContextTar tars the build context in memory.
+func dockerBuildContextTar(contextPath, dockerfile string) (*bytes.Buffer, error) {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	err := walkDockerBuildContext(contextPath, dockerfile, func(rel, path string, info fs.FileInfo) error {
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = rel
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive build context: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// customizeDiffDockerImageBuildContext hashes the local context during plan
+// and forces a rebuild when its content changed.
+func customizeDiffDockerImageBuildContext(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	contextPath, _ := d.Get("context_path").(string)
+	if contextPath == "" {
+		return nil
+	}
+	hash, err := dockerBuildContextHash(contextPath, d.Get("dockerfile").(string))
+	if err != nil {
+		return fmt.Errorf("failed to hash context_path %q: %w", contextPath, err)
+	}
+	if d.Get("context_hash").(string) == hash {
+		return nil
+	}
+	if err := d.SetNew("context_hash", hash); err != nil {
+		return err
+	}
+	if d.Id() == "" {
+		return nil
+	}
+	return d.ForceNew("context_hash")
+}
+
+func dockerBuildQuery(d *schema.ResourceData) (url.Values, error) {
+	params := url.Values{}
+	for _, t := range d.Get("tags").([]interface{}) {
+		params.Add("t", t.(string))
+	}
+	params.Set("dockerfile", d.Get("dockerfile").(string))
+	if v, ok := d.GetOk("git_url"); ok {
+		params.Set("remote", v.(string))
+	}
+	if v, ok := d.GetOk("target"); ok {
+		params.Set("target", v.(string))
+	}
+	for attr, param := range map[string]string{"build_args": "buildargs", "labels": "labels"} {
+		if v, ok := d.GetOk(attr); ok {
+			encoded, err := json.Marshal(v.(map[string]interface{}))
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode %s: %w", attr, err)
+			}
+			params.Set(param, string(encoded))
+		}
+	}
+	if d.Get("no_cache").(bool) {
+		params.Set("nocache", "1")
+	}
+	if d.Get("pull").(bool) {
+		params.Set("pull", "1")
+	}
+	return params, nil
+}
+
+// parseDockerBuildStream returns the image ID announced in the build stream,
+// or an error quoting the end of the build log when the build failed.
+func parseDockerBuildStream(body io.Reader) (string, error) {
+	var imageID string
+	var logLines []string
+	decoder := json.NewDecoder(body)
+	for {
+		var msg struct {
+			Stream string `json:"stream"`
+			Error  string `json:"error"`
+			Aux    struct {
+				ID string `json:"ID"`
+			} `json:"aux"`
+		}
+		if err := decoder.Decode(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", fmt.Errorf("failed to decode build output: %w", err)
+		}
+		if msg.Stream != "" {
+			logLines = append(logLines, strings.Split(strings.TrimRight(msg.Stream, "\n"), "\n")...)
+		}
+		if msg.Aux.ID != "" {
+			imageID = msg.Aux.ID
+		}
+		if msg.Error != "" {
+			if len(logLines) > 20 {
+				logLines = logLines[len(logLines)-20:]
+			}
+			return "", fmt.Errorf("docker build failed: %s\n%s", msg.Error, strings.Join(logLines, "\n"))
+		}
+	}
+	if imageID == "" {
+		return "", fmt.Errorf("docker build finished without reporting an image ID")
+	}
+	return imageID, nil
+}
+
+func resourceDockerImageBuildCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+
+	timeout := d.Timeout(schema.TimeoutCreate)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	params, err := dockerBuildQuery(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var body io.Reader
+	if contextPath := d.Get("context_path").(string); contextPath != "" {
+		dockerfile := d.Get("dockerfile").(string)
+		archive, err := dockerBuildContextTar(contextPath, dockerfile)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		body = archive
+		hash, err := dockerBuildContextHash(contextPath, dockerfile)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("failed to hash context_path %q: %w", contextPath, err))
+		}
+		_ = d.Set("context_hash", hash)
+	}
+
+	urlPath := fmt.Sprintf("%s/endpoints/%d/docker/build?%s", client.Endpoint, endpointID, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, urlPath, body)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to build request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/x-tar")
+	if client.APIKey != "" {
+		req.Header.Set("X-API-Key", client.APIKey)
+	} else if client.JWTToken != "" {
+		req.Header.Set("Authorization", "Bearer "+client.JWTToken)
+	}
+
+	resp, err := client.HTTPClient.Do(req)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("request failed: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		data, _ := io.ReadAll(resp.Body)
+		return diag.FromErr(fmt.Errorf("failed to build image, status code: %d, body: %s", resp.StatusCode, string(data)))
+	}
+
+	imageID, err := parseDockerBuildStream(resp.Body)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(imageID)
+	_ = d.Set("image_id", imageID)
+	return nil
+}
+
+func resourceDockerImageBuildRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+
+	info, err := inspectDockerImage(client, d.Get("endpoint_id").(int), d.Id(), nil)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if info == nil {
+		// Removed outside of Terraform: plan a rebuild.
+		d.SetId("")
+		return nil
+	}
+	_ = d.Set("image_id", info.ID)
+
+	// Tags moved to another image outside of Terraform are dropped from state,
+	// which plans a rebuild.
+	tags := []interface{}{}
+	for _, t := range d.Get("tags").([]interface{}) {
+		for _, repoTag := range info.RepoTags {
+			if dockerTagsEqual(repoTag, t.(string)) {
+				tags = append(tags, t)
+				break
+			}
+		}
+	}
+	if err := d.Set("tags", tags); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// dockerTagsEqual compares two image references the way Docker normalizes
+// them: an untagged reference is tagged latest, and Docker Hub official images
+// may be written with or without the docker.io/library/ prefix.
+func dockerTagsEqual(a, b string) bool {
+	normalize := func(ref string) string {
+		if i := strings.LastIndex(ref, ":"); i <= strings.LastIndex(ref, "/") {
+			ref += ":latest"
+		}
+		ref = strings.TrimPrefix(ref, "docker.io/")
+		if rest := strings.TrimPrefix(ref, "library/"); !strings.Contains(rest, "/") {
+			ref = rest
+		}
+		return ref
+	}
+	return normalize(a) == normalize(b)
+}
+
+func resourceDockerImageBuildUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// Only keep_locally can change in place; it is used on destroy.
+	return resourceDockerImageBuildRead(ctx, d, meta)
+}
+
+func resourceDockerImageBuildDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+
+	if d.Get("keep_locally").(bool) {
+		d.SetId("")
+		return nil
+	}
+
+	endpointID := d.Get("endpoint_id").(int)
+	tags := []string{}
+	for _, t := range d.Get("tags").([]interface{}) {
+		tags = append(tags, t.(string))
+	}
+	sort.Strings(tags)
+
+	// Untag every tag; Docker removes the image with its last reference.
+	// The image is then removed by ID, in case it had no tag or its tags
+	// were moved to other images.
+	var diags diag.Diagnostics
+	for _, ref := range append(tags, d.Id()) {
+		path := fmt.Sprintf("/endpoints/%d/docker/images/%s", endpointID, url.PathEscape(ref))
+		resp, err := client.DoRequest(http.MethodDelete, path, nil, nil)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("failed to delete image %s: %w", ref, err))
+		}
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode == http.StatusConflict && ref == d.Id():
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  fmt.Sprintf("Image %s was not removed", ref),
+				Detail:   fmt.Sprintf("The image is still in use or referenced by other tags: %s", string(data)),
+			})
+		case resp.StatusCode >= 400 && resp.StatusCode != http.StatusNotFound:
+			return diag.FromErr(fmt.Errorf("failed to delete image %s, status code: %d, body: %s", ref, resp.StatusCode, string(data)))
+		}
+	}
+
+	d.SetId("")
+	return diags
+}
@@ -0,0 +1,260 @@
+package internal
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func writeDockerBuildContext(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// TestDockerImageBuildCreate streams the tarred context, without the
+// .dockerignore'd files, and passes the build options in the query.
+func TestDockerImageBuildCreate(t *testing.T) {
+	dir := writeDockerBuildContext(t, map[string]string{
+		"Dockerfile":    "FROM alpine\nCOPY app.sh /app.sh\n",
+		"app.sh":        "echo hi\n",
+		"notes.md":      "ignored",
+		"docs/keep.md":  "kept",
+		".dockerignore": "*.md\n**/*.md\n!docs/keep.md\n",
+	})
+
+	mock := NewMockServer(t)
+	mock.On("POST", "/endpoints/1/docker/build", RespondString(http.StatusOK, "application/json",
+		`{"stream":"Step 1/2 : FROM alpine\n"}`+"\n"+`{"aux":{"ID":"sha256:built"}}`+"\n"+`{"stream":"Successfully built\n"}`))
+
+	r := resourceDockerImageBuild()
+	d := r.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("context_path", dir)
+	_ = d.Set("dockerfile", "Dockerfile")
+	_ = d.Set("tags", []interface{}{"helper:1.0", "helper:latest"})
+	_ = d.Set("build_args", map[string]interface{}{"VERSION": "1.0"})
+	_ = d.Set("target", "runtime")
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if d.Id() != "sha256:built" || d.Get("image_id") != "sha256:built" {
+		t.Errorf("unexpected ID %q / image_id %v", d.Id(), d.Get("image_id"))
+	}
+	if d.Get("context_hash") == "" {
+		t.Error("expected context_hash to be set")
+	}
+
+	req := mock.FindRequest("POST", "/endpoints/1/docker/build")
+	if req == nil {
+		t.Fatal("expected a POST to /endpoints/1/docker/build")
+	}
+	if got := req.Headers.Get("Content-Type"); got != "application/x-tar" {
+		t.Errorf("expected tar content type, got %q", got)
+	}
+	query, _ := url.ParseQuery(req.Query)
+	if tags := query["t"]; len(tags) != 2 || tags[0] != "helper:1.0" {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+	if query.Get("buildargs") != `{"VERSION":"1.0"}` || query.Get("target") != "runtime" || query.Get("dockerfile") != "Dockerfile" {
+		t.Errorf("unexpected query: %s", req.Query)
+	}
+
+	var names []string
+	tr := tar.NewReader(bytes.NewReader(req.Body))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read tar: %v", err)
+		}
+		names = append(names, hdr.Name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != ".dockerignore,Dockerfile,app.sh,docs/keep.md" {
+		t.Errorf("unexpected context files: %v", names)
+	}
+}
+
+// TestDockerImageBuildCreate_BuildError surfaces the Docker error together
+// with the end of the build log.
+func TestDockerImageBuildCreate_BuildError(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("POST", "/endpoints/1/docker/build", RespondString(http.StatusOK, "application/json",
+		`{"stream":"Step 2/3 : RUN make\n"}`+"\n"+`{"stream":"make: *** No targets.  Stop.\n"}`+"\n"+
+			`{"errorDetail":{"code":2},"error":"The command '/bin/sh -c make' returned a non-zero code: 2"}`))
+
+	r := resourceDockerImageBuild()
+	d := r.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("git_url", "https://github.com/acme/tools.git#main")
+	_ = d.Set("dockerfile", "Dockerfile")
+	_ = d.Set("tags", []interface{}{"tools:dev"})
+
+	err := rcCreate(r, d, mock.Client())
+	if err == nil || !strings.Contains(err.Error(), "non-zero code: 2") || !strings.Contains(err.Error(), "No targets") {
+		t.Fatalf("expected build error with log, got %v", err)
+	}
+	if d.Id() != "" {
+		t.Errorf("expected no ID after failed build, got %q", d.Id())
+	}
+	req := mock.FindRequest("POST", "/endpoints/1/docker/build")
+	if query, _ := url.ParseQuery(req.Query); query.Get("remote") != "https://github.com/acme/tools.git#main" {
+		t.Errorf("expected remote context in query, got %s", req.Query)
+	}
+}
+
+// TestDockerBuildContextHash changes with the sent files only.
+func TestDockerBuildContextHash(t *testing.T) {
+	dir := writeDockerBuildContext(t, map[string]string{
+		"Dockerfile":    "FROM alpine\n",
+		"tmp/cache":     "a",
+		".dockerignore": "tmp\n",
+	})
+	before, err := dockerBuildContextHash(dir, "Dockerfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "tmp", "cache"), []byte("b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := dockerBuildContextHash(dir, "Dockerfile"); after != before {
+		t.Error("expected ignored files not to change the hash")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM busybox\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := dockerBuildContextHash(dir, "Dockerfile"); after == before {
+		t.Error("expected a Dockerfile change to change the hash")
+	}
+}
+
+// TestDockerImageBuildDelete untags every tag, tolerating already removed ones,
+// then removes the image by ID.
+func TestDockerImageBuildDelete(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("DELETE", "/endpoints/1/docker/images/helper:1.0", RespondJSON(http.StatusOK, []interface{}{}))
+	mock.On("DELETE", "/endpoints/1/docker/images/helper:latest", RespondJSON(http.StatusNotFound, nil))
+	mock.On("DELETE", "/endpoints/1/docker/images/sha256:built", RespondJSON(http.StatusOK, []interface{}{}))
+
+	r := resourceDockerImageBuild()
+	d := r.TestResourceData()
+	d.SetId("sha256:built")
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("tags", []interface{}{"helper:latest", "helper:1.0"})
+
+	if err := rcDelete(r, d, mock.Client()); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if n := len(mock.Requests()); n != 3 {
+		t.Errorf("expected 2 untag requests and the image removal, got %d", n)
+	}
+	if mock.FindRequest("DELETE", "/endpoints/1/docker/images/sha256:built") == nil {
+		t.Error("expected the image to be removed by ID")
+	}
+}
+
+// TestDockerIgnored follows Docker's pattern matcher: "**" spans directories,
+// a directory match covers its content and the last matching rule wins.
+func TestDockerIgnored(t *testing.T) {
+	var rules []dockerIgnoreRule
+	for _, line := range []string{"**/*.log", "build", "!build/keep.txt", "docs/*.md", "!docs/README.md", "tmp?"} {
+		rule, err := newDockerIgnoreRule(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules = append(rules, rule)
+	}
+	for rel, want := range map[string]bool{
+		"app.log":          true,
+		"a/b/c/app.log":    true,
+		"app.go":           false,
+		"build/out.bin":    true,
+		"build/keep.txt":   false,
+		"docs/guide.md":    true,
+		"docs/README.md":   false,
+		"docs/sub/deep.md": false,
+		"tmp1/cache":       true,
+		"tmp12/cache":      false,
+	} {
+		if got := dockerIgnored(rel, rules); got != want {
+			t.Errorf("dockerIgnored(%q) = %v, want %v", rel, got, want)
+		}
+	}
+}
+
+// TestDockerBuildContextTar_KeepsBuildFiles sends the Dockerfile and the
+// .dockerignore file even when they are excluded, like `docker build`.
+func TestDockerBuildContextTar_KeepsBuildFiles(t *testing.T) {
+	dir := writeDockerBuildContext(t, map[string]string{
+		"build/Dockerfile": "FROM alpine\n",
+		"build/other":      "x",
+		"app.sh":           "echo hi\n",
+		".dockerignore":    "build\n.dockerignore\n",
+	})
+	archive, err := dockerBuildContextTar(dir, "build/Dockerfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	tr := tar.NewReader(archive)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read tar: %v", err)
+		}
+		names = append(names, hdr.Name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != ".dockerignore,app.sh,build/Dockerfile" {
+		t.Errorf("unexpected context files: %v", names)
+	}
+}
+
+// TestDockerImageBuildRead_TagMoved drops a tag that now points at another
+// image, so that the next plan rebuilds.
+func TestDockerImageBuildRead_TagMoved(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/images/sha256:built/json", RespondJSON(http.StatusOK, map[string]interface{}{
+		"Id":       "sha256:built",
+		"RepoTags": []string{"helper:1.0", "docker.io/library/base:latest"},
+	}))
+
+	r := resourceDockerImageBuild()
+	d := r.TestResourceData()
+	d.SetId("sha256:built")
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("tags", []interface{}{"helper:1.0", "helper:latest", "base"})
+
+	if err := rcRead(r, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	tags := d.Get("tags").([]interface{})
+	if len(tags) != 2 || tags[0] != "helper:1.0" || tags[1] != "base" {
+		t.Errorf("expected the tags still on the image, got %v", tags)
+	}
+}
@@ -1,8 +1,13 @@
 package internal
 
 import (
+	"context"
+	"encoding/base64"
 	"net/http"
+	"strings"
 	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 // TestDockerImageCreate_HappyPath verifies the pull POST hits the create
@@ -89,9 +94,32 @@ func TestDockerImageCreate_InvalidAuthFormat(t *testing.T) {
 	}
 }
 
-// TestDockerImageRead_NoOp verifies that Read is a no-op (the resource has
-// no remote state to refresh from).
-func TestDockerImageRead_NoOp(t *testing.T) {
+// TestDockerImageRead_Refresh verifies Read stores the local image ID and the
+// repo digest matching the image repository.
+func TestDockerImageRead_Refresh(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/images/nginx:1.25/json", RespondJSON(http.StatusOK, map[string]interface{}{
+		"Id":          "sha256:local",
+		"RepoDigests": []string{"mirror.local/nginx@sha256:other", "nginx@sha256:aaaa"},
+	}))
+
+	r := resourceDockerImage()
+	d := r.TestResourceData()
+	d.SetId("1-nginx:1.25")
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("image", "nginx:1.25")
+
+	if err := rcRead(r, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if d.Get("image_id") != "sha256:local" || d.Get("repo_digest") != "nginx@sha256:aaaa" {
+		t.Errorf("unexpected image_id=%v repo_digest=%v", d.Get("image_id"), d.Get("repo_digest"))
+	}
+}
+
+// TestDockerImageRead_Removed verifies an image removed outside Terraform is
+// dropped from state so it gets pulled again.
+func TestDockerImageRead_Removed(t *testing.T) {
 	mock := NewMockServer(t)
 
 	r := resourceDockerImage()
@@ -103,8 +131,96 @@ func TestDockerImageRead_NoOp(t *testing.T) {
 	if err := rcRead(r, d, mock.Client()); err != nil {
 		t.Fatalf("Read failed: %v", err)
 	}
+	if d.Id() != "" {
+		t.Errorf("expected ID cleared, got %q", d.Id())
+	}
+}
+
+// TestDockerImageCreate_RegistryID verifies registry_id is sent as a Portainer
+// registry reference and pull errors reported in the stream are surfaced.
+func TestDockerImageCreate_RegistryID(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("POST", "/endpoints/1/docker/images/create", RespondString(
+		http.StatusOK, "application/json",
+		`{"status":"Pulling from foo/bar"}`+"\n"+`{"errorDetail":{"message":"manifest unknown"},"error":"manifest unknown"}`,
+	))
+
+	r := resourceDockerImage()
+	d := r.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("image", "ghcr.io/foo/bar:latest")
+	_ = d.Set("registry_id", 4)
+
+	err := rcCreate(r, d, mock.Client())
+	if err == nil || !strings.Contains(err.Error(), "manifest unknown") {
+		t.Fatalf("expected pull stream error, got %v", err)
+	}
+	post := mock.FindRequest("POST", "/endpoints/1/docker/images/create")
+	if auth, _ := base64.StdEncoding.DecodeString(post.Headers.Get("X-Registry-Auth")); string(auth) != `{"registryId":4}` {
+		t.Errorf("unexpected X-Registry-Auth: %s", auth)
+	}
+}
+
+// TestDockerImageDiff_MovedTag verifies a new pull is planned when the
+// registry serves another digest for the tag, queried with registry_auth.
+func TestDockerImageDiff_MovedTag(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/distribution/nginx:latest/json", RespondJSON(http.StatusOK, map[string]interface{}{
+		"Descriptor": map[string]string{"digest": "sha256:bbbb"},
+	}))
+
+	r := resourceDockerImage()
+	state := &terraform.InstanceState{ID: "1-nginx:latest", Attributes: map[string]string{
+		"id":            "1-nginx:latest",
+		"endpoint_id":   "1",
+		"image":         "nginx:latest",
+		"keep_locally":  "false",
+		"image_id":      "sha256:local",
+		"repo_digest":   "nginx@sha256:aaaa",
+		"registry_auth": "bob:secret",
+	}}
+	cfg := terraform.NewResourceConfigRaw(map[string]interface{}{"endpoint_id": 1, "image": "nginx:latest", "registry_auth": "bob:secret"})
+
+	diff, err := r.Diff(context.Background(), state, cfg, mock.Client())
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	attr := diff.Attributes["repo_digest"]
+	if attr == nil || !attr.NewComputed || attr.RequiresNew {
+		t.Fatalf("expected in-place repo_digest change, got %+v", attr)
+	}
+	lookup := mock.FindRequest("GET", "/endpoints/1/docker/distribution/nginx:latest/json")
+	if auth, _ := base64.StdEncoding.DecodeString(lookup.Headers.Get("X-Registry-Auth")); !strings.Contains(string(auth), `"username":"bob"`) {
+		t.Errorf("expected registry_auth in the digest lookup, got %s", auth)
+	}
+
+	state.Attributes["repo_digest"] = "nginx@sha256:bbbb"
+	diff, err = r.Diff(context.Background(), state, cfg, mock.Client())
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if diff != nil && !diff.Empty() {
+		t.Errorf("expected no diff when digests match, got %+v", diff.Attributes)
+	}
+}
+
+// TestDockerImageDelete_KeepLocally verifies no DELETE is sent when the image
+// must stay on the host.
+func TestDockerImageDelete_KeepLocally(t *testing.T) {
+	mock := NewMockServer(t)
+
+	r := resourceDockerImage()
+	d := r.TestResourceData()
+	d.SetId("1-nginx:1.25")
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("image", "nginx:1.25")
+	_ = d.Set("keep_locally", true)
+
+	if err := rcDelete(r, d, mock.Client()); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
 	if len(mock.Requests()) != 0 {
-		t.Errorf("expected zero HTTP requests during Read, got %d", len(mock.Requests()))
+		t.Errorf("expected no HTTP requests, got %d", len(mock.Requests()))
 	}
 }
 
@@ -13,6 +13,67 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
+// dockerNode is the subset of a Swarm node used by the node resources and
+// data sources.
+type dockerNode struct {
+	ID      string `json:"ID"`
+	Version struct {
+		Index int `json:"Index"`
+	} `json:"Version"`
+	Description struct {
+		Hostname string `json:"Hostname"`
+	} `json:"Description"`
+	Spec struct {
+		Availability string            `json:"Availability"`
+		Name         string            `json:"Name"`
+		Role         string            `json:"Role"`
+		Labels       map[string]string `json:"Labels"`
+	} `json:"Spec"`
+	Status struct {
+		State string `json:"State"`
+		Addr  string `json:"Addr"`
+	} `json:"Status"`
+	ManagerStatus *struct {
+		Leader       bool   `json:"Leader"`
+		Reachability string `json:"Reachability"`
+		Addr         string `json:"Addr"`
+	} `json:"ManagerStatus"`
+}
+
+func listDockerNodes(client *APIClient, endpointID int) ([]dockerNode, error) {
+	path := fmt.Sprintf("/endpoints/%d/docker/nodes", endpointID)
+	resp, err := client.DoRequest(http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list docker nodes: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		// Nodes endpoint might fail if not in a Swarm cluster
+		return nil, fmt.Errorf("failed to list docker nodes (is this a Swarm cluster?), status %d: %s", resp.StatusCode, string(data))
+	}
+
+	var nodes []dockerNode
+	if err := json.NewDecoder(resp.Body).Decode(&nodes); err != nil {
+		return nil, fmt.Errorf("failed to decode docker node list: %w", err)
+	}
+	return nodes, nil
+}
+
+func findDockerNodeByHostname(client *APIClient, endpointID int, hostname string) (*dockerNode, error) {
+	nodes, err := listDockerNodes(client, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range nodes {
+		if nodes[i].Description.Hostname == hostname {
+			return &nodes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("docker node with hostname %s not found in endpoint %d", hostname, endpointID)
+}
+
 type DockerNodeUpdatePayload struct {
 	Availability string            `json:"Availability,omitempty"`
 	Name         string            `json:"Name,omitempty"`
@@ -34,15 +95,26 @@ func resourceDockerNode() *schema.Resource {
 				Description: "ID of the Portainer environment (Docker Swarm cluster) where the node is managed.",
 			},
 			"node_id": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "Docker Swarm node ID to update.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"node_id", "hostname"},
+				Description:  "Docker Swarm node ID to update.",
+			},
+			"hostname": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"node_id", "hostname"},
+				Description:  "Hostname of the Docker Swarm node to update, as an alternative to `node_id`.",
 			},
 			"version": {
 				Type:        schema.TypeInt,
-				Required:    true,
-				Description: "Swarm node version required for update operation",
+				Optional:    true,
+				Computed:    true,
+				Description: "Swarm node version required for update operation. If not set, the current version is read from the node.",
 			},
 			"name": {
 				Type:        schema.TypeString,
@@ -75,6 +147,36 @@ func resourceDockerNodeUpdate(ctx context.Context, d *schema.ResourceData, meta
 	nodeID := d.Get("node_id").(string)
 	version := d.Get("version").(int)
 
+	if nodeID == "" {
+		node, err := findDockerNodeByHostname(client, endpointID, d.Get("hostname").(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		nodeID = node.ID
+		if version == 0 {
+			version = node.Version.Index
+		}
+		_ = d.Set("node_id", nodeID)
+	}
+	if version == 0 {
+		path := fmt.Sprintf("/endpoints/%d/docker/nodes/%s", endpointID, url.PathEscape(nodeID))
+		resp, err := client.DoRequest(http.MethodGet, path, nil, nil)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("failed to read node version: %w", err))
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			data, _ := io.ReadAll(resp.Body)
+			return diag.FromErr(fmt.Errorf("failed to read node version, status: %d, body: %s", resp.StatusCode, string(data)))
+		}
+		var node dockerNode
+		if err := json.NewDecoder(resp.Body).Decode(&node); err != nil {
+			return diag.FromErr(fmt.Errorf("failed to decode node: %w", err))
+		}
+		version = node.Version.Index
+	}
+	_ = d.Set("version", version)
+
 	payload := DockerNodeUpdatePayload{
 		Availability: d.Get("availability").(string),
 		Name:         d.Get("name").(string),
@@ -149,19 +251,7 @@ func resourceDockerNodeRead(ctx context.Context, d *schema.ResourceData, meta in
 		return diag.FromErr(fmt.Errorf("failed to read node: %s", string(body)))
 	}
 
-	var result struct {
-		ID      string `json:"ID"`
-		Version struct {
-			Index int `json:"Index"`
-		} `json:"Version"`
-		Spec struct {
-			Availability string            `json:"Availability"`
-			Name         string            `json:"Name"`
-			Role         string            `json:"Role"`
-			Labels       map[string]string `json:"Labels"`
-		} `json:"Spec"`
-	}
-
+	var result dockerNode
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return diag.FromErr(fmt.Errorf("failed to decode response: %w", err))
 	}
@@ -181,6 +271,9 @@ func resourceDockerNodeRead(ctx context.Context, d *schema.ResourceData, meta in
 	if err := d.Set("labels", result.Spec.Labels); err != nil {
 		return diag.FromErr(err)
 	}
+	if result.Description.Hostname != "" {
+		_ = d.Set("hostname", result.Description.Hostname)
+	}
 	d.SetId(fmt.Sprintf("%d-%s", endpointID, nodeID))
 	return nil
 }
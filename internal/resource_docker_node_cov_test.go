@@ -194,3 +194,32 @@ func TestDockerNodeDelete_HTTPError(t *testing.T) {
 		t.Fatal("expected error on HTTP 500, got nil")
 	}
 }
+
+// TestDockerNodeUpdate_ByHostname resolves the node ID and its current
+// version from the hostname.
+func TestDockerNodeUpdate_ByHostname(t *testing.T) {
+	mock := NewMockServer(t)
+
+	mock.On("GET", "/endpoints/1/docker/nodes", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{"ID": "node-abc", "Version": map[string]interface{}{"Index": 40}, "Description": map[string]interface{}{"Hostname": "edge-7"}},
+	}))
+	mock.On("POST", "/endpoints/1/docker/nodes/node-abc/update", RespondJSON(http.StatusOK, map[string]interface{}{}))
+
+	r := resourceDockerNode()
+	d := r.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("hostname", "edge-7")
+	_ = d.Set("role", "worker")
+	_ = d.Set("labels", map[string]interface{}{"site": "plant-7"})
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if d.Id() != "1-node-abc" || d.Get("node_id") != "node-abc" {
+		t.Errorf("unexpected state: id=%s node_id=%v", d.Id(), d.Get("node_id"))
+	}
+	post := mock.FindRequest("POST", "/endpoints/1/docker/nodes/node-abc/update")
+	if post == nil || post.Query != "version=40" {
+		t.Errorf("expected update with version=40, got %+v", post)
+	}
+}
@@ -0,0 +1,532 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+var dockerPruneKinds = []string{"containers", "images", "networks", "volumes", "build_cache"}
+
+func resourceDockerPrune() *schema.Resource {
+	until := &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Description: "Only prune objects created before this point: a duration relative to now (e.g. `24h`) or a timestamp.",
+	}
+	labels := &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validation.NoZeroValues},
+		Description: "Label filters: `key` or `key=value` to only prune matching objects, prefixed with `!` to exclude them.",
+	}
+
+	return &schema.Resource{
+		CreateContext: resourceDockerPruneApply,
+		ReadContext:   resourceDockerPruneRead,
+		UpdateContext: resourceDockerPruneApply,
+		DeleteContext: resourceDockerPruneDelete,
+
+		Schema: map[string]*schema.Schema{
+			"endpoint_id": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"endpoint_id", "schedule"},
+				Description:  "ID of the Portainer environment to prune immediately. Mutually exclusive with `schedule`.",
+			},
+			"swarm_node_id": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"schedule"},
+				Description:   "Swarm node to prune (sent as `X-PortainerAgent-Target`).",
+			},
+			"containers": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				MaxItems:     1,
+				AtLeastOneOf: dockerPruneKinds,
+				Description:  "Remove stopped containers.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"until":  until,
+						"labels": labels,
+					},
+				},
+			},
+			"images": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				MaxItems:     1,
+				AtLeastOneOf: dockerPruneKinds,
+				Description:  "Remove unused images.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"all": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Remove all images not used by a container instead of dangling images only.",
+						},
+						"until":  until,
+						"labels": labels,
+					},
+				},
+			},
+			"networks": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				MaxItems:     1,
+				AtLeastOneOf: dockerPruneKinds,
+				Description:  "Remove networks not used by a container.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"until":  until,
+						"labels": labels,
+					},
+				},
+			},
+			"volumes": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				MaxItems:     1,
+				AtLeastOneOf: dockerPruneKinds,
+				Description:  "Remove volumes not used by a container.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"all": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Remove unused named volumes too, not only anonymous ones (Docker 23+).",
+						},
+						"labels": labels,
+					},
+				},
+			},
+			"build_cache": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				MaxItems:     1,
+				AtLeastOneOf: dockerPruneKinds,
+				Description:  "Remove the build cache.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"all": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Remove all build cache, not only dangling entries.",
+						},
+						"until": until,
+						"keep_storage": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IntAtLeast(0),
+							Description:  "Amount of build cache, in bytes, to keep.",
+						},
+					},
+				},
+			},
+			"schedule": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				MaxItems:     1,
+				ExactlyOneOf: []string{"endpoint_id", "schedule"},
+				Description:  "Run the prune as a Portainer edge job on edge environments instead of once against `endpoint_id`.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.NoZeroValues,
+							Description:  "Name of the edge job.",
+						},
+						"cron_expression": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Cron expression scheduling the prune.",
+						},
+						"edge_groups": {
+							Type:        schema.TypeList,
+							Required:    true,
+							Elem:        &schema.Schema{Type: schema.TypeInt},
+							Description: "Edge groups the job runs on.",
+						},
+						"endpoints": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							Elem:        &schema.Schema{Type: schema.TypeInt},
+							Description: "Edge environments the job runs on in addition to `edge_groups`.",
+						},
+						"recurring": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     true,
+							Description: "Whether the job runs on every cron occurrence (true) or only once (false).",
+						},
+					},
+				},
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Arbitrary values; changing any of them prunes `endpoint_id` again.",
+			},
+			// Computed attributes
+			"space_reclaimed": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Disk space reclaimed by the last prune, in bytes.",
+			},
+			"containers_deleted": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of containers removed by the last prune.",
+			},
+			"images_deleted": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of image layers removed by the last prune.",
+			},
+			"networks_deleted": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of networks removed by the last prune.",
+			},
+			"volumes_deleted": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of volumes removed by the last prune.",
+			},
+			"build_cache_deleted": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of build cache entries removed by the last prune.",
+			},
+			"edge_job_id": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the edge job when `schedule` is set.",
+			},
+			"script": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Shell script run by the edge job when `schedule` is set.",
+			},
+		},
+	}
+}
+
+// dockerPruneReport merges the responses of the Docker prune endpoints.
+type dockerPruneReport struct {
+	ContainersDeleted []string `json:"ContainersDeleted"`
+	ImagesDeleted     []struct {
+		Untagged string `json:"Untagged"`
+		Deleted  string `json:"Deleted"`
+	} `json:"ImagesDeleted"`
+	NetworksDeleted []string `json:"NetworksDeleted"`
+	VolumesDeleted  []string `json:"VolumesDeleted"`
+	CachesDeleted   []string `json:"CachesDeleted"`
+	SpaceReclaimed  int64    `json:"SpaceReclaimed"`
+}
+
+// dockerPruneOptions returns the settings of a prune block and whether the
+// block is present. An empty block (e.g. `containers {}`) is present.
+func dockerPruneOptions(d interface{ Get(string) interface{} }, kind string) (map[string]interface{}, bool) {
+	list, ok := d.Get(kind).([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, false
+	}
+	opts, _ := list[0].(map[string]interface{})
+	if opts == nil {
+		opts = map[string]interface{}{}
+	}
+	return opts, true
+}
+
+// dockerPruneFilters converts the options of a prune block to Docker filters.
+func dockerPruneFilters(kind string, opts map[string]interface{}) map[string][]string {
+	filters := map[string][]string{}
+	if v, _ := opts["until"].(string); v != "" {
+		filters["until"] = []string{v}
+	}
+	if labels, ok := opts["labels"].([]interface{}); ok {
+		for _, l := range labels {
+			label, _ := l.(string)
+			if strings.HasPrefix(label, "!") {
+				filters["label!"] = append(filters["label!"], strings.TrimPrefix(label, "!"))
+			} else if label != "" {
+				filters["label"] = append(filters["label"], label)
+			}
+		}
+	}
+	all, _ := opts["all"].(bool)
+	switch kind {
+	case "images":
+		filters["dangling"] = []string{strconv.FormatBool(!all)}
+	case "volumes":
+		if all {
+			filters["all"] = []string{"true"}
+		}
+	}
+	return filters
+}
+
+func dockerPrunePath(endpointID int, kind string, opts map[string]interface{}) string {
+	query := url.Values{}
+	if filters := dockerPruneFilters(kind, opts); len(filters) > 0 {
+		data, _ := json.Marshal(filters)
+		query.Set("filters", string(data))
+	}
+	object := kind
+	if kind == "build_cache" {
+		object = "build"
+		if all, _ := opts["all"].(bool); all {
+			query.Set("all", "true")
+		}
+		if v, _ := opts["keep_storage"].(int); v > 0 {
+			query.Set("keep-storage", strconv.Itoa(v))
+		}
+	}
+	path := fmt.Sprintf("/endpoints/%d/docker/%s/prune", endpointID, object)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path
+}
+
+// dockerPruneScript renders the prune blocks as docker CLI commands for an
+// edge job, in the same order as `docker system prune`.
+func dockerPruneScript(d interface{ Get(string) interface{} }) string {
+	commands := map[string]string{
+		"containers":  "docker container prune --force",
+		"images":      "docker image prune --force",
+		"networks":    "docker network prune --force",
+		"volumes":     "docker volume prune --force",
+		"build_cache": "docker builder prune --force",
+	}
+
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\nset -e\n")
+	for _, kind := range dockerPruneKinds {
+		opts, ok := dockerPruneOptions(d, kind)
+		if !ok {
+			continue
+		}
+		b.WriteString(commands[kind])
+		if all, _ := opts["all"].(bool); all {
+			b.WriteString(" --all")
+		}
+		if v, _ := opts["keep_storage"].(int); v > 0 {
+			fmt.Fprintf(&b, " --keep-storage %d", v)
+		}
+		filters := dockerPruneFilters(kind, opts)
+		delete(filters, "dangling")
+		delete(filters, "all")
+		keys := make([]string, 0, len(filters))
+		for k := range filters {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			for _, v := range filters[k] {
+				fmt.Fprintf(&b, " --filter '%s'", strings.ReplaceAll(k+"="+v, "'", `'\''`))
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func resourceDockerPruneApply(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if _, ok := d.GetOk("schedule"); ok {
+		return resourceDockerPruneSchedule(d, meta)
+	}
+
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+	headers := dockerContainerHeaders(d)
+
+	total := dockerPruneReport{}
+	for _, kind := range dockerPruneKinds {
+		opts, ok := dockerPruneOptions(d, kind)
+		if !ok {
+			continue
+		}
+		resp, err := client.DoRequest(http.MethodPost, dockerPrunePath(endpointID, kind, opts), headers, nil)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("failed to prune docker %s: %w", kind, err))
+		}
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return diag.FromErr(fmt.Errorf("failed to prune docker %s, status code: %d, body: %s", kind, resp.StatusCode, string(data)))
+		}
+		var report dockerPruneReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			return diag.FromErr(fmt.Errorf("failed to decode docker %s prune response: %w", kind, err))
+		}
+		total.ContainersDeleted = append(total.ContainersDeleted, report.ContainersDeleted...)
+		total.ImagesDeleted = append(total.ImagesDeleted, report.ImagesDeleted...)
+		total.NetworksDeleted = append(total.NetworksDeleted, report.NetworksDeleted...)
+		total.VolumesDeleted = append(total.VolumesDeleted, report.VolumesDeleted...)
+		total.CachesDeleted = append(total.CachesDeleted, report.CachesDeleted...)
+		total.SpaceReclaimed += report.SpaceReclaimed
+	}
+
+	imagesDeleted := 0
+	for _, img := range total.ImagesDeleted {
+		if img.Deleted != "" {
+			imagesDeleted++
+		}
+	}
+	_ = d.Set("space_reclaimed", int(total.SpaceReclaimed))
+	_ = d.Set("containers_deleted", len(total.ContainersDeleted))
+	_ = d.Set("images_deleted", imagesDeleted)
+	_ = d.Set("networks_deleted", len(total.NetworksDeleted))
+	_ = d.Set("volumes_deleted", len(total.VolumesDeleted))
+	_ = d.Set("build_cache_deleted", len(total.CachesDeleted))
+	_ = d.Set("edge_job_id", 0)
+	_ = d.Set("script", "")
+
+	d.SetId(strconv.Itoa(endpointID))
+	return nil
+}
+
+// resourceDockerPruneSchedule creates or updates the edge job running the
+// prune script.
+func resourceDockerPruneSchedule(d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	schedule := d.Get("schedule").([]interface{})[0].(map[string]interface{})
+	endpoints := []interface{}{}
+	if v, ok := schedule["endpoints"].([]interface{}); ok {
+		endpoints = v
+	}
+	script := dockerPruneScript(d)
+
+	payload := map[string]interface{}{
+		"name":           schedule["name"].(string),
+		"cronExpression": schedule["cron_expression"].(string),
+		"edgeGroups":     schedule["edge_groups"].([]interface{}),
+		"endpoints":      endpoints,
+		"recurring":      schedule["recurring"].(bool),
+		"fileContent":    script,
+	}
+
+	method, path := http.MethodPost, "/edge_jobs/create/string"
+	if d.Id() != "" {
+		method, path = http.MethodPut, fmt.Sprintf("/edge_jobs/%s", d.Id())
+	}
+	resp, err := client.DoRequest(method, path, nil, payload)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to save prune edge job: %w", err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return diag.FromErr(fmt.Errorf("failed to save prune edge job, status code: %d, body: %s", resp.StatusCode, string(data)))
+	}
+
+	if d.Id() == "" {
+		var result struct {
+			ID int `json:"Id"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return diag.FromErr(fmt.Errorf("failed to decode edge job response: %w", err))
+		}
+		d.SetId(strconv.Itoa(result.ID))
+	}
+
+	jobID, _ := strconv.Atoi(d.Id())
+	_ = d.Set("edge_job_id", jobID)
+	_ = d.Set("script", script)
+	return nil
+}
+
+func resourceDockerPruneRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if _, ok := d.GetOk("schedule"); !ok {
+		// An immediate prune leaves nothing to read back.
+		return nil
+	}
+
+	client := meta.(*APIClient)
+	resp, err := client.DoRequest(http.MethodGet, fmt.Sprintf("/edge_jobs/%s", d.Id()), nil, nil)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to read prune edge job: %w", err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		d.SetId("")
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return diag.FromErr(fmt.Errorf("failed to read prune edge job, status code: %d, body: %s", resp.StatusCode, string(data)))
+	}
+
+	var job struct {
+		Name           string                 `json:"Name"`
+		CronExpression string                 `json:"CronExpression"`
+		EdgeGroups     []int                  `json:"EdgeGroups"`
+		Endpoints      map[string]interface{} `json:"Endpoints"`
+		Recurring      bool                   `json:"Recurring"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to decode prune edge job: %w", err))
+	}
+
+	endpointIDs := []int{}
+	for k := range job.Endpoints {
+		if id, err := strconv.Atoi(k); err == nil {
+			endpointIDs = append(endpointIDs, id)
+		}
+	}
+	sort.Ints(endpointIDs)
+
+	schedule := map[string]interface{}{
+		"name":            job.Name,
+		"cron_expression": job.CronExpression,
+		"edge_groups":     job.EdgeGroups,
+		"endpoints":       endpointIDs,
+		"recurring":       job.Recurring,
+	}
+	if err := d.Set("schedule", []interface{}{schedule}); err != nil {
+		return diag.FromErr(err)
+	}
+	jobID, _ := strconv.Atoi(d.Id())
+	_ = d.Set("edge_job_id", jobID)
+	return nil
+}
+
+func resourceDockerPruneDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if _, ok := d.GetOk("schedule"); !ok {
+		d.SetId("")
+		return nil
+	}
+
+	client := meta.(*APIClient)
+	resp, err := client.DoRequest(http.MethodDelete, fmt.Sprintf("/edge_jobs/%s", d.Id()), nil, nil)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to delete prune edge job: %w", err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		data, _ := io.ReadAll(resp.Body)
+		return diag.FromErr(fmt.Errorf("failed to delete prune edge job, status code: %d, body: %s", resp.StatusCode, string(data)))
+	}
+
+	d.SetId("")
+	return nil
+}
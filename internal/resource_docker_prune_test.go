@@ -0,0 +1,147 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// TestDockerPruneApply prunes each configured object type in order and sums
+// up the report.
+func TestDockerPruneApply(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("POST", "/endpoints/1/docker/containers/prune", RespondJSON(http.StatusOK, map[string]interface{}{
+		"ContainersDeleted": []string{"c1", "c2"}, "SpaceReclaimed": 100,
+	}))
+	mock.On("POST", "/endpoints/1/docker/images/prune", RespondJSON(http.StatusOK, map[string]interface{}{
+		"ImagesDeleted":  []map[string]string{{"Untagged": "nginx:1.25"}, {"Deleted": "sha256:a"}, {"Deleted": "sha256:b"}},
+		"SpaceReclaimed": 2000,
+	}))
+	mock.On("POST", "/endpoints/1/docker/build/prune", RespondJSON(http.StatusOK, map[string]interface{}{
+		"CachesDeleted": []string{"k1"}, "SpaceReclaimed": 30,
+	}))
+
+	r := resourceDockerPrune()
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"endpoint_id":   1,
+		"swarm_node_id": "node1",
+		"containers":    []interface{}{map[string]interface{}{"until": "24h"}},
+		"images": []interface{}{map[string]interface{}{
+			"all":    true,
+			"labels": []interface{}{"app=web", "!keep"},
+		}},
+		"build_cache": []interface{}{map[string]interface{}{"all": true, "keep_storage": 1024}},
+	})
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if d.Id() != "1" {
+		t.Errorf("unexpected ID %q", d.Id())
+	}
+	if d.Get("space_reclaimed").(int) != 2130 || d.Get("containers_deleted").(int) != 2 ||
+		d.Get("images_deleted").(int) != 2 || d.Get("build_cache_deleted").(int) != 1 {
+		t.Errorf("unexpected report: reclaimed=%v containers=%v images=%v cache=%v",
+			d.Get("space_reclaimed"), d.Get("containers_deleted"), d.Get("images_deleted"), d.Get("build_cache_deleted"))
+	}
+	if mock.FindRequest("POST", "/endpoints/1/docker/networks/prune") != nil {
+		t.Error("networks must not be pruned without a networks block")
+	}
+
+	images := mock.FindRequest("POST", "/endpoints/1/docker/images/prune")
+	if images.Headers.Get("X-PortainerAgent-Target") != "node1" {
+		t.Errorf("expected agent target header, got %q", images.Headers.Get("X-PortainerAgent-Target"))
+	}
+	query, _ := url.ParseQuery(images.Query)
+	var filters map[string][]string
+	if err := json.Unmarshal([]byte(query.Get("filters")), &filters); err != nil {
+		t.Fatalf("decode filters: %v", err)
+	}
+	if filters["dangling"][0] != "false" || filters["label"][0] != "app=web" || filters["label!"][0] != "keep" {
+		t.Errorf("unexpected image filters: %v", filters)
+	}
+
+	build, _ := url.ParseQuery(mock.FindRequest("POST", "/endpoints/1/docker/build/prune").Query)
+	if build.Get("all") != "true" || build.Get("keep-storage") != "1024" {
+		t.Errorf("unexpected build prune query: %v", build)
+	}
+}
+
+// TestDockerPruneSchedule creates an edge job running the equivalent
+// docker CLI commands.
+func TestDockerPruneSchedule(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("POST", "/edge_jobs/create/string", RespondJSON(http.StatusOK, map[string]int{"Id": 7}))
+
+	r := resourceDockerPrune()
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"containers": []interface{}{map[string]interface{}{"until": "72h"}},
+		"volumes":    []interface{}{map[string]interface{}{"labels": []interface{}{"it's"}}},
+		"schedule": []interface{}{map[string]interface{}{
+			"name":            "nightly-prune",
+			"cron_expression": "0 3 * * *",
+			"edge_groups":     []interface{}{2},
+			"recurring":       true,
+		}},
+	})
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if d.Id() != "7" || d.Get("edge_job_id").(int) != 7 {
+		t.Errorf("unexpected ID %q / edge_job_id %v", d.Id(), d.Get("edge_job_id"))
+	}
+
+	var payload struct {
+		Name           string
+		CronExpression string
+		EdgeGroups     []int
+		Recurring      bool
+		FileContent    string
+	}
+	if err := mock.FindRequest("POST", "/edge_jobs/create/string").DecodeJSON(&payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.Name != "nightly-prune" || payload.CronExpression != "0 3 * * *" || len(payload.EdgeGroups) != 1 || !payload.Recurring {
+		t.Errorf("unexpected edge job payload: %+v", payload)
+	}
+	for _, want := range []string{
+		"docker container prune --force --filter 'until=72h'\n",
+		`docker volume prune --force --filter 'label=it'\''s'` + "\n",
+	} {
+		if !strings.Contains(payload.FileContent, want) {
+			t.Errorf("script missing %q:\n%s", want, payload.FileContent)
+		}
+	}
+	if strings.Contains(payload.FileContent, "image prune") {
+		t.Errorf("unexpected image prune in script:\n%s", payload.FileContent)
+	}
+}
+
+// TestDockerPruneDelete removes the edge job of a scheduled prune.
+func TestDockerPruneDelete(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("DELETE", "/edge_jobs/7", RespondJSON(http.StatusNoContent, nil))
+
+	r := resourceDockerPrune()
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"images": []interface{}{map[string]interface{}{}},
+		"schedule": []interface{}{map[string]interface{}{
+			"name":            "nightly-prune",
+			"cron_expression": "0 3 * * *",
+			"edge_groups":     []interface{}{2},
+		}},
+	})
+	d.SetId("7")
+
+	if err := rcDelete(r, d, mock.Client()); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if mock.FindRequest("DELETE", "/edge_jobs/7") == nil {
+		t.Error("expected the edge job to be deleted")
+	}
+}
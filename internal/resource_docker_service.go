@@ -0,0 +1,811 @@
+package internal
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceDockerService() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceDockerServiceCreate,
+		ReadContext:   resourceDockerServiceRead,
+		UpdateContext: resourceDockerServiceUpdate,
+		DeleteContext: resourceDockerServiceDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+				// Expect ID in format "<endpoint_id>:<service_id>"
+				parts := strings.SplitN(d.Id(), ":", 2)
+				if len(parts) != 2 {
+					return nil, fmt.Errorf("unexpected format of ID (%q), expected <endpoint_id>:<service_id>", d.Id())
+				}
+				endpointID, err := strconv.Atoi(parts[0])
+				if err != nil {
+					return nil, fmt.Errorf("invalid endpoint ID: %w", err)
+				}
+				_ = d.Set("endpoint_id", endpointID)
+				_ = d.Set("wait_for_convergence", true)
+				_ = d.Set("wait_between_checks", 5)
+				_ = d.Set("max_retries", 60)
+				d.SetId(parts[1])
+				return []*schema.ResourceData{d}, nil
+			},
+		},
+		Schema: map[string]*schema.Schema{
+			"endpoint_id": {Type: schema.TypeInt, Required: true, ForceNew: true, Description: "ID of the Portainer environment (Docker Swarm) where the service is created."},
+			"name":        {Type: schema.TypeString, Required: true, ForceNew: true, Description: "Name of the Swarm service."},
+			"image":       {Type: schema.TypeString, Required: true, Description: "Image reference run by the service tasks (for example `nginx:1.27`)."},
+			"registry_id": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "ID of a Portainer registry whose credentials are forwarded to the nodes pulling the image.",
+			},
+			"command": {Type: schema.TypeList, Optional: true, Elem: &schema.Schema{Type: schema.TypeString}, Description: "Command overriding the image ENTRYPOINT."},
+			"args":    {Type: schema.TypeList, Optional: true, Elem: &schema.Schema{Type: schema.TypeString}, Description: "Arguments overriding the image CMD."},
+			"env":     {Type: schema.TypeMap, Optional: true, Elem: &schema.Schema{Type: schema.TypeString}, Description: "Environment variables set in the service tasks."},
+			"labels":  {Type: schema.TypeMap, Optional: true, Elem: &schema.Schema{Type: schema.TypeString}, Description: "Key/value labels attached to the service."},
+			"mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "replicated",
+				ValidateFunc: validation.StringInSlice([]string{"replicated", "global"}, false),
+				Description:  "Scheduling mode: `replicated` or `global` (one task per matching node).",
+			},
+			"replicas": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      1,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Number of tasks in `replicated` mode. Ignored in `global` mode.",
+			},
+			"update_config":   dockerServiceUpdateConfigSchema("Strategy used when the service is updated."),
+			"rollback_config": dockerServiceUpdateConfigSchema("Strategy used when an update is rolled back."),
+			"placement": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Placement of the service tasks on the Swarm nodes.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"constraints":  {Type: schema.TypeList, Optional: true, Elem: &schema.Schema{Type: schema.TypeString}, Description: "Placement constraints (e.g. `node.role==worker`)."},
+						"preferences":  {Type: schema.TypeList, Optional: true, Elem: &schema.Schema{Type: schema.TypeString}, Description: "Spread preferences, given as the spread descriptor (e.g. `node.labels.zone`)."},
+						"max_replicas": {Type: schema.TypeInt, Optional: true, Description: "Maximum number of tasks per node. 0 means unlimited."},
+					},
+				},
+			},
+			"secret": dockerServiceFileReferenceSchema("secret"),
+			"config": dockerServiceFileReferenceSchema("config"),
+			"port": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Port published by the service.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"target_port":    {Type: schema.TypeInt, Required: true, Description: "Port inside the tasks."},
+						"published_port": {Type: schema.TypeInt, Optional: true, Description: "Port published on the Swarm nodes. Swarm picks one when omitted."},
+						"protocol": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "tcp",
+							ValidateFunc: validation.StringInSlice([]string{"tcp", "udp", "sctp"}, false),
+							Description:  "Port protocol: `tcp`, `udp` or `sctp`.",
+						},
+						"publish_mode": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "ingress",
+							ValidateFunc: validation.StringInSlice([]string{"ingress", "host"}, false),
+							Description:  "`ingress` (routing mesh) or `host` (published on the node running the task).",
+						},
+					},
+				},
+			},
+			"network": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Networks the service tasks are attached to.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name":    {Type: schema.TypeString, Required: true, Description: "Network name or ID."},
+						"aliases": {Type: schema.TypeList, Optional: true, Elem: &schema.Schema{Type: schema.TypeString}, Description: "DNS aliases of the service on this network."},
+					},
+				},
+			},
+			"resources": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "CPU and memory limits and reservations of each task.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"limit_cpus":     {Type: schema.TypeFloat, Optional: true, Description: "CPU limit (e.g. `0.5`)."},
+						"limit_memory":   {Type: schema.TypeInt, Optional: true, Description: "Memory limit in MB."},
+						"reserve_cpus":   {Type: schema.TypeFloat, Optional: true, Description: "Reserved CPUs used for scheduling."},
+						"reserve_memory": {Type: schema.TypeInt, Optional: true, Description: "Reserved memory in MB used for scheduling."},
+					},
+				},
+			},
+			"wait_for_convergence": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Whether create and update wait until Swarm completed the update and all its tasks run.",
+			},
+			"wait_between_checks": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     5,
+				Description: "Wait time between convergence checks (seconds).",
+			},
+			"max_retries": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     60,
+				Description: "Maximum number of convergence checks before failing.",
+			},
+			"resource_control_id": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the Portainer resource control associated with this Docker service.",
+			},
+		},
+	}
+}
+
+func dockerServiceUpdateConfigSchema(description string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Description: description,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"parallelism": {Type: schema.TypeInt, Optional: true, Default: 1, Description: "Number of tasks changed at the same time. 0 changes all tasks at once."},
+				"delay":       dockerDurationSchema("Wait time between two batches (e.g. `10s`).", false),
+				"failure_action": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      "pause",
+					ValidateFunc: validation.StringInSlice([]string{"pause", "continue", "rollback"}, false),
+					Description:  "Action on failure: `pause`, `continue` or `rollback`.",
+				},
+				"monitor":           dockerDurationSchema("Time each task is monitored for failure after it changed.", false),
+				"max_failure_ratio": {Type: schema.TypeFloat, Optional: true, Description: "Tolerated ratio of failed tasks (0 to 1)."},
+				"order": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      "stop-first",
+					ValidateFunc: validation.StringInSlice([]string{"stop-first", "start-first"}, false),
+					Description:  "`stop-first` or `start-first`.",
+				},
+			},
+		},
+	}
+}
+
+// dockerServiceFileReferenceSchema describes a secret or config mounted as a
+// file in the tasks, e.g. referencing portainer_docker_secret.
+func dockerServiceFileReferenceSchema(kind string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		Description: fmt.Sprintf("Swarm %s exposed to the tasks as a file.", kind),
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				kind + "_id":   {Type: schema.TypeString, Required: true, Description: fmt.Sprintf("ID of the %s (e.g. `portainer_docker_%s.x.id`).", kind, kind)},
+				kind + "_name": {Type: schema.TypeString, Required: true, Description: fmt.Sprintf("Name of the %s.", kind)},
+				"file_name":    {Type: schema.TypeString, Required: true, Description: fmt.Sprintf("File name or absolute path of the %s inside the tasks.", kind)},
+				"uid":          {Type: schema.TypeString, Optional: true, Default: "0", Description: "UID owning the file."},
+				"gid":          {Type: schema.TypeString, Optional: true, Default: "0", Description: "GID owning the file."},
+				"mode":         {Type: schema.TypeInt, Optional: true, Default: 292, Description: "File mode in decimal (292 = 0444)."},
+			},
+		},
+	}
+}
+
+type dockerServiceInspect struct {
+	ID      string `json:"ID"`
+	Version struct {
+		Index int `json:"Index"`
+	} `json:"Version"`
+	Spec         map[string]interface{} `json:"Spec"`
+	UpdateStatus *struct {
+		State   string `json:"State"`
+		Message string `json:"Message"`
+	} `json:"UpdateStatus"`
+	Portainer struct {
+		ResourceControl struct {
+			Id int `json:"Id"`
+		} `json:"ResourceControl"`
+	} `json:"Portainer"`
+}
+
+func dockerServiceRegistryHeaders(d *schema.ResourceData) map[string]string {
+	auth := []byte(`{}`)
+	if v, ok := d.GetOk("registry_id"); ok {
+		auth, _ = json.Marshal(map[string]int{"registryId": v.(int)})
+	}
+	return map[string]string{"X-Registry-Auth": base64.StdEncoding.EncodeToString(auth)}
+}
+
+func dockerServiceUpdateConfig(raw []interface{}) map[string]interface{} {
+	if len(raw) == 0 || raw[0] == nil {
+		return nil
+	}
+	c := raw[0].(map[string]interface{})
+	return map[string]interface{}{
+		"Parallelism":     c["parallelism"].(int),
+		"Delay":           dockerDurationNanos(c["delay"].(string)),
+		"FailureAction":   c["failure_action"].(string),
+		"Monitor":         dockerDurationNanos(c["monitor"].(string)),
+		"MaxFailureRatio": c["max_failure_ratio"].(float64),
+		"Order":           c["order"].(string),
+	}
+}
+
+func buildDockerServiceSpec(d *schema.ResourceData) map[string]interface{} {
+	containerSpec := map[string]interface{}{
+		"Image": d.Get("image").(string),
+	}
+	if v, ok := d.GetOk("command"); ok {
+		containerSpec["Command"] = v.([]interface{})
+	}
+	if v, ok := d.GetOk("args"); ok {
+		containerSpec["Args"] = v.([]interface{})
+	}
+	if v, ok := d.GetOk("env"); ok {
+		env := []string{}
+		for k, val := range v.(map[string]interface{}) {
+			env = append(env, k+"="+val.(string))
+		}
+		sort.Strings(env)
+		containerSpec["Env"] = env
+	}
+	for kind, field := range map[string]string{"secret": "Secret", "config": "Config"} {
+		refs := []map[string]interface{}{}
+		for _, r := range d.Get(kind).([]interface{}) {
+			ref := r.(map[string]interface{})
+			refs = append(refs, map[string]interface{}{
+				"File": map[string]interface{}{
+					"Name": ref["file_name"].(string),
+					"UID":  ref["uid"].(string),
+					"GID":  ref["gid"].(string),
+					"Mode": ref["mode"].(int),
+				},
+				field + "ID":   ref[kind+"_id"].(string),
+				field + "Name": ref[kind+"_name"].(string),
+			})
+		}
+		if len(refs) > 0 {
+			containerSpec[field+"s"] = refs
+		}
+	}
+
+	taskTemplate := map[string]interface{}{
+		"ContainerSpec": containerSpec,
+	}
+
+	if p := d.Get("placement").([]interface{}); len(p) > 0 && p[0] != nil {
+		placement := p[0].(map[string]interface{})
+		prefs := []map[string]interface{}{}
+		for _, pref := range placement["preferences"].([]interface{}) {
+			prefs = append(prefs, map[string]interface{}{"Spread": map[string]string{"SpreadDescriptor": pref.(string)}})
+		}
+		taskTemplate["Placement"] = map[string]interface{}{
+			"Constraints": placement["constraints"].([]interface{}),
+			"Preferences": prefs,
+			"MaxReplicas": placement["max_replicas"].(int),
+		}
+	}
+
+	if r := d.Get("resources").([]interface{}); len(r) > 0 && r[0] != nil {
+		res := r[0].(map[string]interface{})
+		taskTemplate["Resources"] = map[string]interface{}{
+			"Limits": map[string]int64{
+				"NanoCPUs":    int64(math.Round(res["limit_cpus"].(float64) * 1e9)),
+				"MemoryBytes": int64(res["limit_memory"].(int)) * 1024 * 1024,
+			},
+			"Reservations": map[string]int64{
+				"NanoCPUs":    int64(math.Round(res["reserve_cpus"].(float64) * 1e9)),
+				"MemoryBytes": int64(res["reserve_memory"].(int)) * 1024 * 1024,
+			},
+		}
+	}
+
+	networks := []map[string]interface{}{}
+	for _, n := range d.Get("network").([]interface{}) {
+		network := n.(map[string]interface{})
+		item := map[string]interface{}{"Target": network["name"].(string)}
+		if aliases := network["aliases"].([]interface{}); len(aliases) > 0 {
+			item["Aliases"] = aliases
+		}
+		networks = append(networks, item)
+	}
+	if len(networks) > 0 {
+		taskTemplate["Networks"] = networks
+	}
+
+	spec := map[string]interface{}{
+		"Name":         d.Get("name").(string),
+		"TaskTemplate": taskTemplate,
+	}
+	if v, ok := d.GetOk("labels"); ok {
+		spec["Labels"] = v.(map[string]interface{})
+	}
+
+	if d.Get("mode").(string) == "global" {
+		spec["Mode"] = map[string]interface{}{"Global": map[string]interface{}{}}
+	} else {
+		spec["Mode"] = map[string]interface{}{"Replicated": map[string]interface{}{"Replicas": d.Get("replicas").(int)}}
+	}
+
+	if c := dockerServiceUpdateConfig(d.Get("update_config").([]interface{})); c != nil {
+		spec["UpdateConfig"] = c
+	}
+	if c := dockerServiceUpdateConfig(d.Get("rollback_config").([]interface{})); c != nil {
+		spec["RollbackConfig"] = c
+	}
+
+	ports := []map[string]interface{}{}
+	for _, p := range d.Get("port").([]interface{}) {
+		port := p.(map[string]interface{})
+		ports = append(ports, map[string]interface{}{
+			"Protocol":      port["protocol"].(string),
+			"TargetPort":    port["target_port"].(int),
+			"PublishedPort": port["published_port"].(int),
+			"PublishMode":   port["publish_mode"].(string),
+		})
+	}
+	if len(ports) > 0 {
+		spec["EndpointSpec"] = map[string]interface{}{"Mode": "vip", "Ports": ports}
+	}
+
+	return spec
+}
+
+func dockerDurationNanos(v string) int64 {
+	if v == "" {
+		return 0
+	}
+	dur, err := time.ParseDuration(v)
+	if err != nil {
+		return 0
+	}
+	return dur.Nanoseconds()
+}
+
+func inspectDockerService(client *APIClient, endpointID int, id string) (*dockerServiceInspect, int, error) {
+	path := fmt.Sprintf("/endpoints/%d/docker/services/%s", endpointID, id)
+	resp, err := client.DoRequest(http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read docker service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, resp.StatusCode, fmt.Errorf("failed to read docker service: %s", string(body))
+	}
+
+	var result dockerServiceInspect
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("failed to decode docker service response: %w", err)
+	}
+	return &result, resp.StatusCode, nil
+}
+
+// waitForDockerServiceConvergence waits until Swarm finished updating the
+// service (UpdateStatus) and every task it wants running, i.e. a task of the
+// current service version, is running. It fails when Swarm paused or rolled
+// back the update.
+func waitForDockerServiceConvergence(d *schema.ResourceData, client *APIClient) error {
+	if !d.Get("wait_for_convergence").(bool) {
+		return nil
+	}
+	endpointID := d.Get("endpoint_id").(int)
+
+	minTasks := 0
+	if d.Get("mode").(string) != "global" {
+		minTasks = d.Get("replicas").(int)
+		if minTasks == 0 {
+			return nil
+		}
+	}
+
+	maxRetries := d.Get("max_retries").(int)
+	waitBetween := time.Duration(d.Get("wait_between_checks").(int)) * time.Second
+	var out strings.Builder
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		service, _, err := inspectDockerService(client, endpointID, d.Id())
+		if err != nil {
+			return err
+		}
+		updateState := ""
+		if s := service.UpdateStatus; s != nil {
+			if s.State == "paused" || strings.HasPrefix(s.State, "rollback") {
+				return fmt.Errorf("update of docker service %s ended in state %q: %s", d.Get("name").(string), s.State, s.Message)
+			}
+			updateState = s.State
+		}
+
+		running, total, code, err := matchSwarmTasks(client, endpointID, d.Id(), "running", func(task map[string]interface{}) bool {
+			state, _ := mustMap(task["Status"])["State"].(string)
+			return state == "running"
+		})
+		if err != nil {
+			return err
+		}
+		switch {
+		case code != http.StatusOK:
+			out.WriteString(fmt.Sprintf("Attempt %d/%d: failed to fetch tasks (status %d)\n", attempt, maxRetries, code))
+		case updateState == "updating":
+			out.WriteString(fmt.Sprintf("Attempt %d/%d: update in progress, %d/%d tasks running\n", attempt, maxRetries, running, total))
+		case total > 0 && running == total && running >= minTasks:
+			return nil
+		default:
+			out.WriteString(fmt.Sprintf("Attempt %d/%d: %d/%d tasks running\n", attempt, maxRetries, running, total))
+		}
+		time.Sleep(waitBetween)
+	}
+	return fmt.Errorf("service %q did not reach state \"running\" after %d retries\n%s", d.Id(), maxRetries, out.String())
+}
+
+func resourceDockerServiceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+
+	path := fmt.Sprintf("/endpoints/%d/docker/services/create", endpointID)
+	resp, err := client.DoRequest(http.MethodPost, path, dockerServiceRegistryHeaders(d), buildDockerServiceSpec(d))
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to create docker service: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return diag.FromErr(fmt.Errorf("failed to create docker service: %s", string(body)))
+	}
+
+	var response struct {
+		ID        string `json:"ID"`
+		Portainer struct {
+			ResourceControl struct {
+				Id int `json:"Id"`
+			} `json:"ResourceControl"`
+		} `json:"Portainer"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to decode docker service create response: %w", err))
+	}
+	d.SetId(response.ID)
+	if response.Portainer.ResourceControl.Id != 0 {
+		_ = d.Set("resource_control_id", response.Portainer.ResourceControl.Id)
+	}
+
+	if err := waitForDockerServiceConvergence(d, client); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceDockerServiceRead(ctx, d, meta)
+}
+
+func resourceDockerServiceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+
+	service, status, err := inspectDockerService(client, endpointID, d.Id())
+	if status == http.StatusNotFound {
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var spec struct {
+		Name         string            `json:"Name"`
+		Labels       map[string]string `json:"Labels"`
+		TaskTemplate struct {
+			ContainerSpec struct {
+				Image   string   `json:"Image"`
+				Command []string `json:"Command"`
+				Args    []string `json:"Args"`
+				Env     []string `json:"Env"`
+				Secrets []struct {
+					File       dockerServiceFile `json:"File"`
+					SecretID   string            `json:"SecretID"`
+					SecretName string            `json:"SecretName"`
+				} `json:"Secrets"`
+				Configs []struct {
+					File       dockerServiceFile `json:"File"`
+					ConfigID   string            `json:"ConfigID"`
+					ConfigName string            `json:"ConfigName"`
+				} `json:"Configs"`
+			} `json:"ContainerSpec"`
+			Placement *struct {
+				Constraints []string `json:"Constraints"`
+				Preferences []struct {
+					Spread struct {
+						SpreadDescriptor string `json:"SpreadDescriptor"`
+					} `json:"Spread"`
+				} `json:"Preferences"`
+				MaxReplicas int `json:"MaxReplicas"`
+			} `json:"Placement"`
+			Resources *struct {
+				Limits       dockerServiceResources `json:"Limits"`
+				Reservations dockerServiceResources `json:"Reservations"`
+			} `json:"Resources"`
+			Networks []struct {
+				Target  string   `json:"Target"`
+				Aliases []string `json:"Aliases"`
+			} `json:"Networks"`
+		} `json:"TaskTemplate"`
+		Mode struct {
+			Replicated *struct {
+				Replicas int `json:"Replicas"`
+			} `json:"Replicated"`
+			Global *struct{} `json:"Global"`
+		} `json:"Mode"`
+		UpdateConfig   *dockerServiceUpdateSpec `json:"UpdateConfig"`
+		RollbackConfig *dockerServiceUpdateSpec `json:"RollbackConfig"`
+		EndpointSpec   struct {
+			Ports []struct {
+				Protocol      string `json:"Protocol"`
+				TargetPort    int    `json:"TargetPort"`
+				PublishedPort int    `json:"PublishedPort"`
+				PublishMode   string `json:"PublishMode"`
+			} `json:"Ports"`
+		} `json:"EndpointSpec"`
+	}
+	raw, _ := json.Marshal(service.Spec)
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to decode docker service spec: %w", err))
+	}
+	cs := spec.TaskTemplate.ContainerSpec
+
+	_ = d.Set("name", spec.Name)
+
+	// Swarm pins the digest it resolved; ignore it unless the image was
+	// configured with one.
+	image := cs.Image
+	if !strings.Contains(d.Get("image").(string), "@") {
+		image = strings.SplitN(image, "@", 2)[0]
+	}
+	_ = d.Set("image", image)
+	_ = d.Set("command", cs.Command)
+	_ = d.Set("args", cs.Args)
+
+	env := map[string]interface{}{}
+	for _, kv := range cs.Env {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+	_ = d.Set("env", env)
+	_ = d.Set("labels", spec.Labels)
+
+	if spec.Mode.Global != nil {
+		_ = d.Set("mode", "global")
+	} else {
+		_ = d.Set("mode", "replicated")
+		if spec.Mode.Replicated != nil {
+			_ = d.Set("replicas", spec.Mode.Replicated.Replicas)
+		}
+	}
+
+	// Swarm fills in defaults for the strategies, so they are only
+	// refreshed when configured.
+	if len(d.Get("update_config").([]interface{})) > 0 {
+		_ = d.Set("update_config", flattenDockerServiceUpdateConfig(spec.UpdateConfig))
+	}
+	if len(d.Get("rollback_config").([]interface{})) > 0 {
+		_ = d.Set("rollback_config", flattenDockerServiceUpdateConfig(spec.RollbackConfig))
+	}
+
+	if p := spec.TaskTemplate.Placement; p != nil && (len(p.Constraints) > 0 || len(p.Preferences) > 0 || p.MaxReplicas > 0) {
+		prefs := []string{}
+		for _, pref := range p.Preferences {
+			prefs = append(prefs, pref.Spread.SpreadDescriptor)
+		}
+		_ = d.Set("placement", []interface{}{map[string]interface{}{
+			"constraints":  p.Constraints,
+			"preferences":  prefs,
+			"max_replicas": p.MaxReplicas,
+		}})
+	} else {
+		_ = d.Set("placement", nil)
+	}
+
+	if r := spec.TaskTemplate.Resources; r != nil && (r.Limits != dockerServiceResources{} || r.Reservations != dockerServiceResources{}) {
+		_ = d.Set("resources", []interface{}{map[string]interface{}{
+			"limit_cpus":     float64(r.Limits.NanoCPUs) / 1e9,
+			"limit_memory":   int(r.Limits.MemoryBytes / (1024 * 1024)),
+			"reserve_cpus":   float64(r.Reservations.NanoCPUs) / 1e9,
+			"reserve_memory": int(r.Reservations.MemoryBytes / (1024 * 1024)),
+		}})
+	} else {
+		_ = d.Set("resources", nil)
+	}
+
+	secrets := []interface{}{}
+	for _, s := range cs.Secrets {
+		secrets = append(secrets, s.File.flatten("secret", s.SecretID, s.SecretName))
+	}
+	_ = d.Set("secret", secrets)
+	configs := []interface{}{}
+	for _, c := range cs.Configs {
+		configs = append(configs, c.File.flatten("config", c.ConfigID, c.ConfigName))
+	}
+	_ = d.Set("config", configs)
+
+	ports := []interface{}{}
+	for _, p := range spec.EndpointSpec.Ports {
+		ports = append(ports, map[string]interface{}{
+			"target_port":    p.TargetPort,
+			"published_port": p.PublishedPort,
+			"protocol":       p.Protocol,
+			"publish_mode":   p.PublishMode,
+		})
+	}
+	_ = d.Set("port", ports)
+
+	// Swarm stores network IDs; keep the configured name when it resolves
+	// to the attached network.
+	configured := map[string]string{}
+	for _, n := range d.Get("network").([]interface{}) {
+		name := n.(map[string]interface{})["name"].(string)
+		configured[dockerNetworkID(client, endpointID, name)] = name
+	}
+	networks := []interface{}{}
+	for _, n := range spec.TaskTemplate.Networks {
+		name := n.Target
+		if v, ok := configured[n.Target]; ok {
+			name = v
+		}
+		networks = append(networks, map[string]interface{}{
+			"name":    name,
+			"aliases": n.Aliases,
+		})
+	}
+	_ = d.Set("network", networks)
+
+	if service.Portainer.ResourceControl.Id != 0 {
+		_ = d.Set("resource_control_id", service.Portainer.ResourceControl.Id)
+	}
+
+	return nil
+}
+
+type dockerServiceFile struct {
+	Name string `json:"Name"`
+	UID  string `json:"UID"`
+	GID  string `json:"GID"`
+	Mode int    `json:"Mode"`
+}
+
+func (f dockerServiceFile) flatten(kind, id, name string) map[string]interface{} {
+	return map[string]interface{}{
+		kind + "_id":   id,
+		kind + "_name": name,
+		"file_name":    f.Name,
+		"uid":          f.UID,
+		"gid":          f.GID,
+		"mode":         f.Mode,
+	}
+}
+
+type dockerServiceResources struct {
+	NanoCPUs    int64 `json:"NanoCPUs"`
+	MemoryBytes int64 `json:"MemoryBytes"`
+}
+
+type dockerServiceUpdateSpec struct {
+	Parallelism     int     `json:"Parallelism"`
+	Delay           int64   `json:"Delay"`
+	FailureAction   string  `json:"FailureAction"`
+	Monitor         int64   `json:"Monitor"`
+	MaxFailureRatio float64 `json:"MaxFailureRatio"`
+	Order           string  `json:"Order"`
+}
+
+func flattenDockerServiceUpdateConfig(c *dockerServiceUpdateSpec) []interface{} {
+	if c == nil {
+		return nil
+	}
+	item := map[string]interface{}{
+		"parallelism":       c.Parallelism,
+		"failure_action":    c.FailureAction,
+		"max_failure_ratio": c.MaxFailureRatio,
+		"order":             c.Order,
+	}
+	if c.Delay != 0 {
+		item["delay"] = time.Duration(c.Delay).String()
+	}
+	if c.Monitor != 0 {
+		item["monitor"] = time.Duration(c.Monitor).String()
+	}
+	return []interface{}{item}
+}
+
+// dockerNetworkID resolves a network name to its ID, returning the input
+// unchanged when the network cannot be inspected.
+func dockerNetworkID(client *APIClient, endpointID int, name string) string {
+	path := fmt.Sprintf("/endpoints/%d/docker/networks/%s", endpointID, url.PathEscape(name))
+	resp, err := client.DoRequest(http.MethodGet, path, nil, nil)
+	if err != nil {
+		return name
+	}
+	defer resp.Body.Close()
+
+	var network struct {
+		ID string `json:"Id"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&network) != nil || network.ID == "" {
+		return name
+	}
+	return network.ID
+}
+
+func resourceDockerServiceUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+
+	if d.HasChangesExcept("wait_for_convergence", "wait_between_checks", "max_retries") {
+		service, _, err := inspectDockerService(client, endpointID, d.Id())
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		path := fmt.Sprintf("/endpoints/%d/docker/services/%s/update?version=%d", endpointID, d.Id(), service.Version.Index)
+		resp, err := client.DoRequest(http.MethodPost, path, dockerServiceRegistryHeaders(d), buildDockerServiceSpec(d))
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("failed to update docker service: %w", err))
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			return diag.FromErr(fmt.Errorf("failed to update docker service: %s", string(body)))
+		}
+
+		if err := waitForDockerServiceConvergence(d, client); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return resourceDockerServiceRead(ctx, d, meta)
+}
+
+func resourceDockerServiceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+
+	path := fmt.Sprintf("/endpoints/%d/docker/services/%s", endpointID, d.Id())
+	resp, err := client.DoRequest(http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to delete docker service: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		body, _ := io.ReadAll(resp.Body)
+		return diag.FromErr(fmt.Errorf("failed to delete docker service: %s", string(body)))
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,283 @@
+package internal
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+var dockerServiceTestTemplate = map[string]interface{}{
+	"ContainerSpec": map[string]interface{}{
+		"Image": "nginx:1.27@sha256:abcd",
+		"Env":   []string{"APP_ENV=prod"},
+		"Secrets": []map[string]interface{}{{
+			"File":       map[string]interface{}{"Name": "db_password", "UID": "0", "GID": "0", "Mode": 292},
+			"SecretID":   "sec1",
+			"SecretName": "db_password",
+		}},
+	},
+	"Placement": map[string]interface{}{
+		"Constraints": []string{"node.role==worker"},
+		"Preferences": []map[string]interface{}{{"Spread": map[string]string{"SpreadDescriptor": "node.labels.zone"}}},
+	},
+	"Networks": []map[string]interface{}{{"Target": "netid1", "Aliases": []string{"web"}}},
+}
+
+func dockerServiceInspectResponse(updateState string) map[string]interface{} {
+	resp := map[string]interface{}{
+		"ID":      "svc1",
+		"Version": map[string]int{"Index": 42},
+		"Spec": map[string]interface{}{
+			"Name":         "web",
+			"Labels":       map[string]string{"team": "web"},
+			"TaskTemplate": dockerServiceTestTemplate,
+			"Mode":         map[string]interface{}{"Replicated": map[string]int{"Replicas": 2}},
+			"UpdateConfig": map[string]interface{}{"Parallelism": 1, "Delay": 10000000000, "FailureAction": "rollback", "Order": "start-first"},
+			"EndpointSpec": map[string]interface{}{
+				"Ports": []map[string]interface{}{{"Protocol": "tcp", "TargetPort": 80, "PublishedPort": 8080, "PublishMode": "ingress"}},
+			},
+		},
+		"Portainer": map[string]interface{}{"ResourceControl": map[string]int{"Id": 21}},
+	}
+	if updateState != "" {
+		resp["UpdateStatus"] = map[string]string{"State": updateState, "Message": "update rolled back due to failure"}
+	}
+	return resp
+}
+
+func registerDockerServiceMocks(mock *MockServer, updateState string) {
+	task := map[string]interface{}{"Spec": dockerServiceTestTemplate, "Status": map[string]string{"State": "running"}}
+	mock.On("GET", "/endpoints/1/docker/services/svc1", RespondJSON(http.StatusOK, dockerServiceInspectResponse(updateState)))
+	mock.On("GET", "/endpoints/1/docker/tasks", RespondJSON(http.StatusOK, []map[string]interface{}{task, task}))
+	mock.On("GET", "/endpoints/1/docker/networks/frontend", RespondJSON(http.StatusOK, map[string]string{"Id": "netid1"}))
+}
+
+func newDockerServiceTestData(t *testing.T) *schema.ResourceData {
+	return schema.TestResourceDataRaw(t, resourceDockerService().Schema, map[string]interface{}{
+		"endpoint_id":         1,
+		"name":                "web",
+		"image":               "nginx:1.27",
+		"replicas":            2,
+		"env":                 map[string]interface{}{"APP_ENV": "prod"},
+		"labels":              map[string]interface{}{"team": "web"},
+		"wait_between_checks": 0,
+		"max_retries":         1,
+		"update_config": []interface{}{map[string]interface{}{
+			"delay":          "10s",
+			"failure_action": "rollback",
+			"order":          "start-first",
+		}},
+		"placement": []interface{}{map[string]interface{}{
+			"constraints": []interface{}{"node.role==worker"},
+			"preferences": []interface{}{"node.labels.zone"},
+		}},
+		"secret": []interface{}{map[string]interface{}{
+			"secret_id":   "sec1",
+			"secret_name": "db_password",
+			"file_name":   "db_password",
+		}},
+		"port":    []interface{}{map[string]interface{}{"target_port": 80, "published_port": 8080}},
+		"network": []interface{}{map[string]interface{}{"name": "frontend", "aliases": []interface{}{"web"}}},
+	})
+}
+
+// TestDockerServiceCreate sends a Swarm service spec and waits until the
+// tasks run the current task template.
+func TestDockerServiceCreate(t *testing.T) {
+	mock := NewMockServer(t)
+	registerDockerServiceMocks(mock, "")
+	mock.On("POST", "/endpoints/1/docker/services/create", RespondJSON(http.StatusCreated, map[string]interface{}{
+		"ID":        "svc1",
+		"Portainer": map[string]interface{}{"ResourceControl": map[string]int{"Id": 21}},
+	}))
+
+	r := resourceDockerService()
+	d := newDockerServiceTestData(t)
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if d.Id() != "svc1" || d.Get("resource_control_id").(int) != 21 {
+		t.Errorf("unexpected state: id=%s rc=%v", d.Id(), d.Get("resource_control_id"))
+	}
+
+	var spec struct {
+		Name         string
+		TaskTemplate struct {
+			ContainerSpec struct {
+				Env     []string
+				Secrets []struct {
+					SecretID string
+					File     struct{ Mode int }
+				}
+			}
+			Placement struct {
+				Preferences []struct {
+					Spread struct{ SpreadDescriptor string }
+				}
+			}
+			Networks []struct{ Target string }
+		}
+		Mode struct {
+			Replicated struct{ Replicas int }
+		}
+		UpdateConfig struct {
+			Delay         int64
+			FailureAction string
+			Order         string
+		}
+		EndpointSpec struct {
+			Ports []struct {
+				TargetPort    int
+				PublishedPort int
+				PublishMode   string
+			}
+		}
+	}
+	if err := mock.FindRequest("POST", "/endpoints/1/docker/services/create").DecodeJSON(&spec); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+	cs := spec.TaskTemplate.ContainerSpec
+	if spec.Name != "web" || spec.Mode.Replicated.Replicas != 2 || cs.Env[0] != "APP_ENV=prod" {
+		t.Errorf("unexpected spec: %+v", spec)
+	}
+	if len(cs.Secrets) != 1 || cs.Secrets[0].SecretID != "sec1" || cs.Secrets[0].File.Mode != 292 {
+		t.Errorf("unexpected secrets: %+v", cs.Secrets)
+	}
+	if spec.TaskTemplate.Placement.Preferences[0].Spread.SpreadDescriptor != "node.labels.zone" || spec.TaskTemplate.Networks[0].Target != "frontend" {
+		t.Errorf("unexpected task template: %+v", spec.TaskTemplate)
+	}
+	if spec.UpdateConfig.Delay != 10e9 || spec.UpdateConfig.Order != "start-first" {
+		t.Errorf("unexpected update config: %+v", spec.UpdateConfig)
+	}
+	if p := spec.EndpointSpec.Ports[0]; p.TargetPort != 80 || p.PublishedPort != 8080 || p.PublishMode != "ingress" {
+		t.Errorf("unexpected port: %+v", p)
+	}
+
+	tasks := mock.FindRequest("GET", "/endpoints/1/docker/tasks")
+	if tasks == nil || !strings.Contains(tasks.Query, "svc1") {
+		t.Errorf("expected task polling for the service ID, got %+v", tasks)
+	}
+}
+
+// TestDockerServiceRead_Drift keeps the configured image and network names
+// when Swarm reports a pinned digest and network IDs.
+func TestDockerServiceRead_Drift(t *testing.T) {
+	mock := NewMockServer(t)
+	registerDockerServiceMocks(mock, "")
+
+	r := resourceDockerService()
+	d := newDockerServiceTestData(t)
+	d.SetId("svc1")
+
+	if err := rcRead(r, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if d.Get("image") != "nginx:1.27" {
+		t.Errorf("expected digest to be ignored, got %v", d.Get("image"))
+	}
+	if d.Get("network.0.name") != "frontend" {
+		t.Errorf("expected configured network name, got %v", d.Get("network.0.name"))
+	}
+	if d.Get("update_config.0.delay") != "10s" || d.Get("replicas").(int) != 2 {
+		t.Errorf("unexpected refresh: delay=%v replicas=%v", d.Get("update_config.0.delay"), d.Get("replicas"))
+	}
+	if d.Get("secret.0.secret_id") != "sec1" || d.Get("placement.0.constraints.0") != "node.role==worker" {
+		t.Errorf("unexpected secret/placement: %v %v", d.Get("secret"), d.Get("placement"))
+	}
+}
+
+// TestDockerServiceUpdate_RolledBack posts the spec with the current version
+// and reports a rollback performed by Swarm as an error.
+func TestDockerServiceUpdate_RolledBack(t *testing.T) {
+	mock := NewMockServer(t)
+	registerDockerServiceMocks(mock, "rollback_completed")
+	mock.On("POST", "/endpoints/1/docker/services/svc1/update", RespondJSON(http.StatusOK, map[string]interface{}{"Warnings": nil}))
+
+	r := resourceDockerService()
+	d := newDockerServiceTestData(t)
+	d.SetId("svc1")
+
+	err := rcUpdate(r, d, mock.Client())
+	if err == nil || !strings.Contains(err.Error(), "rollback_completed") {
+		t.Fatalf("expected rollback error, got %v", err)
+	}
+	update := mock.FindRequest("POST", "/endpoints/1/docker/services/svc1/update")
+	if update == nil || update.Query != "version=42" {
+		t.Errorf("expected update with version=42, got %+v", update)
+	}
+}
+
+// TestDockerServiceCreate_NotConverged fails when the tasks do not run the
+// current spec within max_retries.
+func TestDockerServiceCreate_NotConverged(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("POST", "/endpoints/1/docker/services/create", RespondJSON(http.StatusCreated, map[string]string{"ID": "svc1"}))
+	mock.On("GET", "/endpoints/1/docker/services/svc1", RespondJSON(http.StatusOK, dockerServiceInspectResponse("")))
+	mock.On("GET", "/endpoints/1/docker/tasks", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{"Spec": dockerServiceTestTemplate, "Status": map[string]string{"State": "preparing"}},
+	}))
+
+	r := resourceDockerService()
+	d := newDockerServiceTestData(t)
+
+	err := rcCreate(r, d, mock.Client())
+	if err == nil || !strings.Contains(err.Error(), "did not reach") {
+		t.Fatalf("expected convergence error, got %v", err)
+	}
+}
+
+// TestDockerServiceUpdate_Convergence decides convergence from the update
+// state of the service and the running tasks, not from the task specs, which
+// Docker fills with defaults.
+func TestDockerServiceUpdate_Convergence(t *testing.T) {
+	for _, tc := range []struct {
+		updateState string
+		wantErr     bool
+	}{
+		{"completed", false},
+		{"updating", true},
+	} {
+		mock := NewMockServer(t)
+		task := map[string]interface{}{
+			"Spec":         map[string]interface{}{"ContainerSpec": map[string]interface{}{"Image": "nginx:1.27@sha256:abcd", "Isolation": "default"}},
+			"DesiredState": "running",
+			"Status":       map[string]string{"State": "running"},
+		}
+		mock.On("GET", "/endpoints/1/docker/services/svc1", RespondJSON(http.StatusOK, dockerServiceInspectResponse(tc.updateState)))
+		mock.On("GET", "/endpoints/1/docker/tasks", RespondJSON(http.StatusOK, []map[string]interface{}{task, task}))
+		mock.On("GET", "/endpoints/1/docker/networks/frontend", RespondJSON(http.StatusOK, map[string]string{"Id": "netid1"}))
+		mock.On("POST", "/endpoints/1/docker/services/svc1/update", RespondJSON(http.StatusOK, map[string]interface{}{"Warnings": nil}))
+
+		r := resourceDockerService()
+		d := newDockerServiceTestData(t)
+		d.SetId("svc1")
+
+		err := rcUpdate(r, d, mock.Client())
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: expected error=%v, got %v", tc.updateState, tc.wantErr, err)
+		}
+		if tc.wantErr && !strings.Contains(err.Error(), "update in progress") {
+			t.Errorf("%s: unexpected error %v", tc.updateState, err)
+		}
+	}
+}
+
+// TestDockerServiceDelete treats a missing service as deleted.
+func TestDockerServiceDelete(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("DELETE", "/endpoints/1/docker/services/svc1", RespondJSON(http.StatusNotFound, nil))
+
+	r := resourceDockerService()
+	d := newDockerServiceTestData(t)
+	d.SetId("svc1")
+
+	if err := rcDelete(r, d, mock.Client()); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if d.Id() != "" {
+		t.Error("expected ID to be cleared")
+	}
+}
@@ -0,0 +1,312 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceDockerSwarm() *schema.Resource {
+	dispatcherHeartbeat := dockerDurationSchema("Interval at which nodes report their status to the managers (e.g. `5s`).", false)
+	dispatcherHeartbeat.Computed = true
+	nodeCertExpiry := dockerDurationSchema("Validity of the node certificates issued by the Swarm CA (e.g. `2160h`).", false)
+	nodeCertExpiry.Computed = true
+
+	return &schema.Resource{
+		CreateContext: resourceDockerSwarmApply,
+		ReadContext:   resourceDockerSwarmRead,
+		UpdateContext: resourceDockerSwarmApply,
+		DeleteContext: resourceDockerSwarmDelete,
+		Schema: map[string]*schema.Schema{
+			"endpoint_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the Portainer environment (Docker Swarm cluster) whose settings are managed.",
+			},
+			"task_history_retention_limit": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				Description: "Number of historic tasks kept per task slot.",
+			},
+			"snapshot_interval": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				Description: "Number of Raft log entries between snapshots.",
+			},
+			"keep_old_snapshots": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				Description: "Number of old Raft snapshots kept beside the current one.",
+			},
+			"log_entries_for_slow_followers": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				Description: "Number of Raft log entries kept to sync up slow followers after a snapshot.",
+			},
+			"dispatcher_heartbeat_period": dispatcherHeartbeat,
+			"node_cert_expiry":            nodeCertExpiry,
+			"ca_force_rotate": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				Description: "Counter forcing a rotation of the Swarm root CA. Increment it to rotate the CA and reissue every node certificate.",
+			},
+			"autolock_managers": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Computed:    true,
+				Description: "Whether manager Raft data is encrypted and managers need the unlock key after a restart.",
+			},
+			// Computed attributes
+			"swarm_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "ID of the Swarm cluster.",
+			},
+			"version": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Version of the Swarm object.",
+			},
+			"root_rotation_in_progress": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether a root CA rotation is still in progress.",
+			},
+			"unlock_key": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Key unlocking the managers when `autolock_managers` is enabled.",
+			},
+		},
+	}
+}
+
+type dockerSwarmSpec struct {
+	Orchestration struct {
+		TaskHistoryRetentionLimit int64 `json:"TaskHistoryRetentionLimit"`
+	} `json:"Orchestration"`
+	Raft struct {
+		SnapshotInterval           int64 `json:"SnapshotInterval"`
+		KeepOldSnapshots           int64 `json:"KeepOldSnapshots"`
+		LogEntriesForSlowFollowers int64 `json:"LogEntriesForSlowFollowers"`
+		ElectionTick               int   `json:"ElectionTick"`
+		HeartbeatTick              int   `json:"HeartbeatTick"`
+	} `json:"Raft"`
+	Dispatcher struct {
+		HeartbeatPeriod int64 `json:"HeartbeatPeriod"`
+	} `json:"Dispatcher"`
+	CAConfig struct {
+		NodeCertExpiry int64 `json:"NodeCertExpiry"`
+		ForceRotate    int64 `json:"ForceRotate"`
+	} `json:"CAConfig"`
+	EncryptionConfig struct {
+		AutoLockManagers bool `json:"AutoLockManagers"`
+	} `json:"EncryptionConfig"`
+}
+
+type dockerSwarmInspect struct {
+	ID        string `json:"ID"`
+	CreatedAt string `json:"CreatedAt"`
+	UpdatedAt string `json:"UpdatedAt"`
+	Version   struct {
+		Index int `json:"Index"`
+	} `json:"Version"`
+	Spec       dockerSwarmSpec `json:"Spec"`
+	JoinTokens struct {
+		Worker  string `json:"Worker"`
+		Manager string `json:"Manager"`
+	} `json:"JoinTokens"`
+	RootRotationInProgress bool     `json:"RootRotationInProgress"`
+	DefaultAddrPool        []string `json:"DefaultAddrPool"`
+	SubnetSize             int      `json:"SubnetSize"`
+	DataPathPort           int      `json:"DataPathPort"`
+
+	// rawSpec keeps every field of the spec, including the ones not modelled
+	// above, so that updates send them back unchanged.
+	rawSpec map[string]interface{}
+}
+
+func inspectDockerSwarm(client *APIClient, endpointID int) (*dockerSwarmInspect, error) {
+	path := fmt.Sprintf("/endpoints/%d/docker/swarm", endpointID)
+	resp, err := client.DoRequest(http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect swarm: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to inspect swarm (is this a Swarm manager?), status %d: %s", resp.StatusCode, string(data))
+	}
+
+	var swarm dockerSwarmInspect
+	if err := json.Unmarshal(data, &swarm); err != nil {
+		return nil, fmt.Errorf("failed to decode swarm response: %w", err)
+	}
+	var raw struct {
+		Spec map[string]interface{} `json:"Spec"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode swarm spec: %w", err)
+	}
+	swarm.rawSpec = raw.Spec
+	if swarm.rawSpec == nil {
+		swarm.rawSpec = map[string]interface{}{}
+	}
+	return &swarm, nil
+}
+
+// setDockerSwarmSpec flattens the settings shared by the portainer_docker_swarm
+// resource and data source.
+func setDockerSwarmSpec(d *schema.ResourceData, swarm *dockerSwarmInspect) {
+	spec := swarm.Spec
+	_ = d.Set("swarm_id", swarm.ID)
+	_ = d.Set("version", swarm.Version.Index)
+	_ = d.Set("root_rotation_in_progress", swarm.RootRotationInProgress)
+	_ = d.Set("task_history_retention_limit", int(spec.Orchestration.TaskHistoryRetentionLimit))
+	_ = d.Set("snapshot_interval", int(spec.Raft.SnapshotInterval))
+	_ = d.Set("keep_old_snapshots", int(spec.Raft.KeepOldSnapshots))
+	_ = d.Set("log_entries_for_slow_followers", int(spec.Raft.LogEntriesForSlowFollowers))
+	_ = d.Set("dispatcher_heartbeat_period", time.Duration(spec.Dispatcher.HeartbeatPeriod).String())
+	_ = d.Set("node_cert_expiry", time.Duration(spec.CAConfig.NodeCertExpiry).String())
+	_ = d.Set("ca_force_rotate", int(spec.CAConfig.ForceRotate))
+	_ = d.Set("autolock_managers", spec.EncryptionConfig.AutoLockManagers)
+}
+
+func fetchDockerSwarmUnlockKey(client *APIClient, endpointID int) (string, error) {
+	path := fmt.Sprintf("/endpoints/%d/docker/swarm/unlockkey", endpointID)
+	resp, err := client.DoRequest(http.MethodGet, path, nil, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch swarm unlock key: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to fetch swarm unlock key, status %d: %s", resp.StatusCode, string(data))
+	}
+	var result struct {
+		UnlockKey string `json:"UnlockKey"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode swarm unlock key: %w", err)
+	}
+	return result.UnlockKey, nil
+}
+
+// dockerSwarmSetting reports whether a setting must be sent: it is either
+// written in the configuration or changed since the last apply.
+func dockerSwarmSetting(d *schema.ResourceData, key string) bool {
+	if d.HasChange(key) {
+		return true
+	}
+	raw, diags := d.GetRawConfigAt(cty.GetAttrPath(key))
+	return !diags.HasError() && raw.IsKnown() && !raw.IsNull()
+}
+
+func dockerSwarmSpecSection(spec map[string]interface{}, section string) map[string]interface{} {
+	if m, ok := spec[section].(map[string]interface{}); ok {
+		return m
+	}
+	m := map[string]interface{}{}
+	spec[section] = m
+	return m
+}
+
+func resourceDockerSwarmApply(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+
+	swarm, err := inspectDockerSwarm(client, endpointID)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	spec := swarm.rawSpec
+	for key, field := range map[string][2]string{
+		"task_history_retention_limit":   {"Orchestration", "TaskHistoryRetentionLimit"},
+		"snapshot_interval":              {"Raft", "SnapshotInterval"},
+		"keep_old_snapshots":             {"Raft", "KeepOldSnapshots"},
+		"log_entries_for_slow_followers": {"Raft", "LogEntriesForSlowFollowers"},
+		"ca_force_rotate":                {"CAConfig", "ForceRotate"},
+	} {
+		if dockerSwarmSetting(d, key) {
+			dockerSwarmSpecSection(spec, field[0])[field[1]] = d.Get(key).(int)
+		}
+	}
+	for key, field := range map[string][2]string{
+		"dispatcher_heartbeat_period": {"Dispatcher", "HeartbeatPeriod"},
+		"node_cert_expiry":            {"CAConfig", "NodeCertExpiry"},
+	} {
+		if v := d.Get(key).(string); v != "" && dockerSwarmSetting(d, key) {
+			dur, err := time.ParseDuration(v)
+			if err != nil {
+				return diag.FromErr(fmt.Errorf("invalid %s %q: %w", key, v, err))
+			}
+			dockerSwarmSpecSection(spec, field[0])[field[1]] = dur.Nanoseconds()
+		}
+	}
+	if dockerSwarmSetting(d, "autolock_managers") {
+		dockerSwarmSpecSection(spec, "EncryptionConfig")["AutoLockManagers"] = d.Get("autolock_managers").(bool)
+	}
+
+	path := fmt.Sprintf("/endpoints/%d/docker/swarm/update?version=%d", endpointID, swarm.Version.Index)
+	resp, err := client.DoRequest(http.MethodPost, path, nil, spec)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to update swarm: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		data, _ := io.ReadAll(resp.Body)
+		return diag.FromErr(fmt.Errorf("failed to update swarm, status %d: %s", resp.StatusCode, string(data)))
+	}
+
+	d.SetId(fmt.Sprintf("%d-%s", endpointID, swarm.ID))
+	return resourceDockerSwarmRead(ctx, d, meta)
+}
+
+func resourceDockerSwarmRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+
+	swarm, err := inspectDockerSwarm(client, endpointID)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	setDockerSwarmSpec(d, swarm)
+
+	unlockKey := ""
+	if swarm.Spec.EncryptionConfig.AutoLockManagers {
+		if unlockKey, err = fetchDockerSwarmUnlockKey(client, endpointID); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	_ = d.Set("unlock_key", unlockKey)
+
+	d.SetId(fmt.Sprintf("%d-%s", endpointID, swarm.ID))
+	return nil
+}
+
+func resourceDockerSwarmDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// The Swarm itself is left untouched: its settings stay as last applied.
+	d.SetId("")
+	return nil
+}
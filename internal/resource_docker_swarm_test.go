@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dockerSwarmInspectResponse(autolock bool) map[string]interface{} {
+	return map[string]interface{}{
+		"ID":         "swarm1",
+		"Version":    map[string]int{"Index": 17},
+		"JoinTokens": map[string]string{"Worker": "SWMTKN-1-worker", "Manager": "SWMTKN-1-manager"},
+		"Spec": map[string]interface{}{
+			"Name":          "default",
+			"Labels":        map[string]string{"env": "prod"},
+			"Orchestration": map[string]interface{}{"TaskHistoryRetentionLimit": 5},
+			"Raft":          map[string]interface{}{"SnapshotInterval": 10000, "KeepOldSnapshots": 0, "LogEntriesForSlowFollowers": 500, "ElectionTick": 10, "HeartbeatTick": 1},
+			"Dispatcher":    map[string]interface{}{"HeartbeatPeriod": 5000000000},
+			"CAConfig":      map[string]interface{}{"NodeCertExpiry": int64(7776000000000000), "ExternalCAs": []interface{}{}},
+			"EncryptionConfig": map[string]interface{}{
+				"AutoLockManagers": autolock,
+			},
+		},
+		"DefaultAddrPool": []string{"10.0.0.0/8"},
+		"SubnetSize":      24,
+		"DataPathPort":    4789,
+	}
+}
+
+// TestDockerSwarmApply sends the full spec back with the changed settings and
+// the current version, and exposes the unlock key once autolock is enabled.
+func TestDockerSwarmApply(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/swarm", RespondJSON(http.StatusOK, dockerSwarmInspectResponse(true)))
+	mock.On("POST", "/endpoints/1/docker/swarm/update", RespondJSON(http.StatusOK, nil))
+	mock.On("GET", "/endpoints/1/docker/swarm/unlockkey", RespondJSON(http.StatusOK, map[string]string{"UnlockKey": "SWMKEY-1-abc"}))
+
+	r := resourceDockerSwarm()
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"endpoint_id":                  1,
+		"task_history_retention_limit": 2,
+		"dispatcher_heartbeat_period":  "10s",
+		"ca_force_rotate":              1,
+		"autolock_managers":            true,
+	})
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if d.Id() != "1-swarm1" || d.Get("unlock_key") != "SWMKEY-1-abc" {
+		t.Errorf("unexpected state: id=%s unlock_key=%v", d.Id(), d.Get("unlock_key"))
+	}
+
+	update := mock.FindRequest("POST", "/endpoints/1/docker/swarm/update")
+	if update == nil || update.Query != "version=17" {
+		t.Fatalf("expected update with version=17, got %+v", update)
+	}
+	var spec struct {
+		Name          string
+		Labels        map[string]string
+		Orchestration struct{ TaskHistoryRetentionLimit int }
+		Raft          struct{ SnapshotInterval int }
+		Dispatcher    struct{ HeartbeatPeriod int64 }
+		CAConfig      struct {
+			NodeCertExpiry int64
+			ForceRotate    int
+		}
+		EncryptionConfig struct{ AutoLockManagers bool }
+	}
+	if err := update.DecodeJSON(&spec); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+	if spec.Name != "default" || spec.Labels["env"] != "prod" || spec.Raft.SnapshotInterval != 10000 || spec.CAConfig.NodeCertExpiry != 7776000000000000 {
+		t.Errorf("expected unmanaged settings to be preserved, got %+v", spec)
+	}
+	if spec.Orchestration.TaskHistoryRetentionLimit != 2 || spec.Dispatcher.HeartbeatPeriod != 10e9 || spec.CAConfig.ForceRotate != 1 || !spec.EncryptionConfig.AutoLockManagers {
+		t.Errorf("expected configured settings in spec, got %+v", spec)
+	}
+}
+
+// TestDockerSwarmRead refreshes the settings and formats durations.
+func TestDockerSwarmRead(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/swarm", RespondJSON(http.StatusOK, dockerSwarmInspectResponse(false)))
+
+	r := resourceDockerSwarm()
+	d := r.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+
+	if err := rcRead(r, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if d.Get("node_cert_expiry") != "2160h0m0s" || d.Get("dispatcher_heartbeat_period") != "5s" {
+		t.Errorf("unexpected durations: %v %v", d.Get("node_cert_expiry"), d.Get("dispatcher_heartbeat_period"))
+	}
+	if d.Get("unlock_key") != "" || len(mock.Requests()) != 1 {
+		t.Errorf("expected no unlock key request without autolock, got %d requests", len(mock.Requests()))
+	}
+}
@@ -0,0 +1,486 @@
+package internal
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// dockerVolumeHelperMount is where the volume is mounted in the helper
+// container used to copy files in and out of it.
+const dockerVolumeHelperMount = "/volume"
+
+func resourceDockerVolumeFile() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceDockerVolumeFileCreate,
+		ReadContext:   resourceDockerVolumeFileRead,
+		UpdateContext: resourceDockerVolumeFileUpdate,
+		DeleteContext: resourceDockerVolumeFileDelete,
+		CustomizeDiff: customizeDiffDockerVolumeFileChecksum,
+		Schema: map[string]*schema.Schema{
+			"endpoint_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the Portainer environment where the volume is located.",
+			},
+			"swarm_node_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Swarm node holding the volume (sent as `X-PortainerAgent-Target`).",
+			},
+			"volume_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the Docker volume.",
+			},
+			"path": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateDockerVolumeFilePath,
+				Description:  "Path of the file relative to the root of the volume (e.g. `conf/nginx.conf`). Missing directories are created.",
+			},
+			"content": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"content", "content_base64", "source"},
+				Description:  "Content of the file.",
+			},
+			"content_base64": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"content", "content_base64", "source"},
+				Description:  "Base64-encoded content of the file, for binary files.",
+			},
+			"source": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"content", "content_base64", "source"},
+				Description:  "Local file whose content is uploaded.",
+			},
+			"mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "0644",
+				ValidateFunc: validateDockerVolumeFileMode,
+				DiffSuppressFunc: func(_, old, new string, _ *schema.ResourceData) bool {
+					o, err1 := strconv.ParseUint(old, 8, 32)
+					n, err2 := strconv.ParseUint(new, 8, 32)
+					return err1 == nil && err2 == nil && o == n
+				},
+				Description: "Octal file mode (e.g. `0640`).",
+			},
+			"uid": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     0,
+				Description: "Numeric user ID owning the file.",
+			},
+			"gid": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     0,
+				Description: "Numeric group ID owning the file.",
+			},
+			"helper_image": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "busybox:latest",
+				Description: "Image of the short-lived helper container mounting the volume. It is pulled if missing and only started to delete the file.",
+			},
+			"checksum": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "SHA256 of the file content. Drift is detected by comparing it with the desired content.",
+			},
+		},
+	}
+}
+
+func validateDockerVolumeFilePath(v interface{}, k string) ([]string, []error) {
+	p := v.(string)
+	clean := path.Clean("/" + p)
+	if p == "" || clean == "/" || strings.HasSuffix(p, "/") {
+		return nil, []error{fmt.Errorf("%s: %q must name a file inside the volume", k, p)}
+	}
+	if strings.Contains("/"+p+"/", "/../") {
+		return nil, []error{fmt.Errorf("%s: %q must not contain '..'", k, p)}
+	}
+	return nil, nil
+}
+
+func validateDockerVolumeFileMode(v interface{}, k string) ([]string, []error) {
+	if m, err := strconv.ParseUint(v.(string), 8, 32); err != nil || m > 07777 {
+		return nil, []error{fmt.Errorf("%s: %q is not an octal file mode", k, v)}
+	}
+	return nil, nil
+}
+
+// dockerVolumeFilePath returns the path of the file relative to the volume
+// root, without leading slash.
+func dockerVolumeFilePath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
+
+// dockerVolumeFileContent returns the desired content of the file.
+func dockerVolumeFileContent(d interface{ Get(string) interface{} }) ([]byte, error) {
+	if v := d.Get("source").(string); v != "" {
+		data, err := os.ReadFile(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read source %q: %w", v, err)
+		}
+		return data, nil
+	}
+	if v := d.Get("content_base64").(string); v != "" {
+		data, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode content_base64: %w", err)
+		}
+		return data, nil
+	}
+	return []byte(d.Get("content").(string)), nil
+}
+
+// customizeDiffDockerVolumeFileChecksum plans an upload when the desired
+// content differs from the content last read from the volume.
+func customizeDiffDockerVolumeFileChecksum(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if !d.NewValueKnown("content") || !d.NewValueKnown("content_base64") || !d.NewValueKnown("source") {
+		return d.SetNewComputed("checksum")
+	}
+	content, err := dockerVolumeFileContent(d)
+	if err != nil {
+		return err
+	}
+	if sum := fmt.Sprintf("%x", sha256.Sum256(content)); sum != d.Get("checksum").(string) {
+		return d.SetNew("checksum", sum)
+	}
+	return nil
+}
+
+type dockerVolumeFileInfo struct {
+	Content []byte
+	Mode    int64
+	UID     int
+	GID     int
+}
+
+// dockerVolumeExists reports whether the named volume exists. Containers
+// mounting a missing named volume would silently create it.
+func dockerVolumeExists(client *APIClient, endpointID int, volume string, headers map[string]string) (bool, error) {
+	resp, err := client.DoRequest(http.MethodGet, fmt.Sprintf("/endpoints/%d/docker/volumes/%s", endpointID, url.PathEscape(volume)), headers, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect docker volume: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	data, _ := io.ReadAll(resp.Body)
+	return false, fmt.Errorf("failed to inspect docker volume %s, status %d: %s", volume, resp.StatusCode, string(data))
+}
+
+// ensureDockerHelperImage pulls the helper image when it is not on the host.
+func ensureDockerHelperImage(client *APIClient, endpointID int, image string, headers map[string]string) error {
+	info, err := inspectDockerImage(client, endpointID, image, headers)
+	if err != nil || info != nil {
+		return err
+	}
+
+	pullHeaders := map[string]string{"X-Registry-Auth": base64.StdEncoding.EncodeToString([]byte(`{}`))}
+	for k, v := range headers {
+		pullHeaders[k] = v
+	}
+	path := fmt.Sprintf("/endpoints/%d/docker/images/create?%s", endpointID, url.Values{"fromImage": {image}}.Encode())
+	resp, err := client.DoRequest(http.MethodPost, path, pullHeaders, nil)
+	if err != nil {
+		return fmt.Errorf("failed to pull helper image %s: %w", image, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("failed to pull helper image %s: %s", image, string(body))
+	}
+	if err := dockerPullStreamError(body); err != nil {
+		return fmt.Errorf("failed to pull helper image %s: %w", image, err)
+	}
+	return nil
+}
+
+// withDockerVolumeHelper creates a container mounting the volume at
+// dockerVolumeHelperMount, runs fn with its ID and removes it afterwards.
+// The container is only started by fn if it needs to run cmd.
+func withDockerVolumeHelper(client *APIClient, endpointID int, volume, image string, cmd []string, headers map[string]string, fn func(id string) error) error {
+	if err := ensureDockerHelperImage(client, endpointID, image, headers); err != nil {
+		return err
+	}
+
+	payload := map[string]interface{}{
+		"Image":      image,
+		"Entrypoint": []string{},
+		"Cmd":        cmd,
+		"Labels":     map[string]string{"io.portainer.terraform.helper": "volume-file"},
+		"HostConfig": map[string]interface{}{
+			"Mounts": []map[string]interface{}{{"Type": "volume", "Source": volume, "Target": dockerVolumeHelperMount}},
+		},
+	}
+	resp, err := client.DoRequest(http.MethodPost, fmt.Sprintf("/endpoints/%d/docker/containers/create", endpointID), headers, payload)
+	if err != nil {
+		return fmt.Errorf("failed to create volume helper container: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to create volume helper container, status %d: %s", resp.StatusCode, string(data))
+	}
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return fmt.Errorf("failed to decode volume helper container: %w", err)
+	}
+
+	defer func() {
+		if r, err := client.DoRequest(http.MethodDelete, fmt.Sprintf("/endpoints/%d/docker/containers/%s?force=true", endpointID, created.ID), headers, nil); err == nil {
+			r.Body.Close()
+		}
+	}()
+	return fn(created.ID)
+}
+
+// readDockerVolumeFile downloads a file through the archive API of the helper
+// container. It returns nil when the file does not exist.
+func readDockerVolumeFile(client *APIClient, endpointID int, containerID, filePath string, headers map[string]string) (*dockerVolumeFileInfo, error) {
+	query := url.Values{"path": {path.Join(dockerVolumeHelperMount, filePath)}}
+	resp, err := client.DoRequest(http.MethodGet, fmt.Sprintf("/endpoints/%d/docker/containers/%s/archive?%s", endpointID, containerID, query.Encode()), headers, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", filePath, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to download %s, status %d: %s", filePath, resp.StatusCode, string(data))
+	}
+
+	tr := tar.NewReader(resp.Body)
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive of %s: %w", filePath, err)
+	}
+	if hdr.Typeflag != tar.TypeReg {
+		return nil, fmt.Errorf("%s is not a regular file", filePath)
+	}
+	content, err := io.ReadAll(tr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive of %s: %w", filePath, err)
+	}
+	return &dockerVolumeFileInfo{Content: content, Mode: hdr.Mode & 07777, UID: hdr.Uid, GID: hdr.Gid}, nil
+}
+
+// writeDockerVolumeFile uploads the file as a tar archive extracted at the
+// root of the volume.
+func writeDockerVolumeFile(ctx context.Context, client *APIClient, endpointID int, containerID, filePath string, file dockerVolumeFileInfo, headers map[string]string) error {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	hdr := &tar.Header{
+		Name:     filePath,
+		Typeflag: tar.TypeReg,
+		Mode:     file.Mode,
+		Uid:      file.UID,
+		Gid:      file.GID,
+		Size:     int64(len(file.Content)),
+		ModTime:  time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if _, err := tw.Write(file.Content); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	query := url.Values{"path": {dockerVolumeHelperMount}}
+	reqURL := fmt.Sprintf("%s/endpoints/%d/docker/containers/%s/archive?%s", client.Endpoint, endpointID, containerID, query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, reqURL, buf)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-tar")
+	if client.APIKey != "" {
+		req.Header.Set("X-API-Key", client.APIKey)
+	} else if client.JWTToken != "" {
+		req.Header.Set("Authorization", "Bearer "+client.JWTToken)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", filePath, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to upload %s, status %d: %s", filePath, resp.StatusCode, string(data))
+	}
+	return nil
+}
+
+func resourceDockerVolumeFileCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	endpointID := d.Get("endpoint_id").(int)
+	if diags := resourceDockerVolumeFileUpload(ctx, d, meta); diags.HasError() {
+		return diags
+	}
+	d.SetId(fmt.Sprintf("%d:%s:%s", endpointID, d.Get("volume_name").(string), dockerVolumeFilePath(d.Get("path").(string))))
+	return resourceDockerVolumeFileRead(ctx, d, meta)
+}
+
+func resourceDockerVolumeFileUpload(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+	volume := d.Get("volume_name").(string)
+	headers := dockerContainerHeaders(d)
+
+	content, err := dockerVolumeFileContent(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	mode, _ := strconv.ParseInt(d.Get("mode").(string), 8, 64)
+	file := dockerVolumeFileInfo{Content: content, Mode: mode, UID: d.Get("uid").(int), GID: d.Get("gid").(int)}
+
+	exists, err := dockerVolumeExists(client, endpointID, volume, headers)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if !exists {
+		return diag.FromErr(fmt.Errorf("docker volume %s not found in endpoint %d", volume, endpointID))
+	}
+
+	err = withDockerVolumeHelper(client, endpointID, volume, d.Get("helper_image").(string), nil, headers, func(id string) error {
+		return writeDockerVolumeFile(ctx, client, endpointID, id, dockerVolumeFilePath(d.Get("path").(string)), file, headers)
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func resourceDockerVolumeFileRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+	volume := d.Get("volume_name").(string)
+	headers := dockerContainerHeaders(d)
+
+	exists, err := dockerVolumeExists(client, endpointID, volume, headers)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if !exists {
+		d.SetId("")
+		return nil
+	}
+
+	var file *dockerVolumeFileInfo
+	err = withDockerVolumeHelper(client, endpointID, volume, d.Get("helper_image").(string), nil, headers, func(id string) error {
+		var err error
+		file, err = readDockerVolumeFile(client, endpointID, id, dockerVolumeFilePath(d.Get("path").(string)), headers)
+		return err
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if file == nil {
+		d.SetId("")
+		return nil
+	}
+
+	_ = d.Set("checksum", fmt.Sprintf("%x", sha256.Sum256(file.Content)))
+	_ = d.Set("mode", fmt.Sprintf("%04o", file.Mode))
+	_ = d.Set("uid", file.UID)
+	_ = d.Set("gid", file.GID)
+	return nil
+}
+
+func resourceDockerVolumeFileUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if diags := resourceDockerVolumeFileUpload(ctx, d, meta); diags.HasError() {
+		return diags
+	}
+	return resourceDockerVolumeFileRead(ctx, d, meta)
+}
+
+func resourceDockerVolumeFileDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	endpointID := d.Get("endpoint_id").(int)
+	volume := d.Get("volume_name").(string)
+	headers := dockerContainerHeaders(d)
+
+	exists, err := dockerVolumeExists(client, endpointID, volume, headers)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if !exists {
+		d.SetId("")
+		return nil
+	}
+
+	// The archive API cannot remove files: run the helper container once.
+	target := path.Join(dockerVolumeHelperMount, dockerVolumeFilePath(d.Get("path").(string)))
+	err = withDockerVolumeHelper(client, endpointID, volume, d.Get("helper_image").(string), []string{"rm", "-f", target}, headers, func(id string) error {
+		if err := dockerContainerAction(client, endpointID, id, "start", headers, nil); err != nil {
+			return err
+		}
+		resp, err := client.DoRequest(http.MethodPost, fmt.Sprintf("/endpoints/%d/docker/containers/%s/wait", endpointID, id), headers, nil)
+		if err != nil {
+			return fmt.Errorf("failed to wait for volume helper container: %w", err)
+		}
+		defer resp.Body.Close()
+
+		var result struct {
+			StatusCode int `json:"StatusCode"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return fmt.Errorf("failed to decode volume helper result: %w", err)
+		}
+		if result.StatusCode != 0 {
+			return fmt.Errorf("failed to remove %s from volume %s, exit code %d", target, volume, result.StatusCode)
+		}
+		return nil
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+	return nil
+}
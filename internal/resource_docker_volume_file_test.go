@@ -0,0 +1,185 @@
+package internal
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func dockerVolumeFileArchive(t *testing.T, name, content string, mode int64, uid, gid int) string {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: mode, Uid: uid, Gid: gid, Size: int64(len(content))}); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = tw.Write([]byte(content))
+	_ = tw.Close()
+	return buf.String()
+}
+
+func registerDockerVolumeHelperMocks(mock *MockServer) {
+	mock.On("GET", "/endpoints/1/docker/volumes/app-config", RespondJSON(http.StatusOK, map[string]string{"Name": "app-config"}))
+	mock.On("GET", "/endpoints/1/docker/images/busybox:latest/json", RespondJSON(http.StatusOK, map[string]string{"Id": "sha256:busybox"}))
+	mock.On("POST", "/endpoints/1/docker/containers/create", RespondJSON(http.StatusCreated, map[string]string{"Id": "helper1"}))
+	mock.On("DELETE", "/endpoints/1/docker/containers/helper1", RespondJSON(http.StatusNoContent, nil))
+}
+
+func newDockerVolumeFileTestData(t *testing.T) *schema.ResourceData {
+	return schema.TestResourceDataRaw(t, resourceDockerVolumeFile().Schema, map[string]interface{}{
+		"endpoint_id":  1,
+		"volume_name":  "app-config",
+		"path":         "conf/app.yml",
+		"content":      "listen: 8080\n",
+		"mode":         "0640",
+		"uid":          101,
+		"gid":          101,
+		"helper_image": "busybox:latest",
+	})
+}
+
+// TestDockerVolumeFileCreate uploads the file as tar through a helper
+// container mounting the volume, then removes the helper.
+func TestDockerVolumeFileCreate(t *testing.T) {
+	mock := NewMockServer(t)
+	registerDockerVolumeHelperMocks(mock)
+	mock.On("PUT", "/endpoints/1/docker/containers/helper1/archive", RespondJSON(http.StatusOK, nil))
+	mock.On("GET", "/endpoints/1/docker/containers/helper1/archive", RespondString(http.StatusOK, "application/x-tar",
+		dockerVolumeFileArchive(t, "app.yml", "listen: 8080\n", 0o640, 101, 101)))
+
+	r := resourceDockerVolumeFile()
+	d := newDockerVolumeFileTestData(t)
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if d.Id() != "1:app-config:conf/app.yml" {
+		t.Errorf("unexpected ID %q", d.Id())
+	}
+	if want := fmt.Sprintf("%x", sha256.Sum256([]byte("listen: 8080\n"))); d.Get("checksum") != want {
+		t.Errorf("expected checksum %s, got %v", want, d.Get("checksum"))
+	}
+
+	var helper struct {
+		Image      string
+		HostConfig struct {
+			Mounts []struct{ Type, Source, Target string }
+		}
+	}
+	if err := mock.FindRequest("POST", "/endpoints/1/docker/containers/create").DecodeJSON(&helper); err != nil {
+		t.Fatalf("decode helper: %v", err)
+	}
+	if m := helper.HostConfig.Mounts; len(m) != 1 || m[0].Source != "app-config" || m[0].Target != "/volume" {
+		t.Errorf("unexpected helper mounts: %+v", m)
+	}
+
+	put := mock.FindRequest("PUT", "/endpoints/1/docker/containers/helper1/archive")
+	if query, _ := url.ParseQuery(put.Query); query.Get("path") != "/volume" {
+		t.Errorf("unexpected upload path: %s", put.Query)
+	}
+	tr := tar.NewReader(bytes.NewReader(put.Body))
+	hdr, err := tr.Next()
+	if err != nil {
+		t.Fatalf("read tar: %v", err)
+	}
+	content, _ := io.ReadAll(tr)
+	if hdr.Name != "conf/app.yml" || hdr.Mode != 0o640 || hdr.Uid != 101 || string(content) != "listen: 8080\n" {
+		t.Errorf("unexpected archive entry: %+v %q", hdr, content)
+	}
+	if mock.FindRequest("DELETE", "/endpoints/1/docker/containers/helper1") == nil {
+		t.Error("expected the helper container to be removed")
+	}
+}
+
+// TestDockerVolumeFileRead_Removed clears the state when the file is gone.
+func TestDockerVolumeFileRead_Removed(t *testing.T) {
+	mock := NewMockServer(t)
+	registerDockerVolumeHelperMocks(mock)
+	mock.On("GET", "/endpoints/1/docker/containers/helper1/archive", RespondJSON(http.StatusNotFound, map[string]string{"message": "no such file"}))
+
+	r := resourceDockerVolumeFile()
+	d := newDockerVolumeFileTestData(t)
+	d.SetId("1:app-config:conf/app.yml")
+
+	if err := rcRead(r, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if d.Id() != "" {
+		t.Errorf("expected ID to be cleared, got %q", d.Id())
+	}
+}
+
+// TestDockerVolumeFileDiff_Drift plans an in-place upload when the checksum
+// read from the volume no longer matches the configured content.
+func TestDockerVolumeFileDiff_Drift(t *testing.T) {
+	mock := NewMockServer(t)
+	r := resourceDockerVolumeFile()
+	state := &terraform.InstanceState{
+		ID: "1:app-config:conf/app.yml",
+		Attributes: map[string]string{
+			"endpoint_id":  "1",
+			"volume_name":  "app-config",
+			"path":         "conf/app.yml",
+			"content":      "listen: 8080\n",
+			"mode":         "0644",
+			"uid":          "0",
+			"gid":          "0",
+			"helper_image": "busybox:latest",
+			"checksum":     "edited-by-hand",
+		},
+	}
+	raw := map[string]interface{}{
+		"endpoint_id": 1,
+		"volume_name": "app-config",
+		"path":        "conf/app.yml",
+		"content":     "listen: 8080\n",
+		"mode":        "644",
+	}
+
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), mock.Client())
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	attr, ok := diff.Attributes["checksum"]
+	if !ok || attr.New != fmt.Sprintf("%x", sha256.Sum256([]byte("listen: 8080\n"))) || diff.RequiresNew() {
+		t.Errorf("expected in-place checksum update, got %+v", diff)
+	}
+	if _, ok := diff.Attributes["mode"]; ok {
+		t.Error("expected equivalent modes not to produce a diff")
+	}
+}
+
+// TestDockerVolumeFileDelete runs the helper container to remove the file.
+func TestDockerVolumeFileDelete(t *testing.T) {
+	mock := NewMockServer(t)
+	registerDockerVolumeHelperMocks(mock)
+	mock.On("POST", "/endpoints/1/docker/containers/helper1/start", RespondJSON(http.StatusNoContent, nil))
+	mock.On("POST", "/endpoints/1/docker/containers/helper1/wait", RespondJSON(http.StatusOK, map[string]int{"StatusCode": 0}))
+
+	r := resourceDockerVolumeFile()
+	d := newDockerVolumeFileTestData(t)
+	d.SetId("1:app-config:conf/app.yml")
+
+	if err := rcDelete(r, d, mock.Client()); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	var helper struct{ Cmd []string }
+	if err := mock.FindRequest("POST", "/endpoints/1/docker/containers/create").DecodeJSON(&helper); err != nil {
+		t.Fatalf("decode helper: %v", err)
+	}
+	if len(helper.Cmd) != 3 || helper.Cmd[2] != "/volume/conf/app.yml" {
+		t.Errorf("unexpected helper command: %v", helper.Cmd)
+	}
+	if d.Id() != "" {
+		t.Error("expected ID to be cleared")
+	}
+}
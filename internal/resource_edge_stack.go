@@ -16,7 +16,9 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func resourceEdgeStack() *schema.Resource {
@@ -33,6 +35,7 @@ func resourceEdgeStack() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		CustomizeDiff: customdiff.All(customizeDiffEdgeStackStaggeredRollout, customizeDiffEdgeStackValidation),
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:        schema.TypeString,
@@ -50,6 +53,11 @@ func resourceEdgeStack() *schema.Resource {
 				ForceNew:    true,
 				Description: "Local filesystem path to a Compose or manifest file used as the Edge stack definition. Changing this value forces resource recreation.",
 			},
+			"skip_file_validation": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Skip the plan-time validation of the Compose file or Kubernetes manifest.",
+			},
 			"pre_pull_image": {
 				Type:        schema.TypeBool,
 				Optional:    true,
@@ -186,10 +194,327 @@ func resourceEdgeStack() *schema.Resource {
 				Default:     false,
 				Description: "Whether the agent must always clone the git repository for relative path. Only valid when relative_path is set.",
 			},
+			"wait_for_deployment": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Wait after create and update until the edge agents run the stack. The apply fails with the list of failing environments when the threshold is not reached.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"threshold": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "all",
+							ValidateFunc: validation.StringInSlice([]string{"all", "percentage", "min_count"}, false),
+							Description:  "Success threshold: `all` environments, a `percentage` of them or a `min_count` of environments.",
+						},
+						"percentage": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      100,
+							ValidateFunc: validation.IntBetween(1, 100),
+							Description:  "Percentage of environments that must run the stack when threshold is `percentage`.",
+						},
+						"min_count": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      1,
+							ValidateFunc: validation.IntAtLeast(1),
+							Description:  "Number of environments that must run the stack when threshold is `min_count`.",
+						},
+						"timeout": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "10m",
+							ValidateFunc: func(v interface{}, k string) ([]string, []error) {
+								if _, err := time.ParseDuration(v.(string)); err != nil {
+									return nil, []error{fmt.Errorf("%s: invalid duration %q: %v", k, v, err)}
+								}
+								return nil, nil
+							},
+							Description: "Maximum time to wait for the deployment (e.g. `10m`). The create/update timeouts of the resource still apply.",
+						},
+						"interval": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      10,
+							ValidateFunc: validation.IntAtLeast(1),
+							Description:  "Seconds between two status checks.",
+						},
+					},
+				},
+			},
+			"environment_status": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        edgeStackEnvironmentStatusSchema(),
+				Description: "Deployment status of the stack on each environment of its edge groups.",
+			},
+			"staggered_rollout": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Update the environments in batches instead of all at once (Portainer BE " + edgeStackStaggerMinVersion + " or newer).",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"parallelism_mode": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "fixed",
+							ValidateFunc: validation.StringInSlice([]string{"fixed", "incremental"}, false),
+							Description:  "`fixed` updates `parallelism` environments per batch; `incremental` starts with `parallelism` environments and multiplies the batch size by `increment_factor`.",
+						},
+						"parallelism": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      1,
+							ValidateFunc: validation.IntAtLeast(1),
+							Description:  "Number of environments updated in parallel (in the first batch for `incremental`).",
+						},
+						"increment_factor": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      2,
+							ValidateFunc: validation.IntAtLeast(2),
+							Description:  "Factor applied to the batch size after each batch when parallelism_mode is `incremental`.",
+						},
+						"update_delay": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      0,
+							ValidateFunc: validation.IntAtLeast(0),
+							Description:  "Minutes to wait between two batches.",
+						},
+						"timeout": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      15,
+							ValidateFunc: validation.IntAtLeast(1),
+							Description:  "Minutes an environment has to update before the update is considered failed.",
+						},
+						"failure_action": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "continue",
+							ValidateFunc: validation.StringInSlice([]string{"continue", "pause", "rollback"}, false),
+							Description:  "Action when an environment fails to update: `continue` with the next batches, `pause` the rollout or `rollback` the updated environments.",
+						},
+					},
+				},
+			},
+			"stagger_status": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Status of the staggered rollout reported by Portainer, when staggered_rollout is set.",
+			},
 		},
 	}
 }
 
+// resourceEdgeStackAwaitDeployment waits for the edge agents to deploy the
+// stack when wait_for_deployment is set, then reads the stack.
+func resourceEdgeStackAwaitDeployment(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	raw := d.Get("wait_for_deployment").([]interface{})
+	if len(raw) == 0 || raw[0] == nil || d.Get("dryrun").(bool) {
+		return resourceEdgeStackRead(ctx, d, meta)
+	}
+	cfg := raw[0].(map[string]interface{})
+	timeout, err := time.ParseDuration(cfg["timeout"].(string))
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("invalid wait_for_deployment timeout: %w", err))
+	}
+	wait := edgeStackDeploymentWait{
+		Threshold:  cfg["threshold"].(string),
+		Percentage: cfg["percentage"].(int),
+		MinCount:   cfg["min_count"].(int),
+		Timeout:    timeout,
+		Interval:   time.Duration(cfg["interval"].(int)) * time.Second,
+		FailFast:   !d.Get("retry_deploy").(bool),
+		Staggered:  len(d.Get("staggered_rollout").([]interface{})) > 0,
+	}
+
+	statuses, err := waitForEdgeStackDeployment(ctx, meta.(*APIClient), d.Id(), wait)
+	if err != nil {
+		if statuses != nil {
+			_ = d.Set("environment_status", flattenEdgeStackEnvironments(statuses))
+		}
+		return diag.FromErr(err)
+	}
+	return resourceEdgeStackRead(ctx, d, meta)
+}
+
+// edgeStackStaggerMinVersion is the first Portainer BE version supporting
+// staggered edge stack updates.
+const edgeStackStaggerMinVersion = "2.20.0"
+
+var edgeStackStaggerFailureActions = map[string]int{"continue": 1, "pause": 2, "rollback": 3}
+
+// customizeDiffEdgeStackStaggeredRollout rejects staggered_rollout on
+// Portainer versions ignoring it, which would update every environment at
+// once. Version lookup errors are ignored so plans keep working offline.
+func customizeDiffEdgeStackStaggeredRollout(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if len(d.Get("staggered_rollout").([]interface{})) == 0 || (d.Id() != "" && !d.HasChange("staggered_rollout")) {
+		return nil
+	}
+	client, ok := meta.(*APIClient)
+	if !ok || client == nil {
+		return nil
+	}
+	version, err := fetchPortainerVersion(client)
+	if err != nil {
+		return nil
+	}
+	if !version.isBusinessEdition() || !version.atLeast(edgeStackStaggerMinVersion) {
+		return fmt.Errorf("staggered_rollout requires Portainer BE %s or newer, the server runs %s %s", edgeStackStaggerMinVersion, version.Edition, version.Version)
+	}
+	return nil
+}
+
+// customizeDiffEdgeStackValidation validates the stack file when it may have
+// changed. Environment security settings are not checked: they restrict
+// regular users, who cannot manage edge stacks.
+func customizeDiffEdgeStackValidation(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Get("skip_file_validation").(bool) {
+		return nil
+	}
+	if d.Id() != "" && !d.HasChanges("stack_file_content", "stack_file_path", "repository_reference_name", "deployment_type", "skip_file_validation") {
+		return nil
+	}
+
+	var file stackFile
+	switch {
+	case d.Get("stack_file_content").(string) != "":
+		if !d.NewValueKnown("stack_file_content") {
+			return nil
+		}
+		file = stackFile{Name: "stack_file_content", Content: d.Get("stack_file_content").(string)}
+	case d.Get("stack_file_path").(string) != "":
+		path := d.Get("stack_file_path").(string)
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			// Created during apply, e.g. by local_file: validated by the next plan.
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read stack file from path: %w", err)
+		}
+		file = stackFile{Name: path, Content: string(content)}
+	case d.Get("repository_url").(string) != "":
+		client, ok := meta.(*APIClient)
+		if !ok || client == nil {
+			return nil
+		}
+		path := d.Get("file_path_in_repository").(string)
+		payload := map[string]interface{}{
+			"repository": d.Get("repository_url").(string),
+			"reference":  d.Get("repository_reference_name").(string),
+			"targetFile": path,
+		}
+		if d.Get("git_repository_authentication").(bool) {
+			payload["username"] = d.Get("repository_username").(string)
+			payload["password"] = d.Get("repository_password").(string)
+		}
+		if id := d.Get("repository_git_credential_id").(int); id != 0 {
+			payload["gitCredentialID"] = id
+		}
+		content, err := fetchRepositoryFile(client, payload)
+		if err != nil {
+			return stackFileFetchError(err)
+		}
+		file = stackFile{Name: path, Content: content}
+	default:
+		return nil
+	}
+
+	if d.Get("deployment_type").(int) == 1 {
+		return stackFileViolationsError(validateKubernetesManifest(file))
+	}
+	return stackFileViolationsError(validateComposeFiles([]stackFile{file}, nil))
+}
+
+// expandEdgeStackStaggerConfig converts staggered_rollout to Portainer's
+// staggerConfig. Without the block, the stack is updated all at once.
+func expandEdgeStackStaggerConfig(d *schema.ResourceData) map[string]interface{} {
+	raw := d.Get("staggered_rollout").([]interface{})
+	if len(raw) == 0 || raw[0] == nil {
+		return map[string]interface{}{"staggerOption": 1}
+	}
+	cfg := raw[0].(map[string]interface{})
+	config := map[string]interface{}{
+		"staggerOption":       2,
+		"timeout":             strconv.Itoa(cfg["timeout"].(int)),
+		"updateDelay":         strconv.Itoa(cfg["update_delay"].(int)),
+		"updateFailureAction": edgeStackStaggerFailureActions[cfg["failure_action"].(string)],
+	}
+	if cfg["parallelism_mode"].(string) == "incremental" {
+		config["staggerParallelOption"] = 2
+		config["deviceNumberStartFrom"] = cfg["parallelism"].(int)
+		config["deviceNumberIncrementBy"] = cfg["increment_factor"].(int)
+	} else {
+		config["staggerParallelOption"] = 1
+		config["deviceNumber"] = cfg["parallelism"].(int)
+	}
+	return config
+}
+
+// edgeStackStaggerConfig is the staggerConfig returned by GET /edge_stacks/{id}.
+type edgeStackStaggerConfig struct {
+	StaggerOption           int    `json:"StaggerOption"`
+	StaggerParallelOption   int    `json:"StaggerParallelOption"`
+	DeviceNumber            int    `json:"DeviceNumber"`
+	DeviceNumberStartFrom   int    `json:"DeviceNumberStartFrom"`
+	DeviceNumberIncrementBy int    `json:"DeviceNumberIncrementBy"`
+	Timeout                 string `json:"Timeout"`
+	UpdateDelay             string `json:"UpdateDelay"`
+	UpdateFailureAction     int    `json:"UpdateFailureAction"`
+}
+
+// flattenEdgeStackStaggerConfig converts Portainer's staggerConfig to
+// staggered_rollout.
+func flattenEdgeStackStaggerConfig(config *edgeStackStaggerConfig) []interface{} {
+	if config == nil || config.StaggerOption != 2 {
+		return []interface{}{}
+	}
+	out := map[string]interface{}{
+		"parallelism_mode": "fixed",
+		"parallelism":      config.DeviceNumber,
+		"increment_factor": 2,
+		"failure_action":   "continue",
+	}
+	if config.StaggerParallelOption == 2 {
+		out["parallelism_mode"] = "incremental"
+		out["parallelism"] = config.DeviceNumberStartFrom
+		out["increment_factor"] = config.DeviceNumberIncrementBy
+	}
+	out["timeout"], _ = strconv.Atoi(config.Timeout)
+	out["update_delay"], _ = strconv.Atoi(config.UpdateDelay)
+	for action, value := range edgeStackStaggerFailureActions {
+		if value == config.UpdateFailureAction {
+			out["failure_action"] = action
+		}
+	}
+	return []interface{}{out}
+}
+
+// fetchEdgeStackStaggerStatus returns the status of the staggered rollout of
+// an edge stack.
+func fetchEdgeStackStaggerStatus(ctx context.Context, client *APIClient, stackID string) (string, error) {
+	body, code, err := apiGETWithCodeCtx(ctx, fmt.Sprintf("%s/edge_stacks/%s/stagger/status", client.Endpoint, stackID), client.APIKey, client)
+	if err != nil {
+		return "", err
+	}
+	if code != http.StatusOK {
+		return "", fmt.Errorf("failed to read stagger status of edge stack %s, status %d: %s", stackID, code, string(body))
+	}
+	var out struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return "", err
+	}
+	return out.Status, nil
+}
+
 func setAuthHeaders(client *APIClient, req *http.Request) {
 	if client.APIKey != "" {
 		req.Header.Set("X-API-Key", client.APIKey)
@@ -277,6 +602,7 @@ func resourceEdgeStackCreate(ctx context.Context, d *schema.ResourceData, meta i
 			"stackFileContent":      content.(string),
 			"useManifestNamespaces": useManifest,
 			"registries":            registries,
+			"staggerConfig":         expandEdgeStackStaggerConfig(d),
 		}
 		if envMap, ok := d.GetOk("environment"); ok {
 			envVars := []map[string]string{}
@@ -288,7 +614,10 @@ func resourceEdgeStackCreate(ctx context.Context, d *schema.ResourceData, meta i
 			}
 			payload["envVars"] = envVars
 		}
-		return diag.FromErr(createEdgeStackFromJSON(ctx, client, d, payload, "/edge_stacks/create/string"))
+		if err := createEdgeStackFromJSON(ctx, client, d, payload, "/edge_stacks/create/string"); err != nil {
+			return diag.FromErr(err)
+		}
+		return resourceEdgeStackAwaitDeployment(ctx, d, meta)
 	}
 
 	// Method: stackFilePath (file)
@@ -310,6 +639,7 @@ func resourceEdgeStackCreate(ctx context.Context, d *schema.ResourceData, meta i
 		_ = writer.WriteField("Registries", toJSONString(registries))
 		_ = writer.WriteField("PrePullImage", strconv.FormatBool(d.Get("pre_pull_image").(bool)))
 		_ = writer.WriteField("RetryDeploy", strconv.FormatBool(d.Get("retry_deploy").(bool)))
+		_ = writer.WriteField("StaggerConfig", toJSONString(expandEdgeStackStaggerConfig(d)))
 
 		part, err := writer.CreateFormFile("file", filepath.Base(filePath))
 		if err != nil {
@@ -351,7 +681,7 @@ func resourceEdgeStackCreate(ctx context.Context, d *schema.ResourceData, meta i
 
 		if !d.Get("dryrun").(bool) {
 			d.SetId(strconv.Itoa(result.ID))
-			return resourceEdgeStackRead(ctx, d, meta)
+			return resourceEdgeStackAwaitDeployment(ctx, d, meta)
 		}
 
 		return nil
@@ -373,6 +703,7 @@ func resourceEdgeStackCreate(ctx context.Context, d *schema.ResourceData, meta i
 			"filePathInRepository":      d.Get("file_path_in_repository").(string),
 			"useManifestNamespaces":     useManifest,
 			"registries":                registries,
+			"staggerConfig":             expandEdgeStackStaggerConfig(d),
 		}
 
 		if relPath, ok := d.GetOk("relative_path"); ok && relPath.(string) != "" {
@@ -416,7 +747,10 @@ func resourceEdgeStackCreate(ctx context.Context, d *schema.ResourceData, meta i
 				}
 			}
 		}
-		return diag.FromErr(createEdgeStackFromJSON(ctx, client, d, payload, "/edge_stacks/create/repository"))
+		if err := createEdgeStackFromJSON(ctx, client, d, payload, "/edge_stacks/create/repository"); err != nil {
+			return diag.FromErr(err)
+		}
+		return resourceEdgeStackAwaitDeployment(ctx, d, meta)
 	}
 
 	return diag.FromErr(fmt.Errorf("one of 'stack_file_content', 'stack_file_path', or 'repository_url' must be provided"))
@@ -441,6 +775,7 @@ func resourceEdgeStackUpdate(ctx context.Context, d *schema.ResourceData, meta i
 			"prePullImage":          d.Get("pre_pull_image").(bool),
 			"rePullImage":           d.Get("pull_image").(bool),
 			"registries":            toIntSlice(d.Get("registries").([]interface{})),
+			"staggerConfig":         expandEdgeStackStaggerConfig(d),
 		}
 
 		if v, ok := d.GetOk("stack_file_content"); ok {
@@ -470,7 +805,7 @@ func resourceEdgeStackUpdate(ctx context.Context, d *schema.ResourceData, meta i
 			return diag.FromErr(fmt.Errorf("failed to update edge stack: %s", string(data)))
 		}
 
-		return resourceEdgeStackRead(ctx, d, meta)
+		return resourceEdgeStackAwaitDeployment(ctx, d, meta)
 	}
 
 	// Repository-based update via /git
@@ -490,6 +825,7 @@ func resourceEdgeStackUpdate(ctx context.Context, d *schema.ResourceData, meta i
 			"rePullImage":    d.Get("pull_image").(bool),
 			"registries":     toIntSlice(d.Get("registries").([]interface{})),
 			"retryDeploy":    d.Get("retry_deploy").(bool),
+			"staggerConfig":  expandEdgeStackStaggerConfig(d),
 		}
 
 		if relPath, ok := d.GetOk("relative_path"); ok && relPath.(string) != "" {
@@ -554,7 +890,7 @@ func resourceEdgeStackUpdate(ctx context.Context, d *schema.ResourceData, meta i
 			return diag.FromErr(fmt.Errorf("failed to update repository-based edge stack: %s", string(data)))
 		}
 
-		return resourceEdgeStackRead(ctx, d, meta)
+		return resourceEdgeStackAwaitDeployment(ctx, d, meta)
 	}
 
 	return diag.FromErr(fmt.Errorf("one of 'stack_file_content', 'stack_file_path', or 'repository_url' must be provided for update"))
@@ -657,7 +993,8 @@ func resourceEdgeStackRead(ctx context.Context, d *schema.ResourceData, meta int
 				GitCredentialID int    `json:"GitCredentialID"`
 			} `json:"Authentication"`
 		} `json:"GitConfig"`
-		AutoUpdate *struct {
+		StaggerConfig *edgeStackStaggerConfig `json:"StaggerConfig"`
+		AutoUpdate    *struct {
 			Interval       string `json:"Interval"`
 			Webhook        string `json:"Webhook"`
 			ForcePullImage bool   `json:"ForcePullImage"`
@@ -665,7 +1002,27 @@ func resourceEdgeStackRead(ctx context.Context, d *schema.ResourceData, meta int
 		} `json:"AutoUpdate,omitempty"`
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&stack); err != nil {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := json.Unmarshal(body, &stack); err != nil {
+		return diag.FromErr(err)
+	}
+	var status edgeStackStatusPayload
+	if err := json.Unmarshal(body, &status); err != nil {
+		return diag.FromErr(err)
+	}
+	var diags diag.Diagnostics
+	environments, listErr := edgeStackEnvironments(ctx, client, d.Id(), status)
+	if listErr != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "environment_status only lists the environments that reported a status",
+			Detail:   listErr.Error(),
+		})
+	}
+	if err := d.Set("environment_status", flattenEdgeStackEnvironments(environments)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -696,6 +1053,18 @@ func resourceEdgeStackRead(ctx context.Context, d *schema.ResourceData, meta int
 	if err := d.Set("always_clone", stack.AlwaysCloneGitRepoForRelativePath); err != nil {
 		return diag.FromErr(err)
 	}
+	staggered := flattenEdgeStackStaggerConfig(stack.StaggerConfig)
+	if err := d.Set("staggered_rollout", staggered); err != nil {
+		return diag.FromErr(err)
+	}
+	staggerStatus := ""
+	if len(staggered) > 0 {
+		// Best effort: the status is informational.
+		staggerStatus, _ = fetchEdgeStackStaggerStatus(ctx, client, d.Id())
+	}
+	if err := d.Set("stagger_status", staggerStatus); err != nil {
+		return diag.FromErr(err)
+	}
 
 	envMap := make(map[string]string, len(stack.EnvVars))
 	for _, env := range stack.EnvVars {
@@ -805,7 +1174,7 @@ func resourceEdgeStackRead(ctx context.Context, d *schema.ResourceData, meta int
 		}
 	}
 
-	return nil
+	return diags
 }
 
 func resourceEdgeStackDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"context"
 	"io"
 	"mime"
 	"mime/multipart"
@@ -9,6 +10,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 // resource_edge_stack.go uses raw http.NewRequestWithContext + client.HTTPClient.Do
@@ -584,3 +587,109 @@ func TestEdgeStackCreate_HTTPError(t *testing.T) {
 		t.Errorf("expected empty ID after error, got %q", d.Id())
 	}
 }
+
+// TestEdgeStackCreate_StaggeredRollout verifies staggered_rollout is sent as
+// Portainer's staggerConfig and read back with the rollout status.
+func TestEdgeStackCreate_StaggeredRollout(t *testing.T) {
+	mock := NewMockServer(t)
+
+	mock.On("GET", "/edge_stacks", RespondJSON(http.StatusOK, []map[string]interface{}{}))
+	mock.On("POST", "/edge_stacks/create/string", RespondJSON(http.StatusOK, map[string]interface{}{"Id": 12}))
+	mock.On("GET", "/edge_stacks/12", RespondJSON(http.StatusOK, map[string]interface{}{
+		"Id":   12,
+		"Name": "edge-staggered",
+		"StaggerConfig": map[string]interface{}{
+			"StaggerOption":           2,
+			"StaggerParallelOption":   2,
+			"DeviceNumberStartFrom":   5,
+			"DeviceNumberIncrementBy": 3,
+			"Timeout":                 "30",
+			"UpdateDelay":             "10",
+			"UpdateFailureAction":     3,
+		},
+	}))
+	mock.On("GET", "/edge_stacks/12/stagger/status", RespondJSON(http.StatusOK, map[string]string{"status": "rolling_out"}))
+
+	r := resourceEdgeStack()
+	d := r.TestResourceData()
+	_ = d.Set("name", "edge-staggered")
+	_ = d.Set("deployment_type", 0)
+	_ = d.Set("edge_groups", []interface{}{3})
+	_ = d.Set("stack_file_content", "services: {}\n")
+	_ = d.Set("staggered_rollout", []interface{}{map[string]interface{}{
+		"parallelism_mode": "incremental",
+		"parallelism":      5,
+		"increment_factor": 3,
+		"update_delay":     10,
+		"timeout":          30,
+		"failure_action":   "rollback",
+	}})
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	var payload struct {
+		StaggerConfig map[string]interface{} `json:"staggerConfig"`
+	}
+	if err := mock.FindRequest("POST", "/edge_stacks/create/string").DecodeJSON(&payload); err != nil {
+		t.Fatalf("failed to decode create/string body: %v", err)
+	}
+	want := map[string]interface{}{
+		"staggerOption":           float64(2),
+		"staggerParallelOption":   float64(2),
+		"deviceNumberStartFrom":   float64(5),
+		"deviceNumberIncrementBy": float64(3),
+		"timeout":                 "30",
+		"updateDelay":             "10",
+		"updateFailureAction":     float64(3),
+	}
+	for k, v := range want {
+		if payload.StaggerConfig[k] != v {
+			t.Errorf("staggerConfig.%s: expected %v, got %v", k, v, payload.StaggerConfig[k])
+		}
+	}
+
+	if got := d.Get("staggered_rollout.0.failure_action"); got != "rollback" {
+		t.Errorf("staggered_rollout.0.failure_action: expected rollback, got %v", got)
+	}
+	if got := d.Get("staggered_rollout.0.parallelism"); got != 5 {
+		t.Errorf("staggered_rollout.0.parallelism: expected 5, got %v", got)
+	}
+	if got := d.Get("stagger_status"); got != "rolling_out" {
+		t.Errorf("stagger_status: expected rolling_out, got %v", got)
+	}
+}
+
+// TestEdgeStackCustomizeDiff_StaggeredRolloutVersion rejects staggered_rollout
+// on Portainer CE and on BE versions without staggered updates.
+func TestEdgeStackCustomizeDiff_StaggeredRolloutVersion(t *testing.T) {
+	cfg := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":               "edge-staggered",
+		"deployment_type":    0,
+		"edge_groups":        []interface{}{3},
+		"stack_file_content": "services: {}\n",
+		"staggered_rollout":  []interface{}{map[string]interface{}{"parallelism": 2}},
+	})
+
+	cases := []struct {
+		edition, version string
+		wantErr          bool
+	}{
+		{"CE", "2.27.0", true},
+		{"EE", "2.19.4", true},
+		{"EE", "2.20.0", false},
+		{"EE", "2.27.1", false},
+	}
+	for _, tc := range cases {
+		mock := NewMockServer(t)
+		mock.On("GET", "/system/version", RespondJSON(http.StatusOK, map[string]string{
+			"ServerEdition": tc.edition,
+			"ServerVersion": tc.version,
+		}))
+		_, err := resourceEdgeStack().Diff(context.Background(), nil, cfg, mock.Client())
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s %s: expected error=%v, got %v", tc.edition, tc.version, tc.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,1132 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// Labels and annotations set by the Portainer application form. Objects carrying
+// them are listed under Applications and can be edited from the UI.
+const (
+	portainerAppNameLabel      = "io.portainer.kubernetes.application.name"
+	portainerAppStackLabel     = "io.portainer.kubernetes.application.stack"
+	portainerAppOwnerLabel     = "io.portainer.kubernetes.application.owner"
+	portainerAppNoteAnnotation = "io.portainer.kubernetes.application.note"
+)
+
+func resourceKubernetesApp() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceKubernetesAppCreate,
+		ReadContext:   resourceKubernetesAppRead,
+		UpdateContext: resourceKubernetesAppUpdate,
+		DeleteContext: resourceKubernetesAppDelete,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceKubernetesAppImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"endpoint_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Identifier of the Portainer Kubernetes environment (endpoint) where the application is deployed.",
+			},
+			"namespace": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Kubernetes namespace of the application.",
+			},
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringMatch(regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`), "must be a lowercase RFC 1123 label"),
+				Description:  "Application name, used for the workload and its Service, autoscaler and volumes.",
+			},
+			"image": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Container image, e.g. `nginx:1.27`.",
+			},
+			"stack_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Stack the application is grouped under in the Portainer UI.",
+			},
+			"owner": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Portainer username recorded as the application owner.",
+			},
+			"note": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Free-form note displayed on the application page.",
+			},
+			"deployment_type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "Replicated",
+				ValidateFunc: validation.StringInSlice([]string{"Replicated", "Global"}, false),
+				Description:  "`Replicated` runs `replicas` instances; `Global` runs one instance per node (DaemonSet). Changing this value forces recreation.",
+			},
+			"data_access_policy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "Shared",
+				ValidateFunc: validation.StringInSlice([]string{"Shared", "Isolated"}, false),
+				Description:  "`Shared`: every instance mounts the same persisted folders (Deployment). `Isolated`: each instance gets its own volumes (StatefulSet). Changing this value forces recreation.",
+			},
+			"kind": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Kubernetes workload kind backing the application (Deployment, StatefulSet or DaemonSet).",
+			},
+			"replicas": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      1,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Number of instances for `Replicated` applications. Ignored when `autoscaler` is set.",
+			},
+			"cpu_limit": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "CPU reserved and limited per instance, e.g. `500m`.",
+			},
+			"memory_limit": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Memory reserved and limited per instance, e.g. `256Mi`.",
+			},
+			"env": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Environment variables of the container.",
+			},
+			"configuration": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "ConfigMaps and Secrets exposed to the application.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice([]string{"ConfigMap", "Secret"}, false),
+							Description:  "Configuration kind: `ConfigMap` or `Secret`.",
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Name of the ConfigMap or Secret in the application namespace.",
+						},
+						"mount_path": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Mount the entries as files under this path. When unset, the entries are exposed as environment variables.",
+						},
+					},
+				},
+			},
+			"persisted_folder": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Container paths backed by persistent volumes. Changing persisted folders forces recreation.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"path": {
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "Path inside the container.",
+						},
+						"size": {
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "Requested volume size, e.g. `1Gi`.",
+						},
+						"storage_class": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							ForceNew:    true,
+							Description: "StorageClass of the volume. Uses the cluster default when unset.",
+						},
+					},
+				},
+			},
+			"service_type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "ClusterIP",
+				ValidateFunc: validation.StringInSlice([]string{"ClusterIP", "NodePort", "LoadBalancer"}, false),
+				Description:  "How `published_port` entries are exposed: `ClusterIP` (internal), `NodePort` (cluster) or `LoadBalancer`.",
+			},
+			"published_port": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Container ports published through a Service named after the application.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"container_port": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IsPortNumber,
+							Description:  "Port the container listens on.",
+						},
+						"service_port": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IsPortNumber,
+							Description:  "Port exposed by the Service. Defaults to `container_port`.",
+						},
+						"node_port": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Description: "Node port for `NodePort` and `LoadBalancer` services. Allocated by Kubernetes when unset.",
+						},
+						"protocol": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "TCP",
+							ValidateFunc: validation.StringInSlice([]string{"TCP", "UDP"}, false),
+							Description:  "Port protocol: `TCP` (default) or `UDP`.",
+						},
+					},
+				},
+			},
+			"autoscaler": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Horizontal pod autoscaler for `Replicated` applications.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"min_replicas": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntAtLeast(1),
+							Description:  "Minimum number of instances.",
+						},
+						"max_replicas": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntAtLeast(1),
+							Description:  "Maximum number of instances.",
+						},
+						"target_cpu_utilization": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntBetween(1, 100),
+							Description:  "Average CPU utilization (percent of `cpu_limit`) the autoscaler aims for.",
+						},
+					},
+				},
+			},
+			"placement_policy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "Preferred",
+				ValidateFunc: validation.StringInSlice([]string{"Preferred", "Mandatory"}, false),
+				Description:  "Whether `placement` rules are `Preferred` or `Mandatory` when scheduling.",
+			},
+			"placement": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Node label rules used to place the application.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"label": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Node label key.",
+						},
+						"operator": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "In",
+							ValidateFunc: validation.StringInSlice([]string{"In", "NotIn", "Exists", "DoesNotExist"}, false),
+							Description:  "Match operator: `In` (default), `NotIn`, `Exists` or `DoesNotExist`.",
+						},
+						"value": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Label value, for the `In` and `NotIn` operators.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func kubernetesAppKind(d *schema.ResourceData) string {
+	switch {
+	case d.Get("deployment_type").(string) == "Global":
+		return "DaemonSet"
+	case d.Get("data_access_policy").(string) == "Isolated":
+		return "StatefulSet"
+	default:
+		return "Deployment"
+	}
+}
+
+func kubernetesAppCollectionURL(client *APIClient, envID int, namespace, kind string) string {
+	switch kind {
+	case "Service":
+		return k8sProxyURL(client, envID, "", namespace, "services")
+	case "PersistentVolumeClaim":
+		return k8sProxyURL(client, envID, "", namespace, "persistentvolumeclaims")
+	case "HorizontalPodAutoscaler":
+		return k8sProxyURL(client, envID, "autoscaling/v2", namespace, "horizontalpodautoscalers")
+	default:
+		return k8sProxyURL(client, envID, "apps/v1", namespace, strings.ToLower(kind)+"s")
+	}
+}
+
+// kubernetesAppMetadata returns the Portainer labels and annotations shared by
+// every object of the application.
+func kubernetesAppMetadata(d *schema.ResourceData, name string) map[string]interface{} {
+	labels := map[string]interface{}{
+		portainerAppNameLabel: name,
+	}
+	if v := d.Get("stack_name").(string); v != "" {
+		labels[portainerAppStackLabel] = v
+	}
+	if v := d.Get("owner").(string); v != "" {
+		labels[portainerAppOwnerLabel] = v
+	}
+	meta := map[string]interface{}{
+		"name":      name,
+		"namespace": d.Get("namespace").(string),
+		"labels":    labels,
+	}
+	if v := d.Get("note").(string); v != "" {
+		meta["annotations"] = map[string]interface{}{portainerAppNoteAnnotation: v}
+	}
+	return meta
+}
+
+func buildKubernetesAppWorkload(d *schema.ResourceData, kind string) map[string]interface{} {
+	name := d.Get("name").(string)
+
+	container := map[string]interface{}{
+		"name":  name,
+		"image": d.Get("image").(string),
+	}
+
+	envMap := d.Get("env").(map[string]interface{})
+	keys := make([]string, 0, len(envMap))
+	for k := range envMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	env := make([]map[string]interface{}, 0, len(keys))
+	for _, k := range keys {
+		env = append(env, map[string]interface{}{"name": k, "value": envMap[k]})
+	}
+	if len(env) > 0 {
+		container["env"] = env
+	}
+
+	resources := map[string]interface{}{}
+	if v := d.Get("cpu_limit").(string); v != "" {
+		resources["cpu"] = v
+	}
+	if v := d.Get("memory_limit").(string); v != "" {
+		resources["memory"] = v
+	}
+	if len(resources) > 0 {
+		// The form reserves what it limits.
+		container["resources"] = map[string]interface{}{"requests": resources, "limits": resources}
+	}
+
+	var ports []map[string]interface{}
+	for _, item := range d.Get("published_port").([]interface{}) {
+		p := item.(map[string]interface{})
+		ports = append(ports, map[string]interface{}{
+			"containerPort": p["container_port"],
+			"protocol":      p["protocol"],
+		})
+	}
+	if len(ports) > 0 {
+		container["ports"] = ports
+	}
+
+	var volumes, mounts, envFrom []map[string]interface{}
+	for i, item := range d.Get("configuration").([]interface{}) {
+		c := item.(map[string]interface{})
+		cfgName := c["name"].(string)
+		mountPath := c["mount_path"].(string)
+		if mountPath == "" {
+			ref := map[string]interface{}{"name": cfgName}
+			if c["type"] == "Secret" {
+				envFrom = append(envFrom, map[string]interface{}{"secretRef": ref})
+			} else {
+				envFrom = append(envFrom, map[string]interface{}{"configMapRef": ref})
+			}
+			continue
+		}
+		volName := fmt.Sprintf("config-%d", i)
+		if c["type"] == "Secret" {
+			volumes = append(volumes, map[string]interface{}{"name": volName, "secret": map[string]interface{}{"secretName": cfgName}})
+		} else {
+			volumes = append(volumes, map[string]interface{}{"name": volName, "configMap": map[string]interface{}{"name": cfgName}})
+		}
+		mounts = append(mounts, map[string]interface{}{"name": volName, "mountPath": mountPath, "readOnly": true})
+	}
+	if len(envFrom) > 0 {
+		container["envFrom"] = envFrom
+	}
+
+	var claimTemplates []map[string]interface{}
+	for i, item := range d.Get("persisted_folder").([]interface{}) {
+		f := item.(map[string]interface{})
+		volName := fmt.Sprintf("%s-data-%d", name, i)
+		mounts = append(mounts, map[string]interface{}{"name": volName, "mountPath": f["path"]})
+		if kind == "StatefulSet" {
+			claimTemplates = append(claimTemplates, buildKubernetesAppClaim(d, volName, f))
+			continue
+		}
+		volumes = append(volumes, map[string]interface{}{
+			"name":                  volName,
+			"persistentVolumeClaim": map[string]interface{}{"claimName": volName},
+		})
+	}
+	if len(mounts) > 0 {
+		container["volumeMounts"] = mounts
+	}
+
+	podLabels := map[string]interface{}{
+		"app":                 name,
+		portainerAppNameLabel: name,
+	}
+	podSpec := map[string]interface{}{
+		"containers": []interface{}{container},
+	}
+	if len(volumes) > 0 {
+		podSpec["volumes"] = volumes
+	}
+	if affinity := buildKubernetesAppAffinity(d); affinity != nil {
+		podSpec["affinity"] = affinity
+	}
+
+	spec := map[string]interface{}{
+		"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": name}},
+		"template": map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": podLabels},
+			"spec":     podSpec,
+		},
+	}
+	if kind != "DaemonSet" {
+		replicas := d.Get("replicas").(int)
+		if as := d.Get("autoscaler").([]interface{}); len(as) > 0 && as[0] != nil {
+			replicas = as[0].(map[string]interface{})["min_replicas"].(int)
+		}
+		spec["replicas"] = replicas
+	}
+	if kind == "StatefulSet" {
+		spec["serviceName"] = name + "-headless"
+		if len(claimTemplates) > 0 {
+			spec["volumeClaimTemplates"] = claimTemplates
+		}
+	}
+
+	return map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       kind,
+		"metadata":   kubernetesAppMetadata(d, name),
+		"spec":       spec,
+	}
+}
+
+func buildKubernetesAppClaim(d *schema.ResourceData, name string, folder map[string]interface{}) map[string]interface{} {
+	spec := map[string]interface{}{
+		"accessModes": []interface{}{"ReadWriteOnce"},
+		"resources": map[string]interface{}{
+			"requests": map[string]interface{}{"storage": folder["size"]},
+		},
+	}
+	if v := folder["storage_class"].(string); v != "" {
+		spec["storageClassName"] = v
+	}
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "PersistentVolumeClaim",
+		"metadata":   kubernetesAppMetadata(d, name),
+		"spec":       spec,
+	}
+}
+
+func buildKubernetesAppAffinity(d *schema.ResourceData) map[string]interface{} {
+	var exprs []map[string]interface{}
+	for _, item := range d.Get("placement").([]interface{}) {
+		p := item.(map[string]interface{})
+		expr := map[string]interface{}{
+			"key":      p["label"],
+			"operator": p["operator"],
+		}
+		if p["operator"] == "In" || p["operator"] == "NotIn" {
+			expr["values"] = []interface{}{p["value"]}
+		}
+		exprs = append(exprs, expr)
+	}
+	if len(exprs) == 0 {
+		return nil
+	}
+
+	term := map[string]interface{}{"matchExpressions": exprs}
+	nodeAffinity := map[string]interface{}{}
+	if d.Get("placement_policy").(string) == "Mandatory" {
+		nodeAffinity["requiredDuringSchedulingIgnoredDuringExecution"] = map[string]interface{}{
+			"nodeSelectorTerms": []interface{}{term},
+		}
+	} else {
+		nodeAffinity["preferredDuringSchedulingIgnoredDuringExecution"] = []interface{}{
+			map[string]interface{}{"weight": 1, "preference": term},
+		}
+	}
+	return map[string]interface{}{"nodeAffinity": nodeAffinity}
+}
+
+func buildKubernetesAppService(d *schema.ResourceData) map[string]interface{} {
+	name := d.Get("name").(string)
+	serviceType := d.Get("service_type").(string)
+
+	var ports []map[string]interface{}
+	for _, item := range d.Get("published_port").([]interface{}) {
+		p := item.(map[string]interface{})
+		servicePort := p["service_port"].(int)
+		if servicePort == 0 {
+			servicePort = p["container_port"].(int)
+		}
+		port := map[string]interface{}{
+			"name":       fmt.Sprintf("port-%d", len(ports)),
+			"port":       servicePort,
+			"targetPort": p["container_port"],
+			"protocol":   p["protocol"],
+		}
+		if v := p["node_port"].(int); v != 0 && serviceType != "ClusterIP" {
+			port["nodePort"] = v
+		}
+		ports = append(ports, port)
+	}
+
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   kubernetesAppMetadata(d, name),
+		"spec": map[string]interface{}{
+			"type":     serviceType,
+			"selector": map[string]interface{}{"app": name},
+			"ports":    ports,
+		},
+	}
+}
+
+func buildKubernetesAppAutoscaler(d *schema.ResourceData, kind string) map[string]interface{} {
+	name := d.Get("name").(string)
+	as := d.Get("autoscaler").([]interface{})[0].(map[string]interface{})
+	return map[string]interface{}{
+		"apiVersion": "autoscaling/v2",
+		"kind":       "HorizontalPodAutoscaler",
+		"metadata":   kubernetesAppMetadata(d, name),
+		"spec": map[string]interface{}{
+			"scaleTargetRef": map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       kind,
+				"name":       name,
+			},
+			"minReplicas": as["min_replicas"],
+			"maxReplicas": as["max_replicas"],
+			"metrics": []interface{}{
+				map[string]interface{}{
+					"type": "Resource",
+					"resource": map[string]interface{}{
+						"name": "cpu",
+						"target": map[string]interface{}{
+							"type":               "Utilization",
+							"averageUtilization": as["target_cpu_utilization"],
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// applyKubernetesApp converges every object of the application: shared volumes,
+// the workload, its Service and its autoscaler. Optional objects that are no
+// longer configured are removed.
+func applyKubernetesApp(ctx context.Context, d *schema.ResourceData, client *APIClient) error {
+	envID := d.Get("endpoint_id").(int)
+	namespace := d.Get("namespace").(string)
+	name := d.Get("name").(string)
+	kind := kubernetesAppKind(d)
+
+	if kind != "StatefulSet" {
+		pvcURL := kubernetesAppCollectionURL(client, envID, namespace, "PersistentVolumeClaim")
+		for i, item := range d.Get("persisted_folder").([]interface{}) {
+			volName := fmt.Sprintf("%s-data-%d", name, i)
+			// Claims are immutable once bound, so they are only created.
+			data, status, err := k8sProxyDo(ctx, client, http.MethodGet, pvcURL+"/"+volName, nil)
+			if err != nil {
+				return err
+			}
+			if status == http.StatusNotFound {
+				data, status, err = k8sProxyDo(ctx, client, http.MethodPost, pvcURL, buildKubernetesAppClaim(d, volName, item.(map[string]interface{})))
+				if err != nil {
+					return err
+				}
+			}
+			if status < 200 || status >= 300 {
+				return fmt.Errorf("failed to apply PersistentVolumeClaim %q (%d): %s", volName, status, string(data))
+			}
+		}
+	}
+
+	workloadURL := kubernetesAppCollectionURL(client, envID, namespace, kind)
+	workload := buildKubernetesAppWorkload(d, kind)
+	hasAutoscaler := len(d.Get("autoscaler").([]interface{})) > 0
+	if hasAutoscaler && d.Id() != "" {
+		// Keep the replica count chosen by the autoscaler instead of resetting it.
+		data, status, err := k8sProxyDo(ctx, client, http.MethodGet, workloadURL+"/"+name, nil)
+		if err != nil {
+			return err
+		}
+		var live k8sWorkload
+		if status == http.StatusOK && json.Unmarshal(data, &live) == nil && live.Spec.Replicas != nil {
+			workload["spec"].(map[string]interface{})["replicas"] = *live.Spec.Replicas
+		}
+	}
+	if err := k8sApplyObject(ctx, client, workloadURL, name, kind, workload); err != nil {
+		return err
+	}
+
+	svcURL := kubernetesAppCollectionURL(client, envID, namespace, "Service")
+	if kind == "StatefulSet" {
+		headless := map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   kubernetesAppMetadata(d, name+"-headless"),
+			"spec": map[string]interface{}{
+				"clusterIP": "None",
+				"selector":  map[string]interface{}{"app": name},
+			},
+		}
+		if err := k8sApplyObject(ctx, client, svcURL, name+"-headless", "Service", headless); err != nil {
+			return err
+		}
+	}
+	if len(d.Get("published_port").([]interface{})) > 0 {
+		if err := k8sApplyObject(ctx, client, svcURL, name, "Service", buildKubernetesAppService(d)); err != nil {
+			return err
+		}
+	} else if err := k8sDeleteObject(ctx, client, svcURL+"/"+name, "Service"); err != nil {
+		return err
+	}
+
+	hpaURL := kubernetesAppCollectionURL(client, envID, namespace, "HorizontalPodAutoscaler")
+	if hasAutoscaler {
+		if err := k8sApplyObject(ctx, client, hpaURL, name, "HorizontalPodAutoscaler", buildKubernetesAppAutoscaler(d, kind)); err != nil {
+			return err
+		}
+	} else if err := k8sDeleteObject(ctx, client, hpaURL+"/"+name, "HorizontalPodAutoscaler"); err != nil {
+		return err
+	}
+	return nil
+}
+
+func resourceKubernetesAppCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	if err := applyKubernetesApp(ctx, d, client); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(fmt.Sprintf("%d:%s:%s", d.Get("endpoint_id").(int), d.Get("namespace").(string), d.Get("name").(string)))
+	return resourceKubernetesAppRead(ctx, d, meta)
+}
+
+func resourceKubernetesAppUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout(schema.TimeoutUpdate))
+	defer cancel()
+
+	if err := applyKubernetesApp(ctx, d, client); err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceKubernetesAppRead(ctx, d, meta)
+}
+
+func resourceKubernetesAppRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+
+	envID, namespace, name := parseApllicationsID(d.Id())
+	if envID == 0 || namespace == "" || name == "" {
+		return diag.FromErr(fmt.Errorf("invalid ID format, expected 'endpointID:namespace:name': %s", d.Id()))
+	}
+
+	kind := kubernetesAppKind(d)
+	data, status, err := k8sProxyDo(ctx, client, http.MethodGet, kubernetesAppCollectionURL(client, envID, namespace, kind)+"/"+name, nil)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if status == http.StatusNotFound {
+		d.SetId("")
+		return nil
+	}
+	if status < 200 || status >= 300 {
+		return diag.FromErr(fmt.Errorf("failed to read %s %s (%d): %s", kind, name, status, string(data)))
+	}
+
+	var live kubernetesAppWorkload
+	if err := json.Unmarshal(data, &live); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to decode %s %s: %w", kind, name, err))
+	}
+
+	values := map[string]interface{}{
+		"endpoint_id": envID,
+		"namespace":   namespace,
+		"name":        name,
+		"kind":        kind,
+		"stack_name":  live.Metadata.Labels[portainerAppStackLabel],
+		"owner":       live.Metadata.Labels[portainerAppOwnerLabel],
+		"note":        live.Metadata.Annotations[portainerAppNoteAnnotation],
+	}
+	pod := live.Spec.Template.Spec
+	if len(pod.Containers) > 0 {
+		c := pod.Containers[0]
+		values["image"] = c.Image
+		env := map[string]interface{}{}
+		for _, e := range c.Env {
+			if len(e.ValueFrom) == 0 {
+				env[e.Name] = e.Value
+			}
+		}
+		values["env"] = env
+		values["configuration"] = kubernetesAppConfiguration(pod)
+		folders, err := kubernetesAppPersistedFolders(ctx, client, d, envID, namespace, kind, &live)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		values["persisted_folder"] = folders
+	}
+	if policy, placement := kubernetesAppPlacement(pod.Affinity.NodeAffinity); placement != nil {
+		values["placement_policy"] = policy
+		values["placement"] = placement
+	} else {
+		values["placement"] = []interface{}{}
+	}
+	// Under an autoscaler the live count is not ours to track.
+	if kind != "DaemonSet" && len(d.Get("autoscaler").([]interface{})) == 0 && live.Spec.Replicas != nil {
+		values["replicas"] = *live.Spec.Replicas
+	}
+
+	var service struct {
+		Spec struct {
+			Type  string `json:"type"`
+			Ports []struct {
+				Port       int         `json:"port"`
+				TargetPort interface{} `json:"targetPort"`
+				NodePort   int         `json:"nodePort"`
+				Protocol   string      `json:"protocol"`
+			} `json:"ports"`
+		} `json:"spec"`
+	}
+	found, err := kubernetesAppGetObject(ctx, client, kubernetesAppCollectionURL(client, envID, namespace, "Service")+"/"+name, "Service", &service)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	ports := []interface{}{}
+	if found {
+		prior := d.Get("published_port").([]interface{})
+		for i, p := range service.Spec.Ports {
+			containerPort := p.Port
+			if v, ok := p.TargetPort.(float64); ok {
+				containerPort = int(v)
+			}
+			port := map[string]interface{}{
+				"container_port": containerPort,
+				"service_port":   p.Port,
+				"node_port":      p.NodePort,
+				"protocol":       p.Protocol,
+			}
+			// Defaults and allocations stay unset when they were not configured.
+			if i < len(prior) && prior[i] != nil {
+				was := prior[i].(map[string]interface{})
+				if was["service_port"].(int) == 0 && p.Port == containerPort {
+					port["service_port"] = 0
+				}
+				if was["node_port"].(int) == 0 {
+					port["node_port"] = 0
+				}
+			}
+			ports = append(ports, port)
+		}
+		values["service_type"] = service.Spec.Type
+	}
+	values["published_port"] = ports
+
+	var hpa struct {
+		Spec struct {
+			MinReplicas int `json:"minReplicas"`
+			MaxReplicas int `json:"maxReplicas"`
+			Metrics     []struct {
+				Resource struct {
+					Name   string `json:"name"`
+					Target struct {
+						AverageUtilization int `json:"averageUtilization"`
+					} `json:"target"`
+				} `json:"resource"`
+			} `json:"metrics"`
+		} `json:"spec"`
+	}
+	found, err = kubernetesAppGetObject(ctx, client, kubernetesAppCollectionURL(client, envID, namespace, "HorizontalPodAutoscaler")+"/"+name, "HorizontalPodAutoscaler", &hpa)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	autoscaler := []interface{}{}
+	if found {
+		target := 0
+		for _, m := range hpa.Spec.Metrics {
+			if m.Resource.Name == "cpu" {
+				target = m.Resource.Target.AverageUtilization
+			}
+		}
+		autoscaler = append(autoscaler, map[string]interface{}{
+			"min_replicas":           hpa.Spec.MinReplicas,
+			"max_replicas":           hpa.Spec.MaxReplicas,
+			"target_cpu_utilization": target,
+		})
+	}
+	values["autoscaler"] = autoscaler
+
+	for k, v := range values {
+		if err := d.Set(k, v); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return nil
+}
+
+// kubernetesAppWorkload is the part of the application workload mapped back
+// to the form model.
+type kubernetesAppWorkload struct {
+	Metadata struct {
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec struct {
+		Replicas *int `json:"replicas"`
+		Template struct {
+			Spec kubernetesAppPodSpec `json:"spec"`
+		} `json:"template"`
+		VolumeClaimTemplates []kubernetesAppClaim `json:"volumeClaimTemplates"`
+	} `json:"spec"`
+}
+
+type kubernetesAppPodSpec struct {
+	Containers []struct {
+		Image string `json:"image"`
+		Env   []struct {
+			Name      string          `json:"name"`
+			Value     string          `json:"value"`
+			ValueFrom json.RawMessage `json:"valueFrom"`
+		} `json:"env"`
+		EnvFrom []struct {
+			ConfigMapRef *struct {
+				Name string `json:"name"`
+			} `json:"configMapRef"`
+			SecretRef *struct {
+				Name string `json:"name"`
+			} `json:"secretRef"`
+		} `json:"envFrom"`
+		VolumeMounts []struct {
+			Name      string `json:"name"`
+			MountPath string `json:"mountPath"`
+		} `json:"volumeMounts"`
+	} `json:"containers"`
+	Volumes []struct {
+		Name      string `json:"name"`
+		ConfigMap *struct {
+			Name string `json:"name"`
+		} `json:"configMap"`
+		Secret *struct {
+			SecretName string `json:"secretName"`
+		} `json:"secret"`
+	} `json:"volumes"`
+	Affinity struct {
+		NodeAffinity kubernetesAppNodeAffinity `json:"nodeAffinity"`
+	} `json:"affinity"`
+}
+
+type kubernetesAppNodeSelectorTerm struct {
+	MatchExpressions []struct {
+		Key      string   `json:"key"`
+		Operator string   `json:"operator"`
+		Values   []string `json:"values"`
+	} `json:"matchExpressions"`
+}
+
+type kubernetesAppNodeAffinity struct {
+	Required *struct {
+		NodeSelectorTerms []kubernetesAppNodeSelectorTerm `json:"nodeSelectorTerms"`
+	} `json:"requiredDuringSchedulingIgnoredDuringExecution"`
+	Preferred []struct {
+		Preference kubernetesAppNodeSelectorTerm `json:"preference"`
+	} `json:"preferredDuringSchedulingIgnoredDuringExecution"`
+}
+
+type kubernetesAppClaim struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		StorageClassName string `json:"storageClassName"`
+		Resources        struct {
+			Requests struct {
+				Storage string `json:"storage"`
+			} `json:"requests"`
+		} `json:"resources"`
+	} `json:"spec"`
+}
+
+// kubernetesAppGetObject reads the object at url into out. It reports false
+// when the object does not exist.
+func kubernetesAppGetObject(ctx context.Context, client *APIClient, url, kind string, out interface{}) (bool, error) {
+	data, status, err := k8sProxyDo(ctx, client, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	if status == http.StatusNotFound {
+		return false, nil
+	}
+	if status < 200 || status >= 300 {
+		return false, fmt.Errorf("failed to read %s (%d): %s", kind, status, string(data))
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return false, fmt.Errorf("failed to decode %s: %w", kind, err)
+	}
+	return true, nil
+}
+
+// kubernetesAppConfiguration maps the ConfigMaps and Secrets of the pod back
+// to configuration entries. Mounted entries keep their position, recorded in
+// the volume name (config-<index>); the others fill the remaining positions.
+func kubernetesAppConfiguration(pod kubernetesAppPodSpec) []interface{} {
+	var fromEnv []interface{}
+	for _, e := range pod.Containers[0].EnvFrom {
+		switch {
+		case e.ConfigMapRef != nil:
+			fromEnv = append(fromEnv, map[string]interface{}{"type": "ConfigMap", "name": e.ConfigMapRef.Name, "mount_path": ""})
+		case e.SecretRef != nil:
+			fromEnv = append(fromEnv, map[string]interface{}{"type": "Secret", "name": e.SecretRef.Name, "mount_path": ""})
+		}
+	}
+
+	mountPaths := map[string]string{}
+	for _, m := range pod.Containers[0].VolumeMounts {
+		mountPaths[m.Name] = m.MountPath
+	}
+	mounted := map[int]interface{}{}
+	var indexes []int
+	for _, v := range pod.Volumes {
+		var i int
+		if _, err := fmt.Sscanf(v.Name, "config-%d", &i); err != nil || mountPaths[v.Name] == "" {
+			continue
+		}
+		switch {
+		case v.ConfigMap != nil:
+			mounted[i] = map[string]interface{}{"type": "ConfigMap", "name": v.ConfigMap.Name, "mount_path": mountPaths[v.Name]}
+		case v.Secret != nil:
+			mounted[i] = map[string]interface{}{"type": "Secret", "name": v.Secret.SecretName, "mount_path": mountPaths[v.Name]}
+		default:
+			continue
+		}
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	configuration := []interface{}{}
+	for i := 0; len(fromEnv) > 0 || len(indexes) > 0; i++ {
+		switch {
+		case len(indexes) > 0 && indexes[0] <= i:
+			configuration = append(configuration, mounted[indexes[0]])
+			indexes = indexes[1:]
+		case len(fromEnv) > 0:
+			configuration = append(configuration, fromEnv[0])
+			fromEnv = fromEnv[1:]
+		}
+	}
+	return configuration
+}
+
+// kubernetesAppPersistedFolders maps the volumes named <name>-data-<index>
+// back to persisted folders, with the size and StorageClass of their claims.
+// The StorageClass the cluster defaulted is not reported when none was set.
+func kubernetesAppPersistedFolders(ctx context.Context, client *APIClient, d *schema.ResourceData, envID int, namespace, kind string, live *kubernetesAppWorkload) ([]interface{}, error) {
+	name := d.Get("name").(string)
+	prior := d.Get("persisted_folder").([]interface{})
+	templates := map[string]kubernetesAppClaim{}
+	for _, c := range live.Spec.VolumeClaimTemplates {
+		templates[c.Metadata.Name] = c
+	}
+
+	folders := []interface{}{}
+	for _, m := range live.Spec.Template.Spec.Containers[0].VolumeMounts {
+		if !strings.HasPrefix(m.Name, name+"-data-") {
+			continue
+		}
+		claim, ok := templates[m.Name]
+		if kind != "StatefulSet" {
+			found, err := kubernetesAppGetObject(ctx, client, kubernetesAppCollectionURL(client, envID, namespace, "PersistentVolumeClaim")+"/"+m.Name, "PersistentVolumeClaim", &claim)
+			if err != nil {
+				return nil, err
+			}
+			ok = found
+		}
+		if !ok {
+			continue
+		}
+		folder := map[string]interface{}{
+			"path":          m.MountPath,
+			"size":          claim.Spec.Resources.Requests.Storage,
+			"storage_class": claim.Spec.StorageClassName,
+		}
+		if len(folders) < len(prior) && prior[len(folders)] != nil {
+			was := prior[len(folders)].(map[string]interface{})
+			if k8sQuantityEqual(was["size"].(string), claim.Spec.Resources.Requests.Storage) {
+				folder["size"] = was["size"]
+			}
+			if was["storage_class"].(string) == "" {
+				folder["storage_class"] = ""
+			}
+		}
+		folders = append(folders, folder)
+	}
+	return folders, nil
+}
+
+// kubernetesAppPlacement maps the node affinity back to placement rules and
+// their policy. It returns a nil placement when the pod has no node affinity.
+func kubernetesAppPlacement(affinity kubernetesAppNodeAffinity) (string, []interface{}) {
+	var term kubernetesAppNodeSelectorTerm
+	policy := "Preferred"
+	switch {
+	case affinity.Required != nil && len(affinity.Required.NodeSelectorTerms) > 0:
+		policy, term = "Mandatory", affinity.Required.NodeSelectorTerms[0]
+	case len(affinity.Preferred) > 0:
+		term = affinity.Preferred[0].Preference
+	default:
+		return "", nil
+	}
+	placement := []interface{}{}
+	for _, e := range term.MatchExpressions {
+		value := ""
+		if len(e.Values) > 0 {
+			value = e.Values[0]
+		}
+		placement = append(placement, map[string]interface{}{"label": e.Key, "operator": e.Operator, "value": value})
+	}
+	return policy, placement
+}
+
+func resourceKubernetesAppDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout(schema.TimeoutDelete))
+	defer cancel()
+
+	envID, namespace, name := parseApllicationsID(d.Id())
+	kind := kubernetesAppKind(d)
+
+	targets := []struct{ kind, url string }{
+		{"HorizontalPodAutoscaler", kubernetesAppCollectionURL(client, envID, namespace, "HorizontalPodAutoscaler") + "/" + name},
+		{"Service", kubernetesAppCollectionURL(client, envID, namespace, "Service") + "/" + name},
+		{kind, kubernetesAppCollectionURL(client, envID, namespace, kind) + "/" + name},
+	}
+	if kind == "StatefulSet" {
+		targets = append(targets, struct{ kind, url string }{"Service", kubernetesAppCollectionURL(client, envID, namespace, "Service") + "/" + name + "-headless"})
+	}
+	for _, t := range targets {
+		if err := k8sDeleteObject(ctx, client, t.url, t.kind); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	// Volumes (including the per-instance claims of a StatefulSet) carry the
+	// application label, so one collection delete removes them all.
+	pvcURL := kubernetesAppCollectionURL(client, envID, namespace, "PersistentVolumeClaim") + "?" +
+		url.Values{"labelSelector": {portainerAppNameLabel + "=" + name}}.Encode()
+	if err := k8sDeleteObject(ctx, client, pvcURL, "PersistentVolumeClaims"); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceKubernetesAppImport restores the identity fields and the workload kind
+// (deployment_type / data_access_policy) from the live object, trying the three
+// kinds the application form can produce.
+func resourceKubernetesAppImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*APIClient)
+
+	envID, namespace, name := parseApllicationsID(d.Id())
+	if envID == 0 || namespace == "" || name == "" {
+		return nil, fmt.Errorf("invalid ID format, expected 'endpointID:namespace:name': %s", d.Id())
+	}
+
+	for _, kind := range []string{"Deployment", "StatefulSet", "DaemonSet"} {
+		_, status, err := k8sProxyDo(ctx, client, http.MethodGet, kubernetesAppCollectionURL(client, envID, namespace, kind)+"/"+name, nil)
+		if err != nil {
+			return nil, err
+		}
+		if status != http.StatusOK {
+			continue
+		}
+		deploymentType, policy := "Replicated", "Shared"
+		switch kind {
+		case "DaemonSet":
+			deploymentType = "Global"
+		case "StatefulSet":
+			policy = "Isolated"
+		}
+		_ = d.Set("endpoint_id", envID)
+		_ = d.Set("namespace", namespace)
+		_ = d.Set("name", name)
+		_ = d.Set("deployment_type", deploymentType)
+		_ = d.Set("data_access_policy", policy)
+		return []*schema.ResourceData{d}, nil
+	}
+	return nil, fmt.Errorf("no Deployment, StatefulSet or DaemonSet named %q found in namespace %q", name, namespace)
+}
@@ -0,0 +1,432 @@
+package internal
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+const kubernetesAppDeploymentPath = "/endpoints/1/kubernetes/apis/apps/v1/namespaces/default/deployments"
+
+// TestKubernetesAppCreate_Deployment verifies the form model is rendered into a
+// Deployment, a Service and an autoscaler carrying the Portainer labels.
+func TestKubernetesAppCreate_Deployment(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("POST", kubernetesAppDeploymentPath, RespondJSON(http.StatusCreated, map[string]interface{}{}))
+	mock.On("POST", "/endpoints/1/kubernetes/api/v1/namespaces/default/services", RespondJSON(http.StatusCreated, map[string]interface{}{}))
+	mock.On("POST", "/endpoints/1/kubernetes/apis/autoscaling/v2/namespaces/default/horizontalpodautoscalers", RespondJSON(http.StatusCreated, map[string]interface{}{}))
+	mock.On("POST", "/endpoints/1/kubernetes/api/v1/namespaces/default/persistentvolumeclaims", RespondJSON(http.StatusCreated, map[string]interface{}{}))
+
+	r := resourceKubernetesApp()
+	d := r.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("namespace", "default")
+	_ = d.Set("name", "web")
+	_ = d.Set("image", "nginx:1.27")
+	_ = d.Set("stack_name", "frontend")
+	_ = d.Set("note", "managed by terraform")
+	_ = d.Set("deployment_type", "Replicated")
+	_ = d.Set("data_access_policy", "Shared")
+	_ = d.Set("service_type", "NodePort")
+	_ = d.Set("placement_policy", "Mandatory")
+	_ = d.Set("env", map[string]interface{}{"LOG_LEVEL": "info"})
+	_ = d.Set("configuration", []interface{}{
+		map[string]interface{}{"type": "ConfigMap", "name": "web-config", "mount_path": ""},
+		map[string]interface{}{"type": "Secret", "name": "web-tls", "mount_path": "/etc/tls"},
+	})
+	_ = d.Set("persisted_folder", []interface{}{
+		map[string]interface{}{"path": "/data", "size": "1Gi", "storage_class": ""},
+	})
+	_ = d.Set("published_port", []interface{}{
+		map[string]interface{}{"container_port": 80, "service_port": 0, "node_port": 30080, "protocol": "TCP"},
+	})
+	_ = d.Set("autoscaler", []interface{}{
+		map[string]interface{}{"min_replicas": 2, "max_replicas": 5, "target_cpu_utilization": 70},
+	})
+	_ = d.Set("placement", []interface{}{
+		map[string]interface{}{"label": "disktype", "operator": "In", "value": "ssd"},
+	})
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	post := mock.FindRequest("POST", kubernetesAppDeploymentPath)
+	if post == nil {
+		t.Fatal("expected Deployment POST")
+	}
+	var dep struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Labels      map[string]string `json:"labels"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+		Spec struct {
+			Replicas int `json:"replicas"`
+			Template struct {
+				Metadata struct {
+					Labels map[string]string `json:"labels"`
+				} `json:"metadata"`
+				Spec struct {
+					Affinity struct {
+						NodeAffinity map[string]interface{} `json:"nodeAffinity"`
+					} `json:"affinity"`
+					Volumes    []map[string]interface{} `json:"volumes"`
+					Containers []struct {
+						Image   string                   `json:"image"`
+						EnvFrom []map[string]interface{} `json:"envFrom"`
+						Mounts  []map[string]interface{} `json:"volumeMounts"`
+					} `json:"containers"`
+				} `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+	}
+	if err := post.DecodeJSON(&dep); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if dep.Kind != "Deployment" {
+		t.Errorf("kind: expected Deployment, got %q", dep.Kind)
+	}
+	if dep.Metadata.Labels[portainerAppNameLabel] != "web" || dep.Metadata.Labels[portainerAppStackLabel] != "frontend" {
+		t.Errorf("missing Portainer labels: %v", dep.Metadata.Labels)
+	}
+	if dep.Metadata.Annotations[portainerAppNoteAnnotation] != "managed by terraform" {
+		t.Errorf("missing note annotation: %v", dep.Metadata.Annotations)
+	}
+	if dep.Spec.Replicas != 2 {
+		t.Errorf("replicas: expected autoscaler minimum 2, got %d", dep.Spec.Replicas)
+	}
+	if dep.Spec.Template.Metadata.Labels["app"] != "web" {
+		t.Errorf("pod template labels: %v", dep.Spec.Template.Metadata.Labels)
+	}
+	if _, ok := dep.Spec.Template.Spec.Affinity.NodeAffinity["requiredDuringSchedulingIgnoredDuringExecution"]; !ok {
+		t.Errorf("expected mandatory node affinity, got %v", dep.Spec.Template.Spec.Affinity.NodeAffinity)
+	}
+	if len(dep.Spec.Template.Spec.Containers) != 1 {
+		t.Fatalf("expected one container, got %d", len(dep.Spec.Template.Spec.Containers))
+	}
+	c := dep.Spec.Template.Spec.Containers[0]
+	if len(c.EnvFrom) != 1 || len(c.Mounts) != 2 || len(dep.Spec.Template.Spec.Volumes) != 2 {
+		t.Errorf("unexpected configuration wiring: envFrom=%v mounts=%v volumes=%v", c.EnvFrom, c.Mounts, dep.Spec.Template.Spec.Volumes)
+	}
+
+	svc := mock.FindRequest("POST", "/endpoints/1/kubernetes/api/v1/namespaces/default/services")
+	if svc == nil {
+		t.Fatal("expected Service POST")
+	}
+	var svcBody struct {
+		Spec struct {
+			Type  string `json:"type"`
+			Ports []struct {
+				Port     int `json:"port"`
+				NodePort int `json:"nodePort"`
+			} `json:"ports"`
+		} `json:"spec"`
+	}
+	if err := svc.DecodeJSON(&svcBody); err != nil {
+		t.Fatalf("decode service: %v", err)
+	}
+	if svcBody.Spec.Type != "NodePort" || len(svcBody.Spec.Ports) != 1 || svcBody.Spec.Ports[0].Port != 80 || svcBody.Spec.Ports[0].NodePort != 30080 {
+		t.Errorf("unexpected service spec: %+v", svcBody.Spec)
+	}
+
+	if mock.FindRequest("POST", "/endpoints/1/kubernetes/apis/autoscaling/v2/namespaces/default/horizontalpodautoscalers") == nil {
+		t.Error("expected HorizontalPodAutoscaler POST")
+	}
+	if mock.FindRequest("POST", "/endpoints/1/kubernetes/api/v1/namespaces/default/persistentvolumeclaims") == nil {
+		t.Error("expected PersistentVolumeClaim POST")
+	}
+}
+
+// TestKubernetesAppCreate_Isolated verifies Isolated applications become a
+// StatefulSet with per-instance claims and a headless Service.
+func TestKubernetesAppCreate_Isolated(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("POST", "/endpoints/1/kubernetes/apis/apps/v1/namespaces/default/statefulsets", RespondJSON(http.StatusCreated, map[string]interface{}{}))
+	mock.On("POST", "/endpoints/1/kubernetes/api/v1/namespaces/default/services", RespondJSON(http.StatusCreated, map[string]interface{}{}))
+
+	r := resourceKubernetesApp()
+	d := r.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("namespace", "default")
+	_ = d.Set("name", "db")
+	_ = d.Set("image", "postgres:16")
+	_ = d.Set("deployment_type", "Replicated")
+	_ = d.Set("data_access_policy", "Isolated")
+	_ = d.Set("replicas", 3)
+	_ = d.Set("persisted_folder", []interface{}{
+		map[string]interface{}{"path": "/var/lib/postgresql/data", "size": "10Gi", "storage_class": "fast"},
+	})
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	post := mock.FindRequest("POST", "/endpoints/1/kubernetes/apis/apps/v1/namespaces/default/statefulsets")
+	if post == nil {
+		t.Fatal("expected StatefulSet POST")
+	}
+	var sts struct {
+		Spec struct {
+			ServiceName          string                   `json:"serviceName"`
+			VolumeClaimTemplates []map[string]interface{} `json:"volumeClaimTemplates"`
+		} `json:"spec"`
+	}
+	if err := post.DecodeJSON(&sts); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if sts.Spec.ServiceName != "db-headless" || len(sts.Spec.VolumeClaimTemplates) != 1 {
+		t.Errorf("unexpected StatefulSet spec: %+v", sts.Spec)
+	}
+	if mock.FindRequest("POST", "/endpoints/1/kubernetes/api/v1/namespaces/default/persistentvolumeclaims") != nil {
+		t.Error("Isolated applications must not create shared claims")
+	}
+}
+
+// TestKubernetesAppRead_Refresh verifies Read refreshes the form fields from the
+// live workload and clears the ID when it is gone.
+func TestKubernetesAppRead_Refresh(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", kubernetesAppDeploymentPath+"/web", RespondJSON(http.StatusOK, map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels":      map[string]interface{}{portainerAppNameLabel: "web", portainerAppStackLabel: "frontend"},
+			"annotations": map[string]interface{}{portainerAppNoteAnnotation: "hello"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": 4,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"image": "nginx:1.28",
+							"env":   []interface{}{map[string]interface{}{"name": "LOG_LEVEL", "value": "debug"}},
+						},
+					},
+				},
+			},
+		},
+	}))
+
+	r := resourceKubernetesApp()
+	d := r.Data(&terraform.InstanceState{
+		ID: "1:default:web",
+		Attributes: map[string]string{
+			"deployment_type":    "Replicated",
+			"data_access_policy": "Shared",
+		},
+	})
+
+	if err := rcRead(r, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if d.Get("image").(string) != "nginx:1.28" || d.Get("replicas").(int) != 4 {
+		t.Errorf("unexpected image/replicas: %v/%v", d.Get("image"), d.Get("replicas"))
+	}
+	if d.Get("stack_name").(string) != "frontend" || d.Get("note").(string) != "hello" {
+		t.Errorf("unexpected stack/note: %v/%v", d.Get("stack_name"), d.Get("note"))
+	}
+	if d.Get("env.LOG_LEVEL").(string) != "debug" {
+		t.Errorf("env.LOG_LEVEL: expected debug, got %v", d.Get("env.LOG_LEVEL"))
+	}
+	if d.Get("kind").(string) != "Deployment" {
+		t.Errorf("kind: expected Deployment, got %v", d.Get("kind"))
+	}
+
+	gone := NewMockServer(t)
+	if err := rcRead(r, d, gone.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if d.Id() != "" {
+		t.Errorf("expected ID to be cleared, got %q", d.Id())
+	}
+}
+
+// TestKubernetesAppRead_FormModel verifies ports, configurations, persisted
+// folders, the autoscaler and placement are mapped back from the live objects,
+// without reporting defaults and allocations that were not configured.
+func TestKubernetesAppRead_FormModel(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", kubernetesAppDeploymentPath+"/web", RespondJSON(http.StatusOK, map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{portainerAppNameLabel: "web"}},
+		"spec": map[string]interface{}{
+			"replicas": 2,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{
+						"image":   "nginx:1.27",
+						"envFrom": []interface{}{map[string]interface{}{"configMapRef": map[string]interface{}{"name": "web-config"}}},
+						"volumeMounts": []interface{}{
+							map[string]interface{}{"name": "config-0", "mountPath": "/etc/tls", "readOnly": true},
+							map[string]interface{}{"name": "web-data-0", "mountPath": "/data"},
+						},
+					}},
+					"volumes": []interface{}{
+						map[string]interface{}{"name": "config-0", "secret": map[string]interface{}{"secretName": "web-tls"}},
+						map[string]interface{}{"name": "web-data-0", "persistentVolumeClaim": map[string]interface{}{"claimName": "web-data-0"}},
+					},
+					"affinity": map[string]interface{}{"nodeAffinity": map[string]interface{}{
+						"requiredDuringSchedulingIgnoredDuringExecution": map[string]interface{}{
+							"nodeSelectorTerms": []interface{}{map[string]interface{}{"matchExpressions": []interface{}{
+								map[string]interface{}{"key": "disktype", "operator": "In", "values": []interface{}{"ssd"}},
+								map[string]interface{}{"key": "gpu", "operator": "DoesNotExist"},
+							}}},
+						},
+					}},
+				},
+			},
+		},
+	}))
+	mock.On("GET", "/endpoints/1/kubernetes/api/v1/namespaces/default/persistentvolumeclaims/web-data-0", RespondJSON(http.StatusOK, map[string]interface{}{
+		"spec": map[string]interface{}{"storageClassName": "standard", "resources": map[string]interface{}{"requests": map[string]interface{}{"storage": "1024Mi"}}},
+	}))
+	mock.On("GET", "/endpoints/1/kubernetes/api/v1/namespaces/default/services/web", RespondJSON(http.StatusOK, map[string]interface{}{
+		"spec": map[string]interface{}{"type": "NodePort", "ports": []interface{}{
+			map[string]interface{}{"port": 80, "targetPort": 80, "nodePort": 31234, "protocol": "TCP"},
+			map[string]interface{}{"port": 8443, "targetPort": 443, "nodePort": 30443, "protocol": "TCP"},
+		}},
+	}))
+	mock.On("GET", "/endpoints/1/kubernetes/apis/autoscaling/v2/namespaces/default/horizontalpodautoscalers/web", RespondJSON(http.StatusOK, map[string]interface{}{
+		"spec": map[string]interface{}{"minReplicas": 2, "maxReplicas": 6, "metrics": []interface{}{
+			map[string]interface{}{"type": "Resource", "resource": map[string]interface{}{"name": "cpu", "target": map[string]interface{}{"type": "Utilization", "averageUtilization": 80}}},
+		}},
+	}))
+
+	r := resourceKubernetesApp()
+	d := r.Data(&terraform.InstanceState{
+		ID: "1:default:web",
+		Attributes: map[string]string{
+			"name":                             "web",
+			"deployment_type":                  "Replicated",
+			"data_access_policy":               "Shared",
+			"persisted_folder.#":               "1",
+			"persisted_folder.0.path":          "/data",
+			"persisted_folder.0.size":          "1Gi",
+			"persisted_folder.0.storage_class": "",
+			"published_port.#":                 "1",
+			"published_port.0.container_port":  "80",
+			"published_port.0.service_port":    "0",
+			"published_port.0.node_port":       "0",
+			"published_port.0.protocol":        "TCP",
+		},
+	})
+
+	if err := rcRead(r, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	for key, want := range map[string]interface{}{
+		"configuration.#":                     2,
+		"configuration.0.type":                "Secret",
+		"configuration.0.name":                "web-tls",
+		"configuration.0.mount_path":          "/etc/tls",
+		"configuration.1.type":                "ConfigMap",
+		"configuration.1.name":                "web-config",
+		"configuration.1.mount_path":          "",
+		"persisted_folder.#":                  1,
+		"persisted_folder.0.path":             "/data",
+		"persisted_folder.0.size":             "1Gi",
+		"persisted_folder.0.storage_class":    "",
+		"service_type":                        "NodePort",
+		"published_port.#":                    2,
+		"published_port.0.container_port":     80,
+		"published_port.0.service_port":       0,
+		"published_port.0.node_port":          0,
+		"published_port.1.container_port":     443,
+		"published_port.1.service_port":       8443,
+		"published_port.1.node_port":          30443,
+		"autoscaler.0.min_replicas":           2,
+		"autoscaler.0.max_replicas":           6,
+		"autoscaler.0.target_cpu_utilization": 80,
+		"placement_policy":                    "Mandatory",
+		"placement.#":                         2,
+		"placement.0.label":                   "disktype",
+		"placement.0.value":                   "ssd",
+		"placement.1.label":                   "gpu",
+		"placement.1.operator":                "DoesNotExist",
+	} {
+		if got := d.Get(key); got != want {
+			t.Errorf("%s: expected %v, got %v", key, want, got)
+		}
+	}
+}
+
+// TestKubernetesAppUpdate_RemovesService verifies the Service and autoscaler are
+// deleted once no longer configured.
+func TestKubernetesAppUpdate_RemovesService(t *testing.T) {
+	mock := NewMockServer(t)
+	live := map[string]interface{}{"metadata": map[string]interface{}{"resourceVersion": "7"}}
+	mock.On("GET", kubernetesAppDeploymentPath+"/web", RespondJSON(http.StatusOK, live))
+	mock.On("PUT", kubernetesAppDeploymentPath+"/web", RespondJSON(http.StatusOK, live))
+	mock.On("DELETE", "/endpoints/1/kubernetes/api/v1/namespaces/default/services/web", RespondJSON(http.StatusOK, map[string]interface{}{}))
+
+	r := resourceKubernetesApp()
+	d := r.Data(&terraform.InstanceState{
+		ID: "1:default:web",
+		Attributes: map[string]string{
+			"endpoint_id":        "1",
+			"namespace":          "default",
+			"name":               "web",
+			"image":              "nginx:1.27",
+			"replicas":           "1",
+			"deployment_type":    "Replicated",
+			"data_access_policy": "Shared",
+			"service_type":       "ClusterIP",
+			"placement_policy":   "Preferred",
+		},
+	})
+	_ = d.Set("image", "nginx:1.28")
+
+	if err := rcUpdate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	put := mock.FindRequest("PUT", kubernetesAppDeploymentPath+"/web")
+	if put == nil {
+		t.Fatal("expected Deployment PUT")
+	}
+	var body struct {
+		Metadata struct {
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"metadata"`
+	}
+	if err := put.DecodeJSON(&body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.Metadata.ResourceVersion != "7" {
+		t.Errorf("expected resourceVersion 7, got %q", body.Metadata.ResourceVersion)
+	}
+	if mock.FindRequest("DELETE", "/endpoints/1/kubernetes/api/v1/namespaces/default/services/web") == nil {
+		t.Error("expected unused Service to be deleted")
+	}
+}
+
+// TestKubernetesAppDelete_RemovesObjects verifies Delete removes the workload,
+// its companions and the labelled volumes.
+func TestKubernetesAppDelete_RemovesObjects(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("DELETE", kubernetesAppDeploymentPath+"/web", RespondJSON(http.StatusOK, map[string]interface{}{}))
+	mock.On("DELETE", "/endpoints/1/kubernetes/api/v1/namespaces/default/persistentvolumeclaims", RespondJSON(http.StatusOK, map[string]interface{}{}))
+
+	r := resourceKubernetesApp()
+	d := r.TestResourceData()
+	d.SetId("1:default:web")
+	_ = d.Set("deployment_type", "Replicated")
+	_ = d.Set("data_access_policy", "Shared")
+
+	if err := rcDelete(r, d, mock.Client()); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if mock.FindRequest("DELETE", kubernetesAppDeploymentPath+"/web") == nil {
+		t.Error("expected Deployment DELETE")
+	}
+	pvc := mock.FindRequest("DELETE", "/endpoints/1/kubernetes/api/v1/namespaces/default/persistentvolumeclaims")
+	if pvc == nil {
+		t.Fatal("expected PersistentVolumeClaim collection DELETE")
+	}
+	if pvc.Query != "labelSelector=io.portainer.kubernetes.application.name%3Dweb" {
+		t.Errorf("unexpected label selector query: %q", pvc.Query)
+	}
+	if d.Id() != "" {
+		t.Errorf("expected ID to be cleared, got %q", d.Id())
+	}
+}
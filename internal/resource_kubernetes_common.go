@@ -1,10 +1,14 @@
 package internal
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -57,3 +61,220 @@ func k8sConfirmExistsByGET(ctx context.Context, d *schema.ResourceData, client *
 	}
 	return nil
 }
+
+// k8sProxyDo sends an authenticated request to url (typically a path under
+// /endpoints/{id}/kubernetes) and returns the raw response body and status code.
+// body, when non-nil, is JSON-encoded.
+func k8sProxyDo(ctx context.Context, client *APIClient, method, url string, body interface{}) ([]byte, int, error) {
+	var buf io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, 0, err
+		}
+		buf = bytes.NewBuffer(jsonBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, buf)
+	if err != nil {
+		return nil, 0, err
+	}
+	if client.APIKey != "" {
+		req.Header.Set("X-API-Key", client.APIKey)
+	} else if client.JWTToken != "" {
+		req.Header.Set("Authorization", "Bearer "+client.JWTToken)
+	} else {
+		return nil, 0, fmt.Errorf("no valid authentication method provided (api_key or jwt token)")
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return data, resp.StatusCode, nil
+}
+
+// k8sApplyObject creates or replaces the object name in collectionURL (e.g.
+// .../api/v1/namespaces/ns/resourcequotas). An existing object is replaced with a
+// PUT carrying its current resourceVersion; a missing one is created with a POST.
+func k8sApplyObject(ctx context.Context, client *APIClient, collectionURL, name, kind string, obj map[string]interface{}) error {
+	objURL := collectionURL + "/" + name
+	data, status, err := k8sProxyDo(ctx, client, http.MethodGet, objURL, nil)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case status == http.StatusNotFound:
+		data, status, err = k8sProxyDo(ctx, client, http.MethodPost, collectionURL, obj)
+	case status >= 200 && status < 300:
+		var live struct {
+			Metadata struct {
+				ResourceVersion string `json:"resourceVersion"`
+			} `json:"metadata"`
+		}
+		_ = json.Unmarshal(data, &live)
+		if meta, ok := obj["metadata"].(map[string]interface{}); ok && live.Metadata.ResourceVersion != "" {
+			meta["resourceVersion"] = live.Metadata.ResourceVersion
+		}
+		data, status, err = k8sProxyDo(ctx, client, http.MethodPut, objURL, obj)
+	default:
+		return fmt.Errorf("failed to read %s %q (%d): %s", kind, name, status, string(data))
+	}
+	if err != nil {
+		return err
+	}
+	if status < 200 || status >= 300 {
+		return fmt.Errorf("failed to apply %s %q (%d): %s", kind, name, status, string(data))
+	}
+	return nil
+}
+
+// k8sDeleteObject deletes the object at url. A 404 is treated as success so
+// cleanup of objects already removed out-of-band does not fail the apply.
+func k8sDeleteObject(ctx context.Context, client *APIClient, url, kind string) error {
+	data, status, err := k8sProxyDo(ctx, client, http.MethodDelete, url, nil)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusNotFound || (status >= 200 && status < 300) {
+		return nil
+	}
+	return fmt.Errorf("failed to delete %s (%d): %s", kind, status, string(data))
+}
+
+// k8sProxyURL builds a Kubernetes API URL through the Portainer proxy. group is
+// "" for the core API (api/v1) or e.g. "apps/v1" for named groups. An empty
+// namespace addresses the cluster-wide collection.
+func k8sProxyURL(client *APIClient, envID int, group, namespace, plural string) string {
+	prefix := "api/v1"
+	if group != "" {
+		prefix = "apis/" + group
+	}
+	if namespace == "" {
+		return fmt.Sprintf("%s/endpoints/%d/kubernetes/%s/%s", client.Endpoint, envID, prefix, plural)
+	}
+	return fmt.Sprintf("%s/endpoints/%d/kubernetes/%s/namespaces/%s/%s", client.Endpoint, envID, prefix, namespace, plural)
+}
+
+// k8sProxyList GETs a Kubernetes list endpoint and decodes its items into out,
+// which must be a pointer to a slice.
+func k8sProxyList(ctx context.Context, client *APIClient, url, kind string, out interface{}) error {
+	data, status, err := k8sProxyDo(ctx, client, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", kind, err)
+	}
+	if status < 200 || status >= 300 {
+		return fmt.Errorf("failed to list %s (%d): %s", kind, status, string(data))
+	}
+	var list struct {
+		Items json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("failed to decode %s list: %w", kind, err)
+	}
+	if len(list.Items) == 0 || string(list.Items) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(list.Items, out); err != nil {
+		return fmt.Errorf("failed to decode %s list: %w", kind, err)
+	}
+	return nil
+}
+
+// k8sTypedMetadataSchema returns the labels/annotations attributes shared by the
+// typed (non-manifest) Kubernetes resources. kind is used in the descriptions.
+func k8sTypedMetadataSchema(kind string) map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"labels": {
+			Type:        schema.TypeMap,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: fmt.Sprintf("Labels applied to the %s (typed form only).", kind),
+		},
+		"annotations": {
+			Type:        schema.TypeMap,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: fmt.Sprintf("Annotations applied to the %s (typed form only).", kind),
+		},
+	}
+}
+
+// k8sTypedMetadata builds the metadata block of a typed resource from its name,
+// namespace, labels and annotations.
+func k8sTypedMetadata(d *schema.ResourceData, name, namespace string) map[string]interface{} {
+	meta := map[string]interface{}{
+		"name":      name,
+		"namespace": namespace,
+	}
+	if labels := d.Get("labels").(map[string]interface{}); len(labels) > 0 {
+		meta["labels"] = labels
+	}
+	if annotations := d.Get("annotations").(map[string]interface{}); len(annotations) > 0 {
+		meta["annotations"] = annotations
+	}
+	return meta
+}
+
+// k8sRefreshAuthored returns the live values of the keys present in authored and
+// drops the keys no longer present live. Keys added out-of-band (controller or
+// admission labels, kubectl annotations, …) are ignored so they never produce a
+// permanent diff, while edits and deletions of authored keys show up as drift.
+func k8sRefreshAuthored(authored map[string]interface{}, live map[string]string) map[string]interface{} {
+	out := make(map[string]interface{}, len(authored))
+	for k := range authored {
+		if v, ok := live[k]; ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// k8sSetTypedMetadata refreshes labels and annotations with k8sRefreshAuthored.
+func k8sSetTypedMetadata(d *schema.ResourceData, labels, annotations map[string]string) error {
+	if err := d.Set("labels", k8sRefreshAuthored(d.Get("labels").(map[string]interface{}), labels)); err != nil {
+		return err
+	}
+	return d.Set("annotations", k8sRefreshAuthored(d.Get("annotations").(map[string]interface{}), annotations))
+}
+
+// k8sQuantitySuffixes are the multipliers of the Kubernetes quantity suffixes.
+var k8sQuantitySuffixes = map[string]*big.Rat{
+	"n": big.NewRat(1, 1e9), "u": big.NewRat(1, 1e6), "m": big.NewRat(1, 1e3), "": big.NewRat(1, 1),
+	"k": big.NewRat(1e3, 1), "M": big.NewRat(1e6, 1), "G": big.NewRat(1e9, 1),
+	"T": big.NewRat(1e12, 1), "P": big.NewRat(1e15, 1), "E": big.NewRat(1e18, 1),
+	"Ki": big.NewRat(1<<10, 1), "Mi": big.NewRat(1<<20, 1), "Gi": big.NewRat(1<<30, 1),
+	"Ti": big.NewRat(1<<40, 1), "Pi": big.NewRat(1<<50, 1), "Ei": big.NewRat(1<<60, 1),
+}
+
+// k8sQuantityEqual reports whether two Kubernetes quantities are equal, e.g.
+// "1" and "1000m" or "1Gi" and "1024Mi". The API server returns quantities in
+// canonical form, which may differ from the configured string.
+func k8sQuantityEqual(a, b string) bool {
+	if a == b {
+		return true
+	}
+	x, okA := parseK8sQuantity(a)
+	y, okB := parseK8sQuantity(b)
+	return okA && okB && x.Cmp(y) == 0
+}
+
+// parseK8sQuantity parses a quantity such as "500m", "1.5Gi" or "1e3".
+func parseK8sQuantity(s string) (*big.Rat, bool) {
+	number := strings.TrimRight(s, "numkKMGTPEi")
+	multiplier, ok := k8sQuantitySuffixes[s[len(number):]]
+	if !ok || number == "" {
+		return nil, false
+	}
+	value, ok := new(big.Rat).SetString(number)
+	if !ok {
+		return nil, false
+	}
+	return value.Mul(value, multiplier), true
+}
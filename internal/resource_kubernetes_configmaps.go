@@ -14,6 +14,48 @@ import (
 )
 
 func resourceKubernetesConfigMaps() *schema.Resource {
+	resourceSchema := map[string]*schema.Schema{
+		"endpoint_id": {
+			Type:        schema.TypeInt,
+			Required:    true,
+			ForceNew:    true,
+			Description: "Identifier of the Portainer Kubernetes environment (endpoint) where the ConfigMap is managed. Changing this value forces resource recreation.",
+		},
+		"namespace": {
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "Kubernetes namespace in which the ConfigMap is created.",
+		},
+		"manifest": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ExactlyOneOf: []string{"manifest", "name"},
+			Description:  "Raw YAML or JSON manifest defining the Kubernetes ConfigMap. Alternative to the typed attributes.",
+		},
+		"name": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ForceNew:     true,
+			ExactlyOneOf: []string{"manifest", "name"},
+			Description:  "Name of the ConfigMap (typed form). Changing this value forces resource recreation.",
+		},
+		"data": {
+			Type:        schema.TypeMap,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "UTF-8 key/value entries of the ConfigMap (typed form only).",
+		},
+		"binary_data": {
+			Type:        schema.TypeMap,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Base64-encoded binary entries of the ConfigMap (typed form only).",
+		},
+	}
+	for k, v := range k8sTypedMetadataSchema("ConfigMap") {
+		resourceSchema[k] = v
+	}
+
 	return &schema.Resource{
 		CreateContext: resourceKubernetesConfigMapsCreate,
 		ReadContext:   resourceKubernetesConfigMapsRead,
@@ -24,24 +66,7 @@ func resourceKubernetesConfigMaps() *schema.Resource {
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 
-		Schema: map[string]*schema.Schema{
-			"endpoint_id": {
-				Type:        schema.TypeInt,
-				Required:    true,
-				ForceNew:    true,
-				Description: "Identifier of the Portainer Kubernetes environment (endpoint) where the ConfigMap is managed. Changing this value forces resource recreation.",
-			},
-			"namespace": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Kubernetes namespace in which the ConfigMap is created.",
-			},
-			"manifest": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Raw YAML or JSON manifest defining the Kubernetes ConfigMap.",
-			},
-		},
+		Schema: resourceSchema,
 	}
 }
 
@@ -52,6 +77,20 @@ func resourceKubernetesConfigMapsCreate(ctx context.Context, d *schema.ResourceD
 	namespace := d.Get("namespace").(string)
 	manifest := d.Get("manifest").(string)
 
+	if manifest == "" {
+		name := d.Get("name").(string)
+		url := fmt.Sprintf("%s/endpoints/%d/kubernetes/api/v1/namespaces/%s/configmaps", client.Endpoint, endpointID, namespace)
+		data, status, err := k8sProxyDo(ctx, client, http.MethodPost, url, buildKubernetesConfigMapObject(d))
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("failed to create ConfigMap: %w", err))
+		}
+		if status < 200 || status >= 300 {
+			return diag.FromErr(fmt.Errorf("failed to create ConfigMap (%d): %s", status, string(data)))
+		}
+		d.SetId(fmt.Sprintf("%d:%s:%s", endpointID, namespace, name))
+		return resourceKubernetesConfigMapsRead(ctx, d, meta)
+	}
+
 	parsed, err := parseManifest(manifest)
 	if err != nil {
 		return diag.FromErr(fmt.Errorf("manifest must be valid JSON or YAML: %w", err))
@@ -136,6 +175,15 @@ func resourceKubernetesConfigMapsDelete(ctx context.Context, d *schema.ResourceD
 }
 
 func resourceKubernetesConfigMapsUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if d.Get("manifest").(string) == "" && !d.HasChanges("manifest", "namespace") {
+		client := meta.(*APIClient)
+		endpointID, namespace, name := parseConfigMapsID(d.Id())
+		url := fmt.Sprintf("%s/endpoints/%d/kubernetes/api/v1/namespaces/%s/configmaps", client.Endpoint, endpointID, namespace)
+		if err := k8sApplyObject(ctx, client, url, name, "ConfigMap", buildKubernetesConfigMapObject(d)); err != nil {
+			return diag.FromErr(err)
+		}
+		return resourceKubernetesConfigMapsRead(ctx, d, meta)
+	}
 	if diags := resourceKubernetesConfigMapsDelete(ctx, d, meta); diags.HasError() {
 		return diags
 	}
@@ -151,6 +199,9 @@ func resourceKubernetesConfigMapsRead(ctx context.Context, d *schema.ResourceDat
 	}
 
 	url := fmt.Sprintf("%s/endpoints/%d/kubernetes/api/v1/namespaces/%s/configmaps/%s", client.Endpoint, endpointID, namespace, name)
+	if d.Get("manifest").(string) == "" {
+		return readKubernetesConfigMapTyped(ctx, d, client, url, endpointID, namespace, name)
+	}
 	if diags := k8sConfirmExistsByGET(ctx, d, client, url, "configmap "+name); diags.HasError() {
 		return diags
 	}
@@ -178,3 +229,64 @@ func parseConfigMapsID(id string) (endpointID int, namespace string, name string
 	name = parts[2]
 	return
 }
+
+func buildKubernetesConfigMapObject(d *schema.ResourceData) map[string]interface{} {
+	obj := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   k8sTypedMetadata(d, d.Get("name").(string), d.Get("namespace").(string)),
+	}
+	if v := d.Get("data").(map[string]interface{}); len(v) > 0 {
+		obj["data"] = v
+	}
+	if v := d.Get("binary_data").(map[string]interface{}); len(v) > 0 {
+		obj["binaryData"] = v
+	}
+	return obj
+}
+
+// readKubernetesConfigMapTyped refreshes the typed attributes from the live
+// ConfigMap. data and binary_data are owned entirely by the resource, so keys
+// added out-of-band show up as drift too.
+func readKubernetesConfigMapTyped(ctx context.Context, d *schema.ResourceData, client *APIClient, url string, endpointID int, namespace, name string) diag.Diagnostics {
+	body, status, err := k8sProxyDo(ctx, client, http.MethodGet, url, nil)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if status == http.StatusNotFound {
+		d.SetId("")
+		return nil
+	}
+	if status < 200 || status >= 300 {
+		return diag.FromErr(fmt.Errorf("failed to read configmap %s (%d): %s", name, status, string(body)))
+	}
+
+	var cm struct {
+		Metadata struct {
+			Labels      map[string]string `json:"labels"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+		Data       map[string]string `json:"data"`
+		BinaryData map[string]string `json:"binaryData"`
+	}
+	if err := json.Unmarshal(body, &cm); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to decode configmap %s: %w", name, err))
+	}
+
+	values := map[string]interface{}{
+		"endpoint_id": endpointID,
+		"namespace":   namespace,
+		"name":        name,
+		"data":        cm.Data,
+		"binary_data": cm.BinaryData,
+	}
+	for k, v := range values {
+		if err := d.Set(k, v); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if err := k8sSetTypedMetadata(d, cm.Metadata.Labels, cm.Metadata.Annotations); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
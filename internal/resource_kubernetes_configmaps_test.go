@@ -3,6 +3,8 @@ package internal
 import (
 	"net/http"
 	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 const configMapManifestJSON = `{
@@ -135,3 +137,75 @@ func TestKubernetesConfigMapsParseID(t *testing.T) {
 		t.Errorf("expected zero values on malformed ID, got (%d, %q, %q)", endpointID, namespace, name)
 	}
 }
+
+// TestKubernetesConfigMapsCreate_Typed verifies the typed form sends data and
+// binaryData and records the composite ID.
+func TestKubernetesConfigMapsCreate_Typed(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("POST", "/endpoints/1/kubernetes/api/v1/namespaces/default/configmaps", RespondJSON(http.StatusCreated, map[string]interface{}{}))
+	mock.On("GET", "/endpoints/1/kubernetes/api/v1/namespaces/default/configmaps/app", RespondJSON(http.StatusOK, map[string]interface{}{
+		"metadata":   map[string]interface{}{"name": "app"},
+		"data":       map[string]interface{}{"LOG_LEVEL": "info"},
+		"binaryData": map[string]interface{}{"logo.png": "iVBORw0K"},
+	}))
+
+	r := resourceKubernetesConfigMaps()
+	d := r.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("namespace", "default")
+	_ = d.Set("name", "app")
+	_ = d.Set("data", map[string]interface{}{"LOG_LEVEL": "info"})
+	_ = d.Set("binary_data", map[string]interface{}{"logo.png": "iVBORw0K"})
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if d.Id() != "1:default:app" {
+		t.Errorf("expected ID %q, got %q", "1:default:app", d.Id())
+	}
+
+	post := mock.FindRequest("POST", "/endpoints/1/kubernetes/api/v1/namespaces/default/configmaps")
+	if post == nil {
+		t.Fatal("expected POST request to be recorded")
+	}
+	var payload map[string]interface{}
+	if err := post.DecodeJSON(&payload); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if data, _ := payload["data"].(map[string]interface{}); data["LOG_LEVEL"] != "info" {
+		t.Errorf("payload.data: unexpected %v", payload["data"])
+	}
+	if bin, _ := payload["binaryData"].(map[string]interface{}); bin["logo.png"] != "iVBORw0K" {
+		t.Errorf("payload.binaryData: unexpected %v", payload["binaryData"])
+	}
+}
+
+// TestKubernetesConfigMapsRead_TypedDrift verifies out-of-band edits to data
+// are reflected in state.
+func TestKubernetesConfigMapsRead_TypedDrift(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/kubernetes/api/v1/namespaces/default/configmaps/app", RespondJSON(http.StatusOK, map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "app"},
+		"data":     map[string]interface{}{"LOG_LEVEL": "debug", "EXTRA": "1"},
+	}))
+
+	r := resourceKubernetesConfigMaps()
+	d := r.Data(&terraform.InstanceState{
+		ID: "1:default:app",
+		Attributes: map[string]string{
+			"endpoint_id":    "1",
+			"namespace":      "default",
+			"name":           "app",
+			"data.%":         "1",
+			"data.LOG_LEVEL": "info",
+		},
+	})
+
+	if err := rcRead(r, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	data := d.Get("data").(map[string]interface{})
+	if data["LOG_LEVEL"] != "debug" || data["EXTRA"] != "1" {
+		t.Errorf("expected live data in state, got %v", data)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -51,10 +52,167 @@ func resourceKubernetesNamespace() *schema.Resource {
 				Description: "Map of annotations applied to the Kubernetes namespace.",
 			},
 			"resource_quota": {
-				Type:        schema.TypeMap,
+				Type:          schema.TypeMap,
+				Optional:      true,
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				ConflictsWith: []string{"quota"},
+				Description:   "Resource quota limits applied to the namespace (e.g. cpu, memory limits and requests).",
+			},
+			"quota": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				MaxItems:      1,
+				ConflictsWith: []string{"resource_quota"},
+				Description:   "Typed ResourceQuota for the namespace. Hard limits Portainer's namespace API can express are sent through Portainer; everything else is applied as a native ResourceQuota through the Kubernetes proxy.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "terraform-quota",
+							Description: "Name of the native ResourceQuota object created when Portainer cannot express the quota.",
+						},
+						"hard": {
+							Type:        schema.TypeMap,
+							Optional:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Arbitrary ResourceQuota spec.hard entries (e.g. `requests.cpu`, `limits.memory`, `pods`, `services`, `requests.storage`).",
+						},
+						"object_counts": {
+							Type:        schema.TypeMap,
+							Optional:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Object count quotas keyed by resource (e.g. `pods`, `secrets`, `deployments.apps`). Each key is expanded to `count/<resource>`.",
+						},
+						"storage_class": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							Description: "Per-StorageClass storage quotas.",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"name": {
+										Type:        schema.TypeString,
+										Required:    true,
+										Description: "Name of the StorageClass the quota applies to.",
+									},
+									"requests_storage": {
+										Type:        schema.TypeString,
+										Optional:    true,
+										Description: "Total storage that PVCs of this class may request (e.g. `100Gi`).",
+									},
+									"persistent_volume_claims": {
+										Type:        schema.TypeString,
+										Optional:    true,
+										Description: "Maximum number of PVCs of this class.",
+									},
+								},
+							},
+						},
+						"scopes": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							Description: "Quota scopes (`Terminating`, `NotTerminating`, `BestEffort`, `NotBestEffort`, `PriorityClass`, `CrossNamespacePodAffinity`). A scoped quota is always applied natively.",
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validation.StringInSlice([]string{"Terminating", "NotTerminating", "BestEffort", "NotBestEffort", "PriorityClass", "CrossNamespacePodAffinity"}, false),
+							},
+						},
+						"scope_selector": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							Description: "Scope selector match expressions. A quota with a scope selector is always applied natively.",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"scope_name": {
+										Type:        schema.TypeString,
+										Required:    true,
+										Description: "Name of the scope the expression applies to (e.g. `PriorityClass`).",
+									},
+									"operator": {
+										Type:         schema.TypeString,
+										Required:     true,
+										ValidateFunc: validation.StringInSlice([]string{"In", "NotIn", "Exists", "DoesNotExist"}, false),
+										Description:  "Selector operator: `In`, `NotIn`, `Exists` or `DoesNotExist`.",
+									},
+									"values": {
+										Type:        schema.TypeList,
+										Optional:    true,
+										Elem:        &schema.Schema{Type: schema.TypeString},
+										Description: "Values for the `In` and `NotIn` operators.",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"limit_range": {
+				Type:        schema.TypeList,
 				Optional:    true,
+				MaxItems:    1,
+				Description: "LimitRange applied to the namespace through the Kubernetes proxy (Portainer's namespace API has no equivalent).",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "terraform-limits",
+							Description: "Name of the LimitRange object.",
+						},
+						"limit": {
+							Type:        schema.TypeList,
+							Required:    true,
+							MinItems:    1,
+							Description: "Limit entries, one per object type.",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"type": {
+										Type:         schema.TypeString,
+										Required:     true,
+										ValidateFunc: validation.StringInSlice([]string{"Container", "Pod", "PersistentVolumeClaim"}, false),
+										Description:  "Object type the limits apply to: `Container`, `Pod` or `PersistentVolumeClaim`.",
+									},
+									"default": {
+										Type:        schema.TypeMap,
+										Optional:    true,
+										Elem:        &schema.Schema{Type: schema.TypeString},
+										Description: "Default limits applied to containers that do not set any (Container only).",
+									},
+									"default_request": {
+										Type:        schema.TypeMap,
+										Optional:    true,
+										Elem:        &schema.Schema{Type: schema.TypeString},
+										Description: "Default requests applied to containers that do not set any (Container only).",
+									},
+									"max": {
+										Type:        schema.TypeMap,
+										Optional:    true,
+										Elem:        &schema.Schema{Type: schema.TypeString},
+										Description: "Maximum allowed resources.",
+									},
+									"min": {
+										Type:        schema.TypeMap,
+										Optional:    true,
+										Elem:        &schema.Schema{Type: schema.TypeString},
+										Description: "Minimum required resources.",
+									},
+									"max_limit_request_ratio": {
+										Type:        schema.TypeMap,
+										Optional:    true,
+										Elem:        &schema.Schema{Type: schema.TypeString},
+										Description: "Maximum ratio of limit to request per resource.",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"native_objects": {
+				Type:        schema.TypeList,
+				Computed:    true,
 				Elem:        &schema.Schema{Type: schema.TypeString},
-				Description: "Resource quota limits applied to the namespace (e.g. cpu, memory limits and requests).",
+				Description: "Native Kubernetes objects managed through the proxy for this namespace, as `Kind/name`.",
 			},
 		},
 	}
@@ -76,29 +234,7 @@ func resourceKubernetesNamespaceCreate(ctx context.Context, d *schema.ResourceDa
 		}
 	}
 
-	quota := map[string]string{}
-	if raw, ok := d.GetOk("resource_quota"); ok {
-		for k, v := range raw.(map[string]interface{}) {
-			quota[k] = v.(string)
-		}
-	}
-
-	var rq map[string]interface{}
-	if licensed {
-		rq = map[string]interface{}{
-			"enabled":       true,
-			"cpuRequest":    quota["cpu_request"],
-			"cpuLimit":      quota["cpu_limit"],
-			"memoryRequest": quota["memory_request"],
-			"memoryLimit":   quota["memory_limit"],
-		}
-	} else {
-		rq = map[string]interface{}{
-			"enabled": true,
-			"cpu":     quota["cpu"],
-			"memory":  quota["memory"],
-		}
-	}
+	rq, nativeHard := buildNamespaceResourceQuota(d, licensed)
 
 	body := map[string]interface{}{
 		"Name":          d.Get("name").(string),
@@ -135,6 +271,10 @@ func resourceKubernetesNamespaceCreate(ctx context.Context, d *schema.ResourceDa
 
 	envID := strconv.Itoa(id)
 	d.SetId(fmt.Sprintf("%s:%s", envID, d.Get("name").(string)))
+
+	if err := applyNamespaceNativePolicies(ctx, d, client, id, d.Get("name").(string), nativeHard); err != nil {
+		return diag.FromErr(err)
+	}
 	return resourceKubernetesNamespaceRead(ctx, d, meta)
 }
 
@@ -236,12 +376,59 @@ func resourceKubernetesNamespaceRead(ctx context.Context, d *schema.ResourceData
 			quota["memory_request"] = v
 		}
 	}
-	if len(quota) > 0 {
+	// With a typed quota block the Portainer-managed keys belong to quota.hard, so
+	// resource_quota must stay empty to avoid a ConflictsWith diff.
+	if _, typed := d.GetOk("quota"); len(quota) > 0 && !typed {
 		if err := d.Set("resource_quota", quota); err != nil {
 			return diag.FromErr(err)
 		}
 	}
 
+	// Native quota/limit range objects deleted out-of-band: drop the owning block
+	// from state so the next plan re-applies it. Objects still present have their
+	// spec read back so that out-of-band edits show up as a diff.
+	portainerHard, err := readPortainerNamespaceQuota(ctx, client, d, envID, name)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	var present []string
+	nativeQuota := false
+	for _, ref := range d.Get("native_objects").([]interface{}) {
+		kind, objName, _ := strings.Cut(ref.(string), "/")
+		url := k8sProxyURL(client, envID, "", name, namespaceNativePlurals[kind]) + "/" + objName
+		data, status, err := k8sProxyDo(ctx, client, http.MethodGet, url, nil)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		switch {
+		case status == http.StatusNotFound && kind == "ResourceQuota":
+			_ = d.Set("quota", nil)
+		case status == http.StatusNotFound && kind == "LimitRange":
+			_ = d.Set("limit_range", nil)
+		case status >= 200 && status < 300:
+			present = append(present, ref.(string))
+			if kind == "ResourceQuota" {
+				nativeQuota = true
+				err = refreshNamespaceQuota(d, data, portainerHard)
+			} else {
+				err = refreshNamespaceLimitRange(d, data)
+			}
+			if err != nil {
+				return diag.FromErr(err)
+			}
+		default:
+			return diag.FromErr(fmt.Errorf("failed to read %s %q (%d)", kind, objName, status))
+		}
+	}
+	if err := d.Set("native_objects", present); err != nil {
+		return diag.FromErr(err)
+	}
+	if !nativeQuota && portainerHard != nil {
+		if err := refreshNamespaceQuota(d, nil, portainerHard); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	return nil
 }
 
@@ -268,29 +455,7 @@ func resourceKubernetesNamespaceUpdate(ctx context.Context, d *schema.ResourceDa
 		}
 	}
 
-	quota := map[string]string{}
-	if raw, ok := d.GetOk("resource_quota"); ok {
-		for k, v := range raw.(map[string]interface{}) {
-			quota[k] = v.(string)
-		}
-	}
-
-	var rq map[string]interface{}
-	if licensed {
-		rq = map[string]interface{}{
-			"enabled":       true,
-			"cpuRequest":    quota["cpu_request"],
-			"cpuLimit":      quota["cpu_limit"],
-			"memoryRequest": quota["memory_request"],
-			"memoryLimit":   quota["memory_limit"],
-		}
-	} else {
-		rq = map[string]interface{}{
-			"enabled": true,
-			"cpu":     quota["cpu"],
-			"memory":  quota["memory"],
-		}
-	}
+	rq, nativeHard := buildNamespaceResourceQuota(d, licensed)
 
 	body := map[string]interface{}{
 		"Name":          newName,
@@ -330,6 +495,9 @@ func resourceKubernetesNamespaceUpdate(ctx context.Context, d *schema.ResourceDa
 		d.SetId(fmt.Sprintf("%d:%s", envID, newName))
 	}
 
+	if err := applyNamespaceNativePolicies(ctx, d, client, envID, newName, nativeHard); err != nil {
+		return diag.FromErr(err)
+	}
 	return resourceKubernetesNamespaceRead(ctx, d, meta)
 }
 
@@ -408,3 +576,458 @@ func hasLicense(ctx context.Context, client *APIClient) (bool, error) {
 
 	return len(licenses) > 0, nil
 }
+
+// portainerQuotaKeys maps the spec.hard keys Portainer's licensed namespace API can
+// express to the field names of its ResourceQuota payload.
+var portainerQuotaKeys = map[string]string{
+	"requests.cpu":    "cpuRequest",
+	"limits.cpu":      "cpuLimit",
+	"requests.memory": "memoryRequest",
+	"limits.memory":   "memoryLimit",
+}
+
+// buildNamespaceResourceQuota returns the ResourceQuota payload for Portainer's
+// namespace API and the spec.hard entries that must be applied natively instead.
+//
+// Without a typed quota block the legacy resource_quota map is translated as before.
+// With one, the cpu/memory request/limit keys are routed through Portainer on licensed
+// (BE) instances when the quota is unscoped; every other key — and everything on CE,
+// whose single cpu/memory pair cannot express separate requests and limits — falls
+// back to a native ResourceQuota.
+func buildNamespaceResourceQuota(d *schema.ResourceData, licensed bool) (map[string]interface{}, map[string]string) {
+	raw := d.Get("quota").([]interface{})
+	if len(raw) == 0 || raw[0] == nil {
+		quota := map[string]string{}
+		if raw, ok := d.GetOk("resource_quota"); ok {
+			for k, v := range raw.(map[string]interface{}) {
+				quota[k] = v.(string)
+			}
+		}
+		if licensed {
+			return map[string]interface{}{
+				"enabled":       true,
+				"cpuRequest":    quota["cpu_request"],
+				"cpuLimit":      quota["cpu_limit"],
+				"memoryRequest": quota["memory_request"],
+				"memoryLimit":   quota["memory_limit"],
+			}, nil
+		}
+		return map[string]interface{}{
+			"enabled": true,
+			"cpu":     quota["cpu"],
+			"memory":  quota["memory"],
+		}, nil
+	}
+
+	q := raw[0].(map[string]interface{})
+	hard := namespaceQuotaHard(q)
+
+	scoped := len(q["scopes"].([]interface{})) > 0 || len(q["scope_selector"].([]interface{})) > 0
+	rq := map[string]interface{}{"enabled": false}
+	if licensed && !scoped {
+		for key, field := range portainerQuotaKeys {
+			if v, ok := hard[key]; ok {
+				rq[field] = v
+				rq["enabled"] = true
+				delete(hard, key)
+			}
+		}
+	}
+	return rq, hard
+}
+
+// storageClassQuotaSuffix separates the StorageClass name from the resource in
+// per-class spec.hard keys.
+const storageClassQuotaSuffix = ".storageclass.storage.k8s.io/"
+
+// storageClassQuotaFields maps the per-class spec.hard resources to the
+// attributes of quota.storage_class.
+var storageClassQuotaFields = map[string]string{
+	"requests.storage":       "requests_storage",
+	"persistentvolumeclaims": "persistent_volume_claims",
+}
+
+// namespaceQuotaHard expands a quota block into ResourceQuota spec.hard entries.
+func namespaceQuotaHard(q map[string]interface{}) map[string]string {
+	hard := map[string]string{}
+	for k, v := range q["hard"].(map[string]interface{}) {
+		hard[k] = v.(string)
+	}
+	for k, v := range q["object_counts"].(map[string]interface{}) {
+		hard["count/"+k] = v.(string)
+	}
+	for _, item := range q["storage_class"].([]interface{}) {
+		sc := item.(map[string]interface{})
+		for resource, field := range storageClassQuotaFields {
+			if v := sc[field].(string); v != "" {
+				hard[sc["name"].(string)+storageClassQuotaSuffix+resource] = v
+			}
+		}
+	}
+	return hard
+}
+
+// namespaceNativePlurals maps the kinds recorded in native_objects to their
+// Kubernetes API resource.
+var namespaceNativePlurals = map[string]string{
+	"ResourceQuota": "resourcequotas",
+	"LimitRange":    "limitranges",
+}
+
+// namespaceLimitFields maps the attributes of a limit_range limit entry to the
+// LimitRangeItem fields.
+var namespaceLimitFields = map[string]string{
+	"default":                 "default",
+	"default_request":         "defaultRequest",
+	"max":                     "max",
+	"min":                     "min",
+	"max_limit_request_ratio": "maxLimitRequestRatio",
+}
+
+// refreshNamespaceQuota sets the quota block from the spec of the native
+// ResourceQuota (data, nil when there is none) and from portainerHard, the
+// spec.hard of the ResourceQuota Portainer manages for the keys sent through
+// its namespace API. Values equal to the configured quantity keep their
+// configured form.
+func refreshNamespaceQuota(d *schema.ResourceData, data []byte, portainerHard map[string]string) error {
+	raw := d.Get("quota").([]interface{})
+	if len(raw) == 0 || raw[0] == nil {
+		return nil
+	}
+	if data == nil {
+		q := raw[0].(map[string]interface{})
+		authored := q["hard"].(map[string]interface{})
+		hard := map[string]interface{}{}
+		for key, value := range authored {
+			if _, ok := portainerQuotaKeys[key]; !ok {
+				hard[key] = value
+			}
+		}
+		refreshPortainerQuotaKeys(hard, authored, nil, portainerHard)
+		q["hard"] = hard
+		return d.Set("quota", []interface{}{q})
+	}
+	var live struct {
+		Spec struct {
+			Hard          map[string]string `json:"hard"`
+			Scopes        []string          `json:"scopes"`
+			ScopeSelector *struct {
+				MatchExpressions []struct {
+					ScopeName string   `json:"scopeName"`
+					Operator  string   `json:"operator"`
+					Values    []string `json:"values"`
+				} `json:"matchExpressions"`
+			} `json:"scopeSelector"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(data, &live); err != nil {
+		return fmt.Errorf("failed to decode ResourceQuota: %w", err)
+	}
+
+	q := raw[0].(map[string]interface{})
+	configured := namespaceQuotaHard(q)
+	authored := q["hard"].(map[string]interface{})
+	hard := map[string]interface{}{}
+	counts := map[string]interface{}{}
+	classes := map[string]map[string]interface{}{}
+	for key, value := range live.Spec.Hard {
+		if v, ok := configured[key]; ok && k8sQuantityEqual(v, value) {
+			value = v
+		}
+		class, resource, perClass := strings.Cut(key, storageClassQuotaSuffix)
+		switch {
+		case authored[key] != nil:
+			hard[key] = value
+		case strings.HasPrefix(key, "count/"):
+			counts[strings.TrimPrefix(key, "count/")] = value
+		case perClass && storageClassQuotaFields[resource] != "":
+			if classes[class] == nil {
+				classes[class] = map[string]interface{}{"name": class, "requests_storage": "", "persistent_volume_claims": ""}
+			}
+			classes[class][storageClassQuotaFields[resource]] = value
+		default:
+			hard[key] = value
+		}
+	}
+	refreshPortainerQuotaKeys(hard, authored, live.Spec.Hard, portainerHard)
+
+	storage := []interface{}{}
+	for _, item := range q["storage_class"].([]interface{}) {
+		name := item.(map[string]interface{})["name"].(string)
+		if sc, ok := classes[name]; ok {
+			storage = append(storage, sc)
+			delete(classes, name)
+		}
+	}
+	extra := make([]string, 0, len(classes))
+	for name := range classes {
+		extra = append(extra, name)
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		storage = append(storage, classes[name])
+	}
+
+	scopes := make([]interface{}, 0, len(live.Spec.Scopes))
+	for _, scope := range live.Spec.Scopes {
+		scopes = append(scopes, scope)
+	}
+	selectors := []interface{}{}
+	if live.Spec.ScopeSelector != nil {
+		for _, expr := range live.Spec.ScopeSelector.MatchExpressions {
+			values := make([]interface{}, 0, len(expr.Values))
+			for _, v := range expr.Values {
+				values = append(values, v)
+			}
+			selectors = append(selectors, map[string]interface{}{
+				"scope_name": expr.ScopeName,
+				"operator":   expr.Operator,
+				"values":     values,
+			})
+		}
+	}
+
+	return d.Set("quota", []interface{}{map[string]interface{}{
+		"name":           q["name"],
+		"hard":           hard,
+		"object_counts":  counts,
+		"storage_class":  storage,
+		"scopes":         scopes,
+		"scope_selector": selectors,
+	}})
+}
+
+// refreshPortainerQuotaKeys sets in hard the authored keys sent through
+// Portainer's namespace API (absent from the native spec.hard) from
+// portainerHard. They are kept as configured when portainerHard is nil: the
+// quota could not be read, e.g. Portainer does not persist quotas when
+// resource over-commit is enabled.
+func refreshPortainerQuotaKeys(hard, authored map[string]interface{}, native, portainerHard map[string]string) {
+	for key := range portainerQuotaKeys {
+		if _, ok := native[key]; ok || authored[key] == nil {
+			continue
+		}
+		v, ok := portainerHard[key]
+		switch {
+		case portainerHard == nil, ok && k8sQuantityEqual(authored[key].(string), v):
+			hard[key] = authored[key]
+		case ok:
+			hard[key] = v
+		}
+	}
+}
+
+// portainerNamespaceQuotaPrefix prefixes the name of the ResourceQuota
+// Portainer manages in a namespace.
+const portainerNamespaceQuotaPrefix = "portainer-rq-"
+
+// readPortainerNamespaceQuota returns the spec.hard of the ResourceQuota
+// Portainer manages in the namespace, read through the Kubernetes proxy like
+// the native objects, when the quota block sets keys sent through Portainer.
+// It returns nil when there is no such quota.
+func readPortainerNamespaceQuota(ctx context.Context, client *APIClient, d *schema.ResourceData, envID int, namespace string) (map[string]string, error) {
+	raw := d.Get("quota").([]interface{})
+	if len(raw) == 0 || raw[0] == nil {
+		return nil, nil
+	}
+	authored := raw[0].(map[string]interface{})["hard"].(map[string]interface{})
+	routed := false
+	for key := range portainerQuotaKeys {
+		routed = routed || authored[key] != nil
+	}
+	if !routed {
+		return nil, nil
+	}
+
+	url := k8sProxyURL(client, envID, "", namespace, "resourcequotas") + "/" + portainerNamespaceQuotaPrefix + namespace
+	data, status, err := k8sProxyDo(ctx, client, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("failed to read ResourceQuota %q (%d): %s", portainerNamespaceQuotaPrefix+namespace, status, string(data))
+	}
+	var live struct {
+		Spec struct {
+			Hard map[string]string `json:"hard"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(data, &live); err != nil {
+		return nil, fmt.Errorf("failed to decode ResourceQuota: %w", err)
+	}
+	if live.Spec.Hard == nil {
+		live.Spec.Hard = map[string]string{}
+	}
+	return live.Spec.Hard, nil
+}
+
+// namespaceLimitDefaults returns the configured values of a limit entry, by
+// attribute, with the defaults the API server fills in for Container limits:
+// default from max, then default_request from default and min.
+func namespaceLimitDefaults(limit map[string]interface{}) map[string]map[string]string {
+	out := map[string]map[string]string{}
+	for field := range namespaceLimitFields {
+		out[field] = map[string]string{}
+		if limit == nil {
+			continue
+		}
+		for k, v := range limit[field].(map[string]interface{}) {
+			out[field][k] = v.(string)
+		}
+	}
+	if limit == nil || limit["type"] != "Container" {
+		return out
+	}
+	for _, from := range []struct{ src, dst string }{{"max", "default"}, {"default", "default_request"}, {"min", "default_request"}} {
+		for k, v := range out[from.src] {
+			if _, ok := out[from.dst][k]; !ok {
+				out[from.dst][k] = v
+			}
+		}
+	}
+	return out
+}
+
+// refreshNamespaceLimitRange sets the limit_range block from the spec of the
+// native LimitRange. Values equal to the configured quantity keep their
+// configured form, and values defaulted by the API server are left out.
+func refreshNamespaceLimitRange(d *schema.ResourceData, data []byte) error {
+	raw := d.Get("limit_range").([]interface{})
+	if len(raw) == 0 || raw[0] == nil {
+		return nil
+	}
+	var live struct {
+		Spec struct {
+			Limits []map[string]interface{} `json:"limits"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(data, &live); err != nil {
+		return fmt.Errorf("failed to decode LimitRange: %w", err)
+	}
+
+	lr := raw[0].(map[string]interface{})
+	configured := lr["limit"].([]interface{})
+	limits := make([]interface{}, 0, len(live.Spec.Limits))
+	for i, item := range live.Spec.Limits {
+		typ, _ := item["type"].(string)
+		var authored map[string]interface{}
+		if i < len(configured) && configured[i].(map[string]interface{})["type"] == typ {
+			authored = configured[i].(map[string]interface{})
+		}
+		expected := namespaceLimitDefaults(authored)
+		entry := map[string]interface{}{"type": typ}
+		for field, key := range namespaceLimitFields {
+			values := map[string]interface{}{}
+			liveValues, _ := item[key].(map[string]interface{})
+			for resource, v := range liveValues {
+				value := fmt.Sprint(v)
+				if want, ok := expected[field][resource]; ok && k8sQuantityEqual(want, value) {
+					if authored != nil && authored[field].(map[string]interface{})[resource] != nil {
+						values[resource] = authored[field].(map[string]interface{})[resource]
+					}
+					continue
+				}
+				values[resource] = value
+			}
+			entry[field] = values
+		}
+		limits = append(limits, entry)
+	}
+
+	return d.Set("limit_range", []interface{}{map[string]interface{}{
+		"name":  lr["name"],
+		"limit": limits,
+	}})
+}
+
+// applyNamespaceNativePolicies creates or replaces the native ResourceQuota and
+// LimitRange objects, deletes the ones no longer configured, and records what is
+// managed in native_objects.
+func applyNamespaceNativePolicies(ctx context.Context, d *schema.ResourceData, client *APIClient, envID int, namespace string, nativeHard map[string]string) error {
+	var managed []string
+	desired := map[string]bool{}
+
+	if raw := d.Get("quota").([]interface{}); len(raw) > 0 && raw[0] != nil {
+		q := raw[0].(map[string]interface{})
+		scopes := q["scopes"].([]interface{})
+		selectors := q["scope_selector"].([]interface{})
+		if len(nativeHard) > 0 || len(scopes) > 0 || len(selectors) > 0 {
+			name := q["name"].(string)
+			spec := map[string]interface{}{"hard": nativeHard}
+			if len(scopes) > 0 {
+				spec["scopes"] = scopes
+			}
+			if len(selectors) > 0 {
+				exprs := make([]map[string]interface{}, 0, len(selectors))
+				for _, item := range selectors {
+					m := item.(map[string]interface{})
+					expr := map[string]interface{}{
+						"scopeName": m["scope_name"],
+						"operator":  m["operator"],
+					}
+					if values := m["values"].([]interface{}); len(values) > 0 {
+						expr["values"] = values
+					}
+					exprs = append(exprs, expr)
+				}
+				spec["scopeSelector"] = map[string]interface{}{"matchExpressions": exprs}
+			}
+			obj := map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ResourceQuota",
+				"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+				"spec":       spec,
+			}
+			if err := k8sApplyObject(ctx, client, k8sProxyURL(client, envID, "", namespace, "resourcequotas"), name, "ResourceQuota", obj); err != nil {
+				return err
+			}
+			ref := "ResourceQuota/" + name
+			managed = append(managed, ref)
+			desired[ref] = true
+		}
+	}
+
+	if raw := d.Get("limit_range").([]interface{}); len(raw) > 0 && raw[0] != nil {
+		lr := raw[0].(map[string]interface{})
+		name := lr["name"].(string)
+		limits := []map[string]interface{}{}
+		for _, item := range lr["limit"].([]interface{}) {
+			m := item.(map[string]interface{})
+			entry := map[string]interface{}{"type": m["type"]}
+			for field, key := range namespaceLimitFields {
+				if v := m[field].(map[string]interface{}); len(v) > 0 {
+					entry[key] = v
+				}
+			}
+			limits = append(limits, entry)
+		}
+		obj := map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "LimitRange",
+			"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+			"spec":       map[string]interface{}{"limits": limits},
+		}
+		if err := k8sApplyObject(ctx, client, k8sProxyURL(client, envID, "", namespace, "limitranges"), name, "LimitRange", obj); err != nil {
+			return err
+		}
+		ref := "LimitRange/" + name
+		managed = append(managed, ref)
+		desired[ref] = true
+	}
+
+	for _, ref := range d.Get("native_objects").([]interface{}) {
+		if desired[ref.(string)] {
+			continue
+		}
+		kind, name, _ := strings.Cut(ref.(string), "/")
+		url := k8sProxyURL(client, envID, "", namespace, namespaceNativePlurals[kind]) + "/" + name
+		if err := k8sDeleteObject(ctx, client, url, kind+" "+name); err != nil {
+			return err
+		}
+	}
+
+	return d.Set("native_objects", managed)
+}
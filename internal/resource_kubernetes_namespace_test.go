@@ -2,6 +2,7 @@ package internal
 
 import (
 	"net/http"
+	"reflect"
 	"testing"
 )
 
@@ -215,3 +216,305 @@ func TestKubernetesNamespaceRead_404ClearsID(t *testing.T) {
 		t.Errorf("expected ID cleared on 404, got %q", d.Id())
 	}
 }
+
+// TestKubernetesNamespaceCreate_TypedQuota_Licensed verifies the cpu/memory keys of
+// the typed quota block go through Portainer's payload on BE, while the remaining
+// hard limits and the limit range are created natively through the Kubernetes proxy.
+func TestKubernetesNamespaceCreate_TypedQuota_Licensed(t *testing.T) {
+	mock := NewMockServer(t)
+
+	mock.On("GET", "/licenses", RespondJSON(http.StatusOK, []map[string]interface{}{{"id": 1}}))
+	mock.On("POST", "/kubernetes/1/namespaces", RespondJSON(http.StatusOK, map[string]interface{}{}))
+	mock.On("GET", "/kubernetes/1/namespaces/team-a", RespondJSON(http.StatusOK, map[string]interface{}{"Name": "team-a"}))
+	quotaURL := "/endpoints/1/kubernetes/api/v1/namespaces/team-a/resourcequotas"
+	limitURL := "/endpoints/1/kubernetes/api/v1/namespaces/team-a/limitranges"
+	mock.On("POST", quotaURL, RespondJSON(http.StatusCreated, map[string]interface{}{}))
+	mock.On("POST", limitURL, RespondJSON(http.StatusCreated, map[string]interface{}{}))
+	// First GET (apply) returns 404, the Read afterwards sees the object.
+	created := map[string]bool{}
+	for _, u := range []string{quotaURL + "/terraform-quota", limitURL + "/terraform-limits"} {
+		u := u
+		mock.On("GET", u, func(w http.ResponseWriter, r *http.Request) {
+			if !created[u] {
+				created[u] = true
+				RespondString(http.StatusNotFound, "application/json", `{}`)(w, r)
+				return
+			}
+			RespondJSON(http.StatusOK, map[string]interface{}{})(w, r)
+		})
+	}
+
+	r := resourceKubernetesNamespace()
+	d := r.TestResourceData()
+	_ = d.Set("environment_id", 1)
+	_ = d.Set("name", "team-a")
+	_ = d.Set("quota", []interface{}{map[string]interface{}{
+		"name": "terraform-quota",
+		"hard": map[string]interface{}{
+			"limits.cpu": "2",
+			"pods":       "20",
+		},
+		"object_counts": map[string]interface{}{"deployments.apps": "5"},
+		"storage_class": []interface{}{map[string]interface{}{
+			"name":                     "gold",
+			"requests_storage":         "100Gi",
+			"persistent_volume_claims": "",
+		}},
+	}})
+	_ = d.Set("limit_range", []interface{}{map[string]interface{}{
+		"name": "terraform-limits",
+		"limit": []interface{}{map[string]interface{}{
+			"type":            "Container",
+			"default":         map[string]interface{}{"cpu": "500m"},
+			"default_request": map[string]interface{}{"cpu": "100m"},
+		}},
+	}})
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	var payload map[string]interface{}
+	if err := mock.FindRequest("POST", "/kubernetes/1/namespaces").DecodeJSON(&payload); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	rq := payload["ResourceQuota"].(map[string]interface{})
+	if rq["enabled"] != true || rq["cpuLimit"] != "2" {
+		t.Errorf("expected Portainer quota with cpuLimit=2, got %v", rq)
+	}
+
+	var quota struct {
+		Spec struct {
+			Hard map[string]string `json:"hard"`
+		} `json:"spec"`
+	}
+	if err := mock.FindRequest("POST", quotaURL).DecodeJSON(&quota); err != nil {
+		t.Fatalf("decode native quota: %v", err)
+	}
+	want := map[string]string{
+		"pods":                   "20",
+		"count/deployments.apps": "5",
+		"gold.storageclass.storage.k8s.io/requests.storage": "100Gi",
+	}
+	for k, v := range want {
+		if quota.Spec.Hard[k] != v {
+			t.Errorf("native hard[%q]: expected %q, got %q", k, v, quota.Spec.Hard[k])
+		}
+	}
+	if _, ok := quota.Spec.Hard["limits.cpu"]; ok {
+		t.Error("limits.cpu should be sent through Portainer, not the native quota")
+	}
+
+	var limits struct {
+		Spec struct {
+			Limits []map[string]interface{} `json:"limits"`
+		} `json:"spec"`
+	}
+	if err := mock.FindRequest("POST", limitURL).DecodeJSON(&limits); err != nil {
+		t.Fatalf("decode limit range: %v", err)
+	}
+	if len(limits.Spec.Limits) != 1 || limits.Spec.Limits[0]["defaultRequest"] == nil {
+		t.Errorf("unexpected limit range spec: %v", limits.Spec.Limits)
+	}
+
+	objs := d.Get("native_objects").([]interface{})
+	if len(objs) != 2 {
+		t.Errorf("expected 2 native objects, got %v", objs)
+	}
+}
+
+// TestKubernetesNamespaceCreate_TypedQuota_Unlicensed verifies CE falls back to a
+// native quota for every key and disables Portainer's own quota.
+func TestKubernetesNamespaceCreate_TypedQuota_Unlicensed(t *testing.T) {
+	mock := NewMockServer(t)
+
+	mock.On("GET", "/licenses", RespondJSON(http.StatusOK, []map[string]interface{}{}))
+	mock.On("POST", "/kubernetes/1/namespaces", RespondJSON(http.StatusOK, map[string]interface{}{}))
+	mock.On("GET", "/kubernetes/1/namespaces/my-ns", RespondJSON(http.StatusOK, map[string]interface{}{"Name": "my-ns"}))
+	quotaURL := "/endpoints/1/kubernetes/api/v1/namespaces/my-ns/resourcequotas"
+	mock.On("GET", quotaURL+"/q", RespondJSON(http.StatusOK, map[string]interface{}{
+		"metadata": map[string]interface{}{"resourceVersion": "42"},
+	}))
+	mock.On("PUT", quotaURL+"/q", RespondJSON(http.StatusOK, map[string]interface{}{}))
+
+	r := resourceKubernetesNamespace()
+	d := r.TestResourceData()
+	_ = d.Set("environment_id", 1)
+	_ = d.Set("name", "my-ns")
+	_ = d.Set("quota", []interface{}{map[string]interface{}{
+		"name":   "q",
+		"hard":   map[string]interface{}{"requests.cpu": "1"},
+		"scopes": []interface{}{"NotBestEffort"},
+	}})
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	var payload map[string]interface{}
+	_ = mock.FindRequest("POST", "/kubernetes/1/namespaces").DecodeJSON(&payload)
+	if rq := payload["ResourceQuota"].(map[string]interface{}); rq["enabled"] != false {
+		t.Errorf("expected Portainer quota disabled, got %v", rq)
+	}
+
+	// The existing object is replaced with its resourceVersion.
+	var obj struct {
+		Metadata map[string]interface{} `json:"metadata"`
+		Spec     map[string]interface{} `json:"spec"`
+	}
+	if err := mock.FindRequest("PUT", quotaURL+"/q").DecodeJSON(&obj); err != nil {
+		t.Fatalf("decode native quota: %v", err)
+	}
+	if obj.Metadata["resourceVersion"] != "42" {
+		t.Errorf("expected resourceVersion 42, got %v", obj.Metadata["resourceVersion"])
+	}
+	if obj.Spec["scopes"] == nil {
+		t.Error("expected scopes on the native quota")
+	}
+}
+
+// TestKubernetesNamespaceRead_NativeQuotaDeleted verifies a native quota removed
+// out-of-band drops the quota block from state so the next plan re-applies it.
+func TestKubernetesNamespaceRead_NativeQuotaDeleted(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/kubernetes/1/namespaces/my-ns", RespondJSON(http.StatusOK, map[string]interface{}{"Name": "my-ns"}))
+	mock.On("GET", "/endpoints/1/kubernetes/api/v1/namespaces/my-ns/resourcequotas/q",
+		RespondString(http.StatusNotFound, "application/json", `{}`))
+
+	r := resourceKubernetesNamespace()
+	d := r.TestResourceData()
+	d.SetId("1:my-ns")
+	_ = d.Set("quota", []interface{}{map[string]interface{}{
+		"name": "q",
+		"hard": map[string]interface{}{"pods": "10"},
+	}})
+	_ = d.Set("native_objects", []interface{}{"ResourceQuota/q"})
+
+	if err := rcRead(r, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(d.Get("quota").([]interface{})) != 0 {
+		t.Errorf("expected quota dropped from state, got %v", d.Get("quota"))
+	}
+	if len(d.Get("native_objects").([]interface{})) != 0 {
+		t.Errorf("expected native_objects emptied, got %v", d.Get("native_objects"))
+	}
+}
+
+// TestKubernetesNamespaceRead_NativeDrift verifies out-of-band edits of the native
+// quota and limit range show up in state, while canonicalized quantities, keys
+// sent through Portainer and values defaulted by the API server do not.
+func TestKubernetesNamespaceRead_NativeDrift(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/kubernetes/1/namespaces/my-ns", RespondJSON(http.StatusOK, map[string]interface{}{"Name": "my-ns"}))
+	mock.On("GET", "/endpoints/1/kubernetes/api/v1/namespaces/my-ns/resourcequotas/q", RespondJSON(http.StatusOK, map[string]interface{}{
+		"spec": map[string]interface{}{"hard": map[string]interface{}{
+			"pods":                   "20",
+			"requests.memory":        "1Gi",
+			"count/deployments.apps": "5",
+		}},
+	}))
+	mock.On("GET", "/endpoints/1/kubernetes/api/v1/namespaces/my-ns/limitranges/l", RespondJSON(http.StatusOK, map[string]interface{}{
+		"spec": map[string]interface{}{"limits": []interface{}{map[string]interface{}{
+			"type":           "Container",
+			"default":        map[string]interface{}{"cpu": "500m", "memory": "1Gi"},
+			"defaultRequest": map[string]interface{}{"cpu": "100m", "memory": "1Gi"},
+			"max":            map[string]interface{}{"memory": "1Gi"},
+		}}},
+	}))
+
+	r := resourceKubernetesNamespace()
+	d := r.TestResourceData()
+	d.SetId("1:my-ns")
+	_ = d.Set("quota", []interface{}{map[string]interface{}{
+		"name":          "q",
+		"hard":          map[string]interface{}{"pods": "10", "limits.cpu": "2", "requests.memory": "1024Mi"},
+		"object_counts": map[string]interface{}{"deployments.apps": "5"},
+	}})
+	_ = d.Set("limit_range", []interface{}{map[string]interface{}{
+		"name": "l",
+		"limit": []interface{}{map[string]interface{}{
+			"type":            "Container",
+			"default":         map[string]interface{}{"cpu": "0.5"},
+			"default_request": map[string]interface{}{"cpu": "200m"},
+			"max":             map[string]interface{}{"memory": "1024Mi"},
+		}},
+	}})
+	_ = d.Set("native_objects", []interface{}{"ResourceQuota/q", "LimitRange/l"})
+
+	if err := rcRead(r, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	wantHard := map[string]interface{}{"pods": "20", "limits.cpu": "2", "requests.memory": "1024Mi"}
+	if got := d.Get("quota.0.hard"); !reflect.DeepEqual(got, wantHard) {
+		t.Errorf("quota.hard: expected %v, got %v", wantHard, got)
+	}
+	if got := d.Get("quota.0.object_counts"); !reflect.DeepEqual(got, map[string]interface{}{"deployments.apps": "5"}) {
+		t.Errorf("unexpected quota.object_counts: %v", got)
+	}
+	for field, want := range map[string]map[string]interface{}{
+		"default":         {"cpu": "0.5"},
+		"default_request": {"cpu": "100m"},
+		"max":             {"memory": "1024Mi"},
+	} {
+		if got := d.Get("limit_range.0.limit.0." + field); !reflect.DeepEqual(got, want) {
+			t.Errorf("limit_range.limit.%s: expected %v, got %v", field, want, got)
+		}
+	}
+}
+
+// TestKubernetesNamespaceRead_PortainerQuotaDrift verifies the quota keys sent
+// through Portainer are refreshed from the ResourceQuota Portainer manages.
+func TestKubernetesNamespaceRead_PortainerQuotaDrift(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/kubernetes/1/namespaces/my-ns", RespondJSON(http.StatusOK, map[string]interface{}{"Name": "my-ns"}))
+	mock.On("GET", "/endpoints/1/kubernetes/api/v1/namespaces/my-ns/resourcequotas/portainer-rq-my-ns", RespondJSON(http.StatusOK, map[string]interface{}{
+		"spec": map[string]interface{}{"hard": map[string]interface{}{
+			"limits.cpu":   "4",
+			"requests.cpu": "500m",
+		}},
+	}))
+
+	r := resourceKubernetesNamespace()
+	d := r.TestResourceData()
+	d.SetId("1:my-ns")
+	_ = d.Set("quota", []interface{}{map[string]interface{}{
+		"name": "q",
+		"hard": map[string]interface{}{"limits.cpu": "2", "requests.cpu": "0.5", "limits.memory": "1Gi"},
+	}})
+
+	if err := rcRead(r, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	// limits.memory was removed out-of-band.
+	wantHard := map[string]interface{}{"limits.cpu": "4", "requests.cpu": "0.5"}
+	if got := d.Get("quota.0.hard"); !reflect.DeepEqual(got, wantHard) {
+		t.Errorf("quota.hard: expected %v, got %v", wantHard, got)
+	}
+}
+
+// TestKubernetesNamespaceUpdate_RemovesStaleNativeObjects verifies objects no longer
+// configured are deleted through the proxy.
+func TestKubernetesNamespaceUpdate_RemovesStaleNativeObjects(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/licenses", RespondJSON(http.StatusOK, []map[string]interface{}{}))
+	mock.On("PUT", "/kubernetes/1/namespaces/my-ns", RespondJSON(http.StatusOK, map[string]interface{}{}))
+	mock.On("GET", "/kubernetes/1/namespaces/my-ns", RespondJSON(http.StatusOK, map[string]interface{}{"Name": "my-ns"}))
+	mock.On("DELETE", "/endpoints/1/kubernetes/api/v1/namespaces/my-ns/limitranges/old", RespondJSON(http.StatusOK, map[string]interface{}{}))
+
+	r := resourceKubernetesNamespace()
+	d := r.TestResourceData()
+	d.SetId("1:my-ns")
+	_ = d.Set("environment_id", 1)
+	_ = d.Set("name", "my-ns")
+	_ = d.Set("native_objects", []interface{}{"LimitRange/old"})
+
+	if err := rcUpdate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if mock.FindRequest("DELETE", "/endpoints/1/kubernetes/api/v1/namespaces/my-ns/limitranges/old") == nil {
+		t.Error("expected stale LimitRange to be deleted")
+	}
+}
@@ -3,17 +3,94 @@ package internal
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func resourceKubernetesSecrets() *schema.Resource {
+	resourceSchema := map[string]*schema.Schema{
+		"endpoint_id": {
+			Type:        schema.TypeInt,
+			Required:    true,
+			ForceNew:    true,
+			Description: "Identifier of the Portainer Kubernetes environment (endpoint) where the Secret is managed. Changing this value forces resource recreation.",
+		},
+		"namespace": {
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "Kubernetes namespace in which the Secret is created.",
+		},
+		"manifest": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ExactlyOneOf: []string{"manifest", "name"},
+			Description:  "Raw YAML or JSON manifest defining the Kubernetes Secret. May contain sensitive data; stored in Terraform state. Alternative to the typed attributes.",
+		},
+		"name": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ForceNew:     true,
+			ExactlyOneOf: []string{"manifest", "name"},
+			Description:  "Name of the Secret (typed form). Changing this value forces resource recreation.",
+		},
+		"type": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+			Computed:    true,
+			Description: "Secret type, e.g. `Opaque` (default), `kubernetes.io/tls` or `kubernetes.io/dockerconfigjson` (typed form only). Changing this value forces resource recreation.",
+		},
+		"data": {
+			Type:        schema.TypeMap,
+			Optional:    true,
+			Sensitive:   true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Base64-encoded secret entries (typed form only; stored in Terraform state).",
+		},
+		"string_data": {
+			Type:        schema.TypeMap,
+			Optional:    true,
+			Sensitive:   true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Plain-text secret entries, encoded by Kubernetes (typed form only; stored in Terraform state).",
+		},
+		"data_wo": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Sensitive:    true,
+			WriteOnly:    true,
+			ValidateFunc: validation.StringIsJSON,
+			RequiredWith: []string{"data_wo_version"},
+			Description:  "Write-only JSON object of base64-encoded secret entries, e.g. `jsonencode({ password = base64encode(ephemeral.value) })` (supports ephemeral values; not stored in Terraform state).",
+		},
+		"string_data_wo": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Sensitive:    true,
+			WriteOnly:    true,
+			ValidateFunc: validation.StringIsJSON,
+			RequiredWith: []string{"data_wo_version"},
+			Description:  "Write-only JSON object of plain-text secret entries, e.g. `jsonencode({ password = ephemeral.value })` (supports ephemeral values; not stored in Terraform state).",
+		},
+		"data_wo_version": {
+			Type:        schema.TypeInt,
+			Optional:    true,
+			Description: "Version flag for write-only data; must be set when using `data_wo` or `string_data_wo`, and bumped to push new values.",
+		},
+	}
+	for k, v := range k8sTypedMetadataSchema("Secret") {
+		resourceSchema[k] = v
+	}
+
 	return &schema.Resource{
 		CreateContext: resourceKubernetesSecretsCreate,
 		ReadContext:   resourceKubernetesSecretsRead,
@@ -24,24 +101,7 @@ func resourceKubernetesSecrets() *schema.Resource {
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 
-		Schema: map[string]*schema.Schema{
-			"endpoint_id": {
-				Type:        schema.TypeInt,
-				Required:    true,
-				ForceNew:    true,
-				Description: "Identifier of the Portainer Kubernetes environment (endpoint) where the Secret is managed. Changing this value forces resource recreation.",
-			},
-			"namespace": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Kubernetes namespace in which the Secret is created.",
-			},
-			"manifest": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Raw YAML or JSON manifest defining the Kubernetes Secret. May contain sensitive data; stored in Terraform state.",
-			},
-		},
+		Schema: resourceSchema,
 	}
 }
 
@@ -52,6 +112,24 @@ func resourceKubernetesSecretsCreate(ctx context.Context, d *schema.ResourceData
 	namespace := d.Get("namespace").(string)
 	manifest := d.Get("manifest").(string)
 
+	if manifest == "" {
+		name := d.Get("name").(string)
+		url := fmt.Sprintf("%s/endpoints/%d/kubernetes/api/v1/namespaces/%s/secrets", client.Endpoint, endpointID, namespace)
+		obj, err := buildKubernetesSecretObject(d)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		data, status, err := k8sProxyDo(ctx, client, http.MethodPost, url, obj)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("failed to create Secret: %w", err))
+		}
+		if status < 200 || status >= 300 {
+			return diag.FromErr(fmt.Errorf("failed to create Secret (%d): %s", status, string(data)))
+		}
+		d.SetId(fmt.Sprintf("%d:%s:%s", endpointID, namespace, name))
+		return resourceKubernetesSecretsRead(ctx, d, meta)
+	}
+
 	parsed, err := parseManifest(manifest)
 	if err != nil {
 		return diag.FromErr(fmt.Errorf("manifest must be valid JSON or YAML: %w", err))
@@ -136,6 +214,19 @@ func resourceKubernetesSecretsDelete(ctx context.Context, d *schema.ResourceData
 }
 
 func resourceKubernetesSecretsUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if d.Get("manifest").(string) == "" && !d.HasChanges("manifest", "namespace") {
+		client := meta.(*APIClient)
+		endpointID, namespace, name := parseSecretsID(d.Id())
+		url := fmt.Sprintf("%s/endpoints/%d/kubernetes/api/v1/namespaces/%s/secrets", client.Endpoint, endpointID, namespace)
+		obj, err := buildKubernetesSecretObject(d)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if err := k8sApplyObject(ctx, client, url, name, "Secret", obj); err != nil {
+			return diag.FromErr(err)
+		}
+		return resourceKubernetesSecretsRead(ctx, d, meta)
+	}
 	if diags := resourceKubernetesSecretsDelete(ctx, d, meta); diags.HasError() {
 		return diags
 	}
@@ -151,6 +242,9 @@ func resourceKubernetesSecretsRead(ctx context.Context, d *schema.ResourceData,
 	}
 
 	url := fmt.Sprintf("%s/endpoints/%d/kubernetes/api/v1/namespaces/%s/secrets/%s", client.Endpoint, endpointID, namespace, name)
+	if d.Get("manifest").(string) == "" {
+		return readKubernetesSecretTyped(ctx, d, client, url, endpointID, namespace, name)
+	}
 	if diags := k8sConfirmExistsByGET(ctx, d, client, url, "secret "+name); diags.HasError() {
 		return diags
 	}
@@ -178,3 +272,132 @@ func parseSecretsID(id string) (endpointID int, namespace string, name string) {
 	name = parts[2]
 	return
 }
+
+// buildKubernetesSecretObject assembles the typed Secret. The object is always
+// written as a whole (POST or PUT), so write-only entries are read from the raw
+// configuration on every apply, not only when data_wo_version changes; otherwise
+// an unrelated update would wipe them.
+func buildKubernetesSecretObject(d *schema.ResourceData) (map[string]interface{}, error) {
+	data := map[string]interface{}{}
+	for k, v := range d.Get("data").(map[string]interface{}) {
+		data[k] = v
+	}
+	stringData := map[string]interface{}{}
+	for k, v := range d.Get("string_data").(map[string]interface{}) {
+		stringData[k] = v
+	}
+	dataWO, err := kubernetesSecretWriteOnly(d, "data_wo")
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range dataWO {
+		data[k] = v
+	}
+	stringDataWO, err := kubernetesSecretWriteOnly(d, "string_data_wo")
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range stringDataWO {
+		stringData[k] = v
+	}
+
+	secretType := d.Get("type").(string)
+	if secretType == "" {
+		secretType = "Opaque"
+	}
+	obj := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"type":       secretType,
+		"metadata":   k8sTypedMetadata(d, d.Get("name").(string), d.Get("namespace").(string)),
+	}
+	if len(data) > 0 {
+		obj["data"] = data
+	}
+	if len(stringData) > 0 {
+		obj["stringData"] = stringData
+	}
+	return obj, nil
+}
+
+// kubernetesSecretWriteOnly decodes a write-only JSON object of secret entries
+// from the raw configuration.
+func kubernetesSecretWriteOnly(d *schema.ResourceData, attr string) (map[string]string, error) {
+	out := map[string]string{}
+	// data_wo_version is required alongside the write-only attributes.
+	if d.Get("data_wo_version").(int) == 0 {
+		return out, nil
+	}
+	raw, diags := d.GetRawConfigAt(cty.GetAttrPath(attr))
+	if diags.HasError() {
+		return nil, fmt.Errorf("unable to read %s: %v", attr, diags)
+	}
+	if !raw.IsKnown() || raw.IsNull() || raw.AsString() == "" {
+		return out, nil
+	}
+	if err := json.Unmarshal([]byte(raw.AsString()), &out); err != nil {
+		return nil, fmt.Errorf("%s must be a JSON object of strings: %w", attr, err)
+	}
+	return out, nil
+}
+
+// readKubernetesSecretTyped refreshes the typed attributes from the live Secret.
+// Kubernetes folds stringData into data, so data and string_data only track the
+// keys they were given; entries supplied through the write-only attributes are
+// never compared.
+func readKubernetesSecretTyped(ctx context.Context, d *schema.ResourceData, client *APIClient, url string, endpointID int, namespace, name string) diag.Diagnostics {
+	body, status, err := k8sProxyDo(ctx, client, http.MethodGet, url, nil)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if status == http.StatusNotFound {
+		d.SetId("")
+		return nil
+	}
+	if status < 200 || status >= 300 {
+		return diag.FromErr(fmt.Errorf("failed to read secret %s (%d): %s", name, status, string(body)))
+	}
+
+	var secret struct {
+		Metadata struct {
+			Labels      map[string]string `json:"labels"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+		Type string            `json:"type"`
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to decode secret %s: %w", name, err))
+	}
+
+	stringData := map[string]interface{}{}
+	for k := range d.Get("string_data").(map[string]interface{}) {
+		encoded, ok := secret.Data[k]
+		if !ok {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("failed to decode secret %s key %q: %w", name, k, err))
+		}
+		stringData[k] = string(decoded)
+	}
+
+	values := map[string]interface{}{
+		"endpoint_id": endpointID,
+		"namespace":   namespace,
+		"name":        name,
+		"type":        secret.Type,
+		"data":        k8sRefreshAuthored(d.Get("data").(map[string]interface{}), secret.Data),
+		"string_data": stringData,
+	}
+	for k, v := range values {
+		if err := d.Set(k, v); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if err := k8sSetTypedMetadata(d, secret.Metadata.Labels, secret.Metadata.Annotations); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
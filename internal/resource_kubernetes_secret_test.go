@@ -3,6 +3,8 @@ package internal
 import (
 	"net/http"
 	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 const secretManifestJSON = `{
@@ -122,3 +124,82 @@ func TestKubernetesSecretParseID(t *testing.T) {
 		t.Errorf("expected (3, ns, sec), got (%d, %q, %q)", endpointID, namespace, name)
 	}
 }
+
+// TestKubernetesSecretCreate_Typed verifies the typed form sends data and
+// stringData and only tracks the authored keys on refresh.
+func TestKubernetesSecretCreate_Typed(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("POST", "/endpoints/1/kubernetes/api/v1/namespaces/default/secrets", RespondJSON(http.StatusCreated, map[string]interface{}{}))
+	mock.On("GET", "/endpoints/1/kubernetes/api/v1/namespaces/default/secrets/db", RespondJSON(http.StatusOK, map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "db"},
+		"type":     "Opaque",
+		"data": map[string]interface{}{
+			"username": "YWRtaW4=",
+			"password": "czNjcjN0",
+		},
+	}))
+
+	r := resourceKubernetesSecrets()
+	d := r.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("namespace", "default")
+	_ = d.Set("name", "db")
+	_ = d.Set("data", map[string]interface{}{"username": "YWRtaW4="})
+	_ = d.Set("string_data", map[string]interface{}{"password": "s3cr3t"})
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	post := mock.FindRequest("POST", "/endpoints/1/kubernetes/api/v1/namespaces/default/secrets")
+	if post == nil {
+		t.Fatal("expected POST request to be recorded")
+	}
+	var payload map[string]interface{}
+	if err := post.DecodeJSON(&payload); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if payload["type"] != "Opaque" {
+		t.Errorf("payload.type: expected Opaque, got %v", payload["type"])
+	}
+	if sd, _ := payload["stringData"].(map[string]interface{}); sd["password"] != "s3cr3t" {
+		t.Errorf("payload.stringData: unexpected %v", payload["stringData"])
+	}
+
+	if data := d.Get("data").(map[string]interface{}); len(data) != 1 || data["username"] != "YWRtaW4=" {
+		t.Errorf("data: expected only authored key, got %v", data)
+	}
+	if sd := d.Get("string_data").(map[string]interface{}); sd["password"] != "s3cr3t" {
+		t.Errorf("string_data: expected decoded live value, got %v", sd)
+	}
+}
+
+// TestKubernetesSecretRead_TypedStringDataDrift verifies an out-of-band change
+// to a string_data entry surfaces as drift.
+func TestKubernetesSecretRead_TypedStringDataDrift(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/kubernetes/api/v1/namespaces/default/secrets/db", RespondJSON(http.StatusOK, map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "db"},
+		"type":     "Opaque",
+		"data":     map[string]interface{}{"password": "Y2hhbmdlZA=="},
+	}))
+
+	r := resourceKubernetesSecrets()
+	d := r.Data(&terraform.InstanceState{
+		ID: "1:default:db",
+		Attributes: map[string]string{
+			"endpoint_id":          "1",
+			"namespace":            "default",
+			"name":                 "db",
+			"string_data.%":        "1",
+			"string_data.password": "s3cr3t",
+		},
+	})
+
+	if err := rcRead(r, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if got := d.Get("string_data.password").(string); got != "changed" {
+		t.Errorf("expected drifted value %q, got %q", "changed", got)
+	}
+}
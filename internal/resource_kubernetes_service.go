@@ -7,13 +7,127 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func resourceKubernetesService() *schema.Resource {
+	resourceSchema := map[string]*schema.Schema{
+		"endpoint_id": {
+			Type:        schema.TypeInt,
+			Required:    true,
+			ForceNew:    true,
+			Description: "Identifier of the Portainer Kubernetes environment (endpoint) where the Service is managed. Changing this value forces resource recreation.",
+		},
+		"namespace": {
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "Kubernetes namespace in which the Service is created.",
+		},
+		"manifest": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ExactlyOneOf: []string{"manifest", "name"},
+			Description:  "Raw YAML or JSON manifest defining the Kubernetes Service. Alternative to the typed attributes.",
+		},
+		"name": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ForceNew:     true,
+			ExactlyOneOf: []string{"manifest", "name"},
+			Description:  "Name of the Service (typed form). Changing this value forces resource recreation.",
+		},
+		"type": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Computed:     true,
+			ValidateFunc: validation.StringInSlice([]string{"ClusterIP", "NodePort", "LoadBalancer", "ExternalName"}, false),
+			Description:  "Service type: `ClusterIP` (default), `NodePort`, `LoadBalancer` or `ExternalName` (typed form only).",
+		},
+		"selector": {
+			Type:        schema.TypeMap,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Pod label selector routing traffic to the Service (typed form only).",
+		},
+		"port": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Description: "Ports exposed by the Service (typed form only).",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"name": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Port name; required by Kubernetes when more than one port is defined.",
+					},
+					"protocol": {
+						Type:         schema.TypeString,
+						Optional:     true,
+						Default:      "TCP",
+						ValidateFunc: validation.StringInSlice([]string{"TCP", "UDP", "SCTP"}, false),
+						Description:  "Port protocol: `TCP` (default), `UDP` or `SCTP`.",
+					},
+					"port": {
+						Type:         schema.TypeInt,
+						Required:     true,
+						ValidateFunc: validation.IsPortNumber,
+						Description:  "Port exposed by the Service.",
+					},
+					"target_port": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Computed:    true,
+						Description: "Port number or named container port on the pods. Defaults to `port`.",
+					},
+					"node_port": {
+						Type:        schema.TypeInt,
+						Optional:    true,
+						Computed:    true,
+						Description: "Node port for `NodePort` and `LoadBalancer` Services. Allocated by Kubernetes when unset.",
+					},
+				},
+			},
+		},
+		"external_traffic_policy": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Computed:     true,
+			ValidateFunc: validation.StringInSlice([]string{"Cluster", "Local"}, false),
+			Description:  "External traffic policy (`Cluster` or `Local`) for `NodePort` and `LoadBalancer` Services (typed form only).",
+		},
+		"session_affinity": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Computed:     true,
+			ValidateFunc: validation.StringInSlice([]string{"None", "ClientIP"}, false),
+			Description:  "Session affinity (`None` or `ClientIP`) (typed form only).",
+		},
+		"external_name": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "DNS name returned for `ExternalName` Services (typed form only).",
+		},
+		"load_balancer_source_ranges": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "CIDRs allowed to reach a `LoadBalancer` Service (typed form only).",
+		},
+		"cluster_ip": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Cluster IP allocated to the Service (typed form only).",
+		},
+	}
+	for k, v := range k8sTypedMetadataSchema("Service") {
+		resourceSchema[k] = v
+	}
+
 	return &schema.Resource{
 		CreateContext: resourceKubernetesServiceCreate,
 		ReadContext:   resourceKubernetesServiceRead,
@@ -24,24 +138,7 @@ func resourceKubernetesService() *schema.Resource {
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 
-		Schema: map[string]*schema.Schema{
-			"endpoint_id": {
-				Type:        schema.TypeInt,
-				Required:    true,
-				ForceNew:    true,
-				Description: "Identifier of the Portainer Kubernetes environment (endpoint) where the Service is managed. Changing this value forces resource recreation.",
-			},
-			"namespace": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Kubernetes namespace in which the Service is created.",
-			},
-			"manifest": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Raw YAML or JSON manifest defining the Kubernetes Service.",
-			},
-		},
+		Schema: resourceSchema,
 	}
 }
 
@@ -52,6 +149,20 @@ func resourceKubernetesServiceCreate(ctx context.Context, d *schema.ResourceData
 	namespace := d.Get("namespace").(string)
 	manifest := d.Get("manifest").(string)
 
+	if manifest == "" {
+		name := d.Get("name").(string)
+		url := fmt.Sprintf("%s/endpoints/%d/kubernetes/api/v1/namespaces/%s/services", client.Endpoint, endpointID, namespace)
+		data, status, err := k8sProxyDo(ctx, client, http.MethodPost, url, buildKubernetesServiceObject(d))
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("failed to create Service: %w", err))
+		}
+		if status < 200 || status >= 300 {
+			return diag.FromErr(fmt.Errorf("failed to create Service (%d): %s", status, string(data)))
+		}
+		d.SetId(fmt.Sprintf("%d:%s:%s", endpointID, namespace, name))
+		return resourceKubernetesServiceRead(ctx, d, meta)
+	}
+
 	parsed, err := parseManifest(manifest)
 	if err != nil {
 		return diag.FromErr(fmt.Errorf("manifest must be valid JSON or YAML: %w", err))
@@ -136,6 +247,17 @@ func resourceKubernetesServiceDelete(ctx context.Context, d *schema.ResourceData
 }
 
 func resourceKubernetesServiceUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// The typed form is replaced in place, keeping the allocated cluster IP and node
+	// ports; only a namespace change needs the object to be recreated.
+	if d.Get("manifest").(string) == "" && !d.HasChanges("manifest", "namespace") {
+		client := meta.(*APIClient)
+		endpointID, namespace, name := parseServiceID(d.Id())
+		url := fmt.Sprintf("%s/endpoints/%d/kubernetes/api/v1/namespaces/%s/services", client.Endpoint, endpointID, namespace)
+		if err := k8sApplyObject(ctx, client, url, name, "Service", buildKubernetesServiceObject(d)); err != nil {
+			return diag.FromErr(err)
+		}
+		return resourceKubernetesServiceRead(ctx, d, meta)
+	}
 	if diags := resourceKubernetesServiceDelete(ctx, d, meta); diags.HasError() {
 		return diags
 	}
@@ -151,6 +273,9 @@ func resourceKubernetesServiceRead(ctx context.Context, d *schema.ResourceData,
 	}
 
 	url := fmt.Sprintf("%s/endpoints/%d/kubernetes/api/v1/namespaces/%s/services/%s", client.Endpoint, endpointID, namespace, name)
+	if d.Get("manifest").(string) == "" {
+		return readKubernetesServiceTyped(ctx, d, client, url, endpointID, namespace, name)
+	}
 	if diags := k8sConfirmExistsByGET(ctx, d, client, url, "service "+name); diags.HasError() {
 		return diags
 	}
@@ -178,3 +303,129 @@ func parseServiceID(id string) (endpointID int, namespace string, name string) {
 	name = parts[2]
 	return
 }
+
+func buildKubernetesServiceObject(d *schema.ResourceData) map[string]interface{} {
+	spec := map[string]interface{}{}
+	if v := d.Get("type").(string); v != "" {
+		spec["type"] = v
+	}
+	if v := d.Get("selector").(map[string]interface{}); len(v) > 0 {
+		spec["selector"] = v
+	}
+	if v := d.Get("external_traffic_policy").(string); v != "" {
+		spec["externalTrafficPolicy"] = v
+	}
+	if v := d.Get("session_affinity").(string); v != "" {
+		spec["sessionAffinity"] = v
+	}
+	if v := d.Get("external_name").(string); v != "" {
+		spec["externalName"] = v
+	}
+	if v := d.Get("load_balancer_source_ranges").([]interface{}); len(v) > 0 {
+		spec["loadBalancerSourceRanges"] = v
+	}
+
+	ports := []map[string]interface{}{}
+	for _, item := range d.Get("port").([]interface{}) {
+		m := item.(map[string]interface{})
+		port := map[string]interface{}{
+			"protocol": m["protocol"],
+			"port":     m["port"],
+		}
+		if v := m["name"].(string); v != "" {
+			port["name"] = v
+		}
+		// targetPort is an IntOrString: send numbers as integers, names as strings.
+		if v := m["target_port"].(string); v != "" {
+			if n, err := strconv.Atoi(v); err == nil {
+				port["targetPort"] = n
+			} else {
+				port["targetPort"] = v
+			}
+		}
+		if v := m["node_port"].(int); v != 0 {
+			port["nodePort"] = v
+		}
+		ports = append(ports, port)
+	}
+	if len(ports) > 0 {
+		spec["ports"] = ports
+	}
+
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   k8sTypedMetadata(d, d.Get("name").(string), d.Get("namespace").(string)),
+		"spec":       spec,
+	}
+}
+
+// readKubernetesServiceTyped refreshes the typed attributes from the live Service,
+// so out-of-band edits show up as plan diffs.
+func readKubernetesServiceTyped(ctx context.Context, d *schema.ResourceData, client *APIClient, url string, endpointID int, namespace, name string) diag.Diagnostics {
+	data, status, err := k8sProxyDo(ctx, client, http.MethodGet, url, nil)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if status == http.StatusNotFound {
+		d.SetId("")
+		return nil
+	}
+	if status < 200 || status >= 300 {
+		return diag.FromErr(fmt.Errorf("failed to read service %s (%d): %s", name, status, string(data)))
+	}
+
+	var svc k8sServiceObject
+	if err := json.Unmarshal(data, &svc); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to decode service %s: %w", name, err))
+	}
+	var extra struct {
+		Metadata struct {
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+		Spec struct {
+			ExternalTrafficPolicy    string   `json:"externalTrafficPolicy"`
+			SessionAffinity          string   `json:"sessionAffinity"`
+			LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges"`
+		} `json:"spec"`
+	}
+	_ = json.Unmarshal(data, &extra)
+
+	ports := make([]map[string]interface{}, 0, len(svc.Spec.Ports))
+	for _, p := range svc.Spec.Ports {
+		target := ""
+		if p.TargetPort != nil {
+			target = fmt.Sprint(p.TargetPort)
+		}
+		ports = append(ports, map[string]interface{}{
+			"name":        p.Name,
+			"protocol":    p.Protocol,
+			"port":        p.Port,
+			"target_port": target,
+			"node_port":   p.NodePort,
+		})
+	}
+
+	values := map[string]interface{}{
+		"endpoint_id":                 endpointID,
+		"namespace":                   namespace,
+		"name":                        name,
+		"type":                        svc.Spec.Type,
+		"selector":                    svc.Spec.Selector,
+		"port":                        ports,
+		"external_traffic_policy":     extra.Spec.ExternalTrafficPolicy,
+		"session_affinity":            extra.Spec.SessionAffinity,
+		"external_name":               svc.Spec.ExternalName,
+		"load_balancer_source_ranges": extra.Spec.LoadBalancerSourceRanges,
+		"cluster_ip":                  svc.Spec.ClusterIP,
+	}
+	for k, v := range values {
+		if err := d.Set(k, v); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if err := k8sSetTypedMetadata(d, svc.Metadata.Labels, extra.Metadata.Annotations); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package internal
 import (
 	"net/http"
 	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 const serviceManifestJSON = `{
@@ -116,3 +118,128 @@ func TestKubernetesServiceParseID(t *testing.T) {
 		t.Errorf("expected (3, ns, svc), got (%d, %q, %q)", endpointID, namespace, name)
 	}
 }
+
+// TestKubernetesServiceCreate_Typed verifies the typed form builds the Service
+// object and refreshes computed fields from the live object.
+func TestKubernetesServiceCreate_Typed(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("POST", "/endpoints/1/kubernetes/api/v1/namespaces/default/services", RespondJSON(http.StatusCreated, map[string]interface{}{}))
+	mock.On("GET", "/endpoints/1/kubernetes/api/v1/namespaces/default/services/web", RespondJSON(http.StatusOK, map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":   "web",
+			"labels": map[string]interface{}{"app": "web", "injected": "true"},
+		},
+		"spec": map[string]interface{}{
+			"type":                  "NodePort",
+			"clusterIP":             "10.0.0.10",
+			"selector":              map[string]interface{}{"app": "web"},
+			"externalTrafficPolicy": "Local",
+			"sessionAffinity":       "None",
+			"ports": []interface{}{
+				map[string]interface{}{"name": "http", "protocol": "TCP", "port": 80, "targetPort": 8080, "nodePort": 30080},
+			},
+		},
+	}))
+
+	r := resourceKubernetesService()
+	d := r.TestResourceData()
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("namespace", "default")
+	_ = d.Set("name", "web")
+	_ = d.Set("type", "NodePort")
+	_ = d.Set("selector", map[string]interface{}{"app": "web"})
+	_ = d.Set("external_traffic_policy", "Local")
+	_ = d.Set("labels", map[string]interface{}{"app": "web"})
+	_ = d.Set("port", []interface{}{
+		map[string]interface{}{"name": "http", "protocol": "TCP", "port": 80, "target_port": "8080"},
+	})
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if d.Id() != "1:default:web" {
+		t.Errorf("expected ID %q, got %q", "1:default:web", d.Id())
+	}
+
+	post := mock.FindRequest("POST", "/endpoints/1/kubernetes/api/v1/namespaces/default/services")
+	if post == nil {
+		t.Fatal("expected POST request to be recorded")
+	}
+	var payload struct {
+		Spec struct {
+			Type                  string `json:"type"`
+			ExternalTrafficPolicy string `json:"externalTrafficPolicy"`
+			Ports                 []struct {
+				Port       int         `json:"port"`
+				TargetPort interface{} `json:"targetPort"`
+			} `json:"ports"`
+		} `json:"spec"`
+	}
+	if err := post.DecodeJSON(&payload); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if payload.Spec.Type != "NodePort" || payload.Spec.ExternalTrafficPolicy != "Local" {
+		t.Errorf("unexpected spec: %+v", payload.Spec)
+	}
+	if len(payload.Spec.Ports) != 1 || payload.Spec.Ports[0].TargetPort != float64(8080) {
+		t.Errorf("expected numeric targetPort 8080, got %+v", payload.Spec.Ports)
+	}
+
+	if got := d.Get("cluster_ip").(string); got != "10.0.0.10" {
+		t.Errorf("cluster_ip: expected 10.0.0.10, got %q", got)
+	}
+	if got := d.Get("port.0.node_port").(int); got != 30080 {
+		t.Errorf("port.0.node_port: expected 30080, got %d", got)
+	}
+	if labels := d.Get("labels").(map[string]interface{}); len(labels) != 1 {
+		t.Errorf("expected only authored labels to be tracked, got %v", labels)
+	}
+}
+
+// TestKubernetesServiceUpdate_TypedInPlace verifies the typed form is updated
+// with a PUT carrying the live resourceVersion instead of being recreated.
+func TestKubernetesServiceUpdate_TypedInPlace(t *testing.T) {
+	mock := NewMockServer(t)
+	live := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web", "resourceVersion": "42"},
+		"spec":     map[string]interface{}{"type": "ClusterIP"},
+	}
+	mock.On("GET", "/endpoints/1/kubernetes/api/v1/namespaces/default/services/web", RespondJSON(http.StatusOK, live))
+	mock.On("PUT", "/endpoints/1/kubernetes/api/v1/namespaces/default/services/web", RespondJSON(http.StatusOK, live))
+
+	r := resourceKubernetesService()
+	d := r.Data(&terraform.InstanceState{
+		ID: "1:default:web",
+		Attributes: map[string]string{
+			"endpoint_id": "1",
+			"namespace":   "default",
+			"name":        "web",
+		},
+	})
+	_ = d.Set("session_affinity", "ClientIP")
+
+	if err := rcUpdate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if mock.FindRequest("DELETE", "/endpoints/1/kubernetes/api/v1/namespaces/default/services/web") != nil {
+		t.Error("typed update must not delete the Service")
+	}
+	put := mock.FindRequest("PUT", "/endpoints/1/kubernetes/api/v1/namespaces/default/services/web")
+	if put == nil {
+		t.Fatal("expected PUT request to be recorded")
+	}
+	var payload struct {
+		Metadata struct {
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"metadata"`
+		Spec struct {
+			SessionAffinity string `json:"sessionAffinity"`
+		} `json:"spec"`
+	}
+	if err := put.DecodeJSON(&payload); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if payload.Metadata.ResourceVersion != "42" || payload.Spec.SessionAffinity != "ClientIP" {
+		t.Errorf("unexpected PUT payload: %+v", payload)
+	}
+}
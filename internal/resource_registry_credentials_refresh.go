@@ -0,0 +1,436 @@
+package internal
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/portainer/client-api-go/v2/pkg/client/registries"
+	"gopkg.in/yaml.v3"
+)
+
+func resourceRegistryCredentialsRefresh() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceRegistryCredentialsRefreshApply,
+		ReadContext:   resourceRegistryCredentialsRefreshRead,
+		UpdateContext: resourceRegistryCredentialsRefreshApply,
+		DeleteContext: removeFromStateContext,
+
+		Schema: map[string]*schema.Schema{
+			"registry_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the Portainer registry whose credentials are propagated.",
+			},
+			"endpoint_ids": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Description: "Only refresh workloads on these environments. Defaults to every environment with access to the registry.",
+			},
+			"stacks": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Redeploy Docker stacks pulling images from the registry, with image pull (Swarm stacks are deployed with registry auth). Git stacks are not redeployed, see skipped_stacks.",
+			},
+			"services": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Update Swarm services outside of refreshed stacks pulling images from the registry with the new registry auth.",
+			},
+			"force_update": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Also restart the tasks of refreshed services. Without it only the credentials stored on the services are replaced.",
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Arbitrary values; changing any of them refreshes the workloads again (e.g. a hash of the registry password).",
+			},
+			// Computed attributes
+			"refreshed_stacks": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Redeployed stacks, as `<endpoint_id>/<stack name>`.",
+			},
+			"skipped_stacks": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Git stacks using the registry that were not redeployed, as `<endpoint_id>/<stack name>`: a Git redeploy would deploy the current head of their reference. Their Swarm services are updated with the services.",
+			},
+			"refreshed_services": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Updated Swarm services, as `<endpoint_id>/<service name>`.",
+			},
+		},
+	}
+}
+
+// registryStack is the part of a Portainer stack needed to redeploy it as is.
+type registryStack struct {
+	ID         int    `json:"Id"`
+	Name       string `json:"Name"`
+	Type       int    `json:"Type"`
+	EndpointID int    `json:"EndpointId"`
+	Env        []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"Env"`
+	Registries []int `json:"Registries"`
+	Option     *struct {
+		Prune bool `json:"prune"`
+	} `json:"Option"`
+	GitConfig *struct {
+		ReferenceName string `json:"ReferenceName"`
+	} `json:"gitConfig"`
+}
+
+// normalizeRegistryRef returns host[/path] in lower case, mapping the Docker
+// Hub aliases to docker.io.
+func normalizeRegistryRef(ref string) string {
+	ref = strings.ToLower(strings.TrimSuffix(ref, "/"))
+	ref = strings.TrimPrefix(strings.TrimPrefix(ref, "https://"), "http://")
+	for _, alias := range []string{"index.docker.io", "registry-1.docker.io", "registry.hub.docker.com"} {
+		if ref == alias || strings.HasPrefix(ref, alias+"/") {
+			return "docker.io" + strings.TrimPrefix(ref, alias)
+		}
+	}
+	return ref
+}
+
+// registryMatchesImage reports whether image is pulled from the registry URL,
+// which may include a namespace (e.g. `ghcr.io/acme`).
+func registryMatchesImage(registryURL, image string) bool {
+	if registryURL == "" || image == "" {
+		return false
+	}
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 1 || !(strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		image = "docker.io/" + image
+	}
+	image, registry := normalizeRegistryRef(image), normalizeRegistryRef(registryURL)
+	return image == registry || strings.HasPrefix(image, registry+"/") || strings.HasPrefix(image, registry+":") || strings.HasPrefix(image, registry+"@")
+}
+
+// composeImages returns the images of the services of a compose file, with
+// `${VAR}` and `${VAR:-default}` replaced from env.
+func composeImages(content string, env map[string]string) ([]string, error) {
+	var compose struct {
+		Services map[string]struct {
+			Image string `yaml:"image"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &compose); err != nil {
+		return nil, err
+	}
+	images := []string{}
+	for _, svc := range compose.Services {
+		if svc.Image == "" {
+			continue
+		}
+		images = append(images, os.Expand(svc.Image, func(name string) string {
+			if i := strings.Index(name, ":-"); i > 0 {
+				if v := env[name[:i]]; v != "" {
+					return v
+				}
+				return name[i+2:]
+			}
+			return env[name]
+		}))
+	}
+	sort.Strings(images)
+	return images, nil
+}
+
+func resourceRegistryCredentialsRefreshApply(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	registryID := d.Get("registry_id").(int)
+
+	ctx, errBody := withErrorCapture(ctx)
+	params := registries.NewRegistryInspectParams()
+	params.SetContext(ctx)
+	params.ID = int64(registryID)
+	resp, err := client.Client.Registries.RegistryInspect(params, client.AuthInfo)
+	if err != nil {
+		var notFound *registries.RegistryInspectNotFound
+		if errors.As(err, &notFound) {
+			return diag.FromErr(ErrRegistryNotFound)
+		}
+		return diag.FromErr(fmt.Errorf("failed to fetch registry: %w", decorateSDKError(err, errBody)))
+	}
+	registryURL := resp.Payload.URL
+
+	// Environments with access to the registry, optionally restricted.
+	endpoints := map[int]bool{}
+	for k := range resp.Payload.RegistryAccesses {
+		if id, err := strconv.Atoi(k); err == nil {
+			endpoints[id] = true
+		}
+	}
+	if v, ok := d.GetOk("endpoint_ids"); ok {
+		restricted := map[int]bool{}
+		for _, id := range v.([]interface{}) {
+			if endpoints[id.(int)] {
+				restricted[id.(int)] = true
+			}
+		}
+		endpoints = restricted
+	}
+
+	refreshedStacks := []string{}
+	skippedStacks := []string{}
+	refreshedServices := []string{}
+	// Workloads refreshed before an error are recorded all the same.
+	record := func() {
+		sort.Strings(refreshedStacks)
+		sort.Strings(skippedStacks)
+		_ = d.Set("refreshed_stacks", refreshedStacks)
+		_ = d.Set("skipped_stacks", skippedStacks)
+		_ = d.Set("refreshed_services", refreshedServices)
+		d.SetId(strconv.Itoa(registryID))
+	}
+	fail := func(err error) diag.Diagnostics {
+		record()
+		return diag.FromErr(err)
+	}
+
+	stackNamespaces := map[string]bool{}
+	if d.Get("stacks").(bool) {
+		stacks, err := listRegistryStacks(client)
+		if err != nil {
+			return fail(err)
+		}
+		for _, stack := range stacks {
+			// Kubernetes stacks (type 3) pull with image pull secrets instead.
+			if !endpoints[stack.EndpointID] || stack.Type == 3 {
+				continue
+			}
+			uses, err := stackUsesRegistry(client, stack, registryID, registryURL)
+			if err != nil {
+				return fail(err)
+			}
+			if !uses {
+				continue
+			}
+			if stack.GitConfig != nil {
+				// Portainer redeploys Git stacks from the head of their
+				// reference, which may not be the deployed commit.
+				skippedStacks = append(skippedStacks, fmt.Sprintf("%d/%s", stack.EndpointID, stack.Name))
+				continue
+			}
+			if err := redeployRegistryStack(client, stack); err != nil {
+				return fail(err)
+			}
+			refreshedStacks = append(refreshedStacks, fmt.Sprintf("%d/%s", stack.EndpointID, stack.Name))
+			stackNamespaces[fmt.Sprintf("%d/%s", stack.EndpointID, stack.Name)] = true
+		}
+	}
+
+	if d.Get("services").(bool) {
+		ids := make([]int, 0, len(endpoints))
+		for id := range endpoints {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		for _, endpointID := range ids {
+			names, err := refreshRegistryServices(client, endpointID, registryID, registryURL, d.Get("force_update").(bool), stackNamespaces)
+			for _, name := range names {
+				refreshedServices = append(refreshedServices, fmt.Sprintf("%d/%s", endpointID, name))
+			}
+			if err != nil {
+				return fail(err)
+			}
+		}
+	}
+
+	record()
+	return nil
+}
+
+func listRegistryStacks(client *APIClient) ([]registryStack, error) {
+	resp, err := client.DoRequest(http.MethodGet, "/stacks", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stacks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to list stacks, status code: %d, body: %s", resp.StatusCode, string(data))
+	}
+	var stacks []registryStack
+	if err := json.NewDecoder(resp.Body).Decode(&stacks); err != nil {
+		return nil, fmt.Errorf("failed to decode stacks: %w", err)
+	}
+	return stacks, nil
+}
+
+func registryStackFile(client *APIClient, stackID int) (string, error) {
+	resp, err := client.DoRequest(http.MethodGet, fmt.Sprintf("/stacks/%d/file", stackID), nil, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch file of stack %d: %w", stackID, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to fetch file of stack %d, status code: %d, body: %s", stackID, resp.StatusCode, string(data))
+	}
+	var file struct {
+		StackFileContent string `json:"StackFileContent"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return "", fmt.Errorf("failed to decode file of stack %d: %w", stackID, err)
+	}
+	return file.StackFileContent, nil
+}
+
+// stackUsesRegistry reports whether the stack lists the registry or one of
+// its services pulls an image from it.
+func stackUsesRegistry(client *APIClient, stack registryStack, registryID int, registryURL string) (bool, error) {
+	for _, id := range stack.Registries {
+		if id == registryID {
+			return true, nil
+		}
+	}
+	content, err := registryStackFile(client, stack.ID)
+	if err != nil {
+		return false, err
+	}
+	env := map[string]string{}
+	for _, e := range stack.Env {
+		env[e.Name] = e.Value
+	}
+	images, err := composeImages(content, env)
+	if err != nil {
+		// Not a compose file we can parse: leave the stack alone.
+		return false, nil
+	}
+	for _, image := range images {
+		if registryMatchesImage(registryURL, image) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// redeployRegistryStack redeploys a stack unchanged with image pull, so that
+// Portainer pulls again with the current registry credentials.
+func redeployRegistryStack(client *APIClient, stack registryStack) error {
+	prune := stack.Option != nil && stack.Option.Prune
+	env := []map[string]string{}
+	for _, e := range stack.Env {
+		env = append(env, map[string]string{"name": e.Name, "value": e.Value})
+	}
+
+	content, err := registryStackFile(client, stack.ID)
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("/stacks/%d?endpointId=%d", stack.ID, stack.EndpointID)
+	payload := map[string]interface{}{
+		"stackFileContent": content,
+		"env":              env,
+		"prune":            prune,
+		"pullImage":        true,
+	}
+
+	resp, err := client.DoRequest(http.MethodPut, path, nil, payload)
+	if err != nil {
+		return fmt.Errorf("failed to redeploy stack %s: %w", stack.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to redeploy stack %s, status code: %d, body: %s", stack.Name, resp.StatusCode, string(data))
+	}
+	return nil
+}
+
+// refreshRegistryServices updates the Swarm services of an environment
+// pulling from the registry, outside of the already redeployed stacks, with
+// the registry credentials (`docker service update --with-registry-auth`).
+// Environments that are not Swarm managers are skipped. On error, the services
+// already updated are returned with it.
+func refreshRegistryServices(client *APIClient, endpointID, registryID int, registryURL string, forceUpdate bool, refreshedStacks map[string]bool) ([]string, error) {
+	resp, err := client.DoRequest(http.MethodGet, fmt.Sprintf("/endpoints/%d/docker/services", endpointID), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services of endpoint %d: %w", endpointID, err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// Standalone Docker environments have no services.
+		return nil, nil
+	}
+
+	var services []struct {
+		ID      string `json:"ID"`
+		Version struct {
+			Index int `json:"Index"`
+		} `json:"Version"`
+		Spec map[string]interface{} `json:"Spec"`
+	}
+	if err := json.Unmarshal(data, &services); err != nil {
+		return nil, fmt.Errorf("failed to decode services of endpoint %d: %w", endpointID, err)
+	}
+
+	auth, _ := json.Marshal(map[string]int{"registryId": registryID})
+	headers := map[string]string{"X-Registry-Auth": base64.StdEncoding.EncodeToString(auth)}
+
+	refreshed := []string{}
+	for _, svc := range services {
+		name, _ := svc.Spec["Name"].(string)
+		labels := mustMap(svc.Spec["Labels"])
+		if ns, _ := labels["com.docker.stack.namespace"].(string); ns != "" && refreshedStacks[fmt.Sprintf("%d/%s", endpointID, ns)] {
+			continue
+		}
+		taskTemplate := mustMap(svc.Spec["TaskTemplate"])
+		image, _ := mustMap(taskTemplate["ContainerSpec"])["Image"].(string)
+		if !registryMatchesImage(registryURL, image) {
+			continue
+		}
+
+		if forceUpdate {
+			n, _ := taskTemplate["ForceUpdate"].(float64)
+			taskTemplate["ForceUpdate"] = int(n) + 1
+			svc.Spec["TaskTemplate"] = taskTemplate
+		}
+		path := fmt.Sprintf("/endpoints/%d/docker/services/%s/update?version=%d", endpointID, svc.ID, svc.Version.Index)
+		resp, err := client.DoRequest(http.MethodPost, path, headers, svc.Spec)
+		if err != nil {
+			return refreshed, fmt.Errorf("failed to update service %s: %w", name, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return refreshed, fmt.Errorf("failed to update service %s, status code: %d, body: %s", name, resp.StatusCode, string(body))
+		}
+		refreshed = append(refreshed, name)
+	}
+	sort.Strings(refreshed)
+	return refreshed, nil
+}
+
+func resourceRegistryCredentialsRefreshRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// Action resource: the refreshed workloads are recorded at apply time.
+	return nil
+}
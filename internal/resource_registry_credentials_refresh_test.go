@@ -0,0 +1,188 @@
+package internal
+
+import (
+	"encoding/base64"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestRegistryMatchesImage(t *testing.T) {
+	cases := []struct {
+		registry, image string
+		want            bool
+	}{
+		{"registry.example.com", "registry.example.com/app:1.0", true},
+		{"registry.example.com:5000", "registry.example.com:5000/app@sha256:abc", true},
+		{"https://ghcr.io/acme/", "ghcr.io/acme/api:2", true},
+		{"ghcr.io/acme", "ghcr.io/other/api:2", false},
+		{"docker.io", "nginx:1.25", true},
+		{"index.docker.io/acme", "acme/web", true},
+		{"registry.example.com", "registry.example.com.evil.io/app", false},
+		{"registry.example.com", "nginx", false},
+	}
+	for _, tc := range cases {
+		if got := registryMatchesImage(tc.registry, tc.image); got != tc.want {
+			t.Errorf("registryMatchesImage(%q, %q) = %v, want %v", tc.registry, tc.image, got, tc.want)
+		}
+	}
+}
+
+// TestRegistryCredentialsRefreshApply redeploys the stacks using the registry
+// and updates the other services pulling from it with registry auth.
+func TestRegistryCredentialsRefreshApply(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/registries/5", RespondJSON(http.StatusOK, map[string]interface{}{
+		"Id":  5,
+		"URL": "registry.example.com",
+		"RegistryAccesses": map[string]interface{}{
+			"1": map[string]interface{}{},
+		},
+	}))
+	mock.On("GET", "/stacks", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{"Id": 10, "Name": "shop", "Type": 1, "EndpointId": 1, "Env": []map[string]string{{"name": "REGISTRY", "value": "registry.example.com"}}},
+		{"Id": 11, "Name": "proxy", "Type": 1, "EndpointId": 1},
+		{"Id": 12, "Name": "elsewhere", "Type": 1, "EndpointId": 2},
+	}))
+	mock.On("GET", "/stacks/10/file", RespondJSON(http.StatusOK, map[string]string{
+		"StackFileContent": "services:\n  web:\n    image: ${REGISTRY}/shop/web:${TAG:-latest}\n",
+	}))
+	mock.On("GET", "/stacks/11/file", RespondJSON(http.StatusOK, map[string]string{
+		"StackFileContent": "services:\n  proxy:\n    image: traefik:v3\n",
+	}))
+	mock.On("PUT", "/stacks/10", RespondJSON(http.StatusOK, map[string]int{"Id": 10}))
+	mock.On("GET", "/endpoints/1/docker/services", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{
+			"ID": "svc-web", "Version": map[string]int{"Index": 7},
+			"Spec": map[string]interface{}{
+				"Name":         "shop_web",
+				"Labels":       map[string]string{"com.docker.stack.namespace": "shop"},
+				"TaskTemplate": map[string]interface{}{"ContainerSpec": map[string]string{"Image": "registry.example.com/shop/web:latest"}},
+			},
+		},
+		{
+			"ID": "svc-worker", "Version": map[string]int{"Index": 3},
+			"Spec": map[string]interface{}{
+				"Name":         "worker",
+				"TaskTemplate": map[string]interface{}{"ContainerSpec": map[string]string{"Image": "registry.example.com/jobs/worker:2@sha256:abc"}, "ForceUpdate": 1},
+			},
+		},
+		{
+			"ID": "svc-proxy", "Version": map[string]int{"Index": 4},
+			"Spec": map[string]interface{}{
+				"Name":         "proxy_proxy",
+				"TaskTemplate": map[string]interface{}{"ContainerSpec": map[string]string{"Image": "traefik:v3"}},
+			},
+		},
+	}))
+	mock.On("POST", "/endpoints/1/docker/services/svc-worker/update", RespondJSON(http.StatusOK, map[string]interface{}{}))
+
+	r := resourceRegistryCredentialsRefresh()
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"registry_id":  5,
+		"force_update": true,
+	})
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if got := d.Get("refreshed_stacks").([]interface{}); !reflect.DeepEqual(got, []interface{}{"1/shop"}) {
+		t.Errorf("refreshed_stacks: got %v", got)
+	}
+	if got := d.Get("refreshed_services").([]interface{}); !reflect.DeepEqual(got, []interface{}{"1/worker"}) {
+		t.Errorf("refreshed_services: got %v", got)
+	}
+	if mock.FindRequest("PUT", "/stacks/11") != nil {
+		t.Error("stack not using the registry must not be redeployed")
+	}
+
+	var stack struct {
+		StackFileContent string
+		PullImage        bool
+		Prune            bool
+	}
+	put := mock.FindRequest("PUT", "/stacks/10")
+	if err := put.DecodeJSON(&stack); err != nil {
+		t.Fatalf("decode stack payload: %v", err)
+	}
+	if !stack.PullImage || stack.StackFileContent == "" || put.Query != "endpointId=1" {
+		t.Errorf("unexpected stack redeploy: %+v (query %q)", stack, put.Query)
+	}
+
+	update := mock.FindRequest("POST", "/endpoints/1/docker/services/svc-worker/update")
+	auth, _ := base64.StdEncoding.DecodeString(update.Headers.Get("X-Registry-Auth"))
+	if string(auth) != `{"registryId":5}` || update.Query != "version=3" {
+		t.Errorf("unexpected service update: auth %s, query %q", auth, update.Query)
+	}
+	var spec struct {
+		TaskTemplate struct{ ForceUpdate int }
+	}
+	if err := update.DecodeJSON(&spec); err != nil {
+		t.Fatalf("decode service spec: %v", err)
+	}
+	if spec.TaskTemplate.ForceUpdate != 2 {
+		t.Errorf("expected ForceUpdate to be incremented, got %d", spec.TaskTemplate.ForceUpdate)
+	}
+}
+
+func TestRegistryCredentialsRefreshApply_GitStackAndPartialFailure(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/registries/5", RespondJSON(http.StatusOK, map[string]interface{}{
+		"Id": 5, "URL": "registry.example.com",
+		"RegistryAccesses": map[string]interface{}{"1": map[string]interface{}{}},
+	}))
+	mock.On("GET", "/stacks", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{"Id": 10, "Name": "shop", "Type": 1, "EndpointId": 1, "Registries": []int{5}},
+		{"Id": 11, "Name": "gitapp", "Type": 1, "EndpointId": 1, "Registries": []int{5},
+			"gitConfig": map[string]interface{}{"ReferenceName": "refs/heads/main"}},
+	}))
+	mock.On("GET", "/stacks/10/file", RespondJSON(http.StatusOK, map[string]string{"StackFileContent": "services: {}\n"}))
+	mock.On("PUT", "/stacks/10", RespondJSON(http.StatusOK, map[string]int{"Id": 10}))
+	mock.On("GET", "/endpoints/1/docker/services", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{
+			"ID": "svc-git", "Version": map[string]int{"Index": 2},
+			"Spec": map[string]interface{}{
+				"Name":         "gitapp_web",
+				"Labels":       map[string]string{"com.docker.stack.namespace": "gitapp"},
+				"TaskTemplate": map[string]interface{}{"ContainerSpec": map[string]string{"Image": "registry.example.com/git/web:1"}},
+			},
+		},
+		{
+			"ID": "svc-worker", "Version": map[string]int{"Index": 3},
+			"Spec": map[string]interface{}{
+				"Name":         "worker",
+				"TaskTemplate": map[string]interface{}{"ContainerSpec": map[string]string{"Image": "registry.example.com/jobs/worker:2"}},
+			},
+		},
+	}))
+	mock.On("POST", "/endpoints/1/docker/services/svc-git/update", RespondJSON(http.StatusOK, map[string]interface{}{}))
+	mock.On("POST", "/endpoints/1/docker/services/svc-worker/update", RespondJSON(http.StatusInternalServerError, map[string]interface{}{}))
+
+	r := resourceRegistryCredentialsRefresh()
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{"registry_id": 5})
+
+	err := rcCreate(r, d, mock.Client())
+	if err == nil || !strings.Contains(err.Error(), "failed to update service worker") {
+		t.Fatalf("expected the worker update to fail, got %v", err)
+	}
+	for _, req := range mock.Requests() {
+		if strings.HasPrefix(req.Path, "/stacks/11") && req.Method != "GET" {
+			t.Errorf("the Git stack must not be redeployed, got %s %s", req.Method, req.Path)
+		}
+	}
+	if d.Id() != "5" {
+		t.Errorf("expected the partial refresh to be recorded, got ID %q", d.Id())
+	}
+	for attr, want := range map[string][]interface{}{
+		"refreshed_stacks":   {"1/shop"},
+		"skipped_stacks":     {"1/gitapp"},
+		"refreshed_services": {"1/gitapp_web"},
+	} {
+		if got := d.Get(attr).([]interface{}); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", attr, want, got)
+		}
+	}
+}
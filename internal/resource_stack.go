@@ -3,11 +3,18 @@ package internal
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
+	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -16,8 +23,10 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"gopkg.in/yaml.v3"
 )
 
 func resourcePortainerStack() *schema.Resource {
@@ -31,6 +40,7 @@ func resourcePortainerStack() *schema.Resource {
 			Update: schema.DefaultTimeout(30 * time.Minute),
 			Delete: schema.DefaultTimeout(10 * time.Minute),
 		},
+		CustomizeDiff: customdiff.All(customizeDiffStackFile, customizeDiffStackTargetCommit, customizeDiffStackEnv, customizeDiffStackValidation, customizeDiffStackKubernetesObjects, customizeDiffStackDependencies),
 		Importer: &schema.ResourceImporter{
 			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
 				// "<endpoint_id>-<stack_id>-<deployment_type>"
@@ -91,27 +101,63 @@ func resourcePortainerStack() *schema.Resource {
 			"endpoint_id": {Type: schema.TypeInt, Required: true, ForceNew: true, Description: "Identifier of the Portainer environment (endpoint) where the stack will be deployed. Changing this value forces resource recreation."},
 			"swarm_id":    {Type: schema.TypeString, Optional: true, ForceNew: true, Computed: true, Description: "Identifier of the Docker Swarm cluster used when deployment_type is 'swarm'. Automatically fetched from Portainer when not provided. Changing this value forces resource recreation."},
 			"namespace":   {Type: schema.TypeString, Optional: true, ForceNew: true, Description: "Kubernetes namespace used when deployment_type is 'kubernetes'. Changing this value forces resource recreation."},
+			"create_namespace": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Create the namespace when it does not exist before deploying or updating a Kubernetes stack. The namespace is not deleted with the stack.",
+			},
+			"kubernetes_objects": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Kubernetes objects owned by the stack (labeled with its ID by Portainer), with their readiness.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"kind":      {Type: schema.TypeString, Computed: true, Description: "Object kind, e.g. Deployment."},
+						"name":      {Type: schema.TypeString, Computed: true, Description: "Object name."},
+						"namespace": {Type: schema.TypeString, Computed: true, Description: "Object namespace."},
+						"ready":     {Type: schema.TypeBool, Computed: true, Description: "Whether the object is ready: all replicas ready and up to date, job complete, claim bound, load balancer provisioned."},
+						"status":    {Type: schema.TypeString, Computed: true, Description: "Short status, e.g. '2/3 ready'."},
+					},
+				},
+			},
+			"kubernetes_missing_objects": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Objects of the manifest that no longer exist in the cluster, as 'Kind namespace/name'. When not empty, the stack is redeployed on the next apply.",
+			},
 			"stack_file_content": {
 				Type:        schema.TypeString,
 				Optional:    true,
-				Description: "Inline Compose or Kubernetes manifest content used to deploy the stack. Required when method is 'string'; populated from stack_file_path when method is 'file'.",
+				Computed:    true,
+				Description: "Inline Compose or Kubernetes manifest content used to deploy the stack. Required when method is 'string'; populated from stack_file_path when method is 'file'. Refreshed from the file stored in Portainer, so edits made in the Portainer editor show up as a diff; formatting-only differences are ignored.",
 				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
-					if d.Get("method").(string) != "file" {
-						return false
-					}
-					path, ok := d.GetOk("stack_file_path")
-					if !ok {
-						return false
-					}
-					content, err := os.ReadFile(path.(string))
-					if err != nil {
-						return false
-					}
-					current := string(content)
-					return strings.TrimSpace(old) == strings.TrimSpace(current)
+					return stackFileContentEqual(old, new)
+				},
+			},
+			"external_dependencies": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "External networks, volumes, secrets and configs declared by the Compose file (`external: true`), which must exist on the environment before the stack is deployed. Names are interpolated from the stack environment variables.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {Type: schema.TypeString, Computed: true, Description: "Dependency type: network, volume, secret or config."},
+						"name": {Type: schema.TypeString, Computed: true, Description: "Name of the object on the environment."},
+					},
 				},
 			},
-			"stack_file_path": {Type: schema.TypeString, Optional: true, Description: "Local filesystem path to a Compose or manifest file. Contents are read and uploaded to Portainer when method is 'file'."},
+			"strict_dependencies": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Check through the Docker API that every external dependency exists on the environment before deploying the stack, and fail with the resource to declare missing ones with (portainer_docker_network, portainer_docker_volume, portainer_docker_secret or portainer_docker_config).",
+			},
+			"stack_file_path":   {Type: schema.TypeString, Optional: true, Description: "Local filesystem path to a Compose or manifest file. Contents are read and uploaded to Portainer when method is 'file'."},
+			"stack_file_sha256": {Type: schema.TypeString, Computed: true, Description: "SHA256 hash of the file at stack_file_path, used to detect local edits when method is 'file'."},
+			"skip_file_validation": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Skip the plan-time validation of the Compose file or Kubernetes manifest against the Compose specification and the security settings of the environment.",
+			},
 			"additional_files": {
 				Type:        schema.TypeList,
 				Optional:    true,
@@ -216,6 +262,17 @@ func resourcePortainerStack() *schema.Resource {
 				Default:     "refs/heads/main",
 				Description: "Git reference (branch or tag) used by Portainer when deploying from the repository. Defaults to refs/heads/main.",
 			},
+			"target_commit": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringMatch(regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`), "must be a full or abbreviated commit hash"),
+				Description:  "Commit the repository stack must run. When it differs from deployed_commit_hash, the stack is redeployed from repository_reference_name. Portainer deploys the head of the reference: the apply warns when the deployed commit is not this commit.",
+			},
+			"deployed_commit_hash": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Commit hash of the repository currently deployed by Portainer (method 'repository').",
+			},
 			"file_path_in_repository": {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -249,6 +306,42 @@ func resourcePortainerStack() *schema.Resource {
 					},
 				},
 			},
+			"env_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Local path to a .env file whose variables are injected into the stack. The file is parsed with Docker Compose's rules (quotes, comments, interpolation of variables defined earlier in the file). Variables also set in env_map, env or sensitive_env_wo are overridden.",
+			},
+			"env_file_sha256": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "SHA256 hash of the variables of env_file as deployed, used to detect edits of the file and drift in Portainer without storing their values in state.",
+			},
+			"env_map": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Map of environment variables injected into the stack. Names must not also appear in env.",
+			},
+			"sensitive_env_wo": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				WriteOnly:    true,
+				ValidateFunc: validation.StringIsJSON,
+				RequiredWith: []string{"sensitive_env_wo_version"},
+				Description:  "Write-only sensitive environment variables, as a JSON-encoded object of strings: wrap the map in `jsonencode`, e.g. `jsonencode({ DB_PASSWORD = ephemeral.value })` (write-only attributes cannot be maps). Supports ephemeral values and is not stored in Terraform state. Names must not also be set in env or env_map; variables of the same name from env_file are overridden.",
+			},
+			"sensitive_env_wo_version": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "Version flag for sensitive_env_wo; must be set when using `sensitive_env_wo`, and bumped to redeploy the stack with new values.",
+			},
+			"sensitive_env_names": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Names of the variables set through sensitive_env_wo. Their values are never read back from Portainer.",
+			},
 			"tlsskip_verify": {Type: schema.TypeBool, Optional: true, Computed: true, ForceNew: true, Description: "Whether to skip TLS verification when Portainer connects to the Git repository. Changing this value forces resource recreation."},
 			"prune": {
 				Type:        schema.TypeBool,
@@ -309,6 +402,306 @@ func resourcePortainerStack() *schema.Resource {
 	}
 }
 
+// stackFileContentEqual reports whether two stack files are the same once
+// parsed, ignoring comments, indentation, key order and quoting. Content that
+// is not valid YAML is compared with surrounding whitespace trimmed.
+func stackFileContentEqual(a, b string) bool {
+	if strings.TrimSpace(a) == strings.TrimSpace(b) {
+		return true
+	}
+	na, errA := normalizeStackFile(a)
+	nb, errB := normalizeStackFile(b)
+	return errA == nil && errB == nil && reflect.DeepEqual(na, nb)
+}
+
+// normalizeStackFile parses every YAML document of a Compose file or
+// Kubernetes manifest.
+func normalizeStackFile(content string) ([]interface{}, error) {
+	var docs []interface{}
+	dec := yaml.NewDecoder(strings.NewReader(content))
+	for {
+		var doc interface{}
+		if err := dec.Decode(&doc); err == io.EOF {
+			return docs, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+}
+
+// readStackFilePath loads stack_file_path into stack_file_content and records
+// its hash in stack_file_sha256.
+func readStackFilePath(d *schema.ResourceData) error {
+	path := d.Get("stack_file_path").(string)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read stack file from path: %w", err)
+	}
+	sum := sha256.Sum256(content)
+	if err := d.Set("stack_file_content", string(content)); err != nil {
+		return err
+	}
+	return d.Set("stack_file_sha256", hex.EncodeToString(sum[:]))
+}
+
+// stackCommitMatches reports whether two commit hashes, possibly
+// abbreviated, designate the same commit.
+func stackCommitMatches(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	return a != "" && b != "" && (strings.HasPrefix(a, b) || strings.HasPrefix(b, a))
+}
+
+// customizeDiffStackTargetCommit plans a redeploy of a repository stack when
+// target_commit is not the deployed commit, e.g. after target_commit changed
+// or Portainer's auto update moved the stack to another commit.
+func customizeDiffStackTargetCommit(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	target := d.Get("target_commit").(string)
+	if d.Id() == "" || target == "" || d.Get("method").(string) != "repository" {
+		return nil
+	}
+	if d.HasChange("target_commit") || !stackCommitMatches(d.Get("deployed_commit_hash").(string), target) {
+		return d.SetNewComputed("deployed_commit_hash")
+	}
+	return nil
+}
+
+// rawConfigReader is implemented by ResourceData and ResourceDiff. Write-only
+// attributes are only available from the raw configuration.
+type rawConfigReader interface {
+	Get(string) interface{}
+	GetRawConfigAt(cty.Path) (cty.Value, diag.Diagnostics)
+}
+
+// errRepositoryUnknown is returned when the repository URL or credentials are
+// not known yet, e.g. write-only values computed during apply.
+var errRepositoryUnknown = errors.New("the repository URL or credentials are not known until apply")
+
+// stackRepositoryCredentials returns the repository URL and credentials,
+// from their write-only equivalents when repository_wo_version is set. known
+// is false when one of them is not known yet.
+func stackRepositoryCredentials(d rawConfigReader) (repoURL, username, password string, known bool) {
+	repoURL = d.Get("repository_url").(string)
+	username = d.Get("repository_username").(string)
+	password = d.Get("repository_password").(string)
+	known = true
+	if d.Get("repository_wo_version").(int) != 0 {
+		for attr, value := range map[string]*string{
+			"repository_url_wo":      &repoURL,
+			"repository_username_wo": &username,
+			"repository_password_wo": &password,
+		} {
+			raw, diags := d.GetRawConfigAt(cty.GetAttrPath(attr))
+			switch {
+			case diags.HasError() || raw.IsNull():
+			case !raw.IsKnown():
+				known = false
+			default:
+				*value = raw.AsString()
+			}
+		}
+	}
+	return repoURL, username, password, known && repoURL != ""
+}
+
+// checkStackTargetCommit checks, before a repository stack with a
+// target_commit is (re)deployed, that repository_reference_name exists,
+// listing the references through Portainer with the stack credentials.
+// Portainer lists reference names but not the commits they point to, so the
+// deployed commit is compared with target_commit after deploying, by
+// stackTargetCommitDiags. When the references cannot be listed, the check is
+// skipped with a warning.
+func checkStackTargetCommit(client *APIClient, d *schema.ResourceData) diag.Diagnostics {
+	if d.Get("target_commit").(string) == "" || d.Get("method").(string) != "repository" {
+		return nil
+	}
+	reference := d.Get("repository_reference_name").(string)
+	repoURL, username, password, _ := stackRepositoryCredentials(d)
+	payload := map[string]interface{}{
+		"repository":    repoURL,
+		"tlsskipVerify": d.Get("tlsskip_verify").(bool),
+	}
+	if d.Get("git_repository_authentication").(bool) {
+		payload["username"] = username
+		payload["password"] = password
+	}
+	if id := d.Get("repository_git_credential_id").(int); id != 0 {
+		payload["gitCredentialID"] = id
+	}
+	if id, err := strconv.Atoi(d.Id()); err == nil {
+		payload["stackID"] = id
+	}
+
+	refs, err := listStackRepositoryRefs(client, payload)
+	if err != nil {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("Reference %s of stack %s not checked", reference, d.Get("name").(string)),
+			Detail:   err.Error(),
+		}}
+	}
+	for _, name := range []string{reference, "refs/heads/" + reference, "refs/tags/" + reference} {
+		for _, ref := range refs {
+			if ref == name {
+				return nil
+			}
+		}
+	}
+	return diag.Errorf("stack %s was not deployed: reference %s not found in %s", d.Get("name").(string), reference, repoURL)
+}
+
+// listStackRepositoryRefs lists the references of a Git repository through
+// Portainer's gitops refs endpoint.
+func listStackRepositoryRefs(client *APIClient, payload map[string]interface{}) ([]string, error) {
+	resp, err := client.DoRequest("POST", "/gitops/repo/refs?force=true", nil, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Git repository refs: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to list Git repository refs (status %d): %s", resp.StatusCode, string(data))
+	}
+	var refs []string
+	if err := json.NewDecoder(resp.Body).Decode(&refs); err != nil {
+		return nil, fmt.Errorf("failed to decode Git refs response: %w", err)
+	}
+	return refs, nil
+}
+
+// stackTargetCommitDiags warns, after a repository stack was (re)deployed,
+// when Portainer deployed another commit than target_commit, i.e. when
+// repository_reference_name did not point to it.
+func stackTargetCommitDiags(d *schema.ResourceData) diag.Diagnostics {
+	target := d.Get("target_commit").(string)
+	deployed := d.Get("deployed_commit_hash").(string)
+	if target == "" || deployed == "" || d.Get("method").(string) != "repository" || stackCommitMatches(deployed, target) {
+		return nil
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  fmt.Sprintf("Stack %s does not run target_commit", d.Get("name").(string)),
+		Detail: fmt.Sprintf("Portainer deployed commit %q, the head of %s, instead of target_commit %q.",
+			deployed, d.Get("repository_reference_name").(string), target),
+	}}
+}
+
+// customizeDiffStackFile plans an update when the file at stack_file_path was
+// edited locally (its hash changed) or differs from the stack file stored in
+// Portainer, e.g. after an edit in the Portainer web editor. A file that is
+// not known or does not exist yet, e.g. written by local_file during apply,
+// is read at apply.
+func customizeDiffStackFile(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if d.Get("method").(string) != "file" {
+		return nil
+	}
+	path := d.Get("stack_file_path").(string)
+	if !d.NewValueKnown("stack_file_path") {
+		return stackFileComputed(d)
+	}
+	if path == "" {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return stackFileComputed(d)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read stack file from path: %w", err)
+	}
+	sum := sha256.Sum256(content)
+	if hash := hex.EncodeToString(sum[:]); d.Get("stack_file_sha256").(string) != hash {
+		if err := d.SetNew("stack_file_sha256", hash); err != nil {
+			return err
+		}
+	}
+	if !stackFileContentEqual(d.Get("stack_file_content").(string), string(content)) {
+		return d.SetNew("stack_file_content", string(content))
+	}
+	return nil
+}
+
+// stackFileComputed plans stack_file_content and stack_file_sha256 as known
+// after apply.
+func stackFileComputed(d *schema.ResourceDiff) error {
+	if err := d.SetNewComputed("stack_file_content"); err != nil {
+		return err
+	}
+	return d.SetNewComputed("stack_file_sha256")
+}
+
+// customizeDiffStackValidation validates the stack file when it may have
+// changed, so that invalid files and settings forbidden on the environment
+// fail the plan instead of the deployment. Repository files are read through
+// Portainer; when the repository or the environment cannot be queried, the
+// corresponding checks are skipped.
+func customizeDiffStackValidation(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Get("skip_file_validation").(bool) {
+		return nil
+	}
+	if d.Id() != "" && !d.HasChanges("stack_file_content", "stack_file_sha256", "file_path_in_repository", "additional_files",
+		"repository_reference_name", "target_commit", "endpoint_id", "skip_file_validation") {
+		return nil
+	}
+	client, _ := meta.(*APIClient)
+
+	var files []stackFile
+	switch method := d.Get("method").(string); method {
+	case "string", "file":
+		content := d.Get("stack_file_content").(string)
+		if !d.NewValueKnown("stack_file_content") || content == "" {
+			return nil
+		}
+		name := "stack_file_content"
+		if method == "file" {
+			name = d.Get("stack_file_path").(string)
+		}
+		files = append(files, stackFile{Name: name, Content: content})
+	case "repository":
+		if client == nil || d.Get("helm_chart_path").(string) != "" {
+			return nil
+		}
+		mainFile := d.Get("file_path_in_repository").(string)
+		if mainFile == "" {
+			if d.Get("deployment_type").(string) == "kubernetes" {
+				return nil
+			}
+			mainFile = "docker-compose.yml"
+		}
+		repoFiles, err := stackRepositoryFiles(client, d, mainFile)
+		if errors.Is(err, errRepositoryUnknown) {
+			log.Printf("[WARN] Stack %q: file validation skipped: %v", d.Get("name").(string), err)
+			return nil
+		}
+		if err != nil {
+			return stackFileFetchError(err)
+		}
+		files = repoFiles
+	default:
+		return nil
+	}
+
+	if d.Get("deployment_type").(string) == "kubernetes" && !d.Get("compose_format").(bool) {
+		var violations []stackFileViolation
+		for _, file := range files {
+			violations = append(violations, validateKubernetesManifest(file)...)
+		}
+		return stackFileViolationsError(violations)
+	}
+
+	// Security settings apply to Docker environments only.
+	var security *SecuritySettings
+	if client != nil && d.NewValueKnown("endpoint_id") && d.Get("deployment_type").(string) != "kubernetes" {
+		var err error
+		if security, err = fetchEndpointSecuritySettings(ctx, client, d.Get("endpoint_id").(int)); err != nil {
+			return stackFileFetchError(err)
+		}
+	}
+	return stackFileViolationsError(validateComposeFiles(files, security))
+}
+
 func expandStringList(rawList []interface{}) []string {
 	result := make([]string, len(rawList))
 	for i, v := range rawList {
@@ -386,6 +779,19 @@ func resourcePortainerStackCreate(ctx context.Context, d *schema.ResourceData, m
 		return resourcePortainerStackUpdate(ctx, d, meta)
 	}
 
+	if err := createStackNamespace(ctx, client, d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	diags := checkStackDependencies(ctx, client, d)
+	if diags.HasError() {
+		return diags
+	}
+	diags = append(diags, checkStackTargetCommit(client, d)...)
+	if diags.HasError() {
+		return diags
+	}
+
 	var err error
 
 	switch deployment {
@@ -394,12 +800,9 @@ func resourcePortainerStackCreate(ctx context.Context, d *schema.ResourceData, m
 		case "string":
 			err = createStackStandaloneString(ctx, d, client)
 		case "file":
-			path := d.Get("stack_file_path").(string)
-			content, readErr := os.ReadFile(path)
-			if readErr != nil {
-				return diag.FromErr(fmt.Errorf("failed to read stack file from path: %w", readErr))
+			if readErr := readStackFilePath(d); readErr != nil {
+				return diag.FromErr(readErr)
 			}
-			_ = d.Set("stack_file_content", string(content))
 			err = createStackStandaloneString(ctx, d, client)
 		case "repository":
 			err = createStackStandaloneRepo(ctx, d, client)
@@ -412,12 +815,9 @@ func resourcePortainerStackCreate(ctx context.Context, d *schema.ResourceData, m
 		case "string":
 			err = createStackSwarmString(ctx, d, client)
 		case "file":
-			path := d.Get("stack_file_path").(string)
-			content, readErr := os.ReadFile(path)
-			if readErr != nil {
-				return diag.FromErr(fmt.Errorf("failed to read stack file from path: %w", readErr))
+			if readErr := readStackFilePath(d); readErr != nil {
+				return diag.FromErr(readErr)
 			}
-			_ = d.Set("stack_file_content", string(content))
 			err = createStackSwarmString(ctx, d, client)
 		case "repository":
 			err = createStackSwarmRepo(ctx, d, client)
@@ -465,8 +865,13 @@ func resourcePortainerStackCreate(ctx context.Context, d *schema.ResourceData, m
 			}
 		}
 
+		env, err := stackEnv(d)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
 		payload := map[string]interface{}{
-			"env":              flattenEnvList(d.Get("env").([]interface{})),
+			"env":              env,
 			"stackFileContent": d.Get("stack_file_content").(string),
 			"prune":            d.Get("prune").(bool),
 			"pullImage":        d.Get("pull_image").(bool),
@@ -520,7 +925,8 @@ func resourcePortainerStackCreate(ctx context.Context, d *schema.ResourceData, m
 		return diag.FromErr(fmt.Errorf("failed to update stack access control: %w", err))
 	}
 
-	return resourcePortainerStackRead(ctx, d, meta)
+	diags = append(diags, resourcePortainerStackRead(ctx, d, meta)...)
+	return append(diags, stackTargetCommitDiags(d)...)
 }
 
 func resourcePortainerStackRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -567,11 +973,8 @@ func resourcePortainerStackRead(ctx context.Context, d *schema.ResourceData, met
 			Webhook        string `json:"Webhook"`
 			ForcePullImage bool   `json:"ForcePullImage"`
 		} `json:"AutoUpdate,omitempty"`
-		Env []struct {
-			Name  string `json:"name"`
-			Value string `json:"value"`
-		} `json:"Env"`
-		Registries []int `json:"Registries"`
+		Env        []stackEnvVar `json:"Env"`
+		Registries []int         `json:"Registries"`
 
 		Option struct {
 			Prune bool `json:"prune"`
@@ -583,6 +986,7 @@ func resourcePortainerStackRead(ctx context.Context, d *schema.ResourceData, met
 			ConfigFilePath  string   `json:"ConfigFilePath"`
 			AdditionalFiles []string `json:"AdditionalFiles"`
 			TLSSkipVerify   bool     `json:"tlsskipVerify"`
+			ConfigHash      string   `json:"ConfigHash"`
 			Authentication  struct {
 				GitCredentialID int `json:"GitCredentialID"`
 			} `json:"Authentication"`
@@ -676,14 +1080,7 @@ func resourcePortainerStackRead(ctx context.Context, d *schema.ResourceData, met
 	}
 
 	// Env → Terraform
-	tfEnvs := make([]map[string]interface{}, 0, len(stack.Env))
-	for _, env := range stack.Env {
-		tfEnvs = append(tfEnvs, map[string]interface{}{
-			"name":  env.Name,
-			"value": env.Value,
-		})
-	}
-	if err := d.Set("env", tfEnvs); err != nil {
+	if err := setStackEnv(d, stack.Env); err != nil {
 		return diag.FromErr(err)
 	}
 	_ = d.Set("method", method)
@@ -692,6 +1089,7 @@ func resourcePortainerStackRead(ctx context.Context, d *schema.ResourceData, met
 	if method == "repository" && stack.GitConfig != nil {
 		_ = d.Set("tlsskip_verify", stack.GitConfig.TLSSkipVerify)
 		_ = d.Set("repository_git_credential_id", stack.GitConfig.Authentication.GitCredentialID)
+		_ = d.Set("deployed_commit_hash", stack.GitConfig.ConfigHash)
 		if stack.GitConfig.URL != "" {
 			_ = d.Set("repository_url", stack.GitConfig.URL)
 		}
@@ -716,6 +1114,31 @@ func resourcePortainerStackRead(ctx context.Context, d *schema.ResourceData, met
 		_ = d.Set("update_interval", stack.AutoUpdate.Interval)
 	}
 
+	if d.Get("deployment_type").(string) == "kubernetes" {
+		manifest := ""
+		if !stack.ComposeFmt {
+			manifest = d.Get("stack_file_content").(string)
+			if method == "repository" {
+				manifest = ""
+				body, code, err := apiGETWithCodeCtx(ctx, fmt.Sprintf("%s/stacks/%s/file", client.Endpoint, stackID), client.APIKey, client)
+				var file struct {
+					StackFileContent string `json:"StackFileContent"`
+				}
+				if err == nil && code == http.StatusOK && json.Unmarshal(body, &file) == nil {
+					manifest = file.StackFileContent
+				}
+			}
+		}
+		if err := setStackKubernetesObjects(ctx, client, d, stack.EndpointID, stack.Namespace, manifest); err != nil {
+			return diag.FromErr(err)
+		}
+	} else if method != "repository" {
+		// Repository stacks record theirs when deployed.
+		if err := setStackExternalDependencies(d, d.Get("stack_file_content").(string), stack.Env); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	if stack.Portainer.ResourceControl.Id != 0 {
 		_ = d.Set("resource_control_id", stack.Portainer.ResourceControl.Id)
 
@@ -855,19 +1278,34 @@ func resourcePortainerStackUpdate(ctx context.Context, d *schema.ResourceData, m
 	}
 
 	if method == "file" {
-		path := d.Get("stack_file_path").(string)
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return diag.FromErr(fmt.Errorf("failed to read stack file for update: %w", err))
+		if err := readStackFilePath(d); err != nil {
+			return diag.FromErr(err)
 		}
-		_ = d.Set("stack_file_content", string(content))
+	}
+
+	if err := createStackNamespace(ctx, client, d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	diags := checkStackDependencies(ctx, client, d)
+	if diags.HasError() {
+		return diags
 	}
 
 	// ---------------- REPOSITORY STACK ----------------
 	if method == "repository" {
+		diags = append(diags, checkStackTargetCommit(client, d)...)
+		if diags.HasError() {
+			return diags
+		}
+		env, err := stackEnv(d)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
 		payload := map[string]interface{}{
 			"supportRelativePath":       d.Get("support_relative_path").(bool),
-			"env":                       flattenEnvList(d.Get("env").([]interface{})),
+			"env":                       env,
 			"prune":                     d.Get("prune").(bool),
 			"pullImage":                 d.Get("pull_image").(bool),
 			"repositoryAuthentication":  d.Get("git_repository_authentication").(bool),
@@ -946,7 +1384,7 @@ func resourcePortainerStackUpdate(ctx context.Context, d *schema.ResourceData, m
 		}
 
 		redeployPayload := map[string]interface{}{
-			"env":                       flattenEnvList(d.Get("env").([]interface{})),
+			"env":                       env,
 			"prune":                     d.Get("prune").(bool),
 			"pullImage":                 d.Get("pull_image").(bool),
 			"repositoryAuthentication":  d.Get("git_repository_authentication").(bool),
@@ -989,7 +1427,8 @@ func resourcePortainerStackUpdate(ctx context.Context, d *schema.ResourceData, m
 			return diag.FromErr(fmt.Errorf("failed to redeploy git stack: %s", string(data)))
 		}
 
-		return resourcePortainerStackRead(ctx, d, meta)
+		diags = append(diags, resourcePortainerStackRead(ctx, d, meta)...)
+		return append(diags, stackTargetCommitDiags(d)...)
 	}
 
 	if err := updateStackAccessControl(d, client, stackID); err != nil {
@@ -998,8 +1437,13 @@ func resourcePortainerStackUpdate(ctx context.Context, d *schema.ResourceData, m
 
 	// ---------------- NON-REPOSITORY STACKS ----------------
 	if method != "repository" {
+		env, err := stackEnv(d)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
 		payload := map[string]interface{}{
-			"env":              flattenEnvList(d.Get("env").([]interface{})),
+			"env":              env,
 			"stackFileContent": d.Get("stack_file_content").(string),
 			"prune":            d.Get("prune").(bool),
 			"pullImage":        d.Get("pull_image").(bool),
@@ -1042,8 +1486,13 @@ func resourcePortainerStackUpdate(ctx context.Context, d *schema.ResourceData, m
 			webhookToken = uuid.New().String()
 		}
 
+		env, err := stackEnv(d)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
 		payload := map[string]interface{}{
-			"env":              flattenEnvList(d.Get("env").([]interface{})),
+			"env":              env,
 			"stackFileContent": d.Get("stack_file_content").(string),
 			"prune":            d.Get("prune").(bool),
 			"pullImage":        d.Get("pull_image").(bool),
@@ -1087,7 +1536,7 @@ func resourcePortainerStackUpdate(ctx context.Context, d *schema.ResourceData, m
 		_ = d.Set("webhook_url", webhookURL)
 	}
 
-	return resourcePortainerStackRead(ctx, d, meta)
+	return append(diags, resourcePortainerStackRead(ctx, d, meta)...)
 }
 
 func flattenEnvList(envList []interface{}) []map[string]string {
@@ -1102,13 +1551,279 @@ func flattenEnvList(envList []interface{}) []map[string]string {
 	return out
 }
 
+// stackEnvVar is an environment variable of a Portainer stack.
+type stackEnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// readStackEnvFile parses the .env file at path.
+func readStackEnvFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env_file: %w", err)
+	}
+	vars, err := parseEnvFile(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse env_file %s: %w", path, err)
+	}
+	return vars, nil
+}
+
+// stackSensitiveEnv decodes sensitive_env_wo from the raw configuration of a
+// ResourceData or a ResourceDiff.
+func stackSensitiveEnv(d rawConfigReader) (map[string]string, error) {
+	out := map[string]string{}
+	// sensitive_env_wo_version is required alongside sensitive_env_wo.
+	if d.Get("sensitive_env_wo_version").(int) == 0 {
+		return out, nil
+	}
+	raw, diags := d.GetRawConfigAt(cty.GetAttrPath("sensitive_env_wo"))
+	if diags.HasError() {
+		return nil, fmt.Errorf("unable to read sensitive_env_wo: %v", diags)
+	}
+	if !raw.IsKnown() || raw.IsNull() || raw.AsString() == "" {
+		return out, nil
+	}
+	if err := json.Unmarshal([]byte(raw.AsString()), &out); err != nil {
+		return nil, fmt.Errorf("sensitive_env_wo must be a JSON object of strings: %w", err)
+	}
+	return out, nil
+}
+
+// stackPlainEnv merges the non-sensitive environment variables of a
+// ResourceData or a ResourceDiff: the env blocks, followed by the variables
+// of env_file and env_map they do not override, sorted by name. Variables of
+// env_file are overridden by env_map.
+func stackPlainEnv(d interface{ Get(string) interface{} }) ([]map[string]string, error) {
+	merged := map[string]string{}
+	if path := d.Get("env_file").(string); path != "" {
+		vars, err := readStackEnvFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range vars {
+			merged[k] = v
+		}
+	}
+	for k, v := range d.Get("env_map").(map[string]interface{}) {
+		merged[k] = v.(string)
+	}
+	env := flattenEnvList(d.Get("env").([]interface{}))
+	for _, e := range env {
+		delete(merged, e["name"])
+	}
+
+	keys := make([]string, 0, len(merged))
+	for k := range merged {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, map[string]string{"name": k, "value": merged[k]})
+	}
+	return env, nil
+}
+
+// stackEnv merges the environment variables sent to Portainer. Variables of
+// env_file are overridden by env_map, env and sensitive_env_wo, in that order.
+// The names of the sensitive variables are recorded in sensitive_env_names.
+func stackEnv(d *schema.ResourceData) ([]map[string]string, error) {
+	env, err := stackPlainEnv(d)
+	if err != nil {
+		return nil, err
+	}
+
+	sensitive, err := stackSensitiveEnv(d)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(sensitive))
+	for k := range sensitive {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	if err := d.Set("sensitive_env_names", names); err != nil {
+		return nil, err
+	}
+
+	for _, e := range env {
+		if v, ok := sensitive[e["name"]]; ok {
+			e["value"] = v
+			delete(sensitive, e["name"])
+		}
+	}
+	for _, k := range names {
+		if v, ok := sensitive[k]; ok {
+			env = append(env, map[string]string{"name": k, "value": v})
+		}
+	}
+	return env, nil
+}
+
+// stackEnvFileHash hashes the variables of env_file that are not overridden
+// by another source, so that changes are detected without storing values.
+func stackEnvFileHash(vars map[string]string, overridden map[string]bool) string {
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		if !overridden[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\x00", k, vars[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// stackEnvOverrides returns the names that override variables of env_file.
+func stackEnvOverrides(env []interface{}, envMap map[string]interface{}, sensitive []interface{}) map[string]bool {
+	names := map[string]bool{}
+	for _, e := range env {
+		names[e.(map[string]interface{})["name"].(string)] = true
+	}
+	for k := range envMap {
+		names[k] = true
+	}
+	for _, k := range sensitive {
+		names[k.(string)] = true
+	}
+	return names
+}
+
+// setStackEnv splits the environment variables read from Portainer between
+// env, env_map and env_file_sha256. Sensitive variables are left out so their
+// values never reach the state; variables not managed by any source show up
+// in env.
+func setStackEnv(d *schema.ResourceData, remote []stackEnvVar) error {
+	sensitive := map[string]bool{}
+	for _, k := range d.Get("sensitive_env_names").([]interface{}) {
+		sensitive[k.(string)] = true
+	}
+	inEnv := map[string]bool{}
+	for _, e := range d.Get("env").([]interface{}) {
+		inEnv[e.(map[string]interface{})["name"].(string)] = true
+	}
+	envMap := d.Get("env_map").(map[string]interface{})
+
+	var fileVars map[string]string
+	path := d.Get("env_file").(string)
+	if path != "" {
+		// A missing or invalid file is reported by the plan.
+		fileVars, _ = readStackEnvFile(path)
+	}
+
+	env := make([]map[string]interface{}, 0, len(remote))
+	mapped := map[string]interface{}{}
+	deployed := map[string]string{}
+	for _, v := range remote {
+		_, inMap := envMap[v.Name]
+		_, inFile := fileVars[v.Name]
+		switch {
+		case sensitive[v.Name]:
+		case inEnv[v.Name]:
+			env = append(env, map[string]interface{}{"name": v.Name, "value": v.Value})
+		case inMap:
+			mapped[v.Name] = v.Value
+		case inFile:
+			deployed[v.Name] = v.Value
+		default:
+			env = append(env, map[string]interface{}{"name": v.Name, "value": v.Value})
+		}
+	}
+
+	if err := d.Set("env", env); err != nil {
+		return err
+	}
+	if len(envMap) > 0 {
+		if err := d.Set("env_map", mapped); err != nil {
+			return err
+		}
+	}
+	if fileVars != nil {
+		// Variables of the file missing in Portainer change the hash.
+		for k := range fileVars {
+			if _, ok := deployed[k]; !ok {
+				deployed[k] = "\x00missing"
+			}
+		}
+		overridden := stackEnvOverrides(d.Get("env").([]interface{}), envMap, d.Get("sensitive_env_names").([]interface{}))
+		if err := d.Set("env_file_sha256", stackEnvFileHash(deployed, overridden)); err != nil {
+			return err
+		}
+	} else if path == "" {
+		if err := d.Set("env_file_sha256", ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// customizeDiffStackEnv rejects variables set in both env and env_map, plans
+// an update when env_file was edited or its variables drifted in Portainer,
+// and when sensitive_env_wo_version is bumped.
+func customizeDiffStackEnv(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	env := d.Get("env").([]interface{})
+	envMap := d.Get("env_map").(map[string]interface{})
+	// A sensitive variable also set in env or env_map would never converge:
+	// sensitive values are not read back, so the other source shows a diff.
+	sensitive, err := stackSensitiveEnv(d)
+	if err != nil {
+		return err
+	}
+	for name := range sensitive {
+		if envMap[name] != nil {
+			return fmt.Errorf("environment variable %q is set in both env_map and sensitive_env_wo", name)
+		}
+	}
+	for _, e := range env {
+		name := e.(map[string]interface{})["name"].(string)
+		if envMap[name] != nil {
+			return fmt.Errorf("environment variable %q is set in both env and env_map", name)
+		}
+		if _, ok := sensitive[name]; ok {
+			return fmt.Errorf("environment variable %q is set in both env and sensitive_env_wo", name)
+		}
+	}
+
+	if d.HasChange("sensitive_env_wo_version") {
+		if err := d.SetNewComputed("sensitive_env_names"); err != nil {
+			return err
+		}
+	}
+
+	path := d.Get("env_file").(string)
+	if path == "" {
+		if d.Get("env_file_sha256").(string) != "" {
+			return d.SetNew("env_file_sha256", "")
+		}
+		return nil
+	}
+	vars, err := readStackEnvFile(path)
+	if err != nil {
+		return err
+	}
+	overridden := stackEnvOverrides(env, envMap, d.Get("sensitive_env_names").([]interface{}))
+	if hash := stackEnvFileHash(vars, overridden); d.Get("env_file_sha256").(string) != hash {
+		return d.SetNew("env_file_sha256", hash)
+	}
+	return nil
+}
+
 // --------------------- STANDALONE ----------------------
 
 func createStackStandaloneString(ctx context.Context, d *schema.ResourceData, client *APIClient) error {
+	env, err := stackEnv(d)
+	if err != nil {
+		return err
+	}
+
 	payload := map[string]interface{}{
 		"name":             d.Get("name").(string),
 		"stackFileContent": d.Get("stack_file_content").(string),
-		"env":              flattenEnvList(d.Get("env").([]interface{})),
+		"env":              env,
 		"fromAppTemplate":  false,
 		"registries":       expandIntList(d.Get("registries").([]interface{})),
 	}
@@ -1168,6 +1883,11 @@ func createStackStandaloneRepo(ctx context.Context, d *schema.ResourceData, clie
 		composeFile = "docker-compose.yml"
 	}
 
+	env, err := stackEnv(d)
+	if err != nil {
+		return err
+	}
+
 	payload := map[string]interface{}{
 		"name":                      d.Get("name").(string),
 		"composeFile":               composeFile,
@@ -1178,7 +1898,7 @@ func createStackStandaloneRepo(ctx context.Context, d *schema.ResourceData, clie
 		"repositoryAuthentication":  d.Get("git_repository_authentication").(bool),
 		"repositoryGitCredentialID": d.Get("repository_git_credential_id").(int),
 		"supportRelativePath":       d.Get("support_relative_path").(bool),
-		"env":                       flattenEnvList(d.Get("env").([]interface{})),
+		"env":                       env,
 		"fromAppTemplate":           false,
 		"tlsskipVerify":             d.Get("tlsskip_verify").(bool),
 		"additionalFiles":           expandStringList(d.Get("additional_files").([]interface{})),
@@ -1251,10 +1971,15 @@ func createStackStandaloneRepo(ctx context.Context, d *schema.ResourceData, clie
 // --------------------- SWARM ----------------------
 
 func createStackSwarmString(ctx context.Context, d *schema.ResourceData, client *APIClient) error {
+	env, err := stackEnv(d)
+	if err != nil {
+		return err
+	}
+
 	payload := map[string]interface{}{
 		"name":             d.Get("name").(string),
 		"stackFileContent": d.Get("stack_file_content").(string),
-		"env":              flattenEnvList(d.Get("env").([]interface{})),
+		"env":              env,
 		"fromAppTemplate":  false,
 		"swarmID":          d.Get("swarm_id").(string),
 		"registries":       expandIntList(d.Get("registries").([]interface{})),
@@ -1315,6 +2040,11 @@ func createStackSwarmRepo(ctx context.Context, d *schema.ResourceData, client *A
 		composeFile = "docker-compose.yml"
 	}
 
+	env, err := stackEnv(d)
+	if err != nil {
+		return err
+	}
+
 	payload := map[string]interface{}{
 		"name":                      d.Get("name").(string),
 		"composeFile":               composeFile,
@@ -1325,7 +2055,7 @@ func createStackSwarmRepo(ctx context.Context, d *schema.ResourceData, client *A
 		"repositoryAuthentication":  d.Get("git_repository_authentication").(bool),
 		"repositoryGitCredentialID": d.Get("repository_git_credential_id").(int),
 		"supportRelativePath":       d.Get("support_relative_path").(bool),
-		"env":                       flattenEnvList(d.Get("env").([]interface{})),
+		"env":                       env,
 		"fromAppTemplate":           false,
 		"tlsskipVerify":             d.Get("tlsskip_verify").(bool),
 		"swarmID":                   d.Get("swarm_id").(string),
@@ -0,0 +1,510 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"gopkg.in/yaml.v3"
+)
+
+func resourceStackPromotion() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceStackPromotionCreate,
+		ReadContext:   resourceStackPromotionRead,
+		DeleteContext: resourceStackPromotionDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(15 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"source_stack_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Identifier of the Docker stack to promote.",
+			},
+			"target_endpoint_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Target environment (endpoint) identifier the stack is copied to.",
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Name of the stack on the target environment. If not set, the source name is kept.",
+			},
+			"env_overrides": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Environment variables of the target stack, added to or replacing those of the source stack.",
+			},
+			"preflight": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				ForceNew:    true,
+				Description: "Check before copying that the external networks, volumes, secrets and configs of the stack exist on the target environment, and that the target can access the registries of its images.",
+			},
+			"readiness_check": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				MaxItems:    1,
+				Description: "Check that every service of the stack runs on the target environment before source_action is applied. When the check fails, the target stack is removed unless keep_target_on_failure is set.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"wait": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     30,
+							Description: "Seconds to wait after the deployment before the first check.",
+						},
+						"wait_between_checks": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     10,
+							Description: "Seconds between checks.",
+						},
+						"max_retries": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     3,
+							Description: "Number of checks before the target stack is considered not ready.",
+						},
+						"keep_target_on_failure": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Keep the target stack when the check fails, e.g. for troubleshooting.",
+						},
+					},
+				},
+			},
+			"source_action": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "keep",
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice([]string{"keep", "stop", "delete"}, false),
+				Description:  "Action on the source stack after a successful promotion: 'keep' (copy), 'stop' or 'delete' (move).",
+			},
+			"target_stack_id": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Identifier of the stack created on the target environment.",
+			},
+			"output": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Human-readable report of the promotion steps.",
+			},
+		},
+	}
+}
+
+// promotionSourceStack is the part of a stack the promotion copies.
+type promotionSourceStack struct {
+	ID         int           `json:"Id"`
+	Name       string        `json:"Name"`
+	Type       int           `json:"Type"`
+	EndpointID int           `json:"EndpointId"`
+	Env        []stackEnvVar `json:"Env"`
+	Registries []int         `json:"Registries"`
+}
+
+func resourceStackPromotionCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	sourceID := d.Get("source_stack_id").(int)
+	targetEndpointID := d.Get("target_endpoint_id").(int)
+	var out strings.Builder
+
+	source, content, err := fetchPromotionSource(ctx, client, sourceID)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if source.Type != 1 && source.Type != 2 {
+		return diag.FromErr(fmt.Errorf("stack %d is a Kubernetes stack: only Docker Swarm and Compose stacks can be promoted", sourceID))
+	}
+
+	name := source.Name
+	if v, ok := d.GetOk("name"); ok {
+		name = v.(string)
+	}
+	env, envMap := promotionEnv(source.Env, d.Get("env_overrides").(map[string]interface{}))
+
+	if existing, err := findExistingStackByName(ctx, client, name, targetEndpointID); err != nil {
+		return diag.FromErr(err)
+	} else if existing != 0 {
+		return diag.FromErr(fmt.Errorf("a stack named %q already exists on environment %d (ID %d)", name, targetEndpointID, existing))
+	}
+
+	swarmID := ""
+	if source.Type == 1 {
+		if swarmID, err = fetchSwarmID(ctx, client, targetEndpointID); err != nil {
+			return diag.FromErr(fmt.Errorf("stack %q is a Swarm stack but environment %d is not a Docker Swarm: %w", source.Name, targetEndpointID, err))
+		}
+		if swarmID == "" {
+			return diag.FromErr(fmt.Errorf("stack %q is a Swarm stack but environment %d is not a Docker Swarm", source.Name, targetEndpointID))
+		}
+	}
+	out.WriteString(fmt.Sprintf("Promoting stack %q (ID %d) from environment %d to %q on environment %d\n", source.Name, sourceID, source.EndpointID, name, targetEndpointID))
+
+	if d.Get("preflight").(bool) {
+		if err := promotionPreflight(ctx, client, targetEndpointID, content, envMap, swarmID != ""); err != nil {
+			return diag.FromErr(err)
+		}
+		out.WriteString("Preflight: OK — external dependencies and registries available on the target\n")
+	}
+
+	targetID, err := createPromotedStack(ctx, client, targetEndpointID, swarmID, name, content, env, source.Registries)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	// From here on the target stack exists: it is recorded in state, so that
+	// errors of the next steps leave it tracked (and tainted) rather than
+	// orphaned on the target environment.
+	d.SetId(fmt.Sprintf("%d-%d", sourceID, targetID))
+	_ = d.Set("target_stack_id", targetID)
+	out.WriteString(fmt.Sprintf("Stack %q created on environment %d (ID %d)\n", name, targetEndpointID, targetID))
+	fail := func(err error) diag.Diagnostics {
+		_ = d.Set("output", out.String())
+		return diag.FromErr(err)
+	}
+
+	if raw := d.Get("readiness_check").([]interface{}); len(raw) > 0 && raw[0] != nil {
+		check := raw[0].(map[string]interface{})
+		if err := checkPromotionReadiness(ctx, client, targetEndpointID, swarmID != "", name, content, check, &out); err != nil {
+			if check["keep_target_on_failure"].(bool) {
+				return fail(fmt.Errorf("stack %q (ID %d) is not ready on environment %d, kept for troubleshooting: %w", name, targetID, targetEndpointID, err))
+			}
+			if delErr := deletePromotionStack(client, targetID, targetEndpointID); delErr != nil {
+				return fail(fmt.Errorf("stack %q is not ready on environment %d: %w; removing it failed: %v", name, targetEndpointID, err, delErr))
+			}
+			d.SetId("")
+			return diag.FromErr(fmt.Errorf("stack %q is not ready on environment %d and was removed: %w", name, targetEndpointID, err))
+		}
+	}
+
+	switch d.Get("source_action").(string) {
+	case "stop":
+		path := fmt.Sprintf("/stacks/%d/stop?endpointId=%d", sourceID, source.EndpointID)
+		resp, err := client.DoRequest(http.MethodPost, path, nil, nil)
+		if err != nil {
+			return fail(fmt.Errorf("stack promoted (ID %d), but failed to stop the source stack: %w", targetID, err))
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return fail(fmt.Errorf("stack promoted (ID %d), but failed to stop the source stack: HTTP %d", targetID, resp.StatusCode))
+		}
+		out.WriteString(fmt.Sprintf("Source stack %d stopped\n", sourceID))
+	case "delete":
+		if err := deletePromotionStack(client, sourceID, source.EndpointID); err != nil {
+			return fail(fmt.Errorf("stack promoted (ID %d), but failed to delete the source stack: %w", targetID, err))
+		}
+		out.WriteString(fmt.Sprintf("Source stack %d deleted\n", sourceID))
+	}
+
+	_ = d.Set("output", out.String())
+	return nil
+}
+
+// resourceStackPromotionRead removes the promotion from state when the
+// target stack no longer exists, so that the next apply promotes it again.
+func resourceStackPromotionRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	body, code, err := apiGETWithCodeCtx(ctx, fmt.Sprintf("%s/stacks/%d", client.Endpoint, d.Get("target_stack_id").(int)), client.APIKey, client)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to read promoted stack: %w", err))
+	}
+	if code == http.StatusNotFound {
+		d.SetId("")
+		return nil
+	}
+	if code != http.StatusOK {
+		return diag.FromErr(fmt.Errorf("failed to read promoted stack: status %d, body: %s", code, string(body)))
+	}
+	return nil
+}
+
+// resourceStackPromotionDelete removes the target stack. The source stack is
+// not restored.
+func resourceStackPromotionDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*APIClient)
+	targetID := d.Get("target_stack_id").(int)
+	if err := deletePromotionStack(client, targetID, d.Get("target_endpoint_id").(int)); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to delete promoted stack %d: %w", targetID, err))
+	}
+	return nil
+}
+
+// fetchPromotionSource reads a stack and its file.
+func fetchPromotionSource(ctx context.Context, client *APIClient, stackID int) (*promotionSourceStack, string, error) {
+	body, code, err := apiGETWithCodeCtx(ctx, fmt.Sprintf("%s/stacks/%d", client.Endpoint, stackID), client.APIKey, client)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read stack %d: %w", stackID, err)
+	}
+	if code != 200 {
+		return nil, "", fmt.Errorf("failed to read stack %d: status %d, body: %s", stackID, code, string(body))
+	}
+	var source promotionSourceStack
+	if err := json.Unmarshal(body, &source); err != nil {
+		return nil, "", fmt.Errorf("failed to parse stack %d: %w", stackID, err)
+	}
+
+	body, code, err = apiGETWithCodeCtx(ctx, fmt.Sprintf("%s/stacks/%d/file", client.Endpoint, stackID), client.APIKey, client)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read file of stack %d: %w", stackID, err)
+	}
+	if code != 200 {
+		return nil, "", fmt.Errorf("failed to read file of stack %d: status %d, body: %s", stackID, code, string(body))
+	}
+	var file struct {
+		StackFileContent string `json:"StackFileContent"`
+	}
+	if err := json.Unmarshal(body, &file); err != nil {
+		return nil, "", fmt.Errorf("failed to parse file of stack %d: %w", stackID, err)
+	}
+	return &source, file.StackFileContent, nil
+}
+
+// promotionEnv applies the overrides to the source environment and returns
+// it both as the API payload, in source order followed by the new names, and
+// as a map.
+func promotionEnv(source []stackEnvVar, overrides map[string]interface{}) ([]map[string]string, map[string]string) {
+	envMap := map[string]string{}
+	env := make([]map[string]string, 0, len(source)+len(overrides))
+	for _, kv := range source {
+		value := kv.Value
+		if v, ok := overrides[kv.Name]; ok {
+			value = v.(string)
+		}
+		envMap[kv.Name] = value
+		env = append(env, map[string]string{"name": kv.Name, "value": value})
+	}
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		if _, ok := envMap[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		envMap[name] = overrides[name].(string)
+		env = append(env, map[string]string{"name": name, "value": envMap[name]})
+	}
+	return env, envMap
+}
+
+// promotionPreflight checks that the external dependencies of the stack exist
+// on the target environment and that the registries of its images are
+// accessible from it.
+func promotionPreflight(ctx context.Context, client *APIClient, endpointID int, content string, env map[string]string, isSwarm bool) error {
+	var problems []string
+
+	deps, err := composeExternalDependencies(content, env)
+	if err != nil {
+		return err
+	}
+	missing, err := missingStackDependencies(ctx, client, endpointID, deps, isSwarm)
+	if err != nil {
+		return err
+	}
+	for _, dep := range missing {
+		if !isSwarm && (dep.Type == "secret" || dep.Type == "config") {
+			problems = append(problems, fmt.Sprintf("external %s %q requires a Docker Swarm environment", dep.Type, dep.Name))
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("external %s %q does not exist", dep.Type, dep.Name))
+	}
+
+	images, err := composeImages(content, env)
+	if err != nil {
+		return err
+	}
+	var registryURLs []string
+	for _, image := range images {
+		if imageRegistryHost(image) == "" {
+			continue
+		}
+		if registryURLs == nil {
+			if registryURLs, err = fetchEndpointRegistryURLs(ctx, client, endpointID); err != nil {
+				return err
+			}
+		}
+		accessible := false
+		for _, u := range registryURLs {
+			accessible = accessible || registryMatchesImage(u, image)
+		}
+		if !accessible {
+			problems = append(problems, fmt.Sprintf("image %q: registry %s is not accessible from the environment", image, imageRegistryHost(image)))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("preflight failed on environment %d:\n  - %s", endpointID, strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// fetchEndpointRegistryURLs returns the URLs of the registries accessible
+// from an environment.
+func fetchEndpointRegistryURLs(ctx context.Context, client *APIClient, endpointID int) ([]string, error) {
+	body, code, err := apiGETWithCodeCtx(ctx, fmt.Sprintf("%s/endpoints/%d/registries", client.Endpoint, endpointID), client.APIKey, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list registries of environment %d: %w", endpointID, err)
+	}
+	if code != 200 {
+		return nil, fmt.Errorf("failed to list registries of environment %d: status %d, body: %s", endpointID, code, string(body))
+	}
+	var registries []struct {
+		URL string `json:"URL"`
+	}
+	if err := json.Unmarshal(body, &registries); err != nil {
+		return nil, fmt.Errorf("failed to parse registries of environment %d: %w", endpointID, err)
+	}
+	urls := []string{}
+	for _, r := range registries {
+		urls = append(urls, r.URL)
+	}
+	return urls, nil
+}
+
+// createPromotedStack creates the string stack on the target environment and
+// returns its ID.
+func createPromotedStack(ctx context.Context, client *APIClient, endpointID int, swarmID, name, content string, env []map[string]string, registries []int) (int, error) {
+	payload := map[string]interface{}{
+		"name":             name,
+		"stackFileContent": content,
+		"env":              env,
+		"fromAppTemplate":  false,
+		"registries":       registries,
+	}
+	kind := "standalone"
+	if swarmID != "" {
+		kind = "swarm"
+		payload["swarmID"] = swarmID
+	}
+	body, _ := json.Marshal(payload)
+	createURL := fmt.Sprintf("%s/stacks/create/%s/string?endpointId=%d", client.Endpoint, kind, endpointID)
+	resp, code, err := apiPOSTWithCodeCtx(ctx, createURL, client.APIKey, client, body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s stack %q: %w", kind, name, err)
+	}
+	if code != 200 {
+		return 0, fmt.Errorf("failed to create %s stack %q: status %d, body: %s", kind, name, code, string(resp))
+	}
+	var result struct {
+		ID int `json:"Id"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return 0, fmt.Errorf("failed to decode stack response: %w", err)
+	}
+	return result.ID, nil
+}
+
+func deletePromotionStack(client *APIClient, stackID, endpointID int) error {
+	resp, err := client.DoRequest(http.MethodDelete, fmt.Sprintf("/stacks/%d?endpointId=%d", stackID, endpointID), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// checkPromotionReadiness waits until every service of the stack runs on the
+// environment: all tasks of a Swarm service, or all containers of a Compose
+// service (at least one).
+func checkPromotionReadiness(ctx context.Context, client *APIClient, endpointID int, isSwarm bool, stackName, content string, check map[string]interface{}, out *strings.Builder) error {
+	var doc struct {
+		Services map[string]interface{} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return fmt.Errorf("failed to parse compose file: %w", err)
+	}
+	services := make([]string, 0, len(doc.Services))
+	for svc := range doc.Services {
+		services = append(services, svc)
+	}
+	sort.Strings(services)
+
+	if err := sleepCtx(ctx, time.Duration(check["wait"].(int))*time.Second); err != nil {
+		return err
+	}
+	interval := time.Duration(check["wait_between_checks"].(int)) * time.Second
+	checkCtx, cancel := context.WithTimeout(ctx, time.Duration(check["max_retries"].(int))*interval)
+	defer cancel()
+
+	full := make([]string, 0, len(services))
+	for _, svc := range services {
+		full = append(full, fmt.Sprintf("%s_%s", stackName, svc))
+	}
+	readiness := swarmConvergenceCheck(client, endpointID, full, "running", 1, func(t map[string]interface{}) bool {
+		state, _ := mustMap(t["Status"])["State"].(string)
+		return strings.EqualFold(state, "running")
+	})
+	if !isSwarm {
+		readiness = composeReadinessCheck(client, endpointID, stackName, services)
+	}
+	if err := pollDockerConvergence(checkCtx, full, interval, out, readiness); err != nil {
+		return err
+	}
+	out.WriteString(fmt.Sprintf("Readiness check: OK — %s running\n", strings.Join(full, ", ")))
+	return nil
+}
+
+// composeReadinessCheck checks once that every service of a Compose project
+// has containers and that all of them are running.
+func composeReadinessCheck(client *APIClient, endpointID int, project string, services []string) dockerConvergenceCheck {
+	return func() (bool, string, error) {
+		filter := fmt.Sprintf(`{"label":["com.docker.compose.project=%s"]}`, project)
+		containersURL := fmt.Sprintf("%s/endpoints/%d/docker/containers/json?all=1&filters=%s", client.Endpoint, endpointID, url.QueryEscape(filter))
+		body, code, err := apiGETWithCode(containersURL, client.APIKey, client)
+		if err != nil || code != 200 {
+			return false, "", fmt.Errorf("failed to list containers (status %d): %w", code, err)
+		}
+		var containers []struct {
+			State  string            `json:"State"`
+			Labels map[string]string `json:"Labels"`
+		}
+		if err := json.Unmarshal(body, &containers); err != nil {
+			return false, "", fmt.Errorf("failed to parse containers list: %w", err)
+		}
+
+		pending := []string{}
+		for _, svc := range services {
+			running, total := 0, 0
+			for _, c := range containers {
+				if c.Labels["com.docker.compose.service"] != svc {
+					continue
+				}
+				total++
+				if strings.EqualFold(c.State, "running") {
+					running++
+				}
+			}
+			if total == 0 || running != total {
+				pending = append(pending, fmt.Sprintf("%s %d/%d", svc, running, total))
+			}
+		}
+		if len(pending) == 0 {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("Waiting for containers: %s", strings.Join(pending, ", ")), nil
+	}
+}
@@ -0,0 +1,233 @@
+package internal
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// resource_stack_promotion copies the stack file and environment of a stack
+// to a string stack on the target environment; Read tracks the target stack
+// and Delete removes it.
+
+const promotionCompose = `services:
+  web:
+    image: registry.example.com/acme/web:${TAG}
+  cache:
+    image: redis:7
+networks:
+  proxy:
+    external: true
+`
+
+// promotionMock serves a standalone source stack 4 on environment 1 and an
+// empty standalone target environment 2.
+func promotionMock(t *testing.T) *MockServer {
+	mock := NewMockServer(t)
+	mock.On("GET", "/stacks/4", RespondJSON(http.StatusOK, map[string]interface{}{
+		"Id":         4,
+		"Name":       "shop",
+		"Type":       2,
+		"EndpointId": 1,
+		"Env": []map[string]interface{}{
+			{"name": "TAG", "value": "1.2"},
+			{"name": "LOG_LEVEL", "value": "debug"},
+		},
+	}))
+	mock.On("GET", "/stacks/4/file", RespondJSON(http.StatusOK, map[string]interface{}{"StackFileContent": promotionCompose}))
+	mock.On("GET", "/stacks", RespondJSON(http.StatusOK, []map[string]interface{}{{"Id": 4, "Name": "shop", "EndpointId": 1}}))
+	mock.On("POST", "/stacks/create/standalone/string", RespondJSON(http.StatusOK, map[string]interface{}{"Id": 9}))
+	return mock
+}
+
+func TestStackPromotionCreate_HappyPath(t *testing.T) {
+	mock := promotionMock(t)
+	mock.On("GET", "/endpoints/2/docker/networks", RespondJSON(http.StatusOK, []map[string]interface{}{{"Name": "proxy"}}))
+	mock.On("GET", "/endpoints/2/registries", RespondJSON(http.StatusOK, []map[string]interface{}{{"URL": "registry.example.com"}}))
+	mock.On("GET", "/endpoints/2/docker/containers/json", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{"State": "running", "Labels": map[string]string{"com.docker.compose.project": "shop-prod", "com.docker.compose.service": "web"}},
+		{"State": "running", "Labels": map[string]string{"com.docker.compose.project": "shop-prod", "com.docker.compose.service": "cache"}},
+	}))
+	mock.On("POST", "/stacks/4/stop", RespondJSON(http.StatusOK, map[string]interface{}{}))
+
+	r := resourceStackPromotion()
+	d := r.TestResourceData()
+	_ = d.Set("source_stack_id", 4)
+	_ = d.Set("target_endpoint_id", 2)
+	_ = d.Set("name", "shop-prod")
+	_ = d.Set("env_overrides", map[string]interface{}{"LOG_LEVEL": "warn", "REPLICAS": "3"})
+	_ = d.Set("preflight", true)
+	_ = d.Set("source_action", "stop")
+	_ = d.Set("readiness_check", []interface{}{map[string]interface{}{
+		"wait": 0, "wait_between_checks": 1, "max_retries": 1, "keep_target_on_failure": false,
+	}})
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if d.Id() != "4-9" || d.Get("target_stack_id").(int) != 9 {
+		t.Errorf("expected ID 4-9 and target_stack_id 9, got %q and %d", d.Id(), d.Get("target_stack_id").(int))
+	}
+
+	create := mock.FindRequest("POST", "/stacks/create/standalone/string")
+	if create == nil || create.Query != "endpointId=2" {
+		t.Fatalf("expected the stack to be created on environment 2, got %+v", create)
+	}
+	var payload struct {
+		Name             string              `json:"name"`
+		StackFileContent string              `json:"stackFileContent"`
+		Env              []map[string]string `json:"env"`
+	}
+	if err := create.DecodeJSON(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Name != "shop-prod" || payload.StackFileContent != promotionCompose {
+		t.Errorf("unexpected name or file: %q", payload.Name)
+	}
+	wantEnv := "TAG=1.2,LOG_LEVEL=warn,REPLICAS=3"
+	var gotEnv []string
+	for _, kv := range payload.Env {
+		gotEnv = append(gotEnv, kv["name"]+"="+kv["value"])
+	}
+	if strings.Join(gotEnv, ",") != wantEnv {
+		t.Errorf("env: expected %s, got %s", wantEnv, strings.Join(gotEnv, ","))
+	}
+
+	stop := mock.FindRequest("POST", "/stacks/4/stop")
+	if stop == nil || stop.Query != "endpointId=1" {
+		t.Errorf("expected the source stack to be stopped on environment 1, got %+v", stop)
+	}
+	out := d.Get("output").(string)
+	for _, s := range []string{"Preflight: OK", "Readiness check: OK", "Source stack 4 stopped"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("output: expected %q, got:\n%s", s, out)
+		}
+	}
+}
+
+func TestStackPromotionCreate_PreflightFails(t *testing.T) {
+	mock := promotionMock(t)
+	mock.On("GET", "/endpoints/2/docker/networks", RespondJSON(http.StatusOK, []map[string]interface{}{{"Name": "bridge"}}))
+	mock.On("GET", "/endpoints/2/registries", RespondJSON(http.StatusOK, []map[string]interface{}{{"URL": "ghcr.io"}}))
+
+	r := resourceStackPromotion()
+	d := r.TestResourceData()
+	_ = d.Set("source_stack_id", 4)
+	_ = d.Set("target_endpoint_id", 2)
+	_ = d.Set("preflight", true)
+
+	err := rcCreate(r, d, mock.Client())
+	if err == nil {
+		t.Fatal("expected the preflight to fail")
+	}
+	for _, s := range []string{`external network "proxy" does not exist`, `image "registry.example.com/acme/web:1.2": registry registry.example.com is not accessible`} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("error: expected %q, got %v", s, err)
+		}
+	}
+	if strings.Contains(err.Error(), "redis") {
+		t.Errorf("error: Docker Hub images need no registry, got %v", err)
+	}
+	if mock.FindRequest("POST", "/stacks/create/standalone/string") != nil {
+		t.Error("expected no stack to be created")
+	}
+}
+
+func TestStackPromotionCreate_NameConflict(t *testing.T) {
+	mock := promotionMock(t)
+
+	r := resourceStackPromotion()
+	d := r.TestResourceData()
+	_ = d.Set("source_stack_id", 4)
+	_ = d.Set("target_endpoint_id", 1)
+
+	err := rcCreate(r, d, mock.Client())
+	if err == nil || !strings.Contains(err.Error(), `a stack named "shop" already exists on environment 1`) {
+		t.Fatalf("expected a name conflict, got %v", err)
+	}
+}
+
+func TestStackPromotionCreate_NotReadyRemovesTarget(t *testing.T) {
+	mock := promotionMock(t)
+	mock.On("GET", "/endpoints/2/docker/containers/json", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{"State": "running", "Labels": map[string]string{"com.docker.compose.project": "shop", "com.docker.compose.service": "cache"}},
+		{"State": "restarting", "Labels": map[string]string{"com.docker.compose.project": "shop", "com.docker.compose.service": "web"}},
+	}))
+	mock.On("DELETE", "/stacks/9", RespondJSON(http.StatusNoContent, nil))
+
+	r := resourceStackPromotion()
+	d := r.TestResourceData()
+	_ = d.Set("source_stack_id", 4)
+	_ = d.Set("target_endpoint_id", 2)
+	_ = d.Set("preflight", false)
+	_ = d.Set("source_action", "delete")
+	_ = d.Set("readiness_check", []interface{}{map[string]interface{}{
+		"wait": 0, "wait_between_checks": 1, "max_retries": 1, "keep_target_on_failure": false,
+	}})
+
+	err := rcCreate(r, d, mock.Client())
+	if err == nil || !strings.Contains(err.Error(), "is not ready on environment 2 and was removed") {
+		t.Fatalf("expected a readiness failure, got %v", err)
+	}
+	del := mock.FindRequest("DELETE", "/stacks/9")
+	if del == nil || del.Query != "endpointId=2" {
+		t.Errorf("expected the target stack to be removed, got %+v", del)
+	}
+	if mock.FindRequest("DELETE", "/stacks/4") != nil {
+		t.Error("expected the source stack to be kept")
+	}
+	if d.Id() != "" {
+		t.Errorf("expected no ID, got %q", d.Id())
+	}
+}
+
+func TestStackPromotionCreate_PartialFailureKeepsTarget(t *testing.T) {
+	mock := promotionMock(t)
+	mock.On("POST", "/stacks/4/stop", RespondJSON(http.StatusInternalServerError, map[string]interface{}{}))
+
+	r := resourceStackPromotion()
+	d := r.TestResourceData()
+	_ = d.Set("source_stack_id", 4)
+	_ = d.Set("target_endpoint_id", 2)
+	_ = d.Set("preflight", false)
+	_ = d.Set("source_action", "stop")
+
+	err := rcCreate(r, d, mock.Client())
+	if err == nil || !strings.Contains(err.Error(), "failed to stop the source stack") {
+		t.Fatalf("expected the source stop to fail, got %v", err)
+	}
+	if d.Id() != "4-9" || d.Get("target_stack_id").(int) != 9 {
+		t.Errorf("expected the created target stack to stay in state, got ID %q", d.Id())
+	}
+	if !strings.Contains(d.Get("output").(string), "created on environment 2 (ID 9)") {
+		t.Errorf("expected the output of the completed steps, got:\n%s", d.Get("output").(string))
+	}
+}
+
+func TestStackPromotionReadDelete(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/stacks/9", RespondJSON(http.StatusOK, map[string]interface{}{"Id": 9}))
+	mock.On("DELETE", "/stacks/9", RespondJSON(http.StatusNoContent, nil))
+
+	r := resourceStackPromotion()
+	d := r.TestResourceData()
+	d.SetId("4-9")
+	_ = d.Set("target_endpoint_id", 2)
+	_ = d.Set("target_stack_id", 9)
+
+	if err := rcRead(r, d, mock.Client()); err != nil || d.Id() != "4-9" {
+		t.Fatalf("expected the existing target stack to be kept in state, got %q, %v", d.Id(), err)
+	}
+	if err := rcDelete(r, d, mock.Client()); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	del := mock.FindRequest("DELETE", "/stacks/9")
+	if del == nil || del.Query != "endpointId=2" {
+		t.Errorf("expected the target stack to be deleted on environment 2, got %+v", del)
+	}
+
+	_ = d.Set("target_stack_id", 10)
+	if err := rcRead(r, d, mock.Client()); err != nil || d.Id() != "" {
+		t.Errorf("expected a deleted target stack to be removed from state, got %q, %v", d.Id(), err)
+	}
+}
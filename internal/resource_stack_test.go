@@ -1,9 +1,19 @@
 package internal
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 // ===========================================================================
@@ -991,3 +1001,388 @@ func TestStackDelete_404_NoError(t *testing.T) {
 		t.Fatalf("Delete should swallow 404, got error: %v", err)
 	}
 }
+
+func TestStackFileContentEqual(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"services:\n  web:\n    image: nginx\n", "services:\n  web:\n    image: nginx", true},
+		{"services:\n  web:\n    image: nginx\n", "# edited\nservices:\n    web: {image: \"nginx\"}\n", true},
+		{"services:\n  web:\n    image: nginx\n", "services:\n  web:\n    image: nginx:1.27\n", false},
+		{"a: 1\n---\nb: 2\n", "a: 1\n---\nb: 3\n", false},
+		{"not: [valid", "not: [valid\n", true},
+		{"not: [valid", "not: [other", false},
+	}
+	for _, tc := range cases {
+		if got := stackFileContentEqual(tc.a, tc.b); got != tc.want {
+			t.Errorf("stackFileContentEqual(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+// TestStackCustomizeDiff_FileDrift plans an update when the stack file stored
+// in Portainer was edited, or when the local file changed, but not for
+// formatting-only local edits.
+func TestStackCustomizeDiff_FileDrift(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docker-compose.yml")
+	local := "services:\n  web:\n    image: nginx:1.27\n"
+	if err := os.WriteFile(path, []byte(local), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(local))
+
+	r := resourcePortainerStack()
+	cfg := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":            "web",
+		"endpoint_id":     1,
+		"deployment_type": "standalone",
+		"method":          "file",
+		"stack_file_path": path,
+	})
+	state := &terraform.InstanceState{ID: "7", Attributes: map[string]string{
+		"id":                    "7",
+		"name":                  "web",
+		"endpoint_id":           "1",
+		"deployment_type":       "standalone",
+		"method":                "file",
+		"stack_file_path":       path,
+		"stack_file_content":    "services:\n  web:\n    image: nginx:1.25 # edited in Portainer\n",
+		"stack_file_sha256":     hex.EncodeToString(sum[:]),
+		"compose_format":        "false",
+		"support_relative_path": "false",
+	}}
+
+	diff, err := r.Diff(context.Background(), state, cfg, nil)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if diff.RequiresNew() {
+		t.Fatal("stack file drift must be updated in place")
+	}
+	if attr := diff.Attributes["stack_file_content"]; attr == nil || attr.New != local {
+		t.Errorf("expected stack_file_content drift to be planned, got %+v", attr)
+	}
+
+	// Formatting-only local edit: the hash changes, the content does not.
+	if err := os.WriteFile(path, []byte("services:\n    web: {image: \"nginx:1.27\"}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	state.Attributes["stack_file_content"] = local
+	diff, err = r.Diff(context.Background(), state, cfg, nil)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if diff.Attributes["stack_file_sha256"] == nil {
+		t.Error("expected stack_file_sha256 change for an edited local file")
+	}
+	if attr := diff.Attributes["stack_file_content"]; attr != nil {
+		t.Errorf("expected formatting-only change to be suppressed, got %+v", attr)
+	}
+}
+
+// TestStackCustomizeDiff_FileCreatedDuringApply plans a stack_file_path that
+// does not exist yet, e.g. written by local_file, with its content known
+// after apply.
+func TestStackCustomizeDiff_FileCreatedDuringApply(t *testing.T) {
+	r := resourcePortainerStack()
+	cfg := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":            "web",
+		"endpoint_id":     1,
+		"deployment_type": "standalone",
+		"method":          "file",
+		"stack_file_path": filepath.Join(t.TempDir(), "docker-compose.yml"),
+	})
+
+	diff, err := r.Diff(context.Background(), nil, cfg, nil)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	for _, attr := range []string{"stack_file_content", "stack_file_sha256"} {
+		if diff.Attributes[attr] == nil || !diff.Attributes[attr].NewComputed {
+			t.Errorf("expected %s to be known after apply, got %+v", attr, diff.Attributes[attr])
+		}
+	}
+}
+
+// TestStackUpdate_Repository_TargetCommit checks the reference through
+// Portainer before redeploying the repository stack, and warns when the
+// deployed commit is not target_commit.
+func TestStackUpdate_Repository_TargetCommit(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		target       string
+		reference    string
+		refsStatus   int
+		wantErr      string
+		wantWarnings int
+	}{
+		{"deployed", "3f2a9c1", "main", http.StatusOK, "", 0},
+		{"other commit deployed", "8b7e6d5", "refs/heads/main", http.StatusOK, "", 1},
+		{"missing reference", "3f2a9c1", "develop", http.StatusOK, "reference develop not found", 0},
+		{"refs not listed", "3f2a9c1", "main", http.StatusInternalServerError, "", 1},
+	} {
+		mock := NewMockServer(t)
+		if tc.refsStatus == http.StatusOK {
+			mock.On("POST", "/gitops/repo/refs", RespondJSON(http.StatusOK, []string{"refs/heads/main", "refs/tags/v1"}))
+		} else {
+			mock.On("POST", "/gitops/repo/refs", RespondString(tc.refsStatus, "text/plain", "authentication required"))
+		}
+		mock.On("POST", "/gitops/repo/file/preview", RespondJSON(http.StatusOK, map[string]interface{}{"FileContent": "services:\n  app:\n    image: app\n"}))
+		mock.On("POST", "/stacks/6/git", RespondJSON(http.StatusOK, map[string]interface{}{}))
+		mock.On("PUT", "/stacks/6/git/redeploy", RespondJSON(http.StatusOK, map[string]interface{}{}))
+		mock.On("GET", "/stacks/6", RespondJSON(http.StatusOK, map[string]interface{}{
+			"Id": 6, "Name": "gitapp", "Status": 1, "Type": 2, "EndpointId": 1,
+			"gitConfig": map[string]interface{}{
+				"URL":           "https://git.example.com/acme/app.git",
+				"ReferenceName": tc.reference,
+				"ConfigHash":    "3f2a9c1e5b7d9f0a2c4e6b8d0f1a3c5e7b9d1f2a",
+			},
+		}))
+
+		r := resourcePortainerStack()
+		d := r.TestResourceData()
+		d.SetId("6")
+		_ = d.Set("method", "repository")
+		_ = d.Set("name", "gitapp")
+		_ = d.Set("endpoint_id", 1)
+		_ = d.Set("repository_url", "https://git.example.com/acme/app.git")
+		_ = d.Set("repository_reference_name", tc.reference)
+		_ = d.Set("git_repository_authentication", true)
+		_ = d.Set("repository_username", "bob")
+		_ = d.Set("repository_password", "secret")
+		_ = d.Set("target_commit", tc.target)
+
+		diags := r.UpdateContext(context.Background(), d, mock.Client())
+		err := diagErr(diags)
+		if tc.wantErr == "" && err != nil || tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Errorf("%s: expected error %q, got %v", tc.name, tc.wantErr, err)
+		}
+		warnings := 0
+		for _, d := range diags {
+			if d.Severity == diag.Warning {
+				warnings++
+			}
+		}
+		if warnings != tc.wantWarnings {
+			t.Errorf("%s: expected %d warnings, got %+v", tc.name, tc.wantWarnings, diags)
+		}
+		redeployed := mock.FindRequest("PUT", "/stacks/6/git/redeploy") != nil
+		if redeployed == (tc.wantErr != "") {
+			t.Errorf("%s: expected redeploy=%v", tc.name, tc.wantErr == "")
+		}
+
+		var payload map[string]interface{}
+		if err := mock.FindRequest("POST", "/gitops/repo/refs").DecodeJSON(&payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload["repository"] != "https://git.example.com/acme/app.git" || payload["username"] != "bob" ||
+			payload["password"] != "secret" || payload["stackID"] != float64(6) {
+			t.Errorf("%s: unexpected refs payload %v", tc.name, payload)
+		}
+	}
+}
+
+// TestStackCustomizeDiff_TargetCommit plans a redeploy when the deployed
+// commit is not target_commit.
+func TestStackCustomizeDiff_TargetCommit(t *testing.T) {
+	r := resourcePortainerStack()
+	cfg := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":            "gitapp",
+		"endpoint_id":     1,
+		"deployment_type": "standalone",
+		"method":          "repository",
+		"repository_url":  "https://github.com/acme/app.git",
+		"target_commit":   "3f2a9c1",
+	})
+	state := &terraform.InstanceState{ID: "6", Attributes: map[string]string{
+		"id":                        "6",
+		"name":                      "gitapp",
+		"endpoint_id":               "1",
+		"deployment_type":           "standalone",
+		"method":                    "repository",
+		"repository_url":            "https://github.com/acme/app.git",
+		"repository_reference_name": "refs/heads/main",
+		"file_path_in_repository":   "docker-compose.yml",
+		"target_commit":             "3f2a9c1",
+		"deployed_commit_hash":      "3f2a9c1e5b7d9f0a2c4e6b8d0f1a3c5e7b9d1f2a",
+		"compose_format":            "false",
+		"support_relative_path":     "false",
+	}}
+
+	diff, err := r.Diff(context.Background(), state, cfg, nil)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if diff != nil && diff.Attributes["deployed_commit_hash"] != nil {
+		t.Errorf("expected no redeploy when the target commit is deployed, got %+v", diff.Attributes["deployed_commit_hash"])
+	}
+
+	state.Attributes["deployed_commit_hash"] = "8b7e6d5c4b3a2918f7e6d5c4b3a2918f7e6d5c4b"
+	diff, err = r.Diff(context.Background(), state, cfg, nil)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if attr := diff.Attributes["deployed_commit_hash"]; attr == nil || !attr.NewComputed || diff.RequiresNew() {
+		t.Errorf("expected in-place redeploy, got %+v", diff)
+	}
+}
+
+// TestStackUpdate_EnvSources sends the variables of env_file, env_map and env
+// merged, and splits them between the sources again on read.
+func TestStackUpdate_EnvSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("LOG_LEVEL=debug\nREGION=eu\nDB_PASSWORD=from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := NewMockServer(t)
+	mock.On("PUT", "/stacks/4", RespondJSON(http.StatusOK, map[string]interface{}{"Id": 4, "Name": "app"}))
+	mock.On("GET", "/stacks/4", RespondJSON(http.StatusOK, map[string]interface{}{
+		"Id": 4, "Name": "app", "Status": 1, "Type": 2, "EndpointId": 1,
+		"Env": []map[string]string{
+			{"name": "TZ", "value": "UTC"},
+			{"name": "LOG_LEVEL", "value": "debug"},
+			{"name": "REGION", "value": "us"},
+			{"name": "REPLICAS", "value": "3"},
+			{"name": "DB_PASSWORD", "value": "s3cr3t"},
+			{"name": "ADDED_IN_UI", "value": "1"},
+		},
+	}))
+	mock.On("GET", "/stacks/4/file", RespondJSON(http.StatusOK, map[string]interface{}{
+		"StackFileContent": "services: {}",
+	}))
+
+	r := resourcePortainerStack()
+	d := r.TestResourceData()
+	d.SetId("4")
+	_ = d.Set("method", "string")
+	_ = d.Set("name", "app")
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("stack_file_content", "services: {}")
+	_ = d.Set("env_file", path)
+	_ = d.Set("env_map", map[string]interface{}{"REGION": "us", "REPLICAS": "3"})
+	_ = d.Set("env", []interface{}{map[string]interface{}{"name": "TZ", "value": "UTC"}})
+
+	if err := rcUpdate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	var payload struct {
+		Env []stackEnvVar `json:"env"`
+	}
+	if err := mock.FindRequest("PUT", "/stacks/4").DecodeJSON(&payload); err != nil {
+		t.Fatalf("failed to decode update PUT body: %v", err)
+	}
+	want := []stackEnvVar{
+		{"TZ", "UTC"},
+		{"DB_PASSWORD", "from-file"},
+		{"LOG_LEVEL", "debug"},
+		{"REGION", "us"},
+		{"REPLICAS", "3"},
+	}
+	if !reflect.DeepEqual(payload.Env, want) {
+		t.Errorf("payload.env:\n got %v\nwant %v", payload.Env, want)
+	}
+
+	// DB_PASSWORD drifted in Portainer: the env_file hash no longer matches
+	// the file, ADDED_IN_UI is not managed by any source and shows up in env.
+	env := d.Get("env").([]interface{})
+	if len(env) != 2 || env[1].(map[string]interface{})["name"] != "ADDED_IN_UI" {
+		t.Errorf("env: expected TZ and ADDED_IN_UI, got %v", env)
+	}
+	if got := d.Get("env_map").(map[string]interface{}); len(got) != 2 || got["REGION"] != "us" {
+		t.Errorf("env_map: got %v", got)
+	}
+	fileVars, _ := readStackEnvFile(path)
+	overridden := map[string]bool{"TZ": true, "REGION": true, "REPLICAS": true}
+	if d.Get("env_file_sha256") == stackEnvFileHash(fileVars, overridden) {
+		t.Error("env_file_sha256 must reflect the drifted value")
+	}
+
+	// With DB_PASSWORD provided through sensitive_env_wo, its value is
+	// neither stored nor compared.
+	_ = d.Set("sensitive_env_names", []interface{}{"DB_PASSWORD"})
+	if err := rcRead(r, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	overridden["DB_PASSWORD"] = true
+	if d.Get("env_file_sha256") != stackEnvFileHash(fileVars, overridden) {
+		t.Error("env_file_sha256 must match the file once DB_PASSWORD is sensitive")
+	}
+	for _, e := range d.Get("env").([]interface{}) {
+		if e.(map[string]interface{})["name"] == "DB_PASSWORD" {
+			t.Error("sensitive variable must not be stored in env")
+		}
+	}
+}
+
+// TestStackCustomizeDiff_EnvFile plans an update when the .env file was edited
+// and rejects variables set in more than one of env, env_map and
+// sensitive_env_wo.
+func TestStackCustomizeDiff_EnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("LOG_LEVEL=info\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r := resourcePortainerStack()
+	raw := map[string]interface{}{
+		"name":               "app",
+		"endpoint_id":        1,
+		"deployment_type":    "standalone",
+		"method":             "string",
+		"stack_file_content": "services: {}",
+		"env_file":           path,
+	}
+	state := &terraform.InstanceState{ID: "4", Attributes: map[string]string{
+		"id":                    "4",
+		"name":                  "app",
+		"endpoint_id":           "1",
+		"deployment_type":       "standalone",
+		"method":                "string",
+		"stack_file_content":    "services: {}",
+		"env_file":              path,
+		"env_file_sha256":       stackEnvFileHash(map[string]string{"LOG_LEVEL": "info"}, nil),
+		"compose_format":        "false",
+		"support_relative_path": "false",
+	}}
+
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), nil)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if diff != nil && diff.Attributes["env_file_sha256"] != nil {
+		t.Errorf("unchanged env_file must not plan an update, got %+v", diff.Attributes["env_file_sha256"])
+	}
+
+	if err := os.WriteFile(path, []byte("LOG_LEVEL=debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	diff, err = r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), nil)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if diff == nil || diff.RequiresNew() || diff.Attributes["env_file_sha256"] == nil {
+		t.Errorf("expected an in-place update for the edited env_file, got %+v", diff)
+	}
+
+	raw["env_map"] = map[string]interface{}{"TZ": "UTC"}
+	raw["env"] = []interface{}{map[string]interface{}{"name": "TZ", "value": "Europe/Paris"}}
+	if _, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), nil); err == nil || !strings.Contains(err.Error(), `"TZ" is set in both env and env_map`) {
+		t.Errorf("expected duplicate variable error, got %v", err)
+	}
+
+	// Variables of sensitive_env_wo must not also be set in env or env_map.
+	delete(raw, "env")
+	raw["sensitive_env_wo_version"] = 1
+	state.RawConfig = cty.ObjectVal(map[string]cty.Value{"sensitive_env_wo": cty.StringVal(`{"TZ":"UTC"}`)})
+	if _, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), nil); err == nil || !strings.Contains(err.Error(), `"TZ" is set in both env_map and sensitive_env_wo`) {
+		t.Errorf("expected duplicate sensitive variable error, got %v", err)
+	}
+	delete(raw, "env_map")
+	raw["env"] = []interface{}{map[string]interface{}{"name": "TZ", "value": "UTC"}}
+	if _, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), nil); err == nil || !strings.Contains(err.Error(), `"TZ" is set in both env and sensitive_env_wo`) {
+		t.Errorf("expected duplicate sensitive variable error, got %v", err)
+	}
+}
@@ -0,0 +1,339 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// stackDependency is an object a Compose file expects to exist on the
+// environment: an external network, volume, secret or config.
+type stackDependency struct {
+	Type string
+	Name string
+}
+
+// stackDependencyTypes maps the top-level Compose sections to the dependency
+// types, in report order.
+var stackDependencyTypes = []struct{ Section, Type string }{
+	{"networks", "network"},
+	{"volumes", "volume"},
+	{"secrets", "secret"},
+	{"configs", "config"},
+}
+
+// stackDependencyResources names the resources managing each dependency type.
+var stackDependencyResources = map[string]string{
+	"network": "portainer_docker_network",
+	"volume":  "portainer_docker_volume",
+	"secret":  "portainer_docker_secret",
+	"config":  "portainer_docker_config",
+}
+
+// composeExternalDependencies returns the external networks, volumes, secrets
+// and configs declared by a Compose file, with ${VAR} references in their
+// names interpolated from env.
+func composeExternalDependencies(content string, env map[string]string) ([]stackDependency, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse compose file: %w", err)
+	}
+
+	var deps []stackDependency
+	for _, t := range stackDependencyTypes {
+		section, _ := doc[t.Section].(map[string]interface{})
+		keys := make([]string, 0, len(section))
+		for key := range section {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			def, _ := section[key].(map[string]interface{})
+			name, _ := def["name"].(string)
+			switch external := def["external"].(type) {
+			case bool:
+				if !external {
+					continue
+				}
+			case map[string]interface{}:
+				// Legacy form: external: {name: ...}
+				if n, ok := external["name"].(string); ok {
+					name = n
+				}
+			default:
+				continue
+			}
+			if name == "" {
+				name = key
+			}
+			name, err := interpolateEnv(name, env)
+			if err != nil {
+				return nil, fmt.Errorf("%s %q: %w", t.Type, key, err)
+			}
+			deps = append(deps, stackDependency{Type: t.Type, Name: name})
+		}
+	}
+	return deps, nil
+}
+
+// fetchDockerObjectNames lists the names of the networks, volumes, secrets or
+// configs of an environment through the Docker proxy.
+func fetchDockerObjectNames(ctx context.Context, client *APIClient, endpointID int, depType string) (map[string]bool, error) {
+	url := fmt.Sprintf("%s/endpoints/%d/docker/%ss", client.Endpoint, endpointID, depType)
+	body, code, err := apiGETWithCodeCtx(ctx, url, client.APIKey, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %ss: %w", depType, err)
+	}
+	if code != 200 {
+		return nil, fmt.Errorf("failed to list %ss: status %d, body: %s", depType, code, string(body))
+	}
+
+	var objects []struct {
+		Name string `json:"Name"`
+		Spec struct {
+			Name string `json:"Name"`
+		} `json:"Spec"`
+	}
+	if depType == "volume" {
+		var list struct {
+			Volumes json.RawMessage `json:"Volumes"`
+		}
+		if err := json.Unmarshal(body, &list); err != nil {
+			return nil, fmt.Errorf("failed to parse volumes: %w", err)
+		}
+		body = list.Volumes
+	}
+	if len(body) > 0 && string(body) != "null" {
+		if err := json.Unmarshal(body, &objects); err != nil {
+			return nil, fmt.Errorf("failed to parse %ss: %w", depType, err)
+		}
+	}
+
+	names := map[string]bool{}
+	for _, o := range objects {
+		// Secrets and configs carry their name in the spec.
+		if o.Name != "" {
+			names[o.Name] = true
+		}
+		if o.Spec.Name != "" {
+			names[o.Spec.Name] = true
+		}
+	}
+	return names, nil
+}
+
+// missingStackDependencies returns the dependencies that do not exist on the
+// environment. Secrets and configs only exist on Docker Swarm, so they are
+// all missing on a standalone environment.
+func missingStackDependencies(ctx context.Context, client *APIClient, endpointID int, deps []stackDependency, isSwarm bool) ([]stackDependency, error) {
+	existing := map[string]map[string]bool{}
+	var missing []stackDependency
+	for _, dep := range deps {
+		if !isSwarm && (dep.Type == "secret" || dep.Type == "config") {
+			missing = append(missing, dep)
+			continue
+		}
+		names, ok := existing[dep.Type]
+		if !ok {
+			var err error
+			if names, err = fetchDockerObjectNames(ctx, client, endpointID, dep.Type); err != nil {
+				return nil, err
+			}
+			existing[dep.Type] = names
+		}
+		if !names[dep.Name] {
+			missing = append(missing, dep)
+		}
+	}
+	return missing, nil
+}
+
+// stackFilesDependencies returns the external dependencies declared by the
+// files of a stack, without duplicates.
+func stackFilesDependencies(files []stackFile, env map[string]string) ([]stackDependency, error) {
+	seen := map[stackDependency]bool{}
+	var deps []stackDependency
+	for _, file := range files {
+		fileDeps, err := composeExternalDependencies(file.Content, env)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		for _, dep := range fileDeps {
+			if !seen[dep] {
+				seen[dep] = true
+				deps = append(deps, dep)
+			}
+		}
+	}
+	return deps, nil
+}
+
+func flattenStackDependencies(deps []stackDependency) []interface{} {
+	out := make([]interface{}, 0, len(deps))
+	for _, dep := range deps {
+		out = append(out, map[string]interface{}{"type": dep.Type, "name": dep.Name})
+	}
+	return out
+}
+
+// setStackExternalDependencies records the external dependencies of a
+// deployed Compose stack. Unparsable files declare none.
+func setStackExternalDependencies(d *schema.ResourceData, content string, env []stackEnvVar) error {
+	vars := map[string]string{}
+	for _, e := range env {
+		vars[e.Name] = e.Value
+	}
+	deps, _ := composeExternalDependencies(content, vars)
+	return d.Set("external_dependencies", flattenStackDependencies(deps))
+}
+
+// customizeDiffStackDependencies plans the external dependencies of string
+// and file stacks from the configured file and environment. They are only
+// known after apply for repository stacks, and when sensitive variables,
+// whose values are not available at plan time, may be interpolated.
+func customizeDiffStackDependencies(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if d.Get("deployment_type").(string) == "kubernetes" {
+		return nil
+	}
+	inputs := []string{"stack_file_content", "stack_file_sha256", "env", "env_map", "env_file_sha256", "sensitive_env_wo_version",
+		"file_path_in_repository", "additional_files", "repository_reference_name", "target_commit"}
+	method := d.Get("method").(string)
+	planned := (method == "string" || method == "file") &&
+		d.NewValueKnown("stack_file_content") && d.NewValueKnown("env") && d.NewValueKnown("env_map") &&
+		len(d.Get("sensitive_env_names").([]interface{})) == 0 && d.Get("sensitive_env_wo_version").(int) == 0
+	if !planned {
+		if d.Id() != "" && d.HasChanges(inputs...) {
+			return d.SetNewComputed("external_dependencies")
+		}
+		return nil
+	}
+
+	// Interpolate the same values as stackConfigDependencies does at apply.
+	envList, err := stackPlainEnv(d)
+	if err != nil {
+		return err
+	}
+	env := map[string]string{}
+	for _, e := range envList {
+		env[e["name"]] = e["value"]
+	}
+	deps, err := composeExternalDependencies(d.Get("stack_file_content").(string), env)
+	if err != nil {
+		// Reported by the file validation unless skipped.
+		deps = nil
+	}
+	if flat := flattenStackDependencies(deps); !reflect.DeepEqual(d.Get("external_dependencies").([]interface{}), flat) {
+		return d.SetNew("external_dependencies", flat)
+	}
+	return nil
+}
+
+// stackConfigDependencies returns the external dependencies declared by the
+// configured stack files, interpolated from the configured environment.
+func stackConfigDependencies(client *APIClient, d *schema.ResourceData) ([]stackDependency, error) {
+	var files []stackFile
+	switch d.Get("method").(string) {
+	case "string":
+		files = []stackFile{{Name: "stack_file_content", Content: d.Get("stack_file_content").(string)}}
+	case "file":
+		if err := readStackFilePath(d); err != nil {
+			return nil, err
+		}
+		files = []stackFile{{Name: d.Get("stack_file_path").(string), Content: d.Get("stack_file_content").(string)}}
+	case "repository":
+		mainFile := d.Get("file_path_in_repository").(string)
+		if mainFile == "" {
+			mainFile = "docker-compose.yml"
+		}
+		var err error
+		if files, err = stackRepositoryFiles(client, d, mainFile); err != nil {
+			return nil, err
+		}
+	}
+
+	envList, err := stackEnv(d)
+	if err != nil {
+		return nil, err
+	}
+	env := map[string]string{}
+	for _, e := range envList {
+		env[e["name"]] = e["value"]
+	}
+	return stackFilesDependencies(files, env)
+}
+
+// checkStackDependencies runs before a Compose stack is deployed. It records
+// the external dependencies of repository stacks, whose files are not read
+// on refresh, and when strict_dependencies is set fails if some do not exist
+// on the environment, naming the resource to declare them with. Without
+// strict_dependencies, dependencies that cannot be determined are reported as
+// a warning.
+func checkStackDependencies(ctx context.Context, client *APIClient, d *schema.ResourceData) diag.Diagnostics {
+	deployment := d.Get("deployment_type").(string)
+	method := d.Get("method").(string)
+	strict := d.Get("strict_dependencies").(bool)
+	if deployment == "kubernetes" || !strict && method != "repository" {
+		return nil
+	}
+
+	deps, err := stackConfigDependencies(client, d)
+	if err != nil {
+		if strict {
+			return diag.FromErr(err)
+		}
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Unable to determine the external dependencies of the stack",
+			Detail:   fmt.Sprintf("external_dependencies was not updated: %v", err),
+		}}
+	}
+	if method == "repository" {
+		if err := d.Set("external_dependencies", flattenStackDependencies(deps)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if !strict {
+		return nil
+	}
+
+	endpointID := d.Get("endpoint_id").(int)
+	isSwarm := deployment == "swarm"
+	missing, err := missingStackDependencies(ctx, client, endpointID, deps, isSwarm)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	var problems []string
+	for _, dep := range missing {
+		if !isSwarm && (dep.Type == "secret" || dep.Type == "config") {
+			problems = append(problems, fmt.Sprintf("external %s %q requires deployment_type 'swarm'", dep.Type, dep.Name))
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("external %s %q does not exist: declare it with %s and reference it from the stack so that it is created first",
+			dep.Type, dep.Name, stackDependencyResources[dep.Type]))
+	}
+	if len(problems) > 0 {
+		return diag.Errorf("stack %q has missing dependencies on environment %d:\n  - %s", d.Get("name").(string), endpointID, strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// imageRegistryHost returns the registry host of an image reference, or ""
+// for Docker Hub images.
+func imageRegistryHost(image string) string {
+	first, _, found := strings.Cut(image, "/")
+	if !found || !strings.ContainsAny(first, ".:") && first != "localhost" {
+		return ""
+	}
+	if first == "docker.io" || first == "index.docker.io" || first == "registry-1.docker.io" {
+		return ""
+	}
+	return first
+}
@@ -0,0 +1,285 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestComposeExternalDependencies(t *testing.T) {
+	content := `
+services:
+  web:
+    image: nginx
+networks:
+  default: {}
+  proxy:
+    external: true
+  legacy:
+    external:
+      name: legacy_net
+volumes:
+  data:
+    external: true
+    name: ${PREFIX}_data
+  cache: {}
+secrets:
+  token:
+    external: true
+configs:
+  app:
+    external: false
+`
+	deps, err := composeExternalDependencies(content, map[string]string{"PREFIX": "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []stackDependency{
+		{Type: "network", Name: "legacy_net"},
+		{Type: "network", Name: "proxy"},
+		{Type: "volume", Name: "prod_data"},
+		{Type: "secret", Name: "token"},
+	}
+	if !reflect.DeepEqual(deps, want) {
+		t.Errorf("expected %v, got %v", want, deps)
+	}
+
+	if _, err := composeExternalDependencies("services: [", nil); err == nil {
+		t.Error("expected an error for invalid YAML")
+	}
+}
+
+func TestMissingStackDependencies(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/docker/networks", RespondJSON(http.StatusOK, []map[string]interface{}{{"Name": "proxy"}}))
+	mock.On("GET", "/endpoints/1/docker/volumes", RespondJSON(http.StatusOK, map[string]interface{}{
+		"Volumes": []map[string]interface{}{{"Name": "prod_data"}},
+	}))
+	mock.On("GET", "/endpoints/1/docker/secrets", RespondJSON(http.StatusOK, []map[string]interface{}{
+		{"ID": "s1", "Spec": map[string]interface{}{"Name": "token"}},
+	}))
+
+	deps := []stackDependency{
+		{Type: "network", Name: "legacy_net"},
+		{Type: "network", Name: "proxy"},
+		{Type: "volume", Name: "prod_data"},
+		{Type: "secret", Name: "token"},
+	}
+
+	missing, err := missingStackDependencies(context.Background(), mock.Client(), 1, deps, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []stackDependency{{Type: "network", Name: "legacy_net"}}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("swarm: expected %v, got %v", want, missing)
+	}
+
+	// Secrets cannot exist on a standalone environment.
+	missing, err = missingStackDependencies(context.Background(), mock.Client(), 1, deps, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []stackDependency{{Type: "network", Name: "legacy_net"}, {Type: "secret", Name: "token"}}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("standalone: expected %v, got %v", want, missing)
+	}
+}
+
+const dependentCompose = `services:
+  web:
+    image: nginx
+networks:
+  proxy:
+    external: true
+volumes:
+  data:
+    external: true
+    name: ${PREFIX}_data
+secrets:
+  token:
+    external: true
+`
+
+func TestStackCreate_StrictDependencies(t *testing.T) {
+	mock := NewMockServer(t)
+	mockEmptyStackList(mock)
+	mock.On("GET", "/endpoints/1/docker/networks", RespondJSON(http.StatusOK, []map[string]interface{}{{"Name": "bridge"}}))
+	mock.On("GET", "/endpoints/1/docker/volumes", RespondJSON(http.StatusOK, map[string]interface{}{
+		"Volumes": []map[string]interface{}{{"Name": "prod_data"}},
+	}))
+
+	r := resourcePortainerStack()
+	d := r.TestResourceData()
+	_ = d.Set("deployment_type", "standalone")
+	_ = d.Set("method", "string")
+	_ = d.Set("name", "web")
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("stack_file_content", dependentCompose)
+	_ = d.Set("env_map", map[string]interface{}{"PREFIX": "prod"})
+	_ = d.Set("strict_dependencies", true)
+
+	err := rcCreate(r, d, mock.Client())
+	if err == nil {
+		t.Fatal("expected missing dependencies to fail the creation")
+	}
+	for _, s := range []string{
+		`stack "web" has missing dependencies on environment 1`,
+		`external network "proxy" does not exist: declare it with portainer_docker_network`,
+		`external secret "token" requires deployment_type 'swarm'`,
+	} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("error: expected %q, got %v", s, err)
+		}
+	}
+	if strings.Contains(err.Error(), "prod_data") {
+		t.Errorf("error: the existing volume must not be reported, got %v", err)
+	}
+	if mock.FindRequest("POST", "/stacks/create/standalone/string") != nil {
+		t.Error("expected no stack to be created")
+	}
+}
+
+func TestStackRead_ExternalDependencies(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/stacks/5", RespondJSON(http.StatusOK, map[string]interface{}{
+		"Id": 5, "Name": "web", "Type": 1, "EndpointId": 1, "Status": 1,
+		"Env": []map[string]interface{}{{"name": "PREFIX", "value": "prod"}},
+	}))
+	mock.On("GET", "/stacks/5/file", RespondJSON(http.StatusOK, map[string]interface{}{"StackFileContent": dependentCompose}))
+
+	r := resourcePortainerStack()
+	d := r.TestResourceData()
+	d.SetId("5")
+	_ = d.Set("deployment_type", "swarm")
+	_ = d.Set("method", "string")
+
+	if err := rcRead(r, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	want := []interface{}{
+		map[string]interface{}{"type": "network", "name": "proxy"},
+		map[string]interface{}{"type": "volume", "name": "prod_data"},
+		map[string]interface{}{"type": "secret", "name": "token"},
+	}
+	if got := d.Get("external_dependencies").([]interface{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestStackCreate_RepositoryDependencies(t *testing.T) {
+	mock := NewMockServer(t)
+	mockEmptyStackList(mock)
+	mock.On("POST", "/gitops/repo/file/preview", RespondJSON(http.StatusOK, map[string]interface{}{"FileContent": dependentCompose}))
+	mock.On("POST", "/stacks/create/swarm/repository", RespondJSON(http.StatusOK, map[string]interface{}{"Id": 11}))
+	mock.On("GET", "/stacks/11", RespondJSON(http.StatusOK, map[string]interface{}{
+		"Id": 11, "Name": "gitstack", "Type": 1, "EndpointId": 1, "Status": 1, "SwarmId": "swarm1",
+	}))
+
+	r := resourcePortainerStack()
+	d := r.TestResourceData()
+	_ = d.Set("deployment_type", "swarm")
+	_ = d.Set("swarm_id", "swarm1")
+	_ = d.Set("method", "repository")
+	_ = d.Set("name", "gitstack")
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("repository_url", "https://github.com/acme/app.git")
+	_ = d.Set("env_map", map[string]interface{}{"PREFIX": "prod"})
+
+	if err := rcCreate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	deps := d.Get("external_dependencies").([]interface{})
+	if len(deps) != 3 || deps[1].(map[string]interface{})["name"] != "prod_data" {
+		t.Errorf("expected the dependencies of the repository file, got %v", deps)
+	}
+	if mock.FindRequest("GET", "/stacks/11/file") != nil {
+		t.Error("did not expect GET /stacks/11/file for repository method")
+	}
+}
+
+// TestStackCreate_RepositoryDependenciesUnavailable deploys a repository
+// stack whose files cannot be read with a warning, without recording an empty
+// list of dependencies.
+func TestStackCreate_RepositoryDependenciesUnavailable(t *testing.T) {
+	mock := NewMockServer(t)
+	mockEmptyStackList(mock)
+	mock.On("POST", "/gitops/repo/file/preview", RespondString(http.StatusInternalServerError, "text/plain", "unreachable"))
+	mock.On("POST", "/stacks/create/swarm/repository", RespondJSON(http.StatusOK, map[string]interface{}{"Id": 11}))
+	mock.On("GET", "/stacks/11", RespondJSON(http.StatusOK, map[string]interface{}{
+		"Id": 11, "Name": "gitstack", "Type": 1, "EndpointId": 1, "Status": 1, "SwarmId": "swarm1",
+	}))
+
+	r := resourcePortainerStack()
+	d := r.TestResourceData()
+	_ = d.Set("deployment_type", "swarm")
+	_ = d.Set("swarm_id", "swarm1")
+	_ = d.Set("method", "repository")
+	_ = d.Set("name", "gitstack")
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("repository_url", "https://github.com/acme/app.git")
+
+	diags := r.CreateContext(context.Background(), d, mock.Client())
+	if diags.HasError() {
+		t.Fatalf("Create failed: %v", diags)
+	}
+	if len(diags) != 1 || !strings.Contains(diags[0].Detail, "unreachable") {
+		t.Errorf("expected a warning naming the fetch error, got %+v", diags)
+	}
+	if d.Id() != "11" {
+		t.Errorf("expected the stack to be deployed, got ID %q", d.Id())
+	}
+	if _, ok := d.GetOk("external_dependencies"); ok {
+		t.Errorf("expected no recorded dependencies, got %v", d.Get("external_dependencies"))
+	}
+}
+
+func TestStackCustomizeDiff_ExternalDependencies(t *testing.T) {
+	r := resourcePortainerStack()
+	raw := map[string]interface{}{
+		"name":               "web",
+		"endpoint_id":        1,
+		"deployment_type":    "swarm",
+		"method":             "string",
+		"stack_file_content": dependentCompose,
+		"env_map":            map[string]interface{}{"PREFIX": "prod"},
+	}
+	attrs := map[string]string{
+		"id":                      "5",
+		"name":                    "web",
+		"endpoint_id":             "1",
+		"deployment_type":         "swarm",
+		"method":                  "string",
+		"stack_file_content":      "services: {}",
+		"env_map.%":               "1",
+		"env_map.PREFIX":          "prod",
+		"compose_format":          "false",
+		"support_relative_path":   "false",
+		"external_dependencies.#": "0",
+	}
+
+	diff, err := r.Diff(context.Background(), &terraform.InstanceState{ID: "5", Attributes: attrs}, terraform.NewResourceConfigRaw(raw), nil)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if diff == nil || diff.Attributes["external_dependencies.1.name"] == nil || diff.Attributes["external_dependencies.1.name"].New != "prod_data" {
+		t.Fatalf("expected the dependencies to be planned, got %+v", diff)
+	}
+
+	attrs["stack_file_content"] = dependentCompose
+	attrs["external_dependencies.#"] = "3"
+	for i, dep := range []stackDependency{{"network", "proxy"}, {"volume", "prod_data"}, {"secret", "token"}} {
+		attrs[fmt.Sprintf("external_dependencies.%d.type", i)] = dep.Type
+		attrs[fmt.Sprintf("external_dependencies.%d.name", i)] = dep.Name
+	}
+	diff, err = r.Diff(context.Background(), &terraform.InstanceState{ID: "5", Attributes: attrs}, terraform.NewResourceConfigRaw(raw), nil)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if diff != nil && diff.Attributes["external_dependencies.#"] != nil {
+		t.Errorf("expected no change of unchanged dependencies, got %+v", diff.Attributes["external_dependencies.#"])
+	}
+}
@@ -0,0 +1,291 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// portainerAppStackIDLabel is set by Portainer on every object deployed by a
+// Kubernetes stack.
+const portainerAppStackIDLabel = "io.portainer.kubernetes.application.stackid"
+
+// k8sStackObjectKinds are the kinds of objects listed for Kubernetes stacks.
+var k8sStackObjectKinds = []struct {
+	Kind, Group, Plural string
+}{
+	{"Deployment", "apps/v1", "deployments"},
+	{"StatefulSet", "apps/v1", "statefulsets"},
+	{"DaemonSet", "apps/v1", "daemonsets"},
+	{"Job", "batch/v1", "jobs"},
+	{"CronJob", "batch/v1", "cronjobs"},
+	{"Service", "", "services"},
+	{"Ingress", "networking.k8s.io/v1", "ingresses"},
+	{"ConfigMap", "", "configmaps"},
+	{"Secret", "", "secrets"},
+	{"PersistentVolumeClaim", "", "persistentvolumeclaims"},
+	{"ServiceAccount", "", "serviceaccounts"},
+}
+
+// k8sStackObject is an object owned by a Kubernetes stack.
+type k8sStackObject struct {
+	Kind      string
+	Name      string
+	Namespace string
+}
+
+func (o k8sStackObject) String() string {
+	return fmt.Sprintf("%s %s/%s", o.Kind, o.Namespace, o.Name)
+}
+
+// k8sObject is the part of a Kubernetes object used to assess its readiness.
+type k8sObject struct {
+	Metadata struct {
+		Name            string `json:"name"`
+		Namespace       string `json:"namespace"`
+		OwnerReferences []struct {
+			Kind string `json:"kind"`
+		} `json:"ownerReferences"`
+	} `json:"metadata"`
+	Spec   map[string]interface{} `json:"spec"`
+	Status map[string]interface{} `json:"status"`
+}
+
+// k8sObjectReadiness reports whether an object is ready, with a short status.
+func k8sObjectReadiness(kind string, obj k8sObject) (bool, string) {
+	num := func(m map[string]interface{}, key string, def int) int {
+		if v, ok := m[key].(float64); ok {
+			return int(v)
+		}
+		return def
+	}
+	switch kind {
+	case "Deployment", "StatefulSet":
+		replicas := num(obj.Spec, "replicas", 1)
+		ready, updated := num(obj.Status, "readyReplicas", 0), num(obj.Status, "updatedReplicas", 0)
+		return ready >= replicas && updated >= replicas, fmt.Sprintf("%d/%d ready", ready, replicas)
+	case "DaemonSet":
+		desired, ready := num(obj.Status, "desiredNumberScheduled", 0), num(obj.Status, "numberReady", 0)
+		return ready >= desired, fmt.Sprintf("%d/%d ready", ready, desired)
+	case "Job":
+		completions := num(obj.Spec, "completions", 1)
+		succeeded, failed := num(obj.Status, "succeeded", 0), num(obj.Status, "failed", 0)
+		switch {
+		case succeeded >= completions:
+			return true, "complete"
+		case failed > 0:
+			return false, fmt.Sprintf("%d failed", failed)
+		}
+		return false, fmt.Sprintf("%d/%d completed", succeeded, completions)
+	case "Service":
+		if obj.Spec["type"] == "LoadBalancer" {
+			ingress, _ := mustMap(obj.Status["loadBalancer"])["ingress"].([]interface{})
+			if len(ingress) == 0 {
+				return false, "pending load balancer"
+			}
+		}
+		return true, "active"
+	case "PersistentVolumeClaim":
+		phase, _ := obj.Status["phase"].(string)
+		return phase == "Bound", phase
+	}
+	return true, "present"
+}
+
+// k8sOwnedByCronJob reports whether an object, e.g. a Job, was created by a
+// CronJob: such Jobs come and go with its schedule.
+func k8sOwnedByCronJob(obj k8sObject) bool {
+	for _, owner := range obj.Metadata.OwnerReferences {
+		if owner.Kind == "CronJob" {
+			return true
+		}
+	}
+	return false
+}
+
+// listK8sStackObjects lists the objects labeled with the stack ID in the
+// namespaces, with their readiness, except the Jobs of CronJobs. Kinds that cannot be listed, e.g. for lack
+// of permissions, are skipped; listed holds the "Kind namespace" pairs that
+// were.
+func listK8sStackObjects(ctx context.Context, client *APIClient, endpointID int, stackID string, namespaces []string) (objects []map[string]interface{}, listed map[string]bool) {
+	selector := "?" + url.Values{"labelSelector": {portainerAppStackIDLabel + "=" + stackID}}.Encode()
+	objects = []map[string]interface{}{}
+	listed = map[string]bool{}
+	for _, ns := range namespaces {
+		for _, k := range k8sStackObjectKinds {
+			var items []k8sObject
+			if err := k8sProxyList(ctx, client, k8sProxyURL(client, endpointID, k.Group, ns, k.Plural)+selector, k.Plural, &items); err != nil {
+				continue
+			}
+			listed[k.Kind+" "+ns] = true
+			for _, item := range items {
+				if k8sOwnedByCronJob(item) {
+					continue
+				}
+				ready, status := k8sObjectReadiness(k.Kind, item)
+				objects = append(objects, map[string]interface{}{
+					"kind":      k.Kind,
+					"name":      item.Metadata.Name,
+					"namespace": item.Metadata.Namespace,
+					"ready":     ready,
+					"status":    status,
+				})
+			}
+		}
+	}
+	return objects, listed
+}
+
+// k8sManifestObjects returns the objects of the listed kinds declared by a
+// manifest, in the namespace of the stack unless they set their own. Jobs
+// with ttlSecondsAfterFinished are left out: Kubernetes deletes them once
+// finished.
+func k8sManifestObjects(manifest, namespace string) ([]k8sStackObject, error) {
+	listed := map[string]bool{}
+	for _, k := range k8sStackObjectKinds {
+		listed[k.Kind] = true
+	}
+
+	type doc struct {
+		Kind     string `yaml:"kind"`
+		Metadata struct {
+			Name      string `yaml:"name"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"metadata"`
+		Spec struct {
+			TTLSecondsAfterFinished *int `yaml:"ttlSecondsAfterFinished"`
+		} `yaml:"spec"`
+		Items []doc `yaml:"items"`
+	}
+	var objects []k8sStackObject
+	var add func(d doc)
+	add = func(d doc) {
+		for _, item := range d.Items {
+			add(item)
+		}
+		if !listed[d.Kind] || d.Metadata.Name == "" {
+			return
+		}
+		if d.Kind == "Job" && d.Spec.TTLSecondsAfterFinished != nil {
+			return
+		}
+		ns := d.Metadata.Namespace
+		if ns == "" {
+			ns = namespace
+		}
+		objects = append(objects, k8sStackObject{Kind: d.Kind, Name: d.Metadata.Name, Namespace: ns})
+	}
+
+	dec := yaml.NewDecoder(strings.NewReader(manifest))
+	for {
+		var d doc
+		if err := dec.Decode(&d); err == io.EOF {
+			return objects, nil
+		} else if err != nil {
+			return nil, err
+		}
+		add(d)
+	}
+}
+
+// setStackKubernetesObjects records the objects owned by a Kubernetes stack
+// and those of its manifest that no longer exist, e.g. deleted with kubectl.
+// manifest is empty when it is unknown (Compose format, unreadable file).
+// Objects of kinds that cannot be listed are never reported missing.
+func setStackKubernetesObjects(ctx context.Context, client *APIClient, d *schema.ResourceData, endpointID int, namespace, manifest string) error {
+	if namespace == "" {
+		namespace = "default"
+	}
+	expected, _ := k8sManifestObjects(manifest, namespace)
+	namespaces := []string{namespace}
+	for _, o := range expected {
+		if !contains(namespaces, o.Namespace) {
+			namespaces = append(namespaces, o.Namespace)
+		}
+	}
+
+	objects, listed := listK8sStackObjects(ctx, client, endpointID, d.Id(), namespaces)
+	existing := map[k8sStackObject]bool{}
+	for _, o := range objects {
+		existing[k8sStackObject{Kind: o["kind"].(string), Name: o["name"].(string), Namespace: o["namespace"].(string)}] = true
+	}
+	missing := []string{}
+	for _, o := range expected {
+		if listed[o.Kind+" "+o.Namespace] && !existing[o] {
+			missing = append(missing, o.String())
+		}
+	}
+	sort.Strings(missing)
+
+	if err := d.Set("kubernetes_objects", objects); err != nil {
+		return err
+	}
+	return d.Set("kubernetes_missing_objects", missing)
+}
+
+// customizeDiffStackKubernetesObjects plans a redeploy of a Kubernetes stack
+// when objects of its manifest were deleted out-of-band.
+func customizeDiffStackKubernetesObjects(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if d.Id() == "" || d.Get("deployment_type").(string) != "kubernetes" {
+		return nil
+	}
+	if len(d.Get("kubernetes_missing_objects").([]interface{})) == 0 {
+		return nil
+	}
+	if err := d.SetNewComputed("kubernetes_missing_objects"); err != nil {
+		return err
+	}
+	return d.SetNewComputed("kubernetes_objects")
+}
+
+// createStackNamespace creates the namespace of a Kubernetes stack with
+// create_namespace before it is deployed or updated, e.g. after the namespace
+// was deleted out-of-band.
+func createStackNamespace(ctx context.Context, client *APIClient, d *schema.ResourceData) error {
+	if d.Get("deployment_type").(string) != "kubernetes" || !d.Get("create_namespace").(bool) {
+		return nil
+	}
+	namespace := d.Get("namespace").(string)
+	if namespace == "" {
+		return fmt.Errorf("create_namespace requires namespace")
+	}
+	return ensureStackNamespace(ctx, client, d.Get("endpoint_id").(int), namespace)
+}
+
+// ensureStackNamespace creates the namespace of a Kubernetes stack when it
+// does not exist.
+func ensureStackNamespace(ctx context.Context, client *APIClient, endpointID int, namespace string) error {
+	nsURL := k8sProxyURL(client, endpointID, "", "", "namespaces")
+	data, status, err := k8sProxyDo(ctx, client, http.MethodGet, nsURL+"/"+namespace, nil)
+	if err != nil {
+		return fmt.Errorf("failed to read namespace %s: %w", namespace, err)
+	}
+	if status == http.StatusOK {
+		return nil
+	}
+	if status != http.StatusNotFound {
+		return fmt.Errorf("failed to read namespace %s (%d): %s", namespace, status, string(data))
+	}
+
+	body := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata":   map[string]interface{}{"name": namespace},
+	}
+	data, status, err = k8sProxyDo(ctx, client, http.MethodPost, nsURL, body)
+	if err != nil {
+		return fmt.Errorf("failed to create namespace %s: %w", namespace, err)
+	}
+	// 409: created concurrently.
+	if (status < 200 || status >= 300) && status != http.StatusConflict {
+		return fmt.Errorf("failed to create namespace %s (%d): %s", namespace, status, string(data))
+	}
+	return nil
+}
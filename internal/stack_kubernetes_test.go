@@ -0,0 +1,238 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+const k8sStackManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+---
+apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: Service
+    metadata:
+      name: web
+  - apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: settings
+      namespace: shared
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+spec:
+  ttlSecondsAfterFinished: 300
+---
+apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+`
+
+func TestK8sManifestObjects(t *testing.T) {
+	objects, err := k8sManifestObjects(k8sStackManifest, "shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []k8sStackObject{
+		{Kind: "Deployment", Name: "web", Namespace: "shop"},
+		{Kind: "Service", Name: "web", Namespace: "shop"},
+		{Kind: "ConfigMap", Name: "settings", Namespace: "shared"},
+	}
+	if !reflect.DeepEqual(objects, want) {
+		t.Errorf("expected %v, got %v", want, objects)
+	}
+}
+
+func TestK8sObjectReadiness(t *testing.T) {
+	cases := []struct {
+		kind   string
+		obj    k8sObject
+		ready  bool
+		status string
+	}{
+		{"Deployment", k8sObject{Spec: map[string]interface{}{"replicas": 3.0}, Status: map[string]interface{}{"readyReplicas": 2.0, "updatedReplicas": 3.0}}, false, "2/3 ready"},
+		{"StatefulSet", k8sObject{Status: map[string]interface{}{"readyReplicas": 1.0, "updatedReplicas": 1.0}}, true, "1/1 ready"},
+		{"Job", k8sObject{Status: map[string]interface{}{"failed": 1.0}}, false, "1 failed"},
+		{"Service", k8sObject{Spec: map[string]interface{}{"type": "LoadBalancer"}}, false, "pending load balancer"},
+		{"PersistentVolumeClaim", k8sObject{Status: map[string]interface{}{"phase": "Bound"}}, true, "Bound"},
+		{"ConfigMap", k8sObject{}, true, "present"},
+	}
+	for _, c := range cases {
+		ready, status := k8sObjectReadiness(c.kind, c.obj)
+		if ready != c.ready || status != c.status {
+			t.Errorf("%s: expected (%v, %q), got (%v, %q)", c.kind, c.ready, c.status, ready, status)
+		}
+	}
+}
+
+func TestStackRead_KubernetesObjects(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/stacks/5", RespondJSON(http.StatusOK, map[string]interface{}{
+		"Id": 5, "Name": "shop", "Type": 3, "EndpointId": 1, "namespace": "shop", "Status": 1,
+	}))
+	mock.On("GET", "/stacks/5/file", RespondJSON(http.StatusOK, map[string]interface{}{"StackFileContent": k8sStackManifest}))
+
+	var selectors []string
+	list := func(items ...map[string]interface{}) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			selectors = append(selectors, r.URL.Query().Get("labelSelector"))
+			RespondJSON(http.StatusOK, map[string]interface{}{"items": items})(w, r)
+		}
+	}
+	mock.On("GET", "/endpoints/1/kubernetes/apis/apps/v1/namespaces/shop/deployments", list(map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web", "namespace": "shop"},
+		"spec":     map[string]interface{}{"replicas": 2},
+		"status":   map[string]interface{}{"readyReplicas": 2, "updatedReplicas": 2},
+	}))
+	// The Job of the manifest was deleted after its TTL; the listed one belongs
+	// to a CronJob.
+	mock.On("GET", "/endpoints/1/kubernetes/apis/batch/v1/namespaces/shop/jobs", list(map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "report-28930", "namespace": "shop", "ownerReferences": []map[string]interface{}{{"kind": "CronJob", "name": "report"}}},
+		"status":   map[string]interface{}{"active": 1},
+	}))
+	// The Service was deleted with kubectl.
+	mock.On("GET", "/endpoints/1/kubernetes/api/v1/namespaces/shop/services", list())
+	// ConfigMaps of the shared namespace cannot be listed (404): not reported.
+
+	r := resourcePortainerStack()
+	d := r.TestResourceData()
+	d.SetId("5")
+	_ = d.Set("deployment_type", "kubernetes")
+	_ = d.Set("method", "string")
+
+	if err := rcRead(r, d, mock.Client()); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	objects := d.Get("kubernetes_objects").([]interface{})
+	if len(objects) != 1 {
+		t.Fatalf("expected 1 object, got %v", objects)
+	}
+	want := map[string]interface{}{"kind": "Deployment", "name": "web", "namespace": "shop", "ready": true, "status": "2/2 ready"}
+	if !reflect.DeepEqual(objects[0], want) {
+		t.Errorf("expected %v, got %v", want, objects[0])
+	}
+	missing := d.Get("kubernetes_missing_objects").([]interface{})
+	if !reflect.DeepEqual(missing, []interface{}{"Service shop/web"}) {
+		t.Errorf("expected the deleted Service to be missing, got %v", missing)
+	}
+	for _, s := range selectors {
+		if s != "io.portainer.kubernetes.application.stackid=5" {
+			t.Errorf("unexpected label selector %q", s)
+		}
+	}
+}
+
+func TestStackCustomizeDiff_KubernetesMissingObjects(t *testing.T) {
+	r := resourcePortainerStack()
+	raw := map[string]interface{}{
+		"name":               "shop",
+		"endpoint_id":        1,
+		"deployment_type":    "kubernetes",
+		"method":             "string",
+		"namespace":          "shop",
+		"stack_file_content": k8sStackManifest,
+	}
+	attrs := map[string]string{
+		"id":                           "5",
+		"name":                         "shop",
+		"endpoint_id":                  "1",
+		"deployment_type":              "kubernetes",
+		"method":                       "string",
+		"namespace":                    "shop",
+		"stack_file_content":           k8sStackManifest,
+		"compose_format":               "false",
+		"support_relative_path":        "false",
+		"kubernetes_missing_objects.#": "0",
+	}
+
+	diff, err := r.Diff(context.Background(), &terraform.InstanceState{ID: "5", Attributes: attrs}, terraform.NewResourceConfigRaw(raw), nil)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if diff != nil && diff.Attributes["kubernetes_missing_objects.#"] != nil {
+		t.Errorf("expected no redeploy without missing objects, got %+v", diff.Attributes["kubernetes_missing_objects.#"])
+	}
+
+	attrs["kubernetes_missing_objects.#"] = "1"
+	attrs["kubernetes_missing_objects.0"] = "Service shop/web"
+	diff, err = r.Diff(context.Background(), &terraform.InstanceState{ID: "5", Attributes: attrs}, terraform.NewResourceConfigRaw(raw), nil)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if diff == nil || diff.RequiresNew() || diff.Attributes["kubernetes_missing_objects.#"] == nil {
+		t.Errorf("expected an in-place redeploy for the missing objects, got %+v", diff)
+	}
+}
+
+func TestEnsureStackNamespace(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("GET", "/endpoints/1/kubernetes/api/v1/namespaces/existing", RespondJSON(http.StatusOK, map[string]interface{}{}))
+	mock.On("POST", "/endpoints/1/kubernetes/api/v1/namespaces", RespondJSON(http.StatusCreated, map[string]interface{}{}))
+
+	if err := ensureStackNamespace(context.Background(), mock.Client(), 1, "existing"); err != nil {
+		t.Fatal(err)
+	}
+	if mock.FindRequest("POST", "/endpoints/1/kubernetes/api/v1/namespaces") != nil {
+		t.Error("expected no creation of an existing namespace")
+	}
+
+	if err := ensureStackNamespace(context.Background(), mock.Client(), 1, "shop"); err != nil {
+		t.Fatal(err)
+	}
+	post := mock.FindRequest("POST", "/endpoints/1/kubernetes/api/v1/namespaces")
+	if post == nil {
+		t.Fatal("expected the namespace to be created")
+	}
+	var body map[string]interface{}
+	if err := post.DecodeJSON(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body["kind"] != "Namespace" || mustMap(body["metadata"])["name"] != "shop" {
+		t.Errorf("unexpected namespace body %v", body)
+	}
+}
+
+// TestStackUpdate_CreateNamespace recreates the namespace of a Kubernetes
+// stack with create_namespace before updating it.
+func TestStackUpdate_CreateNamespace(t *testing.T) {
+	mock := NewMockServer(t)
+	mock.On("POST", "/endpoints/1/kubernetes/api/v1/namespaces", RespondJSON(http.StatusCreated, map[string]interface{}{}))
+	mock.On("PUT", "/stacks/5", RespondJSON(http.StatusOK, map[string]interface{}{}))
+	mock.On("GET", "/stacks/5", RespondJSON(http.StatusOK, map[string]interface{}{
+		"Id": 5, "Name": "shop", "Type": 3, "EndpointId": 1, "namespace": "shop", "Status": 1,
+	}))
+	mock.On("GET", "/stacks/5/file", RespondJSON(http.StatusOK, map[string]interface{}{"StackFileContent": k8sStackManifest}))
+
+	r := resourcePortainerStack()
+	d := r.TestResourceData()
+	d.SetId("5")
+	_ = d.Set("name", "shop")
+	_ = d.Set("endpoint_id", 1)
+	_ = d.Set("deployment_type", "kubernetes")
+	_ = d.Set("method", "string")
+	_ = d.Set("namespace", "shop")
+	_ = d.Set("create_namespace", true)
+	_ = d.Set("stack_file_content", k8sStackManifest)
+
+	if err := rcUpdate(r, d, mock.Client()); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if mock.FindRequest("POST", "/endpoints/1/kubernetes/api/v1/namespaces") == nil {
+		t.Error("expected the missing namespace to be created")
+	}
+}
@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// portainerVersion is the version and edition of the Portainer server.
+type portainerVersion struct {
+	Version string
+	Edition string
+}
+
+// isBusinessEdition reports whether the server runs Portainer BE.
+func (v portainerVersion) isBusinessEdition() bool {
+	return v.Edition != "" && !strings.EqualFold(v.Edition, "CE")
+}
+
+// atLeast reports whether the server version is min or newer. Versions are
+// compared numerically component by component; suffixes such as "-rc1" are
+// ignored.
+func (v portainerVersion) atLeast(min string) bool {
+	have := strings.Split(strings.SplitN(strings.TrimPrefix(v.Version, "v"), "-", 2)[0], ".")
+	want := strings.Split(min, ".")
+	for i := range want {
+		w, _ := strconv.Atoi(want[i])
+		h := 0
+		if i < len(have) {
+			h, _ = strconv.Atoi(have[i])
+		}
+		if h != w {
+			return h > w
+		}
+	}
+	return true
+}
+
+// fetchPortainerVersion reads the server version from GET /system/version.
+func fetchPortainerVersion(client *APIClient) (portainerVersion, error) {
+	resp, err := client.DoRequest(http.MethodGet, "/system/version", nil, nil)
+	if err != nil {
+		return portainerVersion{}, fmt.Errorf("failed to read Portainer version: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return portainerVersion{}, fmt.Errorf("failed to read Portainer version, status %d: %s", resp.StatusCode, string(data))
+	}
+
+	var out struct {
+		ServerVersion string `json:"ServerVersion"`
+		ServerEdition string `json:"ServerEdition"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return portainerVersion{}, fmt.Errorf("failed to decode Portainer version: %w", err)
+	}
+	return portainerVersion{Version: out.ServerVersion, Edition: out.ServerEdition}, nil
+}